@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+)
+
+// respondRepositoryError traduit une erreur des repositories en réponse HTTP :
+// ressource introuvable (404), requête invalide (400), doublon (409), sinon erreur serveur
+func respondRepositoryError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ormerrors.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not found",
+			"message": err.Error(),
+		})
+	case errors.Is(err, ormerrors.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+	case errors.Is(err, ormerrors.ErrDuplicateEntry):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Duplicate entry",
+			"message": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": message,
+		})
+	}
+}
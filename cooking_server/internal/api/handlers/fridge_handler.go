@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -260,3 +261,70 @@ func (h *FridgeHandler) RemoveExpiredItems(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// GetRecipeSuggestions propose des recettes réalisables avec les ingrédients du frigo
+// @Summary Suggestions de recettes selon le frigo
+// @Description Classe les recettes publiques et personnelles selon le nombre d'ingrédients du frigo qu'elles utilisent
+// @Tags Fridge
+// @Produce json
+// @Security ApiKeyAuth
+// @Param match_type query string false "Type de correspondance : any (défaut) ou all"
+// @Param max_missing_ingredients query int false "Nombre maximum d'ingrédients manquants"
+// @Param exclude_categories query []string false "Catégories de recettes à exclure (noms)"
+// @Param limit query int false "Nombre maximum de suggestions (défaut: 20)"
+// @Success 200 {object} dto.RecipeSuggestionsResponse "Suggestions de recettes"
+// @Failure 400 {object} map[string]interface{} "Paramètres invalides"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /fridge/suggestions [get]
+func (h *FridgeHandler) GetRecipeSuggestions(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var request dto.RecipeSearchByIngredientsRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Paramètres invalides", "details": err.Error()})
+		return
+	}
+
+	if request.MatchType == "" {
+		request.MatchType = "any"
+	}
+	if request.MatchType != "any" && request.MatchType != "all" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "match_type doit valoir 'any' ou 'all'"})
+		return
+	}
+	if request.MaxMissingIngredients != nil && *request.MaxMissingIngredients < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_missing_ingredients doit être positif"})
+		return
+	}
+	if request.Limit != nil && (*request.Limit < 1 || *request.Limit > 100) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit doit être compris entre 1 et 100"})
+		return
+	}
+
+	// Accepter aussi les catégories séparées par des virgules (?exclude_categories=Desserts,Boissons)
+	var excludeCategories []string
+	for _, value := range request.ExcludeCategories {
+		for _, category := range strings.Split(value, ",") {
+			if category = strings.TrimSpace(category); category != "" {
+				excludeCategories = append(excludeCategories, category)
+			}
+		}
+	}
+	request.ExcludeCategories = excludeCategories
+
+	suggestions, totalFridgeItems, err := h.ormService.FridgeRepository.GetRecipeSuggestions(c.Request.Context(), userID, &request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur lors de la recherche de suggestions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RecipeSuggestionsResponse{
+		Suggestions:      suggestions,
+		TotalFridgeItems: totalFridgeItems,
+		SearchParameters: request,
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+)
+
+// GetLeftovers liste les restes du frigo de l'utilisateur connecté
+// @Summary Lister mes restes
+// @Description Retourne les restes non périmés ayant encore des portions disponibles, du plus proche de la péremption au plus lointain. Avec include_finished=true, retourne aussi les restes périmés ou entièrement utilisés.
+// @Tags Fridge
+// @Produce json
+// @Security ApiKeyAuth
+// @Param include_finished query bool false "Inclure les restes périmés ou épuisés"
+// @Success 200 {object} map[string]interface{} "Restes"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /fridge/leftovers [get]
+func (h *FridgeHandler) GetLeftovers(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	leftovers, err := h.ormService.LeftoverRepository.GetByUser(c.Request.Context(), userID, c.Query("include_finished") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve leftovers",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    leftovers,
+	})
+}
+
+// GetLeftover récupère des restes du frigo
+// @Summary Récupérer des restes
+// @Description Retourne des restes avec leur recette et leurs portions mangées, prévues et disponibles
+// @Tags Fridge
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID des restes"
+// @Success 200 {object} dto.Leftover "Restes"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Restes non trouvés"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /fridge/leftovers/{id} [get]
+func (h *FridgeHandler) GetLeftover(c *gin.Context) {
+	leftover, ok := h.loadOwnedLeftover(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    leftover,
+	})
+}
+
+// DeleteLeftover retire des restes du frigo
+// @Summary Supprimer des restes
+// @Description Retire des restes du frigo. Les repas à venir prévus avec ces restes restent planifiés et leurs ingrédients comptent de nouveau dans la liste de courses.
+// @Tags Fridge
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID des restes"
+// @Success 200 {object} map[string]interface{} "Restes supprimés"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Restes non trouvés"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /fridge/leftovers/{id} [delete]
+func (h *FridgeHandler) DeleteLeftover(c *gin.Context) {
+	leftover, ok := h.loadOwnedLeftover(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ormService.LeftoverRepository.Delete(c.Request.Context(), leftover.ID); err != nil {
+		respondRepositoryError(c, err, "Failed to delete leftover")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Leftover deleted successfully",
+	})
+}
+
+// ScheduleLeftover planifie un repas avec des restes
+// @Summary Planifier des restes
+// @Description Planifie un repas de la recette d'origine avec des restes, au plus tard à leur date de péremption. Les ingrédients de ce repas ne sont pas ajoutés à la liste de courses ni retirés du frigo à sa réalisation.
+// @Tags Fridge
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID des restes"
+// @Param schedule body dto.LeftoverScheduleRequest true "Créneau et portions"
+// @Success 201 {object} dto.MealPlan "Repas planifié"
+// @Failure 400 {object} map[string]interface{} "Requête invalide, restes périmés ou insuffisants"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Restes non trouvés"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /fridge/leftovers/{id}/schedule [post]
+func (h *FridgeHandler) ScheduleLeftover(c *gin.Context) {
+	var req dto.LeftoverScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	leftover, ok := h.loadOwnedLeftover(c)
+	if !ok {
+		return
+	}
+
+	// Format déjà validé par la requête
+	plannedDate, _ := time.Parse("2006-01-02", req.PlannedDate)
+	mealPlan := &dto.MealPlan{
+		UserID:      leftover.UserID,
+		PlannedDate: plannedDate,
+		MealType:    req.MealType,
+		Servings:    req.Servings,
+		Notes:       req.Notes,
+	}
+	if err := h.ormService.LeftoverRepository.Schedule(c.Request.Context(), leftover.ID, mealPlan); err != nil {
+		respondRepositoryError(c, err, "Failed to schedule leftover")
+		return
+	}
+
+	created, err := h.ormService.MealPlanRepository.GetByID(c.Request.Context(), mealPlan.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve scheduled meal plan")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    created,
+		"message": "Leftover scheduled successfully",
+	})
+}
+
+// loadOwnedLeftover charge les restes du chemin s'ils appartiennent à l'utilisateur connecté
+func (h *FridgeHandler) loadOwnedLeftover(c *gin.Context) (*dto.Leftover, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid leftover ID",
+			"message": "Leftover ID must be a number",
+		})
+		return nil, false
+	}
+
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	leftover, err := h.ormService.LeftoverRepository.GetByID(c.Request.Context(), uint(id))
+	if err == nil && leftover.UserID != userID {
+		err = ormerrors.NewNotFoundError("leftover", id)
+	}
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve leftover")
+		return nil, false
+	}
+	return leftover, true
+}
@@ -31,6 +31,6 @@ func NewHandlers(ormService *orm.ORMService, jwtService *auth.JWTService) *Handl
 		CommentHandler:          NewCommentHandler(ormService),
 		FavoriteHandler:         NewFavoriteHandler(ormService),
 		RecipeListHandler:       NewRecipeListHandler(ormService),
-		RecipeExtractionHandler: NewRecipeExtractionHandler(),
+		RecipeExtractionHandler: NewRecipeExtractionHandler(ormService),
 	}
 }
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	"github.com/romainrodriguez/cooking_server/internal/services/orm"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+)
+
+// IngredientCategoryHandler gère les requêtes liées aux catégories d'ingrédients
+type IngredientCategoryHandler struct {
+	ormService *orm.ORMService
+}
+
+// NewIngredientCategoryHandler crée une nouvelle instance du handler des catégories d'ingrédients
+func NewIngredientCategoryHandler(ormService *orm.ORMService) *IngredientCategoryHandler {
+	return &IngredientCategoryHandler{
+		ormService: ormService,
+	}
+}
+
+// CreateIngredientCategory crée une nouvelle catégorie d'ingrédients
+// @Summary Créer une catégorie d'ingrédients
+// @Description Crée une nouvelle catégorie d'ingrédients (légumes, viandes, épices, ...)
+// @Tags IngredientCategories
+// @Accept json
+// @Produce json
+// @Param category body dto.IngredientCategoryCreateRequest true "Informations de la catégorie"
+// @Success 201 {object} dto.IngredientCategoryResponse "Catégorie créée avec succès"
+// @Failure 400 {object} dto.ErrorResponse "Requête invalide"
+// @Failure 409 {object} dto.ErrorResponse "Catégorie déjà existante"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredient-categories [post]
+func (h *IngredientCategoryHandler) CreateIngredientCategory(c *gin.Context) {
+	var req dto.IngredientCategoryCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	category := &dto.IngredientCategory{
+		Name:        req.Name,
+		Description: req.Description,
+		Icon:        req.Icon,
+		Position:    req.Position,
+	}
+
+	if err := h.ormService.IngredientCategoryRepository.Create(c.Request.Context(), category); err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrDuplicateEntry):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Success: false,
+				Error:   "Ingredient category already exists",
+				Message: "An ingredient category with this name already exists",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Message: "Failed to create ingredient category",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.IngredientCategoryResponse{
+		Success: true,
+		Message: "Ingredient category created successfully",
+		Data:    *category,
+	})
+}
+
+// GetIngredientCategory récupère une catégorie d'ingrédients par son ID
+// @Summary Récupérer une catégorie d'ingrédients
+// @Tags IngredientCategories
+// @Produce json
+// @Param id path uint true "ID de la catégorie"
+// @Success 200 {object} dto.IngredientCategoryResponse "Détails de la catégorie"
+// @Failure 400 {object} dto.ErrorResponse "ID invalide"
+// @Failure 404 {object} dto.ErrorResponse "Catégorie non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredient-categories/{id} [get]
+func (h *IngredientCategoryHandler) GetIngredientCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid category ID",
+			Message: "Category ID must be a number",
+		})
+		return
+	}
+
+	category, err := h.ormService.IngredientCategoryRepository.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Success: false,
+				Error:   "Ingredient category not found",
+				Message: "No ingredient category found with this ID",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Message: "Failed to retrieve ingredient category",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.IngredientCategoryResponse{
+		Success: true,
+		Data:    *category,
+	})
+}
+
+// UpdateIngredientCategory met à jour une catégorie d'ingrédients
+// @Summary Mettre à jour une catégorie d'ingrédients
+// @Description Met à jour une catégorie d'ingrédients. Un changement de nom est reporté sur ses ingrédients.
+// @Tags IngredientCategories
+// @Accept json
+// @Produce json
+// @Param id path uint true "ID de la catégorie"
+// @Param category body dto.IngredientCategoryUpdateRequest true "Données de la catégorie à mettre à jour"
+// @Success 200 {object} dto.IngredientCategoryResponse "Catégorie mise à jour avec succès"
+// @Failure 400 {object} dto.ErrorResponse "Requête invalide"
+// @Failure 404 {object} dto.ErrorResponse "Catégorie non trouvée"
+// @Failure 409 {object} dto.ErrorResponse "Conflit - nom de catégorie déjà utilisé"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredient-categories/{id} [put]
+func (h *IngredientCategoryHandler) UpdateIngredientCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid category ID",
+			Message: "Category ID must be a number",
+		})
+		return
+	}
+
+	var req dto.IngredientCategoryUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	category, err := h.ormService.IngredientCategoryRepository.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Success: false,
+				Error:   "Ingredient category not found",
+				Message: "No ingredient category found with this ID",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Message: "Failed to retrieve ingredient category",
+			})
+		}
+		return
+	}
+
+	if req.Name != "" {
+		category.Name = req.Name
+	}
+	if req.Description != "" {
+		category.Description = req.Description
+	}
+	if req.Icon != "" {
+		category.Icon = req.Icon
+	}
+	if req.Position != nil {
+		category.Position = *req.Position
+	}
+
+	if err := h.ormService.IngredientCategoryRepository.Update(c.Request.Context(), category); err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrDuplicateEntry):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Success: false,
+				Error:   "Ingredient category already exists",
+				Message: "An ingredient category with this name already exists",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Message: "Failed to update ingredient category",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.IngredientCategoryResponse{
+		Success: true,
+		Message: "Ingredient category updated successfully",
+		Data:    *category,
+	})
+}
+
+// DeleteIngredientCategory supprime une catégorie d'ingrédients
+// @Summary Supprimer une catégorie d'ingrédients
+// @Description Supprime une catégorie d'ingrédients. Ses ingrédients sont conservés, sans catégorie.
+// @Tags IngredientCategories
+// @Produce json
+// @Param id path uint true "ID de la catégorie"
+// @Success 200 {object} dto.MessageResponse "Catégorie supprimée avec succès"
+// @Failure 400 {object} dto.ErrorResponse "ID invalide"
+// @Failure 404 {object} dto.ErrorResponse "Catégorie non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredient-categories/{id} [delete]
+func (h *IngredientCategoryHandler) DeleteIngredientCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid category ID",
+			Message: "Category ID must be a number",
+		})
+		return
+	}
+
+	if err := h.ormService.IngredientCategoryRepository.Delete(c.Request.Context(), uint(id)); err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Success: false,
+				Error:   "Ingredient category not found",
+				Message: "No ingredient category found with this ID",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Message: "Failed to delete ingredient category",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Success: true,
+		Message: "Ingredient category deleted successfully",
+	})
+}
+
+// ListIngredientCategories liste les catégories d'ingrédients
+// @Summary Lister les catégories d'ingrédients
+// @Description Récupère toutes les catégories d'ingrédients dans leur ordre d'affichage
+// @Tags IngredientCategories
+// @Produce json
+// @Success 200 {array} dto.IngredientCategory "Liste des catégories"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredient-categories [get]
+func (h *IngredientCategoryHandler) ListIngredientCategories(c *gin.Context) {
+	categories, err := h.ormService.IngredientCategoryRepository.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   "Internal server error",
+			Message: "Failed to retrieve ingredient categories",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    categories,
+	})
+}
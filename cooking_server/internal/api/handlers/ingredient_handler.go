@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
 	"github.com/romainrodriguez/cooking_server/internal/dto"
 	"github.com/romainrodriguez/cooking_server/internal/services/orm"
 	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
@@ -23,15 +26,48 @@ func NewIngredientHandler(ormService *orm.ORMService) *IngredientHandler {
 	}
 }
 
+// RequireAdmin réserve les modifications du référentiel partagé d'ingrédients aux administrateurs
+// À placer après AuthMiddleware
+func (h *IngredientHandler) RequireAdmin(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		c.Abort()
+		return
+	}
+
+	user, err := h.ormService.UserRepository.GetByID(c.Request.Context(), userID)
+	if err != nil && !errors.Is(err, ormerrors.ErrRecordNotFound) {
+		log.Printf("Erreur lors de la vérification des droits administrateur: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   "Internal server error",
+			Message: "Failed to check permissions",
+		})
+		return
+	}
+	if user == nil || !user.IsAdmin {
+		c.AbortWithStatusJSON(http.StatusForbidden, dto.ErrorResponse{
+			Success: false,
+			Error:   "Forbidden",
+			Message: "Only administrators can modify shared ingredients",
+		})
+		return
+	}
+
+	c.Next()
+}
+
 // CreateIngredient crée un nouvel ingrédient
 // @Summary Créer un nouvel ingrédient
 // @Description Crée un nouvel ingrédient utilisable dans les recettes
 // @Tags Ingredients
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param ingredient body dto.IngredientCreateRequest true "Informations de l'ingrédient"
 // @Success 201 {object} dto.IngredientResponse "Ingrédient créé avec succès"
 // @Failure 400 {object} dto.ErrorResponse "Requête invalide"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
 // @Failure 409 {object} dto.ErrorResponse "Ingrédient déjà existant"
 // @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
 // @Router /ingredients [post]
@@ -51,6 +87,21 @@ func (h *IngredientHandler) CreateIngredient(c *gin.Context) {
 		Description: request.Description,
 		Category:    request.Category,
 		Icon:        request.Icon,
+		Density:     request.Density,
+		PieceWeight: request.PieceWeight,
+
+		EnergyKcal:    request.EnergyKcal,
+		Protein:       request.Protein,
+		Fat:           request.Fat,
+		Carbohydrates: request.Carbohydrates,
+		Fiber:         request.Fiber,
+		Salt:          request.Salt,
+
+		Allergens: request.Allergens,
+		Diets:     request.Diets,
+
+		CategoryID: request.CategoryID,
+		ParentID:   request.ParentID,
 	}
 
 	if err := h.ormService.IngredientRepository.Create(c.Request.Context(), ingredient); err != nil {
@@ -61,6 +112,12 @@ func (h *IngredientHandler) CreateIngredient(c *gin.Context) {
 				Error:   "Ingredient already exists",
 				Message: "An ingredient with this name already exists",
 			})
+		case errors.Is(err, ormerrors.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Success: false,
+				Error:   "Invalid input",
+				Message: err.Error(),
+			})
 		default:
 			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 				Success: false,
@@ -120,6 +177,13 @@ func (h *IngredientHandler) GetIngredient(c *gin.Context) {
 		return
 	}
 
+	aliases, err := h.ormService.IngredientRepository.GetAliases(c.Request.Context(), ingredient.ID)
+	if err != nil {
+		log.Printf("Failed to load ingredient aliases: %v", err)
+	}
+	ingredient.Aliases = aliases
+	h.applyDisplayNames(c, []*dto.Ingredient{ingredient})
+
 	c.JSON(http.StatusOK, dto.IngredientResponse{
 		Success: true,
 		Data:    *ingredient,
@@ -132,10 +196,13 @@ func (h *IngredientHandler) GetIngredient(c *gin.Context) {
 // @Tags Ingredients
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path uint true "ID de l'ingrédient"
 // @Param ingredient body dto.IngredientUpdateRequest true "Données de l'ingrédient à mettre à jour"
 // @Success 200 {object} dto.IngredientResponse "Ingrédient mis à jour avec succès"
 // @Failure 400 {object} dto.ErrorResponse "Requête invalide"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 403 {object} dto.ErrorResponse "Réservé aux administrateurs"
 // @Failure 404 {object} dto.ErrorResponse "Ingrédient non trouvé"
 // @Failure 409 {object} dto.ErrorResponse "Conflit - nom d'ingrédient déjà utilisé"
 // @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
@@ -190,11 +257,49 @@ func (h *IngredientHandler) UpdateIngredient(c *gin.Context) {
 		ingredient.Description = request.Description
 	}
 	if request.Category != "" {
+		// Le nom de catégorie désigne une nouvelle catégorie, résolue par le repository
 		ingredient.Category = request.Category
+		ingredient.CategoryID = nil
 	}
 	if request.Icon != "" {
 		ingredient.Icon = request.Icon
 	}
+	if request.Density != nil {
+		ingredient.Density = request.Density
+	}
+	if request.PieceWeight != nil {
+		ingredient.PieceWeight = request.PieceWeight
+	}
+	if request.EnergyKcal != nil {
+		ingredient.EnergyKcal = request.EnergyKcal
+	}
+	if request.Protein != nil {
+		ingredient.Protein = request.Protein
+	}
+	if request.Fat != nil {
+		ingredient.Fat = request.Fat
+	}
+	if request.Carbohydrates != nil {
+		ingredient.Carbohydrates = request.Carbohydrates
+	}
+	if request.Fiber != nil {
+		ingredient.Fiber = request.Fiber
+	}
+	if request.Salt != nil {
+		ingredient.Salt = request.Salt
+	}
+	if request.Allergens != nil {
+		ingredient.Allergens = request.Allergens
+	}
+	if request.Diets != nil {
+		ingredient.Diets = request.Diets
+	}
+	if request.CategoryID != nil {
+		ingredient.CategoryID = request.CategoryID
+	}
+	if request.ParentID != nil {
+		ingredient.ParentID = request.ParentID
+	}
 
 	err = h.ormService.IngredientRepository.Update(c.Request.Context(), ingredient)
 	if err != nil {
@@ -205,6 +310,12 @@ func (h *IngredientHandler) UpdateIngredient(c *gin.Context) {
 				Error:   "Ingredient already exists",
 				Message: "An ingredient with this name already exists",
 			})
+		case errors.Is(err, ormerrors.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Success: false,
+				Error:   "Invalid input",
+				Message: err.Error(),
+			})
 		default:
 			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 				Success: false,
@@ -227,9 +338,12 @@ func (h *IngredientHandler) UpdateIngredient(c *gin.Context) {
 // @Tags Ingredients
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path uint true "ID de l'ingrédient"
 // @Success 200 {object} dto.MessageResponse "Ingrédient supprimé avec succès"
 // @Failure 400 {object} dto.ErrorResponse "ID invalide"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 403 {object} dto.ErrorResponse "Réservé aux administrateurs"
 // @Failure 404 {object} dto.ErrorResponse "Ingrédient non trouvé"
 // @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
 // @Router /ingredients/{id} [delete]
@@ -277,6 +391,7 @@ func (h *IngredientHandler) DeleteIngredient(c *gin.Context) {
 // @Produce json
 // @Param page query int false "Numéro de page (défaut: 1)"
 // @Param limit query int false "Nombre d'éléments par page (défaut: 50)"
+// @Param locale query string false "Langue du nom affiché (défaut: en-tête Accept-Language)"
 // @Success 200 {object} dto.IngredientListResponse "Liste des ingrédients"
 // @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
 // @Router /ingredients [get]
@@ -309,6 +424,8 @@ func (h *IngredientHandler) ListIngredients(c *gin.Context) {
 		return
 	}
 
+	h.applyDisplayNames(c, ingredientPointers)
+
 	// Convert []*dto.Ingredient to []dto.Ingredient
 	ingredients := make([]dto.Ingredient, len(ingredientPointers))
 	for i, ingredientPtr := range ingredientPointers {
@@ -332,12 +449,13 @@ func (h *IngredientHandler) ListIngredients(c *gin.Context) {
 
 // SearchIngredients recherche des ingrédients par nom (autocomplétion)
 // @Summary Rechercher des ingrédients
-// @Description Recherche des ingrédients par nom pour l'autocomplétion
+// @Description Recherche des ingrédients par nom ou alias (synonymes, noms traduits) pour l'autocomplétion
 // @Tags Ingredients
 // @Accept json
 // @Produce json
 // @Param q query string true "Terme de recherche"
 // @Param limit query int false "Limite de résultats (défaut: 10, max: 50)"
+// @Param locale query string false "Langue du nom affiché (défaut: en-tête Accept-Language)"
 // @Success 200 {object} dto.IngredientSearchResponse "Résultats de recherche"
 // @Failure 400 {object} dto.ErrorResponse "Paramètre de recherche manquant"
 // @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
@@ -370,6 +488,8 @@ func (h *IngredientHandler) SearchIngredients(c *gin.Context) {
 		return
 	}
 
+	h.applyDisplayNames(c, ingredientPointers)
+
 	// Convert []*dto.Ingredient to []dto.Ingredient
 	ingredients := make([]dto.Ingredient, len(ingredientPointers))
 	for i, ingredientPtr := range ingredientPointers {
@@ -385,3 +505,507 @@ func (h *IngredientHandler) SearchIngredients(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// ListIngredientAliases liste les alias d'un ingrédient
+// @Summary Lister les alias d'un ingrédient
+// @Description Récupère les synonymes et noms traduits d'un ingrédient, par langue
+// @Tags Ingredients
+// @Produce json
+// @Param id path uint true "ID de l'ingrédient"
+// @Success 200 {array} dto.IngredientAlias "Alias de l'ingrédient"
+// @Failure 400 {object} dto.ErrorResponse "ID invalide"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredients/{id}/aliases [get]
+func (h *IngredientHandler) ListIngredientAliases(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid ingredient ID",
+			Message: "Ingredient ID must be a number",
+		})
+		return
+	}
+
+	aliases, err := h.ormService.IngredientRepository.GetAliases(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   "Internal server error",
+			Message: "Failed to retrieve ingredient aliases",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    aliases,
+	})
+}
+
+// AddIngredientAlias ajoute un alias à un ingrédient
+// @Summary Ajouter un alias à un ingrédient
+// @Description Ajoute un synonyme ou un nom traduit. Un alias préféré devient le nom affiché pour sa langue.
+// @Tags Ingredients
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "ID de l'ingrédient"
+// @Param alias body dto.IngredientAliasCreateRequest true "Alias à ajouter"
+// @Success 201 {object} dto.IngredientAlias "Alias ajouté"
+// @Failure 400 {object} dto.ErrorResponse "Requête invalide"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 403 {object} dto.ErrorResponse "Réservé aux administrateurs"
+// @Failure 404 {object} dto.ErrorResponse "Ingrédient non trouvé"
+// @Failure 409 {object} dto.ErrorResponse "Alias déjà utilisé dans cette langue"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredients/{id}/aliases [post]
+func (h *IngredientHandler) AddIngredientAlias(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid ingredient ID",
+			Message: "Ingredient ID must be a number",
+		})
+		return
+	}
+
+	var request dto.IngredientAliasCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	alias := &dto.IngredientAlias{
+		IngredientID: uint(id),
+		Locale:       request.Locale,
+		Name:         request.Name,
+		IsPreferred:  request.IsPreferred,
+	}
+	if err := h.ormService.IngredientRepository.AddAlias(c.Request.Context(), alias); err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Success: false,
+				Error:   "Ingredient not found",
+				Message: "No ingredient found with this ID",
+			})
+		case errors.Is(err, ormerrors.ErrDuplicateEntry):
+			c.JSON(http.StatusConflict, dto.ErrorResponse{
+				Success: false,
+				Error:   "Alias already exists",
+				Message: "This name is already used by an ingredient in this locale",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Message: "Failed to add ingredient alias",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    alias,
+	})
+}
+
+// DeleteIngredientAlias supprime un alias d'un ingrédient
+// @Summary Supprimer un alias d'un ingrédient
+// @Tags Ingredients
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "ID de l'ingrédient"
+// @Param alias_id path uint true "ID de l'alias"
+// @Success 200 {object} dto.MessageResponse "Alias supprimé"
+// @Failure 400 {object} dto.ErrorResponse "ID invalide"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 403 {object} dto.ErrorResponse "Réservé aux administrateurs"
+// @Failure 404 {object} dto.ErrorResponse "Alias non trouvé"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredients/{id}/aliases/{alias_id} [delete]
+func (h *IngredientHandler) DeleteIngredientAlias(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	aliasID, aliasErr := strconv.ParseUint(c.Param("alias_id"), 10, 32)
+	if err != nil || aliasErr != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid ID",
+			Message: "Ingredient and alias IDs must be numbers",
+		})
+		return
+	}
+
+	if err := h.ormService.IngredientRepository.DeleteAlias(c.Request.Context(), uint(id), uint(aliasID)); err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Success: false,
+				Error:   "Alias not found",
+				Message: "No alias found with this ID for this ingredient",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Message: "Failed to delete ingredient alias",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Success: true,
+		Message: "Ingredient alias deleted successfully",
+	})
+}
+
+// MergeIngredients fusionne des ingrédients en double dans un ingrédient cible
+// @Summary Fusionner des ingrédients en double
+// @Description Reporte toutes les références des doublons (recettes, frigo, consommations, révisions, alias) sur l'ingrédient cible dans une seule transaction, enregistre leurs noms comme alias puis les supprime
+// @Tags Ingredients
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param merge body dto.IngredientMergeRequest true "Ingrédient cible et doublons"
+// @Success 200 {object} dto.IngredientMergeResult "Résultat de la fusion"
+// @Failure 400 {object} dto.ErrorResponse "Requête invalide"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 403 {object} dto.ErrorResponse "Réservé aux administrateurs"
+// @Failure 404 {object} dto.ErrorResponse "Ingrédient non trouvé"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredients/merge [post]
+func (h *IngredientHandler) MergeIngredients(c *gin.Context) {
+	var request dto.IngredientMergeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.ormService.IngredientRepository.Merge(c.Request.Context(), &request)
+	if err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Success: false,
+				Error:   "Ingredient not found",
+				Message: err.Error(),
+			})
+		case errors.Is(err, ormerrors.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Success: false,
+				Error:   "Invalid input",
+				Message: err.Error(),
+			})
+		default:
+			log.Printf("Erreur lors de la fusion des ingrédients: %v", err)
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Message: "Failed to merge ingredients",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ListDuplicateIngredients propose des paires d'ingrédients susceptibles d'être des doublons
+// @Summary Lister les doublons probables
+// @Description Compare les noms d'ingrédients (sans accents ni casse) et propose les paires les plus proches, avec l'ingrédient à conserver
+// @Tags Ingredients
+// @Produce json
+// @Param threshold query number false "Similarité minimale entre 0 et 1 (défaut: 0.5)"
+// @Param limit query int false "Nombre maximum de paires (défaut: 50, max: 200)"
+// @Success 200 {array} dto.IngredientDuplicateCandidate "Paires candidates"
+// @Failure 400 {object} dto.ErrorResponse "Paramètre invalide"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredients/duplicates [get]
+func (h *IngredientHandler) ListDuplicateIngredients(c *gin.Context) {
+	threshold := 0.5
+	if thresholdStr := c.Query("threshold"); thresholdStr != "" {
+		t, err := strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || t <= 0 || t > 1 {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Success: false,
+				Error:   "Invalid threshold",
+				Message: "threshold must be a number between 0 and 1",
+			})
+			return
+		}
+		threshold = t
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+
+	candidates, err := h.ormService.IngredientRepository.FindDuplicateCandidates(c.Request.Context(), threshold, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   "Internal server error",
+			Message: "Failed to find duplicate ingredients",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    candidates,
+	})
+}
+
+// GetIngredientHierarchy récupère la place d'un ingrédient dans la taxonomie
+// @Summary Récupérer la hiérarchie d'un ingrédient
+// @Description Récupère les ingrédients plus généraux (du parent direct à la racine) et les variantes plus spécifiques d'un ingrédient. Une variante satisfait les recettes demandant l'un de ses ancêtres.
+// @Tags Ingredients
+// @Produce json
+// @Param id path uint true "ID de l'ingrédient"
+// @Success 200 {object} dto.IngredientHierarchy "Hiérarchie de l'ingrédient"
+// @Failure 400 {object} dto.ErrorResponse "ID invalide"
+// @Failure 404 {object} dto.ErrorResponse "Ingrédient non trouvé"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredients/{id}/hierarchy [get]
+func (h *IngredientHandler) GetIngredientHierarchy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid ingredient ID",
+			Message: "Ingredient ID must be a number",
+		})
+		return
+	}
+
+	hierarchy, err := h.ormService.IngredientRepository.GetHierarchy(c.Request.Context(), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Success: false,
+				Error:   "Ingredient not found",
+				Message: "No ingredient found with this ID",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Message: "Failed to retrieve ingredient hierarchy",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    hierarchy,
+	})
+}
+
+// ListIngredientSubstitutions liste les remplacements possibles d'un ingrédient
+// @Summary Lister les remplacements d'un ingrédient
+// @Description Récupère les remplacements de l'ingrédient et ceux de ses ingrédients plus généraux, avec leurs quantités de référence
+// @Tags Ingredients
+// @Produce json
+// @Param id path uint true "ID de l'ingrédient"
+// @Success 200 {array} dto.IngredientSubstitution "Remplacements de l'ingrédient"
+// @Failure 400 {object} dto.ErrorResponse "ID invalide"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredients/{id}/substitutions [get]
+func (h *IngredientHandler) ListIngredientSubstitutions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid ingredient ID",
+			Message: "Ingredient ID must be a number",
+		})
+		return
+	}
+
+	substitutions, err := h.ormService.IngredientSubstitutionRepository.GetByIngredient(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Success: false,
+			Error:   "Internal server error",
+			Message: "Failed to retrieve ingredient substitutions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    substitutions,
+	})
+}
+
+// AddIngredientSubstitution ajoute un remplacement à un ingrédient
+// @Summary Ajouter un remplacement à un ingrédient
+// @Description Enregistre un remplacement (ex: 1 œuf → 1 c. à soupe de graines de lin + 3 c. à soupe d'eau). Les quantités des composants correspondent à la quantité de référence et sont appliquées proportionnellement.
+// @Tags Ingredients
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "ID de l'ingrédient remplacé"
+// @Param substitution body dto.IngredientSubstitutionCreateRequest true "Remplacement à ajouter"
+// @Success 201 {object} dto.IngredientSubstitution "Remplacement ajouté"
+// @Failure 400 {object} dto.ErrorResponse "Requête invalide"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 403 {object} dto.ErrorResponse "Réservé aux administrateurs"
+// @Failure 404 {object} dto.ErrorResponse "Ingrédient non trouvé"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredients/{id}/substitutions [post]
+func (h *IngredientHandler) AddIngredientSubstitution(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid ingredient ID",
+			Message: "Ingredient ID must be a number",
+		})
+		return
+	}
+
+	var request dto.IngredientSubstitutionCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	substitution := &dto.IngredientSubstitution{
+		IngredientID: uint(id),
+		Quantity:     request.Quantity,
+		Unit:         request.Unit,
+		Notes:        request.Notes,
+		Diets:        request.Diets,
+		Components:   make([]dto.IngredientSubstitutionComponent, 0, len(request.Components)),
+	}
+	for _, component := range request.Components {
+		substitution.Components = append(substitution.Components, dto.IngredientSubstitutionComponent{
+			IngredientID: component.IngredientID,
+			Quantity:     component.Quantity,
+			Unit:         component.Unit,
+		})
+	}
+
+	if err := h.ormService.IngredientSubstitutionRepository.Create(c.Request.Context(), substitution); err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Success: false,
+				Error:   "Ingredient not found",
+				Message: err.Error(),
+			})
+		case errors.Is(err, ormerrors.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Success: false,
+				Error:   "Invalid input",
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Message: "Failed to add ingredient substitution",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    substitution,
+	})
+}
+
+// DeleteIngredientSubstitution supprime un remplacement d'un ingrédient
+// @Summary Supprimer un remplacement d'un ingrédient
+// @Tags Ingredients
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "ID de l'ingrédient"
+// @Param substitution_id path uint true "ID du remplacement"
+// @Success 200 {object} dto.MessageResponse "Remplacement supprimé"
+// @Failure 400 {object} dto.ErrorResponse "ID invalide"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 403 {object} dto.ErrorResponse "Réservé aux administrateurs"
+// @Failure 404 {object} dto.ErrorResponse "Remplacement non trouvé"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /ingredients/{id}/substitutions/{substitution_id} [delete]
+func (h *IngredientHandler) DeleteIngredientSubstitution(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	substitutionID, substitutionErr := strconv.ParseUint(c.Param("substitution_id"), 10, 32)
+	if err != nil || substitutionErr != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Success: false,
+			Error:   "Invalid ID",
+			Message: "Ingredient and substitution IDs must be numbers",
+		})
+		return
+	}
+
+	if err := h.ormService.IngredientSubstitutionRepository.Delete(c.Request.Context(), uint(id), uint(substitutionID)); err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Success: false,
+				Error:   "Substitution not found",
+				Message: "No substitution found with this ID for this ingredient",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+				Success: false,
+				Error:   "Internal server error",
+				Message: "Failed to delete ingredient substitution",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Success: true,
+		Message: "Ingredient substitution deleted successfully",
+	})
+}
+
+// applyDisplayNames renseigne le nom affiché des ingrédients dans la langue de la requête
+func (h *IngredientHandler) applyDisplayNames(c *gin.Context, ingredients []*dto.Ingredient) {
+	if err := h.ormService.IngredientRepository.ApplyDisplayNames(c.Request.Context(), requestLocale(c), ingredients); err != nil {
+		log.Printf("Failed to apply ingredient display names: %v", err)
+	}
+}
+
+// requestLocale retourne la langue demandée : paramètre "locale", sinon première langue de l'en-tête Accept-Language
+func requestLocale(c *gin.Context) string {
+	locale := c.Query("locale")
+	if locale == "" {
+		locale = strings.Split(c.GetHeader("Accept-Language"), ",")[0]
+		locale = strings.Split(locale, ";")[0]
+	}
+	// Ne garder que la langue principale ("fr-FR" → "fr")
+	locale = strings.Split(strings.TrimSpace(locale), "-")[0]
+	return strings.ToLower(locale)
+}
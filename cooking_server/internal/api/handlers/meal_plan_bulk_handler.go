@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+)
+
+// CopyMealPlans copie les repas d'une période vers une autre date
+// @Summary Copier des repas
+// @Description Copie les repas planifiés d'un jour ou d'une période à partir de la date cible, en conservant l'écart entre les jours. Les copies ne sont pas réalisées et les occurrences de repas récurrents sont copiées comme des repas indépendants.
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param copy body dto.MealPlanCopyRequest true "Période source et date cible"
+// @Success 200 {object} dto.WeeklyMealPlanResponse "Planning de la période cible"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/copy [post]
+func (h *MealPlanHandler) CopyMealPlans(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MealPlanCopyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	startDate, endDate, ok := parseMealPlanRange(c, req.StartDate, req.EndDate)
+	if !ok {
+		return
+	}
+	// Format déjà validé par la requête
+	targetDate, _ := time.Parse("2006-01-02", req.TargetDate)
+
+	count, err := h.ormService.MealPlanRepository.CopyRange(c.Request.Context(), userID, startDate, endDate, targetDate)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to copy meal plans")
+		return
+	}
+
+	h.respondMealPlanRange(c, userID, targetDate, targetDate.Add(endDate.Sub(startDate)), count, "Meal plans copied successfully")
+}
+
+// ShiftMealPlans décale les repas d'une période
+// @Summary Décaler des repas
+// @Description Décale de N jours (négatif pour avancer) les repas non réalisés d'un jour ou d'une période. Les occurrences de repas récurrents déplacées ne suivent plus leur règle.
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param shift body dto.MealPlanShiftRequest true "Période et nombre de jours"
+// @Success 200 {object} dto.WeeklyMealPlanResponse "Planning de la période décalée"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/shift [post]
+func (h *MealPlanHandler) ShiftMealPlans(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MealPlanShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	startDate, endDate, ok := parseMealPlanRange(c, req.StartDate, req.EndDate)
+	if !ok {
+		return
+	}
+
+	count, err := h.ormService.MealPlanRepository.ShiftRange(c.Request.Context(), userID, startDate, endDate, req.Days)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to shift meal plans")
+		return
+	}
+
+	h.respondMealPlanRange(c, userID, startDate.AddDate(0, 0, req.Days), endDate.AddDate(0, 0, req.Days), count, "Meal plans shifted successfully")
+}
+
+// SwapMealPlans échange deux repas
+// @Summary Échanger deux repas
+// @Description Échange la date et le type de repas de deux repas planifiés. Les occurrences de repas récurrents échangées ne suivent plus leur règle, et un repas avec des restes ne peut pas être déplacé après leur péremption.
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param swap body dto.MealPlanSwapRequest true "Repas à échanger"
+// @Success 200 {object} dto.WeeklyMealPlanResponse "Planning des jours concernés"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Repas non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/swap [post]
+func (h *MealPlanHandler) SwapMealPlans(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MealPlanSwapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	swapped, err := h.ormService.MealPlanRepository.Swap(c.Request.Context(), userID, req.FirstID, req.SecondID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to swap meal plans")
+		return
+	}
+
+	startDate, endDate := swapped[0].PlannedDate, swapped[1].PlannedDate
+	if endDate.Before(startDate) {
+		startDate, endDate = endDate, startDate
+	}
+	h.respondMealPlanRange(c, userID, dayStart(startDate), dayStart(endDate), len(swapped), "Meal plans swapped successfully")
+}
+
+// ClearMealPlans supprime les repas d'une période
+// @Summary Vider une période
+// @Description Supprime les repas planifiés d'un jour ou d'une période (les repas réalisés sont conservés sauf demande contraire). Les occurrences de repas récurrents supprimées ne sont pas recréées.
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param clear body dto.MealPlanClearRequest true "Période à vider"
+// @Success 200 {object} dto.WeeklyMealPlanResponse "Planning de la période vidée"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/clear [post]
+func (h *MealPlanHandler) ClearMealPlans(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MealPlanClearRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	startDate, endDate, ok := parseMealPlanRange(c, req.StartDate, req.EndDate)
+	if !ok {
+		return
+	}
+
+	count, err := h.ormService.MealPlanRepository.ClearRange(c.Request.Context(), userID, startDate, endDate, req.IncludeCompleted)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to clear meal plans")
+		return
+	}
+
+	h.respondMealPlanRange(c, userID, startDate, endDate, count, "Meal plans cleared successfully")
+}
+
+// respondMealPlanRange répond avec le planning résultant d'une opération groupée, du premier au dernier jour inclus
+func (h *MealPlanHandler) respondMealPlanRange(c *gin.Context, userID uint, startDate, endDate time.Time, count int, message string) {
+	endOfDay := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 59, 999999999, endDate.Location())
+	weeklyPlan, err := h.weeklyMealPlan(c, userID, startDate, endOfDay)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve resulting meal plan",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"data":     weeklyPlan,
+		"affected": count,
+		"message":  message,
+	})
+}
+
+// parseMealPlanRange lit une période de jours ; sans date de fin, la période se limite au premier jour
+func parseMealPlanRange(c *gin.Context, start, end string) (time.Time, time.Time, bool) {
+	// Formats déjà validés par la requête
+	startDate, _ := time.Parse("2006-01-02", start)
+	endDate := startDate
+	if end != "" {
+		endDate, _ = time.Parse("2006-01-02", end)
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid date range",
+			"message": "end_date must be after or equal to start_date",
+		})
+		return time.Time{}, time.Time{}, false
+	}
+	return startDate, endDate, true
+}
+
+// dayStart retourne le début du jour d'une date
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
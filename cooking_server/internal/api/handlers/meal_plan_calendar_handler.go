@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	"github.com/romainrodriguez/cooking_server/internal/services/export"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+)
+
+// Période couverte par le flux iCalendar, autour du jour courant
+const (
+	calendarFeedPastDays   = 30
+	calendarFeedFutureDays = 90
+)
+
+// maxCalendarImportSize est la taille maximale d'un fichier iCalendar importé
+const maxCalendarImportSize = 1024 * 1024 // 1MB
+
+// GetMealPlanCalendar récupère les réglages du flux iCalendar de l'utilisateur connecté
+// @Summary Récupérer mon flux iCalendar
+// @Description Retourne l'URL d'abonnement au flux iCalendar du planning (créée au premier accès), les heures associées à chaque type de repas, la durée des événements et le fuseau horaire
+// @Tags MealPlans
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.MealPlanCalendar "Réglages du flux"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/calendar [get]
+func (h *MealPlanHandler) GetMealPlanCalendar(c *gin.Context) {
+	calendar, ok := h.loadOwnCalendar(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    calendar,
+	})
+}
+
+// UpdateMealPlanCalendar modifie les réglages du flux iCalendar
+// @Summary Modifier mon flux iCalendar
+// @Description Modifie les heures des repas (HH:MM), la durée des événements (minutes) et le fuseau horaire du flux
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param calendar body dto.MealPlanCalendarUpdateRequest true "Réglages à modifier"
+// @Success 200 {object} dto.MealPlanCalendar "Réglages modifiés"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/calendar [put]
+func (h *MealPlanHandler) UpdateMealPlanCalendar(c *gin.Context) {
+	var req dto.MealPlanCalendarUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	calendar, ok := h.loadOwnCalendar(c)
+	if !ok {
+		return
+	}
+
+	if req.BreakfastTime != "" {
+		calendar.BreakfastTime = req.BreakfastTime
+	}
+	if req.LunchTime != "" {
+		calendar.LunchTime = req.LunchTime
+	}
+	if req.DinnerTime != "" {
+		calendar.DinnerTime = req.DinnerTime
+	}
+	if req.SnackTime != "" {
+		calendar.SnackTime = req.SnackTime
+	}
+	if req.Duration > 0 {
+		calendar.Duration = req.Duration
+	}
+	if req.TimeZone != "" {
+		calendar.TimeZone = req.TimeZone
+	}
+
+	if err := h.ormService.MealPlanCalendarRepository.Update(c.Request.Context(), calendar); err != nil {
+		respondRepositoryError(c, err, "Failed to update meal plan calendar")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    calendar,
+		"message": "Meal plan calendar updated successfully",
+	})
+}
+
+// RegenerateMealPlanCalendarToken remplace le jeton du flux iCalendar
+// @Summary Régénérer l'URL de mon flux iCalendar
+// @Description Remplace le jeton de l'URL d'abonnement : les calendriers abonnés à l'ancienne URL ne reçoivent plus le planning
+// @Tags MealPlans
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.MealPlanCalendar "Réglages avec la nouvelle URL"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/calendar/token [post]
+func (h *MealPlanHandler) RegenerateMealPlanCalendarToken(c *gin.Context) {
+	calendar, ok := h.loadOwnCalendar(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ormService.MealPlanCalendarRepository.RegenerateToken(c.Request.Context(), calendar); err != nil {
+		respondRepositoryError(c, err, "Failed to regenerate meal plan calendar token")
+		return
+	}
+	calendar.FeedURL = calendarFeedURL(c, calendar)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    calendar,
+		"message": "Meal plan calendar token regenerated successfully",
+	})
+}
+
+// GetMealPlanCalendarFeed retourne le flux iCalendar d'un planning
+// @Summary Flux iCalendar du planning
+// @Description Flux d'abonnement (.ics) des repas planifiés des 30 derniers jours et des 90 prochains, protégé par le jeton personnel de l'URL. Chaque repas est un événement à l'heure de son type de repas, avec le lien vers la recette.
+// @Tags MealPlans
+// @Produce text/calendar
+// @Param token path string true "Jeton du flux (suffixe .ics facultatif)"
+// @Success 200 {string} string "Calendrier iCalendar"
+// @Failure 404 {object} map[string]interface{} "Flux non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/feed/{token} [get]
+func (h *MealPlanHandler) GetMealPlanCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	calendar, err := h.ormService.MealPlanCalendarRepository.GetByToken(c.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, ormerrors.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Calendar not found",
+				"message": "No meal plan calendar matches this link",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve meal plan calendar",
+		})
+		return
+	}
+
+	today := dayStart(time.Now())
+	mealPlans, err := h.ormService.MealPlanRepository.GetByUserAndDateRange(c.Request.Context(), calendar.UserID,
+		today.AddDate(0, 0, -calendarFeedPastDays), today.AddDate(0, 0, calendarFeedFutureDays))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve meal plans",
+		})
+		return
+	}
+
+	baseURL := getBaseURL(c)
+	content := export.MealPlanCalendar(mealPlans, export.CalendarOptions{
+		Name:      "Planning des repas",
+		Domain:    c.Request.Host,
+		Location:  calendar.Location(),
+		MealTimes: calendar.MealTimes(),
+		Duration:  time.Duration(calendar.Duration) * time.Minute,
+		RecipeURL: func(recipeID uint) string {
+			return fmt.Sprintf("%s/api/v1/recipes/%d", baseURL, recipeID)
+		},
+	})
+	c.Header("Content-Disposition", `inline; filename="planning-repas.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", content)
+}
+
+// ImportMealPlanCalendar planifie les repas d'un fichier iCalendar
+// @Summary Importer un fichier iCalendar
+// @Description Crée un repas planifié pour chaque événement dont le titre correspond à une recette (mes recettes d'abord, puis les recettes publiques). Le type de repas est lu dans le titre ("Dîner : ...") ou déduit de l'heure de l'événement ; les événements sur la journée utilisent meal_type. Les repas déjà planifiés sont ignorés.
+// @Tags MealPlans
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file true "Fichier .ics (1MB maximum)"
+// @Param meal_type formData string false "Type de repas des événements sur la journée (défaut: dinner)" Enums(breakfast, lunch, dinner, snack)
+// @Success 201 {object} dto.MealPlanCalendarImportResult "Repas créés, ignorés et titres sans recette"
+// @Failure 400 {object} map[string]interface{} "Fichier invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/import [post]
+func (h *MealPlanHandler) ImportMealPlanCalendar(c *gin.Context) {
+	mealType := c.DefaultPostForm("meal_type", "dinner")
+	switch mealType {
+	case "breakfast", "lunch", "dinner", "snack":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid meal type",
+			"message": "meal_type must be one of breakfast, lunch, dinner, snack",
+		})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "An .ics file is required",
+		})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxCalendarImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "File too large",
+			"message": "The calendar file must not exceed 1MB",
+		})
+		return
+	}
+
+	calendar, ok := h.loadOwnCalendar(c)
+	if !ok {
+		return
+	}
+
+	events, err := export.ParseCalendar(file, calendar.Location())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid calendar file",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.ormService.MealPlanCalendarRepository.Import(c.Request.Context(), calendar, events, mealType)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to import meal plans")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    result,
+		"message": fmt.Sprintf("%d meal plan(s) imported", len(result.Created)),
+	})
+}
+
+// loadOwnCalendar charge les réglages du flux iCalendar de l'utilisateur connecté avec son URL d'abonnement
+func (h *MealPlanHandler) loadOwnCalendar(c *gin.Context) (*dto.MealPlanCalendar, bool) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	calendar, err := h.ormService.MealPlanCalendarRepository.GetOrCreateByUser(c.Request.Context(), userID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve meal plan calendar")
+		return nil, false
+	}
+	calendar.FeedURL = calendarFeedURL(c, calendar)
+	return calendar, true
+}
+
+// calendarFeedURL construit l'URL d'abonnement au flux iCalendar
+func calendarFeedURL(c *gin.Context, calendar *dto.MealPlanCalendar) string {
+	return fmt.Sprintf("%s/api/v1/meal-plans/feed/%s.ics", getBaseURL(c), calendar.Token)
+}
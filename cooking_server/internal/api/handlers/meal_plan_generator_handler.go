@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+)
+
+// GenerateMealPlans propose des recettes pour les créneaux libres d'une période
+// @Summary Générer un planning
+// @Description Propose une recette pour chaque créneau libre (jour et type de repas) d'une période de 31 jours au plus, parmi les favoris, les listes de recettes et les recettes publiques. Contraintes : temps maximal par jour de la semaine, régimes, allergènes à éviter, pas deux fois la même recette à moins de N jours et budget. Les recettes utilisant des produits du frigo bientôt périmés sont privilégiées. Rien n'est planifié : la proposition s'accepte via /meal-plans/generate/accept.
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param generate body dto.MealPlanGenerateRequest true "Période et contraintes"
+// @Success 200 {object} dto.MealPlanGenerationPreview "Proposition de planning"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/generate [post]
+func (h *MealPlanHandler) GenerateMealPlans(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MealPlanGenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	startDate, endDate, ok := parseMealPlanRange(c, req.StartDate, req.EndDate)
+	if !ok {
+		return
+	}
+	if req.EndDate == "" {
+		endDate = startDate.AddDate(0, 0, 6)
+	}
+
+	preview, err := h.ormService.MealPlanRepository.Generate(c.Request.Context(), userID, startDate, endDate, &req)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to generate meal plans")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    preview,
+	})
+}
+
+// RerollGeneratedMealPlan propose une autre recette pour un créneau d'une proposition
+// @Summary Retirer un créneau
+// @Description Propose une autre recette pour un créneau, avec les contraintes de la génération. Les recettes retenues pour les autres créneaux comptent pour la variété, les produits du frigo et le budget ; les recettes exclues ne sont pas proposées.
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param reroll body dto.MealPlanRerollRequest true "Contraintes, créneau et choix en cours"
+// @Success 200 {object} dto.MealPlanGenerationSlot "Nouvelle proposition pour le créneau"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/generate/reroll [post]
+func (h *MealPlanHandler) RerollGeneratedMealPlan(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MealPlanRerollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	slot, err := h.ormService.MealPlanRepository.RerollSlot(c.Request.Context(), userID, &req)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to reroll meal plan slot")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    slot,
+		"seed":    req.Seed,
+	})
+}
+
+// AcceptGeneratedMealPlans planifie les créneaux acceptés d'une proposition
+// @Summary Accepter une proposition
+// @Description Planifie les recettes retenues d'une proposition générée. Les créneaux occupés depuis la génération sont ignorés.
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param accept body dto.MealPlanGenerationAcceptRequest true "Créneaux acceptés"
+// @Success 200 {object} dto.WeeklyMealPlanResponse "Planning de la période concernée"
+// @Failure 400 {object} map[string]interface{} "Requête invalide ou recette inexistante"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/generate/accept [post]
+func (h *MealPlanHandler) AcceptGeneratedMealPlans(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MealPlanGenerationAcceptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	count, err := h.ormService.MealPlanRepository.CreateGenerated(c.Request.Context(), userID, req.Slots)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to accept generated meal plans")
+		return
+	}
+
+	// Formats déjà validés par la requête
+	var startDate, endDate time.Time
+	for i, slot := range req.Slots {
+		date, _ := time.Parse("2006-01-02", slot.Date)
+		if i == 0 || date.Before(startDate) {
+			startDate = date
+		}
+		if date.After(endDate) {
+			endDate = date
+		}
+	}
+	h.respondMealPlanRange(c, userID, startDate, endDate, count, "Generated meal plans accepted successfully")
+}
@@ -8,7 +8,10 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
 	"github.com/romainrodriguez/cooking_server/internal/dto"
+	"github.com/romainrodriguez/cooking_server/internal/services/export"
 	"github.com/romainrodriguez/cooking_server/internal/services/orm"
 	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
 )
@@ -171,7 +174,7 @@ func (h *MealPlanHandler) GetMealPlan(c *gin.Context) {
 
 // UpdateMealPlan met à jour un planning de repas
 // @Summary Mettre à jour un planning de repas
-// @Description Met à jour les informations d'un planning de repas existant
+// @Description Met à jour les informations d'un planning de repas existant. Une occurrence de repas récurrent modifiée ne suit plus sa règle.
 // @Tags MealPlans
 // @Accept json
 // @Produce json
@@ -181,6 +184,7 @@ func (h *MealPlanHandler) GetMealPlan(c *gin.Context) {
 // @Success 200 {object} map[string]interface{} "Planning mis à jour avec succès"
 // @Failure 400 {object} map[string]interface{} "Requête invalide"
 // @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 403 {object} map[string]interface{} "Planning d'un autre utilisateur"
 // @Failure 404 {object} map[string]interface{} "Planning non trouvé"
 // @Failure 500 {object} map[string]interface{} "Erreur serveur"
 // @Router /meal-plans/{id} [put]
@@ -227,8 +231,18 @@ func (h *MealPlanHandler) UpdateMealPlan(c *gin.Context) {
 	log.Printf("UpdateMealPlan: Current meal plan before update: RecipeID=%d, MealType=%s, Servings=%d",
 		mealPlan.RecipeID, mealPlan.MealType, mealPlan.Servings)
 
-	// TODO: Vérifier que l'utilisateur peut modifier ce planning
-	// if mealPlan.UserID != currentUserID { ... }
+	// Vérifier que l'utilisateur peut modifier ce planning (la complétion modifie son frigo)
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+	if mealPlan.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Forbidden",
+			"message": "You can only modify your own meal plans",
+		})
+		return
+	}
 
 	// Mettre à jour les champs modifiés
 	if req.RecipeID > 0 {
@@ -249,6 +263,10 @@ func (h *MealPlanHandler) UpdateMealPlan(c *gin.Context) {
 			})
 			return
 		}
+		// Une autre recette ne vient plus des restes
+		if req.RecipeID != mealPlan.RecipeID {
+			mealPlan.LeftoverID = nil
+		}
 		mealPlan.RecipeID = req.RecipeID
 		log.Printf("UpdateMealPlan: RecipeID updated to %d", mealPlan.RecipeID)
 	}
@@ -259,25 +277,46 @@ func (h *MealPlanHandler) UpdateMealPlan(c *gin.Context) {
 	if req.MealType != "" {
 		mealPlan.MealType = req.MealType
 	}
+	extraPortions := 0
 	if req.Servings > 0 {
+		extraPortions = req.Servings - mealPlan.Servings
 		mealPlan.Servings = req.Servings
 	}
+	// Un repas avec des restes doit rester avant leur péremption, dans la limite des portions disponibles
+	if mealPlan.LeftoverID != nil && (!req.PlannedDate.IsZero() || extraPortions > 0) &&
+		!h.checkLeftoverUse(c, *mealPlan.LeftoverID, mealPlan.PlannedDate, extraPortions) {
+		return
+	}
 	if req.Notes != "" {
 		mealPlan.Notes = req.Notes
 	}
 
+	// Une occurrence de repas récurrent modifiée individuellement ne suit plus sa règle
+	if mealPlan.RecurrenceID != nil && (req.RecipeID > 0 || !req.PlannedDate.IsZero() || req.MealType != "" || req.Servings > 0 || req.Notes != "") {
+		mealPlan.Detached = true
+	}
+
 	// Gérer le statut de completion
-	if req.IsCompleted && !mealPlan.IsCompleted {
-		// Marquer comme terminé
-		if err := h.ormService.MealPlanRepository.MarkAsCompleted(c.Request.Context(), mealPlan.ID); err != nil {
+	var consumption *dto.FridgeConsumption
+	if req.IsCompleted != nil && *req.IsCompleted && !mealPlan.IsCompleted {
+		// Marquer comme terminé (consomme le stock du frigo)
+		consumption, err = h.ormService.MealPlanRepository.MarkAsCompleted(c.Request.Context(), mealPlan.ID, userID)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":   "Internal server error",
 				"message": "Failed to mark meal plan as completed",
 			})
 			return
 		}
-	} else if !req.IsCompleted && mealPlan.IsCompleted {
-		// Remettre comme non terminé
+		now := time.Now()
+		mealPlan.IsCompleted = true
+		mealPlan.CompletedAt = &now
+	} else if req.IsCompleted != nil && !*req.IsCompleted && mealPlan.IsCompleted {
+		// Remettre comme non terminé (restaure le frigo si un jeton est fourni)
+		if _, err := h.ormService.MealPlanRepository.UndoCompletion(c.Request.Context(), mealPlan.ID, userID, req.UndoToken); err != nil {
+			respondUndoCompletionError(c, err)
+			return
+		}
 		mealPlan.IsCompleted = false
 		mealPlan.CompletedAt = nil
 	}
@@ -309,16 +348,21 @@ func (h *MealPlanHandler) UpdateMealPlan(c *gin.Context) {
 	log.Printf("UpdateMealPlan: Retrieved updated meal plan with RecipeID=%d, Recipe Title=%s",
 		updatedMealPlan.RecipeID, updatedMealPlan.Recipe.Title)
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"success": true,
 		"data":    updatedMealPlan,
 		"message": "Meal plan updated successfully",
-	})
+	}
+	if consumption != nil {
+		response["fridge_consumption"] = consumption
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteMealPlan supprime un planning de repas
 // @Summary Supprimer un planning de repas
-// @Description Supprime un planning de repas par son ID
+// @Description Supprime un planning de repas par son ID. Une occurrence de repas récurrent supprimée n'est pas recréée.
 // @Tags MealPlans
 // @Accept json
 // @Produce json
@@ -453,7 +497,7 @@ func (h *MealPlanHandler) GetUserMealPlans(c *gin.Context) {
 
 // GetWeeklyMealPlan récupère le planning de la semaine
 // @Summary Récupérer le planning hebdomadaire
-// @Description Récupère le planning de repas pour une semaine donnée
+// @Description Récupère le planning de repas pour une semaine donnée, occurrences des repas récurrents comprises
 // @Tags MealPlans
 // @Accept json
 // @Produce json
@@ -514,7 +558,7 @@ func (h *MealPlanHandler) GetWeeklyMealPlan(c *gin.Context) {
 	startOfWeek = time.Date(startOfWeek.Year(), startOfWeek.Month(), startOfWeek.Day(), 0, 0, 0, 0, startOfWeek.Location())
 	endOfWeek = time.Date(endOfWeek.Year(), endOfWeek.Month(), endOfWeek.Day(), 23, 59, 59, 999999999, endOfWeek.Location())
 
-	mealPlans, err := h.ormService.MealPlanRepository.GetByUserAndDateRange(c.Request.Context(), userID, startOfWeek, endOfWeek)
+	weeklyPlan, err := h.weeklyMealPlan(c, userID, startOfWeek, endOfWeek)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Internal server error",
@@ -523,10 +567,23 @@ func (h *MealPlanHandler) GetWeeklyMealPlan(c *gin.Context) {
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    weeklyPlan,
+	})
+}
+
+// weeklyMealPlan récupère les repas d'une période et les organise par date
+func (h *MealPlanHandler) weeklyMealPlan(c *gin.Context, userID uint, startDate, endDate time.Time) (*dto.WeeklyMealPlan, error) {
+	mealPlans, err := h.ormService.MealPlanRepository.GetByUserAndDateRange(c.Request.Context(), userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
 	// Organiser les repas par date
-	weeklyPlan := dto.WeeklyMealPlan{
-		StartDate: startOfWeek,
-		EndDate:   endOfWeek,
+	weeklyPlan := &dto.WeeklyMealPlan{
+		StartDate: startDate,
+		EndDate:   endDate,
 		MealPlans: make(map[string][]dto.MealPlan),
 	}
 
@@ -535,10 +592,7 @@ func (h *MealPlanHandler) GetWeeklyMealPlan(c *gin.Context) {
 		weeklyPlan.MealPlans[dateKey] = append(weeklyPlan.MealPlans[dateKey], *mealPlan)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    weeklyPlan,
-	})
+	return weeklyPlan, nil
 }
 
 // GetDailyMealPlan récupère le planning d'une journée
@@ -629,7 +683,7 @@ func (h *MealPlanHandler) GetDailyMealPlan(c *gin.Context) {
 
 // GetUpcomingMeals récupère les prochains repas planifiés
 // @Summary Récupérer les prochains repas
-// @Description Récupère les repas planifiés pour les prochains jours (non terminés)
+// @Description Récupère les repas planifiés pour les prochains jours (non terminés), occurrences des repas récurrents comprises
 // @Tags MealPlans
 // @Accept json
 // @Produce json
@@ -688,31 +742,120 @@ func (h *MealPlanHandler) GetUpcomingMeals(c *gin.Context) {
 
 // MarkMealAsCompleted marque un repas comme terminé
 // @Summary Marquer un repas comme terminé
-// @Description Marque un planning de repas comme étant terminé/préparé
+// @Description Marque un planning de repas comme étant terminé/préparé et retire du frigo les ingrédients utilisés (portions et recettes imbriquées comprises). La réponse contient un jeton permettant d'annuler la consommation.
 // @Tags MealPlans
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path uint true "ID du planning de repas"
-// @Success 200 {object} map[string]interface{} "Repas marqué comme terminé"
+// @Success 200 {object} dto.FridgeConsumption "Repas marqué comme terminé"
 // @Failure 400 {object} map[string]interface{} "ID invalide"
 // @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 403 {object} map[string]interface{} "Planning d'un autre utilisateur"
 // @Failure 404 {object} map[string]interface{} "Planning non trouvé"
 // @Failure 500 {object} map[string]interface{} "Erreur serveur"
 // @Router /meal-plans/{id}/complete [patch]
 func (h *MealPlanHandler) MarkMealAsCompleted(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
+	mealPlan, userID, ok := h.loadOwnedMealPlan(c)
+	if !ok {
+		return
+	}
+
+	consumption, err := h.ormService.MealPlanRepository.MarkAsCompleted(c.Request.Context(), mealPlan.ID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ormerrors.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Meal plan not found",
+				"message": "No meal plan found with this ID",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal server error",
+				"message": "Failed to mark meal as completed",
+			})
+		}
+		return
+	}
+
+	if consumption == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Meal plan already completed",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    consumption,
+		"message": "Meal plan marked as completed",
+	})
+}
+
+// UncompleteMeal annule la réalisation d'un repas
+// @Summary Annuler la réalisation d'un repas
+// @Description Remet un planning de repas comme non terminé. Avec le jeton reçu à sa dernière complétion, le stock du frigo consommé est restauré une seule fois. Les restes enregistrés pour ce repas sont retirés (refusé s'ils ont déjà été mangés).
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path uint true "ID du planning de repas"
+// @Param request body dto.MealPlanUncompleteRequest false "Jeton d'annulation"
+// @Success 200 {object} map[string]interface{} "Repas remis comme non terminé"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 403 {object} map[string]interface{} "Planning d'un autre utilisateur"
+// @Failure 404 {object} map[string]interface{} "Planning ou jeton non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/{id}/uncomplete [patch]
+func (h *MealPlanHandler) UncompleteMeal(c *gin.Context) {
+	mealPlan, userID, ok := h.loadOwnedMealPlan(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MealPlanUncompleteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	restored, err := h.ormService.MealPlanRepository.UndoCompletion(c.Request.Context(), mealPlan.ID, userID, req.UndoToken)
+	if err != nil {
+		respondUndoCompletionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"fridge_restored": restored,
+		"message":         "Meal plan marked as not completed",
+	})
+}
+
+// loadOwnedMealPlan charge le planning de repas du chemin et vérifie qu'il appartient à l'utilisateur connecté
+func (h *MealPlanHandler) loadOwnedMealPlan(c *gin.Context) (*dto.MealPlan, uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid meal plan ID",
 			"message": "Meal plan ID must be a number",
 		})
-		return
+		return nil, 0, false
 	}
 
-	// Vérifier que le planning existe et appartient à l'utilisateur
-	_, err = h.ormService.MealPlanRepository.GetByID(c.Request.Context(), uint(id))
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return nil, 0, false
+	}
+
+	mealPlan, err := h.ormService.MealPlanRepository.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
 		switch {
 		case errors.Is(err, ormerrors.ErrRecordNotFound):
@@ -726,37 +869,113 @@ func (h *MealPlanHandler) MarkMealAsCompleted(c *gin.Context) {
 				"message": "Failed to retrieve meal plan",
 			})
 		}
-		return
+		return nil, 0, false
 	}
 
-	// TODO: Vérifier que l'utilisateur peut modifier ce planning
-	// if mealPlan.UserID != currentUserID { ... }
+	if mealPlan.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Forbidden",
+			"message": "You can only modify your own meal plans",
+		})
+		return nil, 0, false
+	}
+	return mealPlan, userID, true
+}
 
-	if err := h.ormService.MealPlanRepository.MarkAsCompleted(c.Request.Context(), uint(id)); err != nil {
+// respondUndoCompletionError traduit les erreurs d'annulation de complétion en réponse HTTP
+func respondUndoCompletionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ormerrors.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not found",
+			"message": "Meal plan or undo token not found",
+		})
+	case errors.Is(err, ormerrors.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+	default:
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Internal server error",
-			"message": "Failed to mark meal as completed",
+			"message": "Failed to mark meal plan as not completed",
+		})
+	}
+}
+
+// GetMealPlanCost estime le coût d'un repas planifié
+// @Summary Coût d'un repas planifié
+// @Description Estime le coût du repas pour ses portions planifiées avec les prix relevés par l'utilisateur, et signale les ingrédients sans prix connu
+// @Tags MealPlans
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID du planning de repas"
+// @Param servings query int false "Nombre de portions (défaut: portions planifiées)"
+// @Param store_id query int false "Magasin dont les prix sont privilégiés"
+// @Success 200 {object} dto.CostEstimate "Coût estimé"
+// @Failure 400 {object} map[string]interface{} "Paramètres invalides"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Planning non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/{id}/cost [get]
+func (h *MealPlanHandler) GetMealPlanCost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid meal plan ID",
+			"message": "Meal plan ID must be a number",
 		})
 		return
 	}
 
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var query dto.CostQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid parameters",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	estimate, err := h.ormService.IngredientPriceRepository.MealPlanCost(c.Request.Context(), uint(id), userID, &query)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to estimate meal plan cost")
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Meal plan marked as completed",
+		"data":    estimate,
 	})
 }
 
 // GetWeeklyShoppingList récupère la liste de courses pour une semaine donnée
 // @Summary Récupérer la liste de courses hebdomadaire
-// @Description Récupère une liste de courses agrégée pour tous les repas planifiés d'une semaine
+// @Description Récupère une liste de courses agrégée pour tous les repas planifiés d'une semaine.
+// @Description Le format suit le paramètre format ou l'en-tête Accept : JSON, texte, Markdown, CSV ou PDF imprimable (groupés par catégorie).
 // @Tags MealPlans
 // @Produce json
+// @Produce plain
+// @Produce text/markdown
+// @Produce text/csv
+// @Produce application/pdf
 // @Security ApiKeyAuth
 // @Param start_date query string true "Date de début (format: 2006-01-02)"
 // @Param end_date query string false "Date de fin (format: 2006-01-02, par défaut: start_date + 6 jours)"
+// @Param subtract_fridge query bool false "Déduire le stock du frigo des quantités à acheter (défaut: false)"
+// @Param store_id query int false "Magasin dont l'ordre des rayons est appliqué (défaut: magasin par défaut)"
+// @Param format query string false "Format d'export (prioritaire sur l'en-tête Accept)" Enums(json, text, markdown, csv, pdf)
+// @Param include_recipes query bool false "Exports : détailler les repas qui utilisent chaque article (défaut: false)"
+// @Param with_cost query bool false "Estimer le coût des quantités à acheter avec mes prix (défaut: false)"
 // @Success 200 {object} dto.WeeklyShoppingListResponse "Liste de courses récupérée avec succès"
 // @Failure 400 {object} map[string]interface{} "Requête invalide"
 // @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Magasin non trouvé"
 // @Failure 500 {object} map[string]interface{} "Erreur serveur"
 // @Router /meal-plans/shopping-list [get]
 func (h *MealPlanHandler) GetWeeklyShoppingList(c *gin.Context) {
@@ -817,13 +1036,94 @@ func (h *MealPlanHandler) GetWeeklyShoppingList(c *gin.Context) {
 		return
 	}
 
+	// Format de sortie : paramètre format, sinon négociation sur l'en-tête Accept
+	format := c.Query("format")
+	if format == "" {
+		format = shoppingListFormats[c.NegotiateFormat(binding.MIMEJSON, "text/plain", "text/markdown", "text/csv", "application/pdf")]
+		if format == "" {
+			format = "json"
+		}
+	}
+	if format != "json" && format != export.FormatText && format != export.FormatMarkdown &&
+		format != export.FormatCSV && format != export.FormatPDF {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid format",
+			"message": "format must be one of json, text, markdown, csv, pdf",
+		})
+		return
+	}
+	var exportOpts export.ShoppingListOptions
+	if includeStr := c.Query("include_recipes"); includeStr != "" {
+		include, err := strconv.ParseBool(includeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid include_recipes",
+				"message": "include_recipes must be a boolean",
+			})
+			return
+		}
+		exportOpts.IncludeRecipes = include
+	}
+
+	// Options de calcul
+	var opts dto.ShoppingListOptions
+	if subtractStr := c.Query("subtract_fridge"); subtractStr != "" {
+		subtract, err := strconv.ParseBool(subtractStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid subtract_fridge",
+				"message": "subtract_fridge must be a boolean",
+			})
+			return
+		}
+		opts.SubtractFridge = subtract
+	}
+	if storeStr := c.Query("store_id"); storeStr != "" {
+		storeID, err := strconv.ParseUint(storeStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid store_id",
+				"message": "store_id must be a number",
+			})
+			return
+		}
+		id := uint(storeID)
+		opts.StoreID = &id
+	}
+	if costStr := c.Query("with_cost"); costStr != "" {
+		withCost, err := strconv.ParseBool(costStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid with_cost",
+				"message": "with_cost must be a boolean",
+			})
+			return
+		}
+		opts.WithCost = withCost
+	}
+
 	// Récupérer la liste de courses
-	shoppingList, err := h.ormService.MealPlanRepository.GetWeeklyShoppingList(c.Request.Context(), userID, startDate, endDate)
+	shoppingList, err := h.ormService.MealPlanRepository.GetWeeklyShoppingList(c.Request.Context(), userID, startDate, endDate, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Internal server error",
-			"message": "Failed to generate shopping list",
-		})
+		respondRepositoryError(c, err, "Failed to generate shopping list")
+		return
+	}
+
+	if format != "json" {
+		content, contentType, err := export.ShoppingList(shoppingList, format, exportOpts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal server error",
+				"message": "Failed to export shopping list",
+			})
+			return
+		}
+		disposition := "inline"
+		if format == export.FormatCSV || format == export.FormatPDF {
+			disposition = "attachment"
+		}
+		c.Header("Content-Disposition", disposition+`; filename="`+export.FileName(shoppingList, format)+`"`)
+		c.Data(http.StatusOK, contentType, content)
 		return
 	}
 
@@ -832,3 +1132,12 @@ func (h *MealPlanHandler) GetWeeklyShoppingList(c *gin.Context) {
 		Data:    *shoppingList,
 	})
 }
+
+// shoppingListFormats associe les types MIME négociés aux formats de la liste de courses
+var shoppingListFormats = map[string]string{
+	binding.MIMEJSON:  "json",
+	"text/plain":      export.FormatText,
+	"text/markdown":   export.FormatMarkdown,
+	"text/csv":        export.FormatCSV,
+	"application/pdf": export.FormatPDF,
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+)
+
+// RecordMealPlanLeftovers enregistre au frigo les portions restantes d'un repas réalisé
+// @Summary Enregistrer des restes
+// @Description Met de côté au frigo les portions non mangées d'un repas réalisé, avec une date de péremption (3 jours après le repas par défaut). Les restes peuvent ensuite être planifiés via /fridge/leftovers/{id}/schedule.
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID du repas réalisé"
+// @Param leftover body dto.LeftoverCreateRequest true "Portions restantes"
+// @Success 201 {object} dto.Leftover "Restes enregistrés"
+// @Failure 400 {object} map[string]interface{} "Requête invalide, repas non réalisé ou plus de portions que servies"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Repas non trouvé"
+// @Failure 409 {object} map[string]interface{} "Restes déjà enregistrés pour ce repas"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/{id}/leftovers [post]
+func (h *MealPlanHandler) RecordMealPlanLeftovers(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid meal plan ID",
+			"message": "Meal plan ID must be a number",
+		})
+		return
+	}
+
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.LeftoverCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	mealPlan, err := h.ormService.MealPlanRepository.GetByID(c.Request.Context(), uint(id))
+	if err == nil && mealPlan.UserID != userID {
+		err = ormerrors.NewNotFoundError("meal plan", id)
+	}
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve meal plan")
+		return
+	}
+	if !mealPlan.IsCompleted {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Meal plan not completed",
+			"message": "Leftovers can only be recorded for a completed meal",
+		})
+		return
+	}
+	if req.Portions > mealPlan.Servings {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Too many portions",
+			"message": "Leftover portions cannot exceed the servings of the meal",
+		})
+		return
+	}
+
+	shelfLife := req.ShelfLifeDays
+	if shelfLife == 0 {
+		shelfLife = dto.DefaultLeftoverShelfLifeDays
+	}
+	cookedAt := time.Now()
+	if mealPlan.CompletedAt != nil {
+		cookedAt = *mealPlan.CompletedAt
+	}
+
+	leftover := &dto.Leftover{
+		UserID:     userID,
+		RecipeID:   mealPlan.RecipeID,
+		MealPlanID: &mealPlan.ID,
+		Portions:   req.Portions,
+		ExpiryDate: dayStart(cookedAt).AddDate(0, 0, shelfLife),
+		Notes:      req.Notes,
+	}
+	if err := h.ormService.LeftoverRepository.Create(c.Request.Context(), leftover); err != nil {
+		respondRepositoryError(c, err, "Failed to record leftovers")
+		return
+	}
+
+	created, err := h.ormService.LeftoverRepository.GetByID(c.Request.Context(), leftover.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve recorded leftovers")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    created,
+		"message": "Leftovers recorded successfully",
+	})
+}
+
+// checkLeftoverUse vérifie qu'un repas avec des restes a lieu avant leur péremption et que
+// les restes couvrent les portions supplémentaires demandées
+func (h *MealPlanHandler) checkLeftoverUse(c *gin.Context, leftoverID uint, plannedDate time.Time, extra int) bool {
+	leftover, err := h.ormService.LeftoverRepository.GetByID(c.Request.Context(), leftoverID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to verify leftovers")
+		return false
+	}
+	if dayStart(plannedDate).After(dayStart(leftover.ExpiryDate)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Leftovers expired",
+			"message": "The leftovers expire before the planned date",
+		})
+		return false
+	}
+	if extra > leftover.AvailablePortions {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Not enough leftovers",
+			"message": "Not enough leftover portions available for these servings",
+		})
+		return false
+	}
+	return true
+}
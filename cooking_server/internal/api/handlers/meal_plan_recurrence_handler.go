@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+)
+
+// ListMealPlanRecurrences liste les repas récurrents de l'utilisateur connecté
+// @Summary Lister mes repas récurrents
+// @Description Retourne les règles de récurrence de l'utilisateur avec leur recette
+// @Tags MealPlans
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Règles de récurrence"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/recurrences [get]
+func (h *MealPlanHandler) ListMealPlanRecurrences(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	recurrences, err := h.ormService.MealPlanRecurrenceRepository.GetByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve meal plan recurrences",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    recurrences,
+	})
+}
+
+// CreateMealPlanRecurrence crée un repas récurrent
+// @Summary Créer un repas récurrent
+// @Description Planifie une recette de façon répétée : tous les N jours (daily) ou toutes les N semaines les jours choisis (weekly), jusqu'à une date de fin facultative. Les occurrences apparaissent dans le planning à sa consultation.
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param recurrence body dto.MealPlanRecurrenceCreateRequest true "Règle de récurrence"
+// @Success 201 {object} dto.MealPlanRecurrence "Règle créée"
+// @Failure 400 {object} map[string]interface{} "Requête invalide ou recette inexistante"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/recurrences [post]
+func (h *MealPlanHandler) CreateMealPlanRecurrence(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MealPlanRecurrenceCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if !h.checkRecipeExists(c, req.RecipeID) {
+		return
+	}
+
+	// Formats déjà validés par la requête
+	startDate, _ := time.Parse("2006-01-02", req.StartDate)
+	recurrence := &dto.MealPlanRecurrence{
+		UserID:    userID,
+		RecipeID:  req.RecipeID,
+		MealType:  req.MealType,
+		Servings:  req.Servings,
+		Notes:     req.Notes,
+		Frequency: req.Frequency,
+		Interval:  req.Interval,
+		Weekdays:  dto.StringList(req.Weekdays),
+		StartDate: startDate,
+	}
+	if req.EndDate != "" {
+		endDate, _ := time.Parse("2006-01-02", req.EndDate)
+		recurrence.EndDate = &endDate
+	}
+
+	if err := h.ormService.MealPlanRecurrenceRepository.Create(c.Request.Context(), recurrence); err != nil {
+		respondRepositoryError(c, err, "Failed to create meal plan recurrence")
+		return
+	}
+
+	created, err := h.ormService.MealPlanRecurrenceRepository.GetByID(c.Request.Context(), recurrence.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve created meal plan recurrence")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    created,
+		"message": "Meal plan recurrence created successfully",
+	})
+}
+
+// GetMealPlanRecurrence récupère un repas récurrent
+// @Summary Récupérer un repas récurrent
+// @Description Retourne une règle de récurrence de l'utilisateur avec sa recette
+// @Tags MealPlans
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la règle"
+// @Success 200 {object} dto.MealPlanRecurrence "Règle de récurrence"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Règle non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/recurrences/{id} [get]
+func (h *MealPlanHandler) GetMealPlanRecurrence(c *gin.Context) {
+	recurrence, ok := h.loadOwnedRecurrence(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    recurrence,
+	})
+}
+
+// UpdateMealPlanRecurrence modifie un repas récurrent
+// @Summary Modifier un repas récurrent
+// @Description Modifie une règle de récurrence. Les occurrences à venir suivent la nouvelle règle, sauf celles modifiées individuellement ou déjà réalisées.
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la règle"
+// @Param recurrence body dto.MealPlanRecurrenceUpdateRequest true "Champs à modifier"
+// @Success 200 {object} dto.MealPlanRecurrence "Règle modifiée"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Règle non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/recurrences/{id} [put]
+func (h *MealPlanHandler) UpdateMealPlanRecurrence(c *gin.Context) {
+	var req dto.MealPlanRecurrenceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	recurrence, ok := h.loadOwnedRecurrence(c)
+	if !ok {
+		return
+	}
+
+	if req.RecipeID > 0 {
+		if !h.checkRecipeExists(c, req.RecipeID) {
+			return
+		}
+		recurrence.RecipeID = req.RecipeID
+	}
+	if req.MealType != "" {
+		recurrence.MealType = req.MealType
+	}
+	if req.Servings > 0 {
+		recurrence.Servings = req.Servings
+	}
+	if req.Notes != nil {
+		recurrence.Notes = *req.Notes
+	}
+	if req.Frequency != "" {
+		recurrence.Frequency = req.Frequency
+	}
+	if req.Interval > 0 {
+		recurrence.Interval = req.Interval
+	}
+	if req.Weekdays != nil {
+		recurrence.Weekdays = dto.StringList(*req.Weekdays)
+	}
+	// Formats déjà validés par la requête
+	if req.StartDate != "" {
+		recurrence.StartDate, _ = time.Parse("2006-01-02", req.StartDate)
+	}
+	if req.EndDate != "" {
+		endDate, _ := time.Parse("2006-01-02", req.EndDate)
+		recurrence.EndDate = &endDate
+	} else if req.NoEndDate {
+		recurrence.EndDate = nil
+	}
+
+	if err := h.ormService.MealPlanRecurrenceRepository.Update(c.Request.Context(), recurrence); err != nil {
+		respondRepositoryError(c, err, "Failed to update meal plan recurrence")
+		return
+	}
+
+	updated, err := h.ormService.MealPlanRecurrenceRepository.GetByID(c.Request.Context(), recurrence.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve updated meal plan recurrence")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+		"message": "Meal plan recurrence updated successfully",
+	})
+}
+
+// DeleteMealPlanRecurrence supprime un repas récurrent
+// @Summary Supprimer un repas récurrent
+// @Description Supprime une règle de récurrence et ses occurrences à venir. Les occurrences passées, réalisées ou modifiées individuellement sont conservées.
+// @Tags MealPlans
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la règle"
+// @Success 200 {object} map[string]interface{} "Règle supprimée"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Règle non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/recurrences/{id} [delete]
+func (h *MealPlanHandler) DeleteMealPlanRecurrence(c *gin.Context) {
+	recurrence, ok := h.loadOwnedRecurrence(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ormService.MealPlanRecurrenceRepository.Delete(c.Request.Context(), recurrence.ID); err != nil {
+		respondRepositoryError(c, err, "Failed to delete meal plan recurrence")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Meal plan recurrence deleted successfully",
+	})
+}
+
+// loadOwnedRecurrence charge la règle de récurrence du chemin si elle appartient à l'utilisateur connecté
+func (h *MealPlanHandler) loadOwnedRecurrence(c *gin.Context) (*dto.MealPlanRecurrence, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid recurrence ID",
+			"message": "Recurrence ID must be a number",
+		})
+		return nil, false
+	}
+
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	recurrence, err := h.ormService.MealPlanRecurrenceRepository.GetByID(c.Request.Context(), uint(id))
+	if err == nil && recurrence.UserID != userID {
+		err = ormerrors.NewNotFoundError("meal plan recurrence", id)
+	}
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve meal plan recurrence")
+		return nil, false
+	}
+	return recurrence, true
+}
+
+// checkRecipeExists vérifie que la recette à planifier existe
+func (h *MealPlanHandler) checkRecipeExists(c *gin.Context, recipeID uint) bool {
+	_, err := h.ormService.RecipeRepository.GetByID(c.Request.Context(), recipeID)
+	if err != nil {
+		if errors.Is(err, ormerrors.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Recipe not found",
+				"message": "The specified recipe does not exist",
+			})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to verify recipe",
+		})
+		return false
+	}
+	return true
+}
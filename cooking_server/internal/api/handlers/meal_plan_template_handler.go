@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+)
+
+// ListMealPlanTemplates liste les semaines types de l'utilisateur connecté
+// @Summary Lister mes semaines types
+// @Description Retourne les semaines types de l'utilisateur avec leurs repas
+// @Tags MealPlans
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Semaines types"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/templates [get]
+func (h *MealPlanHandler) ListMealPlanTemplates(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	templates, err := h.ormService.MealPlanTemplateRepository.GetByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve meal plan templates",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    templates,
+	})
+}
+
+// CreateMealPlanTemplate crée une semaine type
+// @Summary Créer une semaine type
+// @Description Crée une semaine type nommée à partir d'une liste de repas, ou en copiant les 7 jours de repas planifiés à partir de from_date (hors occurrences de repas récurrents)
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param template body dto.MealPlanTemplateCreateRequest true "Semaine type"
+// @Success 201 {object} dto.MealPlanTemplate "Semaine type créée"
+// @Failure 400 {object} map[string]interface{} "Requête invalide ou recette inexistante"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/templates [post]
+func (h *MealPlanHandler) CreateMealPlanTemplate(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MealPlanTemplateCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	template := &dto.MealPlanTemplate{
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+		Items:       mealPlanTemplateItemsFromRequest(req.Items),
+	}
+
+	// Copier une semaine du planning
+	if req.FromDate != "" {
+		// Format déjà validé par la requête
+		fromDate, _ := time.Parse("2006-01-02", req.FromDate)
+		toDate := time.Date(fromDate.Year(), fromDate.Month(), fromDate.Day()+6, 23, 59, 59, 999999999, fromDate.Location())
+
+		mealPlans, err := h.ormService.MealPlanRepository.GetByUserAndDateRange(c.Request.Context(), userID, fromDate, toDate)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal server error",
+				"message": "Failed to retrieve meal plans to copy",
+			})
+			return
+		}
+		for _, mealPlan := range mealPlans {
+			// Les occurrences qui suivent une règle sont déjà planifiées par elle
+			if mealPlan.RecurrenceID != nil && !mealPlan.Detached {
+				continue
+			}
+			planned := time.Date(mealPlan.PlannedDate.Year(), mealPlan.PlannedDate.Month(), mealPlan.PlannedDate.Day(), 0, 0, 0, 0, fromDate.Location())
+			template.Items = append(template.Items, dto.MealPlanTemplateItem{
+				RecipeID:  mealPlan.RecipeID,
+				DayOffset: int(planned.Sub(fromDate).Hours() / 24),
+				MealType:  mealPlan.MealType,
+				Servings:  mealPlan.Servings,
+				Notes:     mealPlan.Notes,
+			})
+		}
+	}
+
+	if err := h.ormService.MealPlanTemplateRepository.Create(c.Request.Context(), template); err != nil {
+		respondRepositoryError(c, err, "Failed to create meal plan template")
+		return
+	}
+
+	created, err := h.ormService.MealPlanTemplateRepository.GetByID(c.Request.Context(), template.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve created meal plan template")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    created,
+		"message": "Meal plan template created successfully",
+	})
+}
+
+// GetMealPlanTemplate récupère une semaine type
+// @Summary Récupérer une semaine type
+// @Description Retourne une semaine type de l'utilisateur avec ses repas dans l'ordre des jours
+// @Tags MealPlans
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la semaine type"
+// @Success 200 {object} dto.MealPlanTemplate "Semaine type"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Semaine type non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/templates/{id} [get]
+func (h *MealPlanHandler) GetMealPlanTemplate(c *gin.Context) {
+	template, _, ok := h.loadOwnedTemplate(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// UpdateMealPlanTemplate modifie une semaine type
+// @Summary Modifier une semaine type
+// @Description Modifie le nom ou la description d'une semaine type, ou remplace ses repas
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la semaine type"
+// @Param template body dto.MealPlanTemplateUpdateRequest true "Champs à modifier"
+// @Success 200 {object} dto.MealPlanTemplate "Semaine type modifiée"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Semaine type non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/templates/{id} [put]
+func (h *MealPlanHandler) UpdateMealPlanTemplate(c *gin.Context) {
+	var req dto.MealPlanTemplateUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	template, _, ok := h.loadOwnedTemplate(c)
+	if !ok {
+		return
+	}
+
+	if req.Name != "" {
+		template.Name = req.Name
+	}
+	if req.Description != nil {
+		template.Description = *req.Description
+	}
+	var items *[]dto.MealPlanTemplateItem
+	if req.Items != nil {
+		replaced := mealPlanTemplateItemsFromRequest(*req.Items)
+		items = &replaced
+	}
+
+	if err := h.ormService.MealPlanTemplateRepository.Update(c.Request.Context(), template, items); err != nil {
+		respondRepositoryError(c, err, "Failed to update meal plan template")
+		return
+	}
+
+	updated, err := h.ormService.MealPlanTemplateRepository.GetByID(c.Request.Context(), template.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve updated meal plan template")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+		"message": "Meal plan template updated successfully",
+	})
+}
+
+// DeleteMealPlanTemplate supprime une semaine type
+// @Summary Supprimer une semaine type
+// @Description Supprime une semaine type. Les repas déjà planifiés à partir d'elle sont conservés.
+// @Tags MealPlans
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la semaine type"
+// @Success 200 {object} map[string]interface{} "Semaine type supprimée"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Semaine type non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/templates/{id} [delete]
+func (h *MealPlanHandler) DeleteMealPlanTemplate(c *gin.Context) {
+	template, _, ok := h.loadOwnedTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ormService.MealPlanTemplateRepository.Delete(c.Request.Context(), template.ID); err != nil {
+		respondRepositoryError(c, err, "Failed to delete meal plan template")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Meal plan template deleted successfully",
+	})
+}
+
+// ApplyMealPlanTemplate planifie les repas d'une semaine type
+// @Summary Appliquer une semaine type
+// @Description Ajoute au planning les repas de la semaine type, à partir de la date de début donnée
+// @Tags MealPlans
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la semaine type"
+// @Param apply body dto.MealPlanTemplateApplyRequest true "Date du premier jour"
+// @Success 201 {object} map[string]interface{} "Repas planifiés"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Semaine type non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /meal-plans/templates/{id}/apply [post]
+func (h *MealPlanHandler) ApplyMealPlanTemplate(c *gin.Context) {
+	var req dto.MealPlanTemplateApplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	template, userID, ok := h.loadOwnedTemplate(c)
+	if !ok {
+		return
+	}
+
+	// Format déjà validé par la requête
+	startDate, _ := time.Parse("2006-01-02", req.StartDate)
+	mealPlans, err := h.ormService.MealPlanTemplateRepository.Apply(c.Request.Context(), template.ID, userID, startDate)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to apply meal plan template")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    mealPlans,
+		"message": "Meal plan template applied successfully",
+	})
+}
+
+// loadOwnedTemplate charge la semaine type du chemin si elle appartient à l'utilisateur connecté
+func (h *MealPlanHandler) loadOwnedTemplate(c *gin.Context) (*dto.MealPlanTemplate, uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid template ID",
+			"message": "Template ID must be a number",
+		})
+		return nil, 0, false
+	}
+
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return nil, 0, false
+	}
+
+	template, err := h.ormService.MealPlanTemplateRepository.GetByID(c.Request.Context(), uint(id))
+	if err == nil && template.UserID != userID {
+		err = ormerrors.NewNotFoundError("meal plan template", id)
+	}
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve meal plan template")
+		return nil, 0, false
+	}
+	return template, userID, true
+}
+
+// mealPlanTemplateItemsFromRequest convertit les repas demandés en repas de semaine type
+func mealPlanTemplateItemsFromRequest(requests []dto.MealPlanTemplateItemRequest) []dto.MealPlanTemplateItem {
+	items := make([]dto.MealPlanTemplateItem, 0, len(requests))
+	for _, req := range requests {
+		servings := req.Servings
+		if servings <= 0 {
+			servings = 1
+		}
+		items = append(items, dto.MealPlanTemplateItem{
+			RecipeID:  req.RecipeID,
+			DayOffset: req.DayOffset,
+			MealType:  req.MealType,
+			Servings:  servings,
+			Notes:     req.Notes,
+		})
+	}
+	return items
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	"github.com/romainrodriguez/cooking_server/internal/services/orm"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+)
+
+// PriceHandler gère les requêtes liées aux prix relevés des ingrédients
+type PriceHandler struct {
+	ormService *orm.ORMService
+}
+
+// NewPriceHandler crée une nouvelle instance du handler des prix
+func NewPriceHandler(ormService *orm.ORMService) *PriceHandler {
+	return &PriceHandler{
+		ormService: ormService,
+	}
+}
+
+// ListPrices liste les prix relevés par l'utilisateur connecté
+// @Summary Lister mes prix
+// @Description Retourne les prix relevés par l'utilisateur, du plus récent au plus ancien
+// @Tags Prices
+// @Produce json
+// @Security ApiKeyAuth
+// @Param ingredient_id query int false "Filtrer par ingrédient"
+// @Param store_id query int false "Filtrer par magasin"
+// @Success 200 {object} map[string]interface{} "Prix relevés"
+// @Failure 400 {object} map[string]interface{} "Paramètres invalides"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /prices [get]
+func (h *PriceHandler) ListPrices(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	ingredientID, ok := optionalUintQuery(c, "ingredient_id")
+	if !ok {
+		return
+	}
+	storeID, ok := optionalUintQuery(c, "store_id")
+	if !ok {
+		return
+	}
+
+	prices, err := h.ormService.IngredientPriceRepository.GetByUser(c.Request.Context(), userID, ingredientID, storeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve prices",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    prices,
+	})
+}
+
+// CreatePrice enregistre un prix relevé pour un ingrédient
+// @Summary Enregistrer un prix
+// @Description Enregistre le prix d'un ingrédient pour une quantité donnée (ex: 2,50 pour 1 kg), éventuellement dans un de mes magasins. Le prix le plus récent est utilisé pour les estimations de coût.
+// @Tags Prices
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param price body dto.IngredientPriceCreateRequest true "Prix relevé"
+// @Success 201 {object} dto.IngredientPrice "Prix enregistré"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Ingrédient ou magasin non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /prices [post]
+func (h *PriceHandler) CreatePrice(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.IngredientPriceCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	price := &dto.IngredientPrice{
+		UserID:       userID,
+		IngredientID: req.IngredientID,
+		StoreID:      req.StoreID,
+		Price:        req.Price,
+		Quantity:     req.Quantity,
+		Unit:         req.Unit,
+		PricedAt:     time.Now().Truncate(24 * time.Hour),
+		Notes:        req.Notes,
+	}
+	if price.Quantity == 0 {
+		price.Quantity = 1
+	}
+	if req.PricedAt != "" {
+		// Format déjà validé par la requête
+		price.PricedAt, _ = time.Parse("2006-01-02", req.PricedAt)
+	}
+
+	if err := h.ormService.IngredientPriceRepository.Create(c.Request.Context(), price); err != nil {
+		respondRepositoryError(c, err, "Failed to create price")
+		return
+	}
+
+	created, err := h.ormService.IngredientPriceRepository.GetByID(c.Request.Context(), price.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve created price")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    created,
+		"message": "Price created successfully",
+	})
+}
+
+// GetPrice récupère un prix relevé
+// @Summary Récupérer un prix
+// @Description Retourne un prix relevé par l'utilisateur
+// @Tags Prices
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID du prix"
+// @Success 200 {object} dto.IngredientPrice "Prix"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Prix non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /prices/{id} [get]
+func (h *PriceHandler) GetPrice(c *gin.Context) {
+	price, ok := h.loadOwnedPrice(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    price,
+	})
+}
+
+// DeletePrice supprime un prix relevé
+// @Summary Supprimer un prix
+// @Description Supprime un prix relevé par l'utilisateur
+// @Tags Prices
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID du prix"
+// @Success 200 {object} map[string]interface{} "Prix supprimé"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Prix non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /prices/{id} [delete]
+func (h *PriceHandler) DeletePrice(c *gin.Context) {
+	price, ok := h.loadOwnedPrice(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ormService.IngredientPriceRepository.Delete(c.Request.Context(), price.ID); err != nil {
+		respondRepositoryError(c, err, "Failed to delete price")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Price deleted successfully",
+	})
+}
+
+// loadOwnedPrice charge le prix du chemin s'il appartient à l'utilisateur connecté
+func (h *PriceHandler) loadOwnedPrice(c *gin.Context) (*dto.IngredientPrice, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid price ID",
+			"message": "Price ID must be a number",
+		})
+		return nil, false
+	}
+
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	price, err := h.ormService.IngredientPriceRepository.GetByID(c.Request.Context(), uint(id))
+	if err == nil && price.UserID != userID {
+		err = ormerrors.NewNotFoundError("ingredient price", id)
+	}
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve price")
+		return nil, false
+	}
+	return price, true
+}
+
+// optionalUintQuery lit un identifiant facultatif dans les paramètres de la requête
+func optionalUintQuery(c *gin.Context, name string) (*uint, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	value, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid " + name,
+			"message": name + " must be a number",
+		})
+		return nil, false
+	}
+	id := uint(value)
+	return &id, true
+}
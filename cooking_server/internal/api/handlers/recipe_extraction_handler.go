@@ -8,19 +8,22 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/romainrodriguez/cooking_server/internal/dto"
 	"github.com/romainrodriguez/cooking_server/internal/services"
+	"github.com/romainrodriguez/cooking_server/internal/services/orm"
 )
 
 // RecipeExtractionHandler gère les requêtes d'extraction de recette
 type RecipeExtractionHandler struct {
 	ocrService *services.OCRService
 	llmService *services.LLMService
+	ormService *orm.ORMService
 }
 
 // NewRecipeExtractionHandler crée un nouveau handler d'extraction
-func NewRecipeExtractionHandler() *RecipeExtractionHandler {
+func NewRecipeExtractionHandler(ormService *orm.ORMService) *RecipeExtractionHandler {
 	return &RecipeExtractionHandler{
 		ocrService: services.NewOCRService(),
 		llmService: services.NewLLMService(),
+		ormService: ormService,
 	}
 }
 
@@ -129,6 +132,9 @@ func (h *RecipeExtractionHandler) ExtractFromImage(c *gin.Context) {
 	}
 	fmt.Printf("[RecipeExtraction] LLM returned recipe data: %+v\n", recipeData)
 
+	// Étape 3: Rattacher les ingrédients extraits aux ingrédients existants (nom ou alias)
+	h.matchIngredients(c, recipeData)
+
 	// Retourner la réponse avec succès
 	c.JSON(http.StatusOK, dto.ExtractRecipeResponse{
 		Success:       true,
@@ -138,6 +144,23 @@ func (h *RecipeExtractionHandler) ExtractFromImage(c *gin.Context) {
 	})
 }
 
+// matchIngredients associe chaque ingrédient extrait à un ingrédient existant, via son nom ou ses alias
+func (h *RecipeExtractionHandler) matchIngredients(c *gin.Context, recipeData *dto.ExtractedRecipeData) {
+	if h.ormService == nil || recipeData == nil {
+		return
+	}
+
+	for i := range recipeData.Ingredients {
+		extracted := &recipeData.Ingredients[i]
+		ingredient, err := h.ormService.IngredientRepository.GetByName(c.Request.Context(), extracted.Name)
+		if err != nil {
+			continue
+		}
+		extracted.IngredientID = &ingredient.ID
+		extracted.MatchedName = ingredient.Name
+	}
+}
+
 // containsRecipeKeywords vérifie si le texte extrait contient des mots-clés de recette
 func (h *RecipeExtractionHandler) containsRecipeKeywords(text string) bool {
 	lowerText := strings.ToLower(text)
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/romainrodriguez/cooking_server/internal/dto"
@@ -162,6 +163,11 @@ func (h *RecipeHandler) CreateRecipe(c *gin.Context) {
 		}
 	}
 
+	// Dériver les allergènes et régimes à partir des ingrédients
+	if err := h.ormService.RecipeRepository.RefreshDietaryLabels(c.Request.Context(), recipe.ID); err != nil {
+		log.Printf("Failed to refresh dietary labels: %v", err)
+	}
+
 	// Recharger la recette avec toutes ses relations pour la réponse
 	updatedRecipe, err := h.ormService.RecipeRepository.GetByID(c.Request.Context(), recipe.ID)
 	if err != nil {
@@ -183,6 +189,7 @@ func (h *RecipeHandler) CreateRecipe(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "ID de la recette"
+// @Param locale query string false "Langue des noms d'ingrédients (défaut: en-tête Accept-Language)"
 // @Success 200 {object} dto.Recipe "Recette trouvée"
 // @Failure 400 {object} dto.ErrorResponse "ID invalide"
 // @Failure 404 {object} dto.ErrorResponse "Recette non trouvée"
@@ -216,6 +223,15 @@ func (h *RecipeHandler) GetRecipe(c *gin.Context) {
 		return
 	}
 
+	// Noms des ingrédients dans la langue demandée
+	ingredients := make([]*dto.Ingredient, len(recipe.Ingredients))
+	for i := range recipe.Ingredients {
+		ingredients[i] = &recipe.Ingredients[i].Ingredient
+	}
+	if err := h.ormService.IngredientRepository.ApplyDisplayNames(c.Request.Context(), requestLocale(c), ingredients); err != nil {
+		log.Printf("Failed to apply ingredient display names: %v", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    recipe,
@@ -342,97 +358,109 @@ func (h *RecipeHandler) UpdateRecipe(c *gin.Context) {
 	}
 	recipe.IsPublic = req.IsPublic
 
-	// Mettre à jour la recette d'abord
-	if err := h.ormService.RecipeRepository.Update(c.Request.Context(), recipe); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Internal server error",
-			"message": "Failed to update recipe",
-		})
-		return
-	}
+	// La modification et sa révision sont enregistrées ensemble ou pas du tout
+	ctx := c.Request.Context()
+	err = h.ormService.WithTransaction(ctx, func(tx *orm.ORMService) error {
+		// Conserver l'état actuel si la recette n'a pas encore d'historique
+		if err := tx.RecipeRevisionRepository.EnsureInitial(ctx, recipe.ID, recipe.AuthorID); err != nil {
+			return err
+		}
 
-	// Mettre à jour les ingrédients si fournis
-	if len(req.Ingredients) > 0 {
-		// Supprimer tous les ingrédients existants
-		if err := h.ormService.RecipeIngredientRepository.DeleteByRecipe(c.Request.Context(), recipe.ID); err != nil {
-			log.Printf("Failed to delete existing recipe ingredients: %v", err)
+		// Mettre à jour la recette d'abord
+		if err := tx.RecipeRepository.Update(ctx, recipe); err != nil {
+			return err
 		}
 
-		// Ajouter les nouveaux ingrédients (position = ordre du tableau envoyé)
-		for i, ingredientReq := range req.Ingredients {
-			// Utiliser "pièce" par défaut si l'unité est vide
-			unit := ingredientReq.Unit
-			if unit == "" {
-				unit = "pièce"
+		// Mettre à jour les ingrédients si fournis
+		if len(req.Ingredients) > 0 {
+			// Supprimer tous les ingrédients existants
+			if err := tx.RecipeIngredientRepository.DeleteByRecipe(ctx, recipe.ID); err != nil {
+				return err
 			}
 
-			recipeIngredient := &dto.RecipeIngredient{
-				RecipeID:     recipe.ID,
-				IngredientID: ingredientReq.IngredientID,
-				Quantity:     ingredientReq.Quantity,
-				Unit:         unit,
-				Notes:        ingredientReq.Notes,
-				IsOptional:   ingredientReq.IsOptional,
-				Group:        ingredientReq.Group,
-				Position:     i,
+			// Ajouter les nouveaux ingrédients (position = ordre du tableau envoyé)
+			for i, ingredientReq := range req.Ingredients {
+				// Utiliser "pièce" par défaut si l'unité est vide
+				unit := ingredientReq.Unit
+				if unit == "" {
+					unit = "pièce"
+				}
+
+				recipeIngredient := &dto.RecipeIngredient{
+					RecipeID:     recipe.ID,
+					IngredientID: ingredientReq.IngredientID,
+					Quantity:     ingredientReq.Quantity,
+					Unit:         unit,
+					Notes:        ingredientReq.Notes,
+					IsOptional:   ingredientReq.IsOptional,
+					Group:        ingredientReq.Group,
+					Position:     i,
+				}
+				if err := tx.RecipeIngredientRepository.Create(ctx, recipeIngredient); err != nil {
+					return err
+				}
 			}
-			if err := h.ormService.RecipeIngredientRepository.Create(c.Request.Context(), recipeIngredient); err != nil {
-				log.Printf("Failed to create recipe ingredient during update: %v", err)
-			}
-		}
-	}
-
-	// Mettre à jour les équipements si fournis
-	if len(req.Equipments) > 0 {
-		// Supprimer tous les équipements existants
-		if err := h.ormService.RecipeEquipmentRepository.DeleteByRecipe(c.Request.Context(), recipe.ID); err != nil {
-			log.Printf("Failed to delete existing recipe equipment: %v", err)
 		}
 
-		// Ajouter les nouveaux équipements
-		for _, equipmentReq := range req.Equipments {
-			recipeEquipment := &dto.RecipeEquipment{
-				RecipeID:    recipe.ID,
-				EquipmentID: equipmentReq.EquipmentID,
-				IsOptional:  equipmentReq.IsOptional,
-				Notes:       equipmentReq.Notes,
+		// Mettre à jour les équipements si fournis
+		if len(req.Equipments) > 0 {
+			// Supprimer tous les équipements existants
+			if err := tx.RecipeEquipmentRepository.DeleteByRecipe(ctx, recipe.ID); err != nil {
+				return err
 			}
-			if err := h.ormService.RecipeEquipmentRepository.Create(c.Request.Context(), recipeEquipment); err != nil {
-				log.Printf("Failed to create recipe equipment during update: %v", err)
+
+			// Ajouter les nouveaux équipements
+			for _, equipmentReq := range req.Equipments {
+				recipeEquipment := &dto.RecipeEquipment{
+					RecipeID:    recipe.ID,
+					EquipmentID: equipmentReq.EquipmentID,
+					IsOptional:  equipmentReq.IsOptional,
+					Notes:       equipmentReq.Notes,
+				}
+				if err := tx.RecipeEquipmentRepository.Create(ctx, recipeEquipment); err != nil {
+					return err
+				}
 			}
 		}
-	}
 
-	// Mettre à jour les catégories si fournies
-	if len(req.CategoryIDs) > 0 {
-		// Supprimer toutes les associations existantes
-		if err := h.ormService.GetDB().Model(recipe).Association("Categories").Clear(); err != nil {
-			log.Printf("Failed to clear existing categories: %v", err)
+		// Remplacer les catégories si fournies
+		if len(req.CategoryIDs) > 0 {
+			var categories []dto.Category
+			if err := tx.GetDB().Where("id IN ?", req.CategoryIDs).Find(&categories).Error; err != nil {
+				return err
+			}
+			if err := tx.GetDB().Model(recipe).Association("Categories").Replace(categories); err != nil {
+				return err
+			}
 		}
 
-		// Ajouter les nouvelles catégories
-		var categories []dto.Category
-		if err := h.ormService.GetDB().Where("id IN ?", req.CategoryIDs).Find(&categories).Error; err == nil {
-			if err := h.ormService.GetDB().Model(recipe).Association("Categories").Append(categories); err != nil {
-				log.Printf("Failed to associate categories during update: %v", err)
+		// Remplacer les tags si fournis
+		if len(req.TagIDs) > 0 {
+			var tags []dto.Tag
+			if err := tx.GetDB().Where("id IN ?", req.TagIDs).Find(&tags).Error; err != nil {
+				return err
+			}
+			if err := tx.GetDB().Model(recipe).Association("Tags").Replace(tags); err != nil {
+				return err
 			}
 		}
-	}
 
-	// Mettre à jour les tags si fournis
-	if len(req.TagIDs) > 0 {
-		// Supprimer toutes les associations existantes
-		if err := h.ormService.GetDB().Model(recipe).Association("Tags").Clear(); err != nil {
-			log.Printf("Failed to clear existing tags: %v", err)
+		// Ingrédients ou sous-recettes ont pu changer : recalculer allergènes et régimes
+		if err := tx.RecipeRepository.RefreshDietaryLabels(ctx, recipe.ID); err != nil {
+			return err
 		}
 
-		// Ajouter les nouveaux tags
-		var tags []dto.Tag
-		if err := h.ormService.GetDB().Where("id IN ?", req.TagIDs).Find(&tags).Error; err == nil {
-			if err := h.ormService.GetDB().Model(recipe).Association("Tags").Append(tags); err != nil {
-				log.Printf("Failed to associate tags during update: %v", err)
-			}
-		}
+		// Enregistrer le nouvel état dans l'historique des révisions
+		_, err := tx.RecipeRevisionRepository.Record(ctx, recipe.ID, currentUserID, "")
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to update recipe %d: %v", recipe.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to update recipe",
+		})
+		return
 	}
 
 	// Recharger la recette avec toutes ses relations pour la réponse
@@ -634,22 +662,38 @@ func (h *RecipeHandler) ListRecipes(c *gin.Context) {
 // @Param q query string false "Terme de recherche"
 // @Param page query int false "Numéro de page (défaut: 1)"
 // @Param limit query int false "Nombre d'éléments par page (défaut: 10)"
-// @Param sort_by query string false "Champ de tri (défaut: created_at)"
+// @Param sort_by query string false "Champ de tri: relevance, created_at, updated_at, title, rating, prep_time, cook_time, total_time (défaut: relevance si q est fourni, sinon created_at)"
 // @Param sort_order query string false "Ordre de tri: asc ou desc (défaut: desc)"
 // @Param difficulty query string false "Niveau de difficulté: easy, medium, hard"
 // @Param prep_time_max query int false "Temps de préparation maximum en minutes"
 // @Param categories query string false "IDs de catégories séparés par des virgules"
 // @Param tags query string false "IDs de tags séparés par des virgules"
+// @Param ingredients_match query string false "Correspondance des ingrédients: any ou all (défaut: any)"
+// @Param equipments_match query string false "Correspondance des équipements: any ou all (défaut: any)"
+// @Param categories_match query string false "Correspondance des catégories: any ou all (défaut: any)"
+// @Param tags_match query string false "Correspondance des tags: any ou all (défaut: any)"
+// @Param exclude_ingredients query string false "IDs d'ingrédients à exclure"
+// @Param exclude_tags query string false "IDs de tags à exclure"
+// @Param exclude_equipments query string false "IDs d'équipements à exclure"
+// @Param diets query string false "Régimes requis: vegan, vegetarian, pescatarian, pork_free, alcohol_free"
+// @Param exclude_allergens query string false "Allergènes à exclure (14 allergènes UE: gluten, milk, eggs, peanuts, ...)"
+// @Param facets query bool false "Inclure les compteurs par tag, catégorie, équipement, difficulté et durée (défaut: false)"
 // @Success 200 {object} dto.RecipeListResponse "Résultats de recherche"
+// @Failure 400 {object} dto.ErrorResponse "Mode de correspondance invalide"
 // @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
 // @Router /recipes/search [get]
 func (h *RecipeHandler) SearchRecipes(c *gin.Context) {
 	// Construire la requête de recherche depuis les paramètres
+	// Avec un texte recherché, les résultats sont classés par pertinence par défaut
+	defaultSortBy := "created_at"
+	if strings.TrimSpace(c.Query("q")) != "" {
+		defaultSortBy = dto.SortByRelevance
+	}
 	searchQuery := &dto.SearchQuery{
 		Query:     c.Query("q"),
 		Page:      1,
 		Limit:     10,
-		SortBy:    c.DefaultQuery("sort_by", "created_at"),
+		SortBy:    c.DefaultQuery("sort_by", defaultSortBy),
 		SortOrder: c.DefaultQuery("sort_order", "desc"),
 	}
 
@@ -732,6 +776,51 @@ func (h *RecipeHandler) SearchRecipes(c *gin.Context) {
 		searchQuery.Tags = tags
 	}
 
+	// Modes de correspondance par filtre
+	matches := []struct {
+		param  string
+		target *string
+	}{
+		{"ingredients_match", &searchQuery.IngredientsMatch},
+		{"equipments_match", &searchQuery.EquipmentsMatch},
+		{"categories_match", &searchQuery.CategoriesMatch},
+		{"tags_match", &searchQuery.TagsMatch},
+	}
+	for _, m := range matches {
+		param, target := m.param, m.target
+		match := c.DefaultQuery(param, dto.MatchAny)
+		if match != dto.MatchAny && match != dto.MatchAll {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid parameter",
+				"message": param + " must be 'any' or 'all'",
+			})
+			return
+		}
+		*target = match
+	}
+
+	// Exclusions
+	searchQuery.ExcludeIngredients = c.QueryArray("exclude_ingredients")
+	searchQuery.ExcludeTags = c.QueryArray("exclude_tags")
+	searchQuery.ExcludeEquipments = c.QueryArray("exclude_equipments")
+
+	// Régimes et allergènes
+	searchQuery.Diets = c.QueryArray("diets")
+	searchQuery.ExcludeAllergens = c.QueryArray("exclude_allergens")
+
+	withFacets := false
+	if facetsStr := c.Query("facets"); facetsStr != "" {
+		parsed, err := strconv.ParseBool(facetsStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid parameter",
+				"message": "facets must be a boolean",
+			})
+			return
+		}
+		withFacets = parsed
+	}
+
 	// Effectuer la recherche
 	// Debug: afficher la requête finale
 	fmt.Printf("DEBUG: SearchRecipes - Final searchQuery.AuthorID: %d\n", searchQuery.AuthorID)
@@ -766,6 +855,19 @@ func (h *RecipeHandler) SearchRecipes(c *gin.Context) {
 		response.Recipes[i] = *recipe
 	}
 
+	if withFacets {
+		facets, err := h.ormService.RecipeRepository.SearchFacets(c.Request.Context(), searchQuery)
+		if err != nil {
+			log.Printf("SearchRecipes facets error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Internal server error",
+				"message": "Failed to compute search facets",
+			})
+			return
+		}
+		response.Facets = facets
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    response,
@@ -905,3 +1007,627 @@ func (h *RecipeHandler) GetUserRecipes(c *gin.Context) {
 		},
 	})
 }
+
+// ListRecipeRevisions liste l'historique des révisions d'une recette
+// @Summary Lister les révisions d'une recette
+// @Description Récupère la liste des révisions enregistrées d'une recette, de la plus récente à la plus ancienne
+// @Tags Recipes
+// @Produce json
+// @Param id path int true "ID de la recette"
+// @Success 200 {object} map[string]interface{} "Liste des révisions"
+// @Failure 400 {object} dto.ErrorResponse "ID invalide"
+// @Failure 404 {object} dto.ErrorResponse "Recette non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /recipes/{id}/revisions [get]
+func (h *RecipeHandler) ListRecipeRevisions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid recipe ID",
+			"message": "Recipe ID must be a number",
+		})
+		return
+	}
+
+	if _, err := h.ormService.RecipeRepository.GetByID(c.Request.Context(), uint(id)); err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve recipe")
+		return
+	}
+
+	revisions, err := h.ormService.RecipeRevisionRepository.GetByRecipe(c.Request.Context(), uint(id))
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve recipe revisions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"revisions":   revisions,
+			"total_count": len(revisions),
+		},
+	})
+}
+
+// GetRecipeRevision récupère le contenu complet d'une révision
+// @Summary Récupérer une révision de recette
+// @Description Récupère l'état complet d'une recette à une révision donnée
+// @Tags Recipes
+// @Produce json
+// @Param id path int true "ID de la recette"
+// @Param number path int true "Numéro de révision"
+// @Success 200 {object} dto.RecipeRevision "Révision trouvée"
+// @Failure 400 {object} dto.ErrorResponse "Paramètres invalides"
+// @Failure 404 {object} dto.ErrorResponse "Révision non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /recipes/{id}/revisions/{number} [get]
+func (h *RecipeHandler) GetRecipeRevision(c *gin.Context) {
+	id, number, ok := parseRevisionParams(c)
+	if !ok {
+		return
+	}
+
+	revision, err := h.ormService.RecipeRevisionRepository.GetByNumber(c.Request.Context(), id, number)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve recipe revision")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    revision,
+	})
+}
+
+// DiffRecipeRevisions compare deux révisions d'une recette
+// @Summary Comparer deux révisions d'une recette
+// @Description Retourne les différences structurées (champs, ingrédients, étapes, équipements, tags, catégories) entre deux révisions
+// @Tags Recipes
+// @Produce json
+// @Param id path int true "ID de la recette"
+// @Param from query int true "Numéro de la révision de départ"
+// @Param to query int true "Numéro de la révision d'arrivée"
+// @Success 200 {object} dto.RecipeRevisionDiff "Différences entre les révisions"
+// @Failure 400 {object} dto.ErrorResponse "Paramètres invalides"
+// @Failure 404 {object} dto.ErrorResponse "Révision non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /recipes/{id}/revisions/diff [get]
+func (h *RecipeHandler) DiffRecipeRevisions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid recipe ID",
+			"message": "Recipe ID must be a number",
+		})
+		return
+	}
+
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil || from < 1 || to < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid revision numbers",
+			"message": "from and to must be positive revision numbers",
+		})
+		return
+	}
+
+	diff, err := h.ormService.RecipeRevisionRepository.Diff(c.Request.Context(), uint(id), from, to)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to compare recipe revisions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    diff,
+	})
+}
+
+// RestoreRecipeRevision restaure une ancienne révision d'une recette
+// @Summary Restaurer une révision de recette
+// @Description Réapplique le contenu d'une ancienne révision à la recette ; la restauration est enregistrée comme une nouvelle révision
+// @Tags Recipes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la recette"
+// @Param number path int true "Numéro de la révision à restaurer"
+// @Success 200 {object} dto.RecipeResponse "Recette restaurée"
+// @Failure 400 {object} dto.ErrorResponse "Paramètres invalides"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 403 {object} dto.ErrorResponse "Accès refusé"
+// @Failure 404 {object} dto.ErrorResponse "Recette ou révision non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /recipes/{id}/revisions/{number}/restore [post]
+func (h *RecipeHandler) RestoreRecipeRevision(c *gin.Context) {
+	id, number, ok := parseRevisionParams(c)
+	if !ok {
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "User not authenticated",
+		})
+		return
+	}
+
+	currentUserID, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Invalid user ID format",
+		})
+		return
+	}
+
+	recipe, err := h.ormService.RecipeRepository.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve recipe")
+		return
+	}
+
+	if recipe.AuthorID != currentUserID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Forbidden",
+			"message": "You can only restore revisions of your own recipes",
+		})
+		return
+	}
+
+	revision, err := h.ormService.RecipeRevisionRepository.Restore(c.Request.Context(), id, number, currentUserID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to restore recipe revision")
+		return
+	}
+
+	restoredRecipe, err := h.ormService.RecipeRepository.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve restored recipe")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"data":     restoredRecipe,
+		"revision": revision.Number,
+		"message":  "Recipe revision restored successfully",
+	})
+}
+
+// GetRecipeForks récupère l'arbre des copies d'une recette
+// @Summary Arbre des copies d'une recette
+// @Description Retourne l'arbre de filiation auquel appartient la recette, depuis le plus ancien ancêtre visible. Les recettes privées ne sont visibles que par leur auteur.
+// @Tags Recipes
+// @Produce json
+// @Param id path int true "ID de la recette"
+// @Success 200 {object} dto.RecipeForkNode "Arbre des copies"
+// @Failure 400 {object} dto.ErrorResponse "ID invalide"
+// @Failure 404 {object} dto.ErrorResponse "Recette non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /recipes/{id}/forks [get]
+func (h *RecipeHandler) GetRecipeForks(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid recipe ID",
+			"message": "Recipe ID must be a number",
+		})
+		return
+	}
+
+	// Authentification facultative : l'utilisateur connecté voit aussi ses copies privées
+	var viewerID uint
+	if userID, exists := c.Get("user_id"); exists {
+		viewerID, _ = userID.(uint)
+	}
+
+	tree, err := h.ormService.RecipeLineageRepository.GetForkTree(c.Request.Context(), uint(id), viewerID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve recipe forks")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tree,
+	})
+}
+
+// GetUpstreamChanges liste les modifications de la recette originale depuis la copie
+// @Summary Modifications de l'originale depuis la copie
+// @Description Pour une copie, retourne les modifications apportées à la recette originale depuis la copie et qui ne sont pas encore reprises dans la copie
+// @Tags Recipes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la copie"
+// @Success 200 {object} dto.RecipeUpstreamChanges "Modifications en attente"
+// @Failure 400 {object} dto.ErrorResponse "La recette n'est pas une copie"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 403 {object} dto.ErrorResponse "Accès refusé"
+// @Failure 404 {object} dto.ErrorResponse "Recette non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /recipes/{id}/upstream-changes [get]
+func (h *RecipeHandler) GetUpstreamChanges(c *gin.Context) {
+	id, ok := h.authorizeCopyOwner(c)
+	if !ok {
+		return
+	}
+
+	changes, err := h.ormService.RecipeLineageRepository.GetUpstreamChanges(c.Request.Context(), id)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to compute upstream changes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    changes,
+	})
+}
+
+// PullUpstreamChanges importe des modifications de la recette originale dans une copie
+// @Summary Importer des modifications de l'originale
+// @Description Applique à la copie les modifications sélectionnées de la recette originale (ingrédients par ID, étapes par numéro dans l'originale). Une nouvelle révision de la copie est enregistrée.
+// @Tags Recipes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la copie"
+// @Param request body dto.RecipeUpstreamPullRequest true "Modifications à importer"
+// @Success 200 {object} dto.RecipeUpstreamChanges "Modifications restant en attente"
+// @Failure 400 {object} dto.ErrorResponse "Requête invalide"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 403 {object} dto.ErrorResponse "Accès refusé"
+// @Failure 404 {object} dto.ErrorResponse "Recette non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /recipes/{id}/upstream-changes/pull [post]
+func (h *RecipeHandler) PullUpstreamChanges(c *gin.Context) {
+	id, ok := h.authorizeCopyOwner(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RecipeUpstreamPullRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+	if len(req.IngredientIDs) == 0 && len(req.StepNumbers) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": "ingredient_ids or step_numbers must be provided",
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	remaining, err := h.ormService.RecipeLineageRepository.PullUpstreamChanges(c.Request.Context(), id, userID.(uint), &req)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to pull upstream changes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    remaining,
+		"message": "Upstream changes pulled successfully",
+	})
+}
+
+// authorizeCopyOwner vérifie que l'utilisateur connecté est l'auteur de la recette du chemin
+func (h *RecipeHandler) authorizeCopyOwner(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid recipe ID",
+			"message": "Recipe ID must be a number",
+		})
+		return 0, false
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "User not authenticated",
+		})
+		return 0, false
+	}
+
+	currentUserID, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Invalid user ID format",
+		})
+		return 0, false
+	}
+
+	recipe, err := h.ormService.RecipeRepository.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve recipe")
+		return 0, false
+	}
+
+	if recipe.AuthorID != currentUserID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "Forbidden",
+			"message": "You can only track upstream changes of your own recipes",
+		})
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+// parseRevisionParams lit l'ID de recette et le numéro de révision du chemin
+func parseRevisionParams(c *gin.Context) (uint, int, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid recipe ID",
+			"message": "Recipe ID must be a number",
+		})
+		return 0, 0, false
+	}
+
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid revision number",
+			"message": "Revision number must be a positive number",
+		})
+		return 0, 0, false
+	}
+
+	return uint(id), number, true
+}
+
+// GetRecipeNutrition calcule les valeurs nutritionnelles d'une recette
+// @Summary Valeurs nutritionnelles d'une recette
+// @Description Calcule l'énergie, les protéines, lipides, glucides, fibres et sel de la recette et par portion, sous-recettes comprises. La couverture indique la part des ingrédients pris en compte.
+// @Tags Recipes
+// @Produce json
+// @Param id path int true "ID de la recette"
+// @Success 200 {object} dto.RecipeNutrition "Valeurs nutritionnelles"
+// @Failure 400 {object} dto.ErrorResponse "ID invalide"
+// @Failure 404 {object} dto.ErrorResponse "Recette non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /recipes/{id}/nutrition [get]
+func (h *RecipeHandler) GetRecipeNutrition(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid recipe ID",
+			"message": "Recipe ID must be a number",
+		})
+		return
+	}
+
+	nutrition, err := h.ormService.RecipeNutritionRepository.GetRecipeNutrition(c.Request.Context(), uint(id))
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to compute recipe nutrition")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    nutrition,
+	})
+}
+
+// GetScaledRecipe retourne une recette mise à l'échelle
+// @Summary Mettre une recette à l'échelle
+// @Description Retourne la recette complète avec des quantités recalculées pour un nombre de portions, un coefficient ou une masse totale, arrondies à des valeurs mesurables (œufs entiers, fractions de cuillères). Les sous-recettes référencées sont mises à l'échelle avec le même coefficient.
+// @Tags Recipes
+// @Produce json
+// @Param id path int true "ID de la recette"
+// @Param servings query int false "Nombre de portions souhaité"
+// @Param factor query number false "Coefficient multiplicateur"
+// @Param yield_grams query number false "Masse totale d'ingrédients souhaitée en grammes"
+// @Param units query string false "Conversion des unités: metric ou imperial"
+// @Success 200 {object} dto.ScaledRecipe "Recette mise à l'échelle"
+// @Failure 400 {object} dto.ErrorResponse "Paramètres invalides"
+// @Failure 404 {object} dto.ErrorResponse "Recette non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /recipes/{id}/scaled [get]
+func (h *RecipeHandler) GetScaledRecipe(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid recipe ID",
+			"message": "Recipe ID must be a number",
+		})
+		return
+	}
+
+	var req dto.RecipeScaleRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	scaled, err := h.ormService.RecipeRepository.GetScaled(c.Request.Context(), uint(id), &req)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to scale recipe")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    scaled,
+	})
+}
+
+// GetRecipeSubstitutions propose des remplacements pour les ingrédients d'une recette
+// @Summary Remplacements d'ingrédients pour une recette
+// @Description Pour chaque ingrédient absent ou insuffisant dans le frigo de l'utilisateur, contenant un allergène à éviter ou incompatible avec un régime demandé, propose des remplacements à la quantité de la recette. Les remplacements réalisables avec le frigo sont proposés en premier.
+// @Tags Recipes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la recette"
+// @Param avoid_allergens query []string false "Allergènes à éviter" collectionFormat(multi)
+// @Param diets query []string false "Régimes à respecter" collectionFormat(multi)
+// @Success 200 {object} dto.RecipeSubstitutions "Remplacements proposés"
+// @Failure 400 {object} dto.ErrorResponse "Paramètres invalides"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 404 {object} dto.ErrorResponse "Recette non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /recipes/{id}/substitutions [get]
+func (h *RecipeHandler) GetRecipeSubstitutions(c *gin.Context) {
+	recipeID, userID, ok := parseRecipeAndUser(c)
+	if !ok {
+		return
+	}
+
+	var query dto.RecipeSubstitutionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid parameters",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	substitutions, err := h.ormService.IngredientSubstitutionRepository.SuggestForRecipe(c.Request.Context(), recipeID, userID, &query)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to suggest substitutions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    substitutions,
+	})
+}
+
+// AdaptRecipe crée une copie de la recette avec les remplacements choisis
+// @Summary Créer une copie adaptée d'une recette
+// @Description Copie la recette pour l'utilisateur et remplace les ingrédients choisis par les composants de leur remplacement, à la quantité de la recette. Les étiquettes alimentaires de la copie sont recalculées.
+// @Tags Recipes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la recette"
+// @Param request body dto.RecipeAdaptRequest true "Remplacements à appliquer"
+// @Success 201 {object} dto.Recipe "Copie adaptée"
+// @Failure 400 {object} dto.ErrorResponse "Requête invalide"
+// @Failure 401 {object} dto.ErrorResponse "Non authentifié"
+// @Failure 404 {object} dto.ErrorResponse "Recette non trouvée"
+// @Failure 500 {object} dto.ErrorResponse "Erreur serveur"
+// @Router /recipes/{id}/substitutions/adapt [post]
+func (h *RecipeHandler) AdaptRecipe(c *gin.Context) {
+	recipeID, userID, ok := parseRecipeAndUser(c)
+	if !ok {
+		return
+	}
+
+	var request dto.RecipeAdaptRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	adapted, err := h.ormService.IngredientSubstitutionRepository.AdaptRecipe(c.Request.Context(), recipeID, userID, &request)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to adapt recipe")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    adapted,
+		"message": "Adapted recipe created successfully",
+	})
+}
+
+// GetRecipeCost estime le coût d'une recette avec les prix relevés par l'utilisateur
+// @Summary Coût d'une recette
+// @Description Estime le coût de la recette (sous-recettes comprises) pour le nombre de portions demandé, en convertissant les quantités vers l'unité des prix. Le prix le plus récent est utilisé, en privilégiant le magasin demandé ; à défaut, celui d'un ingrédient plus général. Les ingrédients sans prix connu sont signalés.
+// @Tags Recipes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la recette"
+// @Param servings query int false "Nombre de portions (défaut: portions de la recette)"
+// @Param store_id query int false "Magasin dont les prix sont privilégiés"
+// @Success 200 {object} dto.CostEstimate "Coût estimé"
+// @Failure 400 {object} map[string]interface{} "Paramètres invalides"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Recette non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /recipes/{id}/cost [get]
+func (h *RecipeHandler) GetRecipeCost(c *gin.Context) {
+	recipeID, userID, ok := parseRecipeAndUser(c)
+	if !ok {
+		return
+	}
+
+	var query dto.CostQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid parameters",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	estimate, err := h.ormService.IngredientPriceRepository.RecipeCost(c.Request.Context(), recipeID, userID, &query)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to estimate recipe cost")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    estimate,
+	})
+}
+
+// parseRecipeAndUser lit l'ID de recette du chemin et l'utilisateur authentifié
+func parseRecipeAndUser(c *gin.Context) (uint, uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid recipe ID",
+			"message": "Recipe ID must be a number",
+		})
+		return 0, 0, false
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "Unauthorized",
+			"message": "User not authenticated",
+		})
+		return 0, 0, false
+	}
+
+	currentUserID, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Invalid user ID format",
+		})
+		return 0, 0, false
+	}
+
+	return uint(id), currentUserID, true
+}
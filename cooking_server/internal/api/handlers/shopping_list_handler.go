@@ -0,0 +1,584 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	"github.com/romainrodriguez/cooking_server/internal/services/orm"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+)
+
+// ShoppingListHandler gère les requêtes liées aux listes de courses enregistrées
+type ShoppingListHandler struct {
+	ormService *orm.ORMService
+}
+
+// NewShoppingListHandler crée une nouvelle instance du handler des listes de courses
+func NewShoppingListHandler(ormService *orm.ORMService) *ShoppingListHandler {
+	return &ShoppingListHandler{
+		ormService: ormService,
+	}
+}
+
+// ListShoppingLists liste les listes de courses de l'utilisateur connecté
+// @Summary Lister mes listes de courses
+// @Description Retourne les listes de courses enregistrées de l'utilisateur, sans leurs lignes
+// @Tags ShoppingLists
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Listes de courses"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /shopping-lists [get]
+func (h *ShoppingListHandler) ListShoppingLists(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	lists, err := h.ormService.ShoppingListRepository.GetByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve shopping lists",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    lists,
+	})
+}
+
+// CreateShoppingList crée une liste de courses
+// @Summary Créer une liste de courses
+// @Description Crée une liste de courses vide, ou générée à partir des repas planifiés si start_date est fourni (end_date par défaut: start_date + 6 jours)
+// @Tags ShoppingLists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param list body dto.ShoppingListCreateRequest true "Informations de la liste"
+// @Success 201 {object} map[string]interface{} "Liste créée"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /shopping-lists [post]
+func (h *ShoppingListHandler) CreateShoppingList(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ShoppingListCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	startDate, endDate, ok := parseShoppingListPeriod(c, req.StartDate, req.EndDate)
+	if !ok {
+		return
+	}
+
+	if req.StoreID != nil && !h.checkOwnedStore(c, *req.StoreID, userID) {
+		return
+	}
+
+	list := &dto.ShoppingList{
+		UserID:         userID,
+		Name:           req.Name,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		SubtractFridge: req.SubtractFridge == nil || *req.SubtractFridge,
+		StoreID:        req.StoreID,
+	}
+	if err := h.ormService.ShoppingListRepository.Create(c.Request.Context(), list); err != nil {
+		respondRepositoryError(c, err, "Failed to create shopping list")
+		return
+	}
+
+	created, err := h.ormService.ShoppingListRepository.GetByID(c.Request.Context(), list.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve created shopping list")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    created,
+		"message": "Shopping list created successfully",
+	})
+}
+
+// GetShoppingList récupère une liste de courses avec ses lignes
+// @Summary Récupérer une liste de courses
+// @Description Retourne une liste de courses de l'utilisateur avec ses lignes, non cochées d'abord, et leur regroupement par rayon selon le magasin de la liste (ou le magasin par défaut)
+// @Tags ShoppingLists
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la liste"
+// @Success 200 {object} map[string]interface{} "Liste de courses"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Liste non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /shopping-lists/{id} [get]
+func (h *ShoppingListHandler) GetShoppingList(c *gin.Context) {
+	list, ok := h.loadOwnedShoppingList(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// UpdateShoppingList modifie le nom ou la déduction du frigo d'une liste de courses
+// @Summary Modifier une liste de courses
+// @Description Modifie le nom d'une liste, la déduction du stock du frigo appliquée aux prochaines générations ou le magasin dont l'ordre des rayons est appliqué
+// @Tags ShoppingLists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la liste"
+// @Param list body dto.ShoppingListUpdateRequest true "Champs à modifier"
+// @Success 200 {object} map[string]interface{} "Liste modifiée"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Liste non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /shopping-lists/{id} [put]
+func (h *ShoppingListHandler) UpdateShoppingList(c *gin.Context) {
+	var req dto.ShoppingListUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	list, ok := h.loadOwnedShoppingList(c)
+	if !ok {
+		return
+	}
+
+	if req.Name != "" {
+		list.Name = req.Name
+	}
+	if req.SubtractFridge != nil {
+		list.SubtractFridge = *req.SubtractFridge
+	}
+	if req.StoreID != nil {
+		// 0 retire le magasin : la liste suit alors le magasin par défaut
+		list.StoreID = nil
+		if *req.StoreID != 0 {
+			if !h.checkOwnedStore(c, *req.StoreID, list.UserID) {
+				return
+			}
+			list.StoreID = req.StoreID
+		}
+	}
+
+	if err := h.ormService.ShoppingListRepository.Update(c.Request.Context(), list); err != nil {
+		respondRepositoryError(c, err, "Failed to update shopping list")
+		return
+	}
+
+	updated, err := h.ormService.ShoppingListRepository.GetByID(c.Request.Context(), list.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve updated shopping list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+		"message": "Shopping list updated successfully",
+	})
+}
+
+// DeleteShoppingList supprime une liste de courses
+// @Summary Supprimer une liste de courses
+// @Description Supprime une liste de courses et toutes ses lignes
+// @Tags ShoppingLists
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la liste"
+// @Success 200 {object} map[string]interface{} "Liste supprimée"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Liste non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /shopping-lists/{id} [delete]
+func (h *ShoppingListHandler) DeleteShoppingList(c *gin.Context) {
+	list, ok := h.loadOwnedShoppingList(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ormService.ShoppingListRepository.Delete(c.Request.Context(), list.ID); err != nil {
+		respondRepositoryError(c, err, "Failed to delete shopping list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Shopping list deleted successfully",
+	})
+}
+
+// RegenerateShoppingList recalcule une liste de courses à partir des repas planifiés
+// @Summary Regénérer une liste de courses
+// @Description Recalcule les lignes issues des repas planifiés, éventuellement sur une nouvelle période. Les quantités déjà cochées sont déduites et les lignes cochées ou ajoutées à la main sont conservées.
+// @Tags ShoppingLists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la liste"
+// @Param period body dto.ShoppingListRegenerateRequest false "Nouvelle période (optionnelle)"
+// @Success 200 {object} map[string]interface{} "Liste regénérée"
+// @Failure 400 {object} map[string]interface{} "Requête invalide ou liste sans période"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Liste non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /shopping-lists/{id}/regenerate [post]
+func (h *ShoppingListHandler) RegenerateShoppingList(c *gin.Context) {
+	var req dto.ShoppingListRegenerateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	list, ok := h.loadOwnedShoppingList(c)
+	if !ok {
+		return
+	}
+
+	// Changer de période avant de regénérer
+	if req.StartDate != "" || req.EndDate != "" {
+		startDate, endDate, ok := parseShoppingListPeriod(c, req.StartDate, req.EndDate)
+		if !ok {
+			return
+		}
+		list.StartDate, list.EndDate = startDate, endDate
+		if err := h.ormService.ShoppingListRepository.Update(c.Request.Context(), list); err != nil {
+			respondRepositoryError(c, err, "Failed to update shopping list period")
+			return
+		}
+	}
+
+	regenerated, err := h.ormService.ShoppingListRepository.Regenerate(c.Request.Context(), list.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to regenerate shopping list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    regenerated,
+		"message": "Shopping list regenerated successfully",
+	})
+}
+
+// AddShoppingListItem ajoute un article à la main dans une liste de courses
+// @Summary Ajouter un article
+// @Description Ajoute un ingrédient ou un article libre (sans ingrédient) à une liste de courses
+// @Tags ShoppingLists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la liste"
+// @Param item body dto.ShoppingListEntryCreateRequest true "Article à ajouter"
+// @Success 201 {object} dto.ShoppingListEntry "Article ajouté"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Liste ou ingrédient non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /shopping-lists/{id}/items [post]
+func (h *ShoppingListHandler) AddShoppingListItem(c *gin.Context) {
+	var req dto.ShoppingListEntryCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	list, ok := h.loadOwnedShoppingList(c)
+	if !ok {
+		return
+	}
+
+	entry := &dto.ShoppingListEntry{
+		ShoppingListID: list.ID,
+		IngredientID:   req.IngredientID,
+		Name:           req.Name,
+		Quantity:       req.Quantity,
+		Unit:           req.Unit,
+		Notes:          req.Notes,
+	}
+	if err := h.ormService.ShoppingListRepository.AddEntry(c.Request.Context(), entry); err != nil {
+		respondRepositoryError(c, err, "Failed to add shopping list item")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    entry,
+		"message": "Shopping list item added successfully",
+	})
+}
+
+// UpdateShoppingListItem coche un article ou corrige sa quantité
+// @Summary Modifier un article
+// @Description Coche ou décoche un article, corrige sa quantité à la main (reset_quantity revient à la quantité calculée) ou modifie son texte
+// @Tags ShoppingLists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la liste"
+// @Param item_id path int true "ID de l'article"
+// @Param item body dto.ShoppingListEntryUpdateRequest true "Champs à modifier"
+// @Success 200 {object} dto.ShoppingListEntry "Article modifié"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Liste ou article non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /shopping-lists/{id}/items/{item_id} [patch]
+func (h *ShoppingListHandler) UpdateShoppingListItem(c *gin.Context) {
+	var req dto.ShoppingListEntryUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	entry, ok := h.loadOwnedShoppingListEntry(c)
+	if !ok {
+		return
+	}
+
+	if req.Checked != nil && *req.Checked != entry.Checked {
+		entry.Checked = *req.Checked
+		entry.CheckedAt = nil
+		if entry.Checked {
+			now := time.Now()
+			entry.CheckedAt = &now
+		}
+	}
+	if req.ResetQuantity {
+		entry.QuantityOverride = nil
+	} else if req.QuantityOverride != nil {
+		entry.QuantityOverride = req.QuantityOverride
+	}
+	if req.Name != "" {
+		entry.Name = req.Name
+	}
+	if req.Unit != nil {
+		entry.Unit = *req.Unit
+	}
+	if req.Notes != nil {
+		entry.Notes = *req.Notes
+	}
+
+	if err := h.ormService.ShoppingListRepository.UpdateEntry(c.Request.Context(), entry); err != nil {
+		respondRepositoryError(c, err, "Failed to update shopping list item")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entry,
+		"message": "Shopping list item updated successfully",
+	})
+}
+
+// DeleteShoppingListItem retire un article d'une liste de courses
+// @Summary Retirer un article
+// @Description Retire un article d'une liste de courses. Un article généré peut réapparaître à la prochaine génération s'il est toujours nécessaire.
+// @Tags ShoppingLists
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la liste"
+// @Param item_id path int true "ID de l'article"
+// @Success 200 {object} map[string]interface{} "Article retiré"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Liste ou article non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /shopping-lists/{id}/items/{item_id} [delete]
+func (h *ShoppingListHandler) DeleteShoppingListItem(c *gin.Context) {
+	entry, ok := h.loadOwnedShoppingListEntry(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ormService.ShoppingListRepository.DeleteEntry(c.Request.Context(), entry.ShoppingListID, entry.ID); err != nil {
+		respondRepositoryError(c, err, "Failed to delete shopping list item")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Shopping list item deleted successfully",
+	})
+}
+
+// CheckoutShoppingList range les articles cochés dans le frigo
+// @Summary Passer en caisse
+// @Description Crée un item de frigo pour chaque article coché puis les retire de la liste. Les articles libres sans ingrédient correspondant sont seulement retirés. Les articles issus des repas planifiés restent mémorisés comme achetés et ne sont pas rajoutés à la régénération.
+// @Tags ShoppingLists
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID de la liste"
+// @Success 200 {object} dto.ShoppingListCheckout "Articles rangés dans le frigo"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Liste non trouvée"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /shopping-lists/{id}/checkout [post]
+func (h *ShoppingListHandler) CheckoutShoppingList(c *gin.Context) {
+	list, ok := h.loadOwnedShoppingList(c)
+	if !ok {
+		return
+	}
+
+	checkout, err := h.ormService.ShoppingListRepository.Checkout(c.Request.Context(), list.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to checkout shopping list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    checkout,
+		"message": "Checked items moved to the fridge",
+	})
+}
+
+// loadOwnedShoppingList charge la liste du chemin si elle appartient à l'utilisateur connecté.
+// Une liste d'un autre utilisateur est traitée comme inexistante.
+func (h *ShoppingListHandler) loadOwnedShoppingList(c *gin.Context) (*dto.ShoppingList, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid shopping list ID",
+			"message": "Shopping list ID must be a number",
+		})
+		return nil, false
+	}
+
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	list, err := h.ormService.ShoppingListRepository.GetByID(c.Request.Context(), uint(id))
+	if err == nil && list.UserID != userID {
+		err = ormerrors.NewNotFoundError("shopping list", id)
+	}
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve shopping list")
+		return nil, false
+	}
+	return list, true
+}
+
+// loadOwnedShoppingListEntry charge l'article du chemin d'une liste de l'utilisateur connecté
+func (h *ShoppingListHandler) loadOwnedShoppingListEntry(c *gin.Context) (*dto.ShoppingListEntry, bool) {
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid item ID",
+			"message": "Item ID must be a number",
+		})
+		return nil, false
+	}
+
+	list, ok := h.loadOwnedShoppingList(c)
+	if !ok {
+		return nil, false
+	}
+
+	entry, err := h.ormService.ShoppingListRepository.GetEntry(c.Request.Context(), list.ID, uint(itemID))
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve shopping list item")
+		return nil, false
+	}
+	return entry, true
+}
+
+// checkOwnedStore vérifie que le magasin choisi pour une liste appartient à l'utilisateur
+func (h *ShoppingListHandler) checkOwnedStore(c *gin.Context, storeID, userID uint) bool {
+	store, err := h.ormService.StoreRepository.GetByID(c.Request.Context(), storeID)
+	if err == nil && store.UserID != userID {
+		err = ormerrors.NewNotFoundError("store", storeID)
+	}
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve store")
+		return false
+	}
+	return true
+}
+
+// parseShoppingListPeriod lit la période d'une liste (end_date par défaut: start_date + 6 jours).
+// Une période vide retourne deux dates nulles.
+func parseShoppingListPeriod(c *gin.Context, start, end string) (*time.Time, *time.Time, bool) {
+	if start == "" {
+		if end != "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Missing start_date",
+				"message": "start_date is required when end_date is provided",
+			})
+			return nil, nil, false
+		}
+		return nil, nil, true
+	}
+
+	startDate, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid start_date",
+			"message": "start_date must be in format 2006-01-02",
+		})
+		return nil, nil, false
+	}
+
+	endDate := startDate.AddDate(0, 0, 6)
+	if end != "" {
+		if endDate, err = time.Parse("2006-01-02", end); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid end_date",
+				"message": "end_date must be in format 2006-01-02",
+			})
+			return nil, nil, false
+		}
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid date range",
+			"message": "end_date must be after or equal to start_date",
+		})
+		return nil, nil, false
+	}
+	return &startDate, &endDate, true
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	"github.com/romainrodriguez/cooking_server/internal/services/orm"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+)
+
+// StoreHandler gère les requêtes liées aux magasins et à l'ordre de leurs rayons
+type StoreHandler struct {
+	ormService *orm.ORMService
+}
+
+// NewStoreHandler crée une nouvelle instance du handler des magasins
+func NewStoreHandler(ormService *orm.ORMService) *StoreHandler {
+	return &StoreHandler{
+		ormService: ormService,
+	}
+}
+
+// ListStores liste les magasins de l'utilisateur connecté
+// @Summary Lister mes magasins
+// @Description Retourne les magasins de l'utilisateur avec leurs rayons dans l'ordre de passage, le magasin par défaut en premier
+// @Tags Stores
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Magasins"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /stores [get]
+func (h *StoreHandler) ListStores(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	stores, err := h.ormService.StoreRepository.GetByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Internal server error",
+			"message": "Failed to retrieve stores",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    stores,
+	})
+}
+
+// CreateStore crée un magasin avec ses rayons
+// @Summary Créer un magasin
+// @Description Crée un magasin avec une liste ordonnée de rayons, chacun associé à des catégories d'ingrédients
+// @Tags Stores
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param store body dto.StoreCreateRequest true "Magasin et rayons dans l'ordre de passage"
+// @Success 201 {object} dto.Store "Magasin créé"
+// @Failure 400 {object} map[string]interface{} "Requête invalide ou catégorie inconnue"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /stores [post]
+func (h *StoreHandler) CreateStore(c *gin.Context) {
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.StoreCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	store := &dto.Store{
+		UserID:    userID,
+		Name:      req.Name,
+		IsDefault: req.IsDefault,
+		Aisles:    storeAislesFromRequest(req.Aisles),
+	}
+	if err := h.ormService.StoreRepository.Create(c.Request.Context(), store); err != nil {
+		respondRepositoryError(c, err, "Failed to create store")
+		return
+	}
+
+	created, err := h.ormService.StoreRepository.GetByID(c.Request.Context(), store.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve created store")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    created,
+		"message": "Store created successfully",
+	})
+}
+
+// GetStore récupère un magasin avec ses rayons
+// @Summary Récupérer un magasin
+// @Description Retourne un magasin de l'utilisateur avec ses rayons dans l'ordre de passage
+// @Tags Stores
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID du magasin"
+// @Success 200 {object} dto.Store "Magasin"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Magasin non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /stores/{id} [get]
+func (h *StoreHandler) GetStore(c *gin.Context) {
+	store, _, ok := h.loadOwnedStore(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    store,
+	})
+}
+
+// UpdateStore modifie un magasin et, si fournis, ses rayons
+// @Summary Modifier un magasin
+// @Description Modifie le nom d'un magasin, son statut par défaut, ou remplace ses rayons (les rayons cités par leur ID conservent leurs placements appris)
+// @Tags Stores
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID du magasin"
+// @Param store body dto.StoreUpdateRequest true "Champs à modifier"
+// @Success 200 {object} dto.Store "Magasin modifié"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Magasin non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /stores/{id} [put]
+func (h *StoreHandler) UpdateStore(c *gin.Context) {
+	var req dto.StoreUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	store, _, ok := h.loadOwnedStore(c)
+	if !ok {
+		return
+	}
+
+	if req.Name != "" {
+		store.Name = req.Name
+	}
+	if req.IsDefault != nil {
+		store.IsDefault = *req.IsDefault
+	}
+	var aisles *[]dto.StoreAisle
+	if req.Aisles != nil {
+		replaced := storeAislesFromRequest(*req.Aisles)
+		aisles = &replaced
+	}
+
+	if err := h.ormService.StoreRepository.Update(c.Request.Context(), store, aisles); err != nil {
+		respondRepositoryError(c, err, "Failed to update store")
+		return
+	}
+
+	updated, err := h.ormService.StoreRepository.GetByID(c.Request.Context(), store.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve updated store")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+		"message": "Store updated successfully",
+	})
+}
+
+// DeleteStore supprime un magasin
+// @Summary Supprimer un magasin
+// @Description Supprime un magasin, ses rayons, les placements appris et les prix relevés dans ce magasin
+// @Tags Stores
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID du magasin"
+// @Success 200 {object} map[string]interface{} "Magasin supprimé"
+// @Failure 400 {object} map[string]interface{} "ID invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Magasin non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /stores/{id} [delete]
+func (h *StoreHandler) DeleteStore(c *gin.Context) {
+	store, _, ok := h.loadOwnedStore(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ormService.StoreRepository.Delete(c.Request.Context(), store.ID); err != nil {
+		respondRepositoryError(c, err, "Failed to delete store")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Store deleted successfully",
+	})
+}
+
+// RecordStorePlacement mémorise le rayon où un ingrédient a été trouvé
+// @Summary Placer un ingrédient dans un rayon
+// @Description Mémorise le rayon où l'utilisateur a trouvé un ingrédient. Ces placements rangent les ingrédients dont la catégorie n'est associée à aucun rayon, dans ce magasin et dans les rayons de même nom des autres magasins.
+// @Tags Stores
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID du magasin"
+// @Param placement body dto.StorePlacementRequest true "Ingrédient et rayon"
+// @Success 200 {object} dto.StoreIngredientPlacement "Placement mémorisé"
+// @Failure 400 {object} map[string]interface{} "Requête invalide"
+// @Failure 401 {object} map[string]interface{} "Non authentifié"
+// @Failure 404 {object} map[string]interface{} "Magasin, rayon ou ingrédient non trouvé"
+// @Failure 500 {object} map[string]interface{} "Erreur serveur"
+// @Router /stores/{id}/placements [put]
+func (h *StoreHandler) RecordStorePlacement(c *gin.Context) {
+	var req dto.StorePlacementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	store, userID, ok := h.loadOwnedStore(c)
+	if !ok {
+		return
+	}
+
+	placement, err := h.ormService.StoreRepository.RecordPlacement(c.Request.Context(), store.ID, userID, &req)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to record placement")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    placement,
+		"message": "Placement recorded successfully",
+	})
+}
+
+// loadOwnedStore charge le magasin du chemin s'il appartient à l'utilisateur connecté
+func (h *StoreHandler) loadOwnedStore(c *gin.Context) (*dto.Store, uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid store ID",
+			"message": "Store ID must be a number",
+		})
+		return nil, 0, false
+	}
+
+	userID, ok := middleware.RequireCurrentUser(c)
+	if !ok {
+		return nil, 0, false
+	}
+
+	store, err := h.ormService.StoreRepository.GetByID(c.Request.Context(), uint(id))
+	if err == nil && store.UserID != userID {
+		err = ormerrors.NewNotFoundError("store", id)
+	}
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve store")
+		return nil, 0, false
+	}
+	return store, userID, true
+}
+
+// storeAislesFromRequest convertit les rayons demandés en rayons du magasin, dans le même ordre
+func storeAislesFromRequest(requests []dto.StoreAisleRequest) []dto.StoreAisle {
+	aisles := make([]dto.StoreAisle, 0, len(requests))
+	for _, req := range requests {
+		aisle := dto.StoreAisle{ID: req.ID, Name: req.Name}
+		for _, categoryID := range req.CategoryIDs {
+			aisle.Categories = append(aisle.Categories, dto.IngredientCategory{ID: categoryID})
+		}
+		aisles = append(aisles, aisle)
+	}
+	return aisles
+}
@@ -22,7 +22,12 @@ func SetupFridgeRoutes(router *gin.RouterGroup, handler *handlers.FridgeHandler,
 		fridge.DELETE("/clear", handler.ClearFridge)          // DELETE /api/v1/fridge/clear
 		fridge.DELETE("/expired", handler.RemoveExpiredItems) // DELETE /api/v1/fridge/expired
 
-		// TODO: Implémenter les suggestions de recettes basées sur le frigo
-		// fridge.GET("/suggestions", handler.GetRecipeSuggestions) // GET /api/v1/fridge/suggestions
+		fridge.GET("/suggestions", handler.GetRecipeSuggestions) // GET /api/v1/fridge/suggestions?match_type=any&max_missing_ingredients=2
+
+		// Restes de repas réalisés
+		fridge.GET("/leftovers", handler.GetLeftovers)                   // GET /api/v1/fridge/leftovers?include_finished=true
+		fridge.GET("/leftovers/:id", handler.GetLeftover)                // GET /api/v1/fridge/leftovers/1
+		fridge.DELETE("/leftovers/:id", handler.DeleteLeftover)          // DELETE /api/v1/fridge/leftovers/1
+		fridge.POST("/leftovers/:id/schedule", handler.ScheduleLeftover) // POST /api/v1/fridge/leftovers/1/schedule
 	}
 }
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/handlers"
+	"github.com/romainrodriguez/cooking_server/internal/services/auth"
+)
+
+// SetupIngredientCategoryRoutes configure les routes pour les catégories d'ingrédients
+func SetupIngredientCategoryRoutes(router *gin.RouterGroup, handler *handlers.IngredientCategoryHandler, jwtService *auth.JWTService) {
+	categories := router.Group("/ingredient-categories")
+	{
+		// Routes publiques (lecture seule)
+		categories.GET("", handler.ListIngredientCategories)  // GET /api/ingredient-categories
+		categories.GET("/:id", handler.GetIngredientCategory) // GET /api/ingredient-categories/1
+
+		// Routes protégées pour la modification (admin seulement dans le futur)
+		// Pour l'instant, on les laisse ouvertes
+		categories.POST("", handler.CreateIngredientCategory)       // POST /api/ingredient-categories
+		categories.PUT("/:id", handler.UpdateIngredientCategory)    // PUT /api/ingredient-categories/1
+		categories.DELETE("/:id", handler.DeleteIngredientCategory) // DELETE /api/ingredient-categories/1
+	}
+}
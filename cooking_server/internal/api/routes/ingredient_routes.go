@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/romainrodriguez/cooking_server/internal/api/handlers"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
 	"github.com/romainrodriguez/cooking_server/internal/services/auth"
 )
 
@@ -15,10 +16,33 @@ func SetupIngredientRoutes(router *gin.RouterGroup, handler *handlers.Ingredient
 		ingredients.GET("/:id", handler.GetIngredient)        // GET /api/ingredients/1
 		ingredients.GET("/search", handler.SearchIngredients) // GET /api/ingredients/search?q=tomato&limit=10
 
-		// Routes protégées pour la modification (admin seulement dans le futur)
-		// Pour l'instant, on les laisse ouvertes
-		ingredients.POST("", handler.CreateIngredient)       // POST /api/ingredients
-		ingredients.PUT("/:id", handler.UpdateIngredient)    // PUT /api/ingredients/1
-		ingredients.DELETE("/:id", handler.DeleteIngredient) // DELETE /api/ingredients/1
+		// Synonymes, remplacements et taxonomie (ingrédients plus généraux et variantes)
+		ingredients.GET("/:id/aliases", handler.ListIngredientAliases)             // GET /api/ingredients/1/aliases
+		ingredients.GET("/:id/substitutions", handler.ListIngredientSubstitutions) // GET /api/ingredients/1/substitutions
+		ingredients.GET("/:id/hierarchy", handler.GetIngredientHierarchy)          // GET /api/ingredients/1/hierarchy
+
+		// Détection des doublons
+		ingredients.GET("/duplicates", handler.ListDuplicateIngredients) // GET /api/ingredients/duplicates?threshold=0.5
+
+		// Routes protégées : tout utilisateur connecté peut proposer un ingrédient
+		protected := ingredients.Group("", middleware.AuthMiddleware(jwtService))
+		{
+			protected.POST("", handler.CreateIngredient) // POST /api/ingredients
+
+			// Le référentiel partagé (parent, synonymes, remplacements, fusions) est réservé aux administrateurs
+			admin := protected.Group("", handler.RequireAdmin)
+			{
+				admin.PUT("/:id", handler.UpdateIngredient)    // PUT /api/ingredients/1
+				admin.DELETE("/:id", handler.DeleteIngredient) // DELETE /api/ingredients/1
+
+				admin.POST("/:id/aliases", handler.AddIngredientAlias)                // POST /api/ingredients/1/aliases
+				admin.DELETE("/:id/aliases/:alias_id", handler.DeleteIngredientAlias) // DELETE /api/ingredients/1/aliases/2
+
+				admin.POST("/:id/substitutions", handler.AddIngredientSubstitution)                       // POST /api/ingredients/1/substitutions
+				admin.DELETE("/:id/substitutions/:substitution_id", handler.DeleteIngredientSubstitution) // DELETE /api/ingredients/1/substitutions/2
+
+				admin.POST("/merge", handler.MergeIngredients) // POST /api/ingredients/merge
+			}
+		}
 	}
 }
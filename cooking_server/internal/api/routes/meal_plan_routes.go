@@ -11,7 +11,10 @@ import (
 func SetupMealPlanRoutes(router *gin.RouterGroup, handler *handlers.MealPlanHandler, jwtService *auth.JWTService) {
 	mealPlans := router.Group("/meal-plans")
 	{
-		// Toutes les routes de planning nécessitent une authentification
+		// Flux iCalendar public, protégé par le jeton personnel de son URL
+		mealPlans.GET("/feed/:token", handler.GetMealPlanCalendarFeed) // GET /api/meal-plans/feed/abc123.ics
+
+		// Toutes les autres routes de planning nécessitent une authentification
 		mealPlans.Use(middleware.AuthMiddleware(jwtService))
 		{
 			// Routes CRUD de base
@@ -29,6 +32,45 @@ func SetupMealPlanRoutes(router *gin.RouterGroup, handler *handlers.MealPlanHand
 
 			// Action de completion
 			mealPlans.PATCH("/:id/complete", handler.MarkMealAsCompleted) // PATCH /api/meal-plans/1/complete
+			mealPlans.PATCH("/:id/uncomplete", handler.UncompleteMeal)    // PATCH /api/meal-plans/1/uncomplete
+
+			// Restes du repas réalisé, mis de côté au frigo
+			mealPlans.POST("/:id/leftovers", handler.RecordMealPlanLeftovers) // POST /api/meal-plans/1/leftovers
+
+			// Coût estimé avec les prix relevés
+			mealPlans.GET("/:id/cost", handler.GetMealPlanCost) // GET /api/meal-plans/1/cost?store_id=2
+
+			// Opérations groupées sur le calendrier
+			mealPlans.POST("/copy", handler.CopyMealPlans)   // POST /api/meal-plans/copy
+			mealPlans.POST("/shift", handler.ShiftMealPlans) // POST /api/meal-plans/shift
+			mealPlans.POST("/swap", handler.SwapMealPlans)   // POST /api/meal-plans/swap
+			mealPlans.POST("/clear", handler.ClearMealPlans) // POST /api/meal-plans/clear
+
+			// Génération automatique sous contraintes
+			mealPlans.POST("/generate", handler.GenerateMealPlans)               // POST /api/meal-plans/generate
+			mealPlans.POST("/generate/reroll", handler.RerollGeneratedMealPlan)  // POST /api/meal-plans/generate/reroll
+			mealPlans.POST("/generate/accept", handler.AcceptGeneratedMealPlans) // POST /api/meal-plans/generate/accept
+
+			// Flux iCalendar et import
+			mealPlans.GET("/calendar", handler.GetMealPlanCalendar)                    // GET /api/meal-plans/calendar
+			mealPlans.PUT("/calendar", handler.UpdateMealPlanCalendar)                 // PUT /api/meal-plans/calendar
+			mealPlans.POST("/calendar/token", handler.RegenerateMealPlanCalendarToken) // POST /api/meal-plans/calendar/token
+			mealPlans.POST("/import", handler.ImportMealPlanCalendar)                  // POST /api/meal-plans/import
+
+			// Repas récurrents
+			mealPlans.GET("/recurrences", handler.ListMealPlanRecurrences)         // GET /api/meal-plans/recurrences
+			mealPlans.POST("/recurrences", handler.CreateMealPlanRecurrence)       // POST /api/meal-plans/recurrences
+			mealPlans.GET("/recurrences/:id", handler.GetMealPlanRecurrence)       // GET /api/meal-plans/recurrences/1
+			mealPlans.PUT("/recurrences/:id", handler.UpdateMealPlanRecurrence)    // PUT /api/meal-plans/recurrences/1
+			mealPlans.DELETE("/recurrences/:id", handler.DeleteMealPlanRecurrence) // DELETE /api/meal-plans/recurrences/1
+
+			// Semaines types
+			mealPlans.GET("/templates", handler.ListMealPlanTemplates)            // GET /api/meal-plans/templates
+			mealPlans.POST("/templates", handler.CreateMealPlanTemplate)          // POST /api/meal-plans/templates
+			mealPlans.GET("/templates/:id", handler.GetMealPlanTemplate)          // GET /api/meal-plans/templates/1
+			mealPlans.PUT("/templates/:id", handler.UpdateMealPlanTemplate)       // PUT /api/meal-plans/templates/1
+			mealPlans.DELETE("/templates/:id", handler.DeleteMealPlanTemplate)    // DELETE /api/meal-plans/templates/1
+			mealPlans.POST("/templates/:id/apply", handler.ApplyMealPlanTemplate) // POST /api/meal-plans/templates/1/apply
 		}
 	}
 }
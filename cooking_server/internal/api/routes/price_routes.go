@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/handlers"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/services/auth"
+)
+
+// SetupPriceRoutes configure les routes pour les prix relevés des ingrédients
+func SetupPriceRoutes(router *gin.RouterGroup, handler *handlers.PriceHandler, jwtService *auth.JWTService) {
+	prices := router.Group("/prices")
+	{
+		// Toutes les routes de prix nécessitent une authentification
+		prices.Use(middleware.AuthMiddleware(jwtService))
+		{
+			prices.GET("", handler.ListPrices)         // GET /api/prices?ingredient_id=3
+			prices.POST("", handler.CreatePrice)       // POST /api/prices
+			prices.GET("/:id", handler.GetPrice)       // GET /api/prices/1
+			prices.DELETE("/:id", handler.DeletePrice) // DELETE /api/prices/1
+		}
+	}
+}
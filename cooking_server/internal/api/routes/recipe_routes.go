@@ -17,6 +17,20 @@ func SetupRecipeRoutes(router *gin.RouterGroup, handler *handlers.RecipeHandler,
 		recipes.GET("/search", handler.SearchRecipes)         // GET /api/recipes/search?q=pasta&category=italian
 		recipes.GET("/user/:user_id", handler.GetUserRecipes) // GET /api/recipes/user/1
 
+		// Historique des révisions
+		recipes.GET("/:id/revisions", handler.ListRecipeRevisions)       // GET /api/recipes/1/revisions
+		recipes.GET("/:id/revisions/diff", handler.DiffRecipeRevisions)  // GET /api/recipes/1/revisions/diff?from=1&to=3
+		recipes.GET("/:id/revisions/:number", handler.GetRecipeRevision) // GET /api/recipes/1/revisions/2
+
+		// Mise à l'échelle
+		recipes.GET("/:id/scaled", handler.GetScaledRecipe) // GET /api/recipes/1/scaled?servings=6&units=metric
+
+		// Valeurs nutritionnelles
+		recipes.GET("/:id/nutrition", handler.GetRecipeNutrition) // GET /api/recipes/1/nutrition
+
+		// Filiation des copies (authentification facultative pour voir ses copies privées)
+		recipes.GET("/:id/forks", middleware.OptionalAuthMiddleware(jwtService), handler.GetRecipeForks) // GET /api/recipes/1/forks
+
 		// Routes protégées (authentification requise pour modification)
 		protected := recipes.Group("", middleware.AuthMiddleware(jwtService))
 		{
@@ -24,6 +38,17 @@ func SetupRecipeRoutes(router *gin.RouterGroup, handler *handlers.RecipeHandler,
 			protected.PUT("/:id", handler.UpdateRecipe)     // PUT /api/recipes/1
 			protected.DELETE("/:id", handler.DeleteRecipe)  // DELETE /api/recipes/1
 			protected.POST("/:id/copy", handler.CopyRecipe) // POST /api/recipes/1/copy
+
+			protected.POST("/:id/revisions/:number/restore", handler.RestoreRecipeRevision) // POST /api/recipes/1/revisions/2/restore
+			protected.GET("/:id/upstream-changes", handler.GetUpstreamChanges)              // GET /api/recipes/5/upstream-changes
+			protected.POST("/:id/upstream-changes/pull", handler.PullUpstreamChanges)       // POST /api/recipes/5/upstream-changes/pull
+
+			// Remplacements d'ingrédients selon le frigo, les allergènes et les régimes
+			protected.GET("/:id/substitutions", handler.GetRecipeSubstitutions) // GET /api/recipes/1/substitutions?avoid_allergens=eggs
+			protected.POST("/:id/substitutions/adapt", handler.AdaptRecipe)     // POST /api/recipes/1/substitutions/adapt
+
+			// Coût estimé avec les prix relevés par l'utilisateur
+			protected.GET("/:id/cost", handler.GetRecipeCost) // GET /api/recipes/1/cost?servings=4&store_id=2
 		}
 	}
 }
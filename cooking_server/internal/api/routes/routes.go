@@ -32,6 +32,10 @@ func SetupRoutes(router *gin.Engine, ormService *orm.ORMService, jwtService *aut
 	feedHandler := handlers.NewFeedHandler(ormService)
 	uploadHandler := handlers.NewUploadHandler(ormService)
 	fridgeHandler := handlers.NewFridgeHandler(ormService)
+	ingredientCategoryHandler := handlers.NewIngredientCategoryHandler(ormService)
+	shoppingListHandler := handlers.NewShoppingListHandler(ormService)
+	storeHandler := handlers.NewStoreHandler(ormService)
+	priceHandler := handlers.NewPriceHandler(ormService)
 
 	// Configuration des routes pour chaque entité
 	SetupUserRoutes(api, userHandler, jwtService)
@@ -47,6 +51,10 @@ func SetupRoutes(router *gin.Engine, ormService *orm.ORMService, jwtService *aut
 	SetupFeedRoutes(api, feedHandler, jwtService)
 	SetupUploadRoutes(api, uploadHandler, jwtService)
 	SetupFridgeRoutes(api, fridgeHandler, jwtService)
+	SetupIngredientCategoryRoutes(api, ingredientCategoryHandler, jwtService)
+	SetupShoppingListRoutes(api, shoppingListHandler, jwtService)
+	SetupStoreRoutes(api, storeHandler, jwtService)
+	SetupPriceRoutes(api, priceHandler, jwtService)
 
 	// Nouvelles routes d'extraction de recette
 	SetupRecipeExtractionRoutes(api, h, jwtService)
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/handlers"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/services/auth"
+)
+
+// SetupShoppingListRoutes configure les routes pour les listes de courses enregistrées
+func SetupShoppingListRoutes(router *gin.RouterGroup, handler *handlers.ShoppingListHandler, jwtService *auth.JWTService) {
+	shoppingLists := router.Group("/shopping-lists")
+	{
+		// Toutes les routes de listes de courses nécessitent une authentification
+		shoppingLists.Use(middleware.AuthMiddleware(jwtService))
+		{
+			// Routes CRUD de base
+			shoppingLists.GET("", handler.ListShoppingLists)         // GET /api/shopping-lists
+			shoppingLists.POST("", handler.CreateShoppingList)       // POST /api/shopping-lists
+			shoppingLists.GET("/:id", handler.GetShoppingList)       // GET /api/shopping-lists/1
+			shoppingLists.PUT("/:id", handler.UpdateShoppingList)    // PUT /api/shopping-lists/1
+			shoppingLists.DELETE("/:id", handler.DeleteShoppingList) // DELETE /api/shopping-lists/1
+
+			// Génération à partir des repas planifiés et passage en caisse
+			shoppingLists.POST("/:id/regenerate", handler.RegenerateShoppingList) // POST /api/shopping-lists/1/regenerate
+			shoppingLists.POST("/:id/checkout", handler.CheckoutShoppingList)     // POST /api/shopping-lists/1/checkout
+
+			// Articles de la liste
+			shoppingLists.POST("/:id/items", handler.AddShoppingListItem)               // POST /api/shopping-lists/1/items
+			shoppingLists.PATCH("/:id/items/:item_id", handler.UpdateShoppingListItem)  // PATCH /api/shopping-lists/1/items/2
+			shoppingLists.DELETE("/:id/items/:item_id", handler.DeleteShoppingListItem) // DELETE /api/shopping-lists/1/items/2
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/romainrodriguez/cooking_server/internal/api/handlers"
+	"github.com/romainrodriguez/cooking_server/internal/api/middleware"
+	"github.com/romainrodriguez/cooking_server/internal/services/auth"
+)
+
+// SetupStoreRoutes configure les routes pour les magasins et leurs rayons
+func SetupStoreRoutes(router *gin.RouterGroup, handler *handlers.StoreHandler, jwtService *auth.JWTService) {
+	stores := router.Group("/stores")
+	{
+		// Toutes les routes de magasins nécessitent une authentification
+		stores.Use(middleware.AuthMiddleware(jwtService))
+		{
+			stores.GET("", handler.ListStores)         // GET /api/stores
+			stores.POST("", handler.CreateStore)       // POST /api/stores
+			stores.GET("/:id", handler.GetStore)       // GET /api/stores/1
+			stores.PUT("/:id", handler.UpdateStore)    // PUT /api/stores/1
+			stores.DELETE("/:id", handler.DeleteStore) // DELETE /api/stores/1
+
+			// Placements appris des ingrédients dans les rayons
+			stores.PUT("/:id/placements", handler.RecordStorePlacement) // PUT /api/stores/1/placements
+		}
+	}
+}
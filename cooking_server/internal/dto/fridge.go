@@ -61,12 +61,13 @@ type RecipeSearchByIngredientsRequest struct {
 
 // RecipeSuggestion représente une suggestion de recette basée sur les ingrédients du frigo
 type RecipeSuggestion struct {
-	Recipe              RecipeSummary `json:"recipe"`
-	MatchingIngredients int           `json:"matching_ingredients"`
-	TotalIngredients    int           `json:"total_ingredients"`
-	MissingIngredients  []Ingredient  `json:"missing_ingredients"`
-	MatchPercentage     float64       `json:"match_percentage"`
-	CanCook             bool          `json:"can_cook"`
+	Recipe                  RecipeSummary `json:"recipe"`
+	MatchingIngredients     int           `json:"matching_ingredients"`
+	TotalIngredients        int           `json:"total_ingredients"`
+	MissingIngredients      []Ingredient  `json:"missing_ingredients"`
+	InsufficientIngredients []Ingredient  `json:"insufficient_ingredients"` // Présents dans le frigo mais en quantité insuffisante
+	MatchPercentage         float64       `json:"match_percentage"`
+	CanCook                 bool          `json:"can_cook"` // Tous les ingrédients obligatoires sont disponibles en quantité suffisante
 }
 
 // RecipeSummary représente un résumé de recette pour les suggestions
@@ -92,3 +93,29 @@ type RecipeSuggestionsResponse struct {
 type FridgeItemRemovedResponse struct {
 	RemovedCount int `json:"removed_count"`
 }
+
+// FridgeConsumption représente le stock du frigo consommé lors de la réalisation d'un repas planifié.
+// Le jeton d'annulation permet de restaurer le stock si le repas est remis comme non réalisé.
+type FridgeConsumption struct {
+	ID         uint                    `json:"id" gorm:"primaryKey"`
+	UserID     uint                    `json:"user_id" gorm:"not null;index"`
+	MealPlanID uint                    `json:"meal_plan_id" gorm:"not null;index"`
+	UndoToken  string                  `json:"undo_token" gorm:"type:varchar(64);not null;uniqueIndex"`
+	RevertedAt *time.Time              `json:"reverted_at,omitempty"` // Date d'annulation de la consommation
+	CreatedAt  time.Time               `json:"created_at" gorm:"autoCreateTime"`
+	Items      []FridgeConsumptionItem `json:"items" gorm:"foreignKey:ConsumptionID;constraint:OnDelete:CASCADE"`
+}
+
+// FridgeConsumptionItem conserve l'état d'un item du frigo avant consommation pour pouvoir le restaurer
+type FridgeConsumptionItem struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	ConsumptionID    uint       `json:"consumption_id" gorm:"not null;index"`
+	FridgeItemID     uint       `json:"fridge_item_id" gorm:"not null"`
+	IngredientID     uint       `json:"ingredient_id" gorm:"not null"`
+	IngredientName   string     `json:"ingredient_name"`
+	ConsumedQuantity float64    `json:"consumed_quantity"` // Quantité retirée, dans l'unité de l'item
+	Unit             *string    `json:"unit,omitempty"`
+	ExpiryDate       *time.Time `json:"expiry_date,omitempty"`
+	Notes            *string    `json:"notes,omitempty"`
+	Removed          bool       `json:"removed"` // L'item a été supprimé car entièrement consommé
+}
@@ -6,11 +6,56 @@ type Ingredient struct {
 	ID          uint   `json:"id" gorm:"primaryKey"`
 	Name        string `json:"name" gorm:"uniqueIndex;not null"`
 	Description string `json:"description,omitempty"` // Description facultative
-	Category    string `json:"category"`              // legume, viande, epice, etc.
+	Category    string `json:"category"`              // legume, viande, epice, etc. (nom de la catégorie)
 	Icon        string `json:"icon"`                  // Icône pour l'affichage
 
+	// Classification
+	CategoryID *uint `json:"category_id,omitempty" gorm:"index"` // Catégorie d'ingrédients
+	ParentID   *uint `json:"parent_id,omitempty" gorm:"index"`   // Ingrédient plus général (ex: tomate pour tomate cerise)
+
+	// Indications de conversion d'unités (facultatives, des valeurs usuelles sont utilisées à défaut)
+	Density     *float64 `json:"density,omitempty"`      // Masse volumique en g/ml (volume ↔ masse)
+	PieceWeight *float64 `json:"piece_weight,omitempty"` // Poids moyen d'une pièce en grammes (pièce ↔ masse)
+
+	// Valeurs nutritionnelles pour 100 g (facultatives)
+	EnergyKcal    *float64 `json:"energy_kcal,omitempty"`   // Énergie en kcal
+	Protein       *float64 `json:"protein,omitempty"`       // Protéines en g
+	Fat           *float64 `json:"fat,omitempty"`           // Lipides en g
+	Carbohydrates *float64 `json:"carbohydrates,omitempty"` // Glucides en g
+	Fiber         *float64 `json:"fiber,omitempty"`         // Fibres en g
+	Salt          *float64 `json:"salt,omitempty"`          // Sel en g
+
+	// Étiquettes alimentaires
+	Allergens StringList `json:"allergens" gorm:"type:jsonb;default:'[]'"` // Allergènes UE contenus (gluten, milk, ...)
+	Diets     StringList `json:"diets" gorm:"type:jsonb;default:'[]'"`     // Régimes compatibles (vegan, vegetarian, ...)
+
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	DisplayName string            `json:"display_name,omitempty" gorm:"-"`                  // Nom dans la langue demandée (non persisté)
+	Aliases     []IngredientAlias `json:"aliases,omitempty" gorm:"foreignKey:IngredientID"` // Synonymes et noms traduits
+
+	IngredientCategory *IngredientCategory `json:"ingredient_category,omitempty" gorm:"foreignKey:CategoryID"`
+	Parent             *Ingredient         `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
+}
+
+// IngredientAlias représente un synonyme ou un nom traduit d'un ingrédient
+type IngredientAlias struct {
+	ID             uint   `json:"id" gorm:"primaryKey"`
+	IngredientID   uint   `json:"ingredient_id" gorm:"not null;index"`
+	Locale         string `json:"locale" gorm:"type:varchar(10);not null;uniqueIndex:idx_ingredient_alias_locale_name"` // Code de langue (fr, en, ...)
+	Name           string `json:"name" gorm:"not null"`
+	NormalizedName string `json:"-" gorm:"not null;uniqueIndex:idx_ingredient_alias_locale_name"` // Minuscules, sans accents, au singulier
+	IsPreferred    bool   `json:"is_preferred" gorm:"default:false"`                              // Nom affiché pour cette langue
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// IngredientAliasCreateRequest représente les données pour ajouter un alias à un ingrédient
+type IngredientAliasCreateRequest struct {
+	Locale      string `json:"locale" binding:"required,min=2,max=10"`
+	Name        string `json:"name" binding:"required,min=2,max=100"`
+	IsPreferred bool   `json:"is_preferred"`
 }
 
 type RecipeIngredient struct {
@@ -24,6 +69,8 @@ type RecipeIngredient struct {
 	Group        string  `json:"group" gorm:"default:''"`          // Groupe/section (ex. "Pour la pâte"). Vide = liste unique.
 	Position     int     `json:"position" gorm:"default:0"`        // Ordre d'affichage dans la recette (0 = ordre historique par ID)
 
+	DisplayQuantity string `json:"display_quantity,omitempty" gorm:"-"` // Quantité formatée pour l'affichage (non persisté, recettes mises à l'échelle)
+
 	Recipe     Recipe     `json:"recipe" gorm:"foreignKey:RecipeID"`
 	Ingredient Ingredient `json:"ingredient" gorm:"foreignKey:IngredientID"`
 }
@@ -39,18 +86,54 @@ type RecipeIngredientRequest struct {
 
 // IngredientCreateRequest représente les données pour créer un ingrédient
 type IngredientCreateRequest struct {
-	Name        string `json:"name" binding:"required,min=2,max=100"`
-	Description string `json:"description,omitempty" binding:"max=500"`
-	Category    string `json:"category,omitempty" binding:"max=50"`
-	Icon        string `json:"icon,omitempty" binding:"max=10"`
+	Name        string   `json:"name" binding:"required,min=2,max=100"`
+	Description string   `json:"description,omitempty" binding:"max=500"`
+	Category    string   `json:"category,omitempty" binding:"max=50"`
+	Icon        string   `json:"icon,omitempty" binding:"max=10"`
+	Density     *float64 `json:"density,omitempty" binding:"omitempty,gt=0"`
+	PieceWeight *float64 `json:"piece_weight,omitempty" binding:"omitempty,gt=0"`
+
+	// Valeurs nutritionnelles pour 100 g
+	EnergyKcal    *float64 `json:"energy_kcal,omitempty" binding:"omitempty,gte=0"`
+	Protein       *float64 `json:"protein,omitempty" binding:"omitempty,gte=0,lte=100"`
+	Fat           *float64 `json:"fat,omitempty" binding:"omitempty,gte=0,lte=100"`
+	Carbohydrates *float64 `json:"carbohydrates,omitempty" binding:"omitempty,gte=0,lte=100"`
+	Fiber         *float64 `json:"fiber,omitempty" binding:"omitempty,gte=0,lte=100"`
+	Salt          *float64 `json:"salt,omitempty" binding:"omitempty,gte=0,lte=100"`
+
+	// Étiquettes alimentaires (une liste vide efface les valeurs)
+	Allergens []string `json:"allergens,omitempty" binding:"omitempty,dive,oneof=gluten crustaceans eggs fish peanuts soybeans milk nuts celery mustard sesame sulphites lupin molluscs"`
+	Diets     []string `json:"diets,omitempty" binding:"omitempty,dive,oneof=vegan vegetarian pescatarian pork_free alcohol_free"`
+
+	// Classification (category_id prime sur category, une catégorie inconnue par son nom est créée)
+	CategoryID *uint `json:"category_id,omitempty"`
+	ParentID   *uint `json:"parent_id,omitempty"`
 }
 
 // IngredientUpdateRequest représente les données pour mettre à jour un ingrédient
 type IngredientUpdateRequest struct {
-	Name        string `json:"name,omitempty" binding:"omitempty,min=2,max=100"`
-	Description string `json:"description,omitempty" binding:"omitempty,max=500"`
-	Category    string `json:"category,omitempty" binding:"omitempty,max=50"`
-	Icon        string `json:"icon,omitempty" binding:"omitempty,max=10"`
+	Name        string   `json:"name,omitempty" binding:"omitempty,min=2,max=100"`
+	Description string   `json:"description,omitempty" binding:"omitempty,max=500"`
+	Category    string   `json:"category,omitempty" binding:"omitempty,max=50"`
+	Icon        string   `json:"icon,omitempty" binding:"omitempty,max=10"`
+	Density     *float64 `json:"density,omitempty" binding:"omitempty,gt=0"`
+	PieceWeight *float64 `json:"piece_weight,omitempty" binding:"omitempty,gt=0"`
+
+	// Valeurs nutritionnelles pour 100 g
+	EnergyKcal    *float64 `json:"energy_kcal,omitempty" binding:"omitempty,gte=0"`
+	Protein       *float64 `json:"protein,omitempty" binding:"omitempty,gte=0,lte=100"`
+	Fat           *float64 `json:"fat,omitempty" binding:"omitempty,gte=0,lte=100"`
+	Carbohydrates *float64 `json:"carbohydrates,omitempty" binding:"omitempty,gte=0,lte=100"`
+	Fiber         *float64 `json:"fiber,omitempty" binding:"omitempty,gte=0,lte=100"`
+	Salt          *float64 `json:"salt,omitempty" binding:"omitempty,gte=0,lte=100"`
+
+	// Étiquettes alimentaires (une liste vide efface les valeurs)
+	Allergens []string `json:"allergens,omitempty" binding:"omitempty,dive,oneof=gluten crustaceans eggs fish peanuts soybeans milk nuts celery mustard sesame sulphites lupin molluscs"`
+	Diets     []string `json:"diets,omitempty" binding:"omitempty,dive,oneof=vegan vegetarian pescatarian pork_free alcohol_free"`
+
+	// Classification (category_id prime sur category, 0 retire la catégorie ou le parent)
+	CategoryID *uint `json:"category_id,omitempty"`
+	ParentID   *uint `json:"parent_id,omitempty"`
 }
 
 // IngredientResponse représente la réponse pour un ingrédient
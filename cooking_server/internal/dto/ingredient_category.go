@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+// IngredientCategory représente une catégorie d'ingrédients (légumes, viandes, épices, ...)
+type IngredientCategory struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"uniqueIndex;not null"`
+	Description string `json:"description,omitempty"`
+	Icon        string `json:"icon"`
+	Position    int    `json:"position" gorm:"default:0"` // Ordre d'affichage (rayons, listes de courses)
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// IngredientCategoryCreateRequest représente les données pour créer une catégorie d'ingrédients
+type IngredientCategoryCreateRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=50"`
+	Description string `json:"description,omitempty" binding:"max=500"`
+	Icon        string `json:"icon,omitempty" binding:"max=10"`
+	Position    int    `json:"position,omitempty" binding:"min=0"`
+}
+
+// IngredientCategoryUpdateRequest représente les données pour mettre à jour une catégorie d'ingrédients
+type IngredientCategoryUpdateRequest struct {
+	Name        string `json:"name,omitempty" binding:"omitempty,min=2,max=50"`
+	Description string `json:"description,omitempty" binding:"omitempty,max=500"`
+	Icon        string `json:"icon,omitempty" binding:"omitempty,max=10"`
+	Position    *int   `json:"position,omitempty" binding:"omitempty,min=0"`
+}
+
+// IngredientCategoryResponse représente la réponse pour une catégorie d'ingrédients
+type IngredientCategoryResponse struct {
+	Success bool               `json:"success"`
+	Message string             `json:"message,omitempty"`
+	Data    IngredientCategory `json:"data"`
+}
+
+// IngredientHierarchy représente la place d'un ingrédient dans la taxonomie :
+// ses ingrédients plus généraux (du parent direct à la racine) et ses variantes plus spécifiques
+type IngredientHierarchy struct {
+	Ingredient  Ingredient   `json:"ingredient"`
+	Ancestors   []Ingredient `json:"ancestors"`
+	Descendants []Ingredient `json:"descendants"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+// IngredientMergeRequest représente la fusion d'ingrédients en double dans un ingrédient cible
+type IngredientMergeRequest struct {
+	TargetID     uint   `json:"target_id" binding:"required"`
+	DuplicateIDs []uint `json:"duplicate_ids" binding:"required,min=1,dive,required"`
+	Locale       string `json:"locale,omitempty" binding:"omitempty,min=2,max=10"` // Langue des alias créés à partir des noms fusionnés (défaut: fr)
+}
+
+// IngredientMergeResult résume les références déplacées lors d'une fusion
+type IngredientMergeResult struct {
+	Target              Ingredient `json:"target"`
+	MergedIDs           []uint     `json:"merged_ids"`
+	MergedNames         []string   `json:"merged_names"`
+	RecipeIngredients   int64      `json:"recipe_ingredients"`    // Lignes de recettes rattachées à la cible
+	FridgeItems         int64      `json:"fridge_items"`          // Items du frigo rattachés à la cible
+	FridgeConsumptions  int64      `json:"fridge_consumptions"`   // Historique de consommation rattaché à la cible
+	RecipeRevisions     int64      `json:"recipe_revisions"`      // Révisions de recettes réécrites
+	ShoppingListEntries int64      `json:"shopping_list_entries"` // Lignes de listes de courses rattachées à la cible
+	StorePlacements     int64      `json:"store_placements"`      // Emplacements en magasin rattachés à la cible (un seul conservé par magasin)
+	Prices              int64      `json:"prices"`                // Relevés de prix rattachés à la cible (ceux de la cible priment par magasin)
+	Substitutions       int64      `json:"substitutions"`         // Remplacements et composants de remplacement rattachés à la cible
+	AliasesMoved        int64      `json:"aliases_moved"`         // Alias existants des doublons rattachés à la cible
+	AliasesAdded        int        `json:"aliases_added"`         // Noms des doublons enregistrés comme alias
+	AffectedRecipes     int        `json:"affected_recipes"`      // Recettes dont les étiquettes ont été recalculées
+}
+
+// IngredientDuplicateCandidate représente une paire d'ingrédients susceptibles d'être des doublons
+type IngredientDuplicateCandidate struct {
+	First           IngredientUsage `json:"first"`
+	Second          IngredientUsage `json:"second"`
+	Similarity      float64         `json:"similarity"`       // Similarité trigramme des noms (0-1)
+	Reason          string          `json:"reason"`           // same_normalized_name ou similar_name
+	SuggestedTarget uint            `json:"suggested_target"` // Ingrédient le plus utilisé, à conserver
+}
+
+// IngredientUsage représente un ingrédient et son nombre d'utilisations dans les recettes
+type IngredientUsage struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	RecipeCount int64  `json:"recipe_count"`
+}
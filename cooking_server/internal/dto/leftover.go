@@ -0,0 +1,43 @@
+package dto
+
+import "time"
+
+// DefaultLeftoverShelfLifeDays est la durée de conservation par défaut des restes au frigo
+const DefaultLeftoverShelfLifeDays = 3
+
+// Leftover représente des portions restantes d'un repas réalisé, conservées au frigo.
+// Les repas planifiés avec ces restes (MealPlan.LeftoverID) n'ajoutent rien à la liste de courses.
+type Leftover struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	RecipeID   uint      `json:"recipe_id" gorm:"not null"`                                        // Recette d'origine
+	MealPlanID *uint     `json:"meal_plan_id,omitempty" gorm:"uniqueIndex:idx_leftover_meal_plan"` // Repas réalisé d'où viennent les restes (une seule fois)
+	Portions   int       `json:"portions" gorm:"not null"`                                         // Portions mises de côté
+	ExpiryDate time.Time `json:"expiry_date" gorm:"type:date;not null"`
+	Notes      string    `json:"notes,omitempty"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Portions calculées à partir des repas planifiés avec ces restes
+	EatenPortions     int `json:"eaten_portions" gorm:"-"`     // Repas réalisés
+	ScheduledPortions int `json:"scheduled_portions" gorm:"-"` // Repas à venir
+	AvailablePortions int `json:"available_portions" gorm:"-"` // Encore libres
+
+	// Relations
+	Recipe Recipe `json:"recipe" gorm:"foreignKey:RecipeID"`
+}
+
+// LeftoverCreateRequest représente l'enregistrement des restes d'un repas réalisé
+type LeftoverCreateRequest struct {
+	Portions      int    `json:"portions" binding:"required,min=1,max=100"`
+	ShelfLifeDays int    `json:"shelf_life_days,omitempty" binding:"omitempty,min=1,max=365"` // Défaut: 3 jours après le repas
+	Notes         string `json:"notes,omitempty" binding:"max=500"`
+}
+
+// LeftoverScheduleRequest représente la planification d'un repas avec des restes
+type LeftoverScheduleRequest struct {
+	PlannedDate string `json:"planned_date" binding:"required,datetime=2006-01-02"`
+	MealType    string `json:"meal_type" binding:"required,oneof=breakfast lunch dinner snack"`
+	Servings    int    `json:"servings,omitempty" binding:"omitempty,min=1"` // Défaut: toutes les portions disponibles
+	Notes       string `json:"notes,omitempty" binding:"max=500"`
+}
@@ -14,6 +14,14 @@ type MealPlan struct {
 	IsCompleted bool       `json:"is_completed" gorm:"default:false"`                                                                            // Indique si le repas a été préparé
 	CompletedAt *time.Time `json:"completed_at,omitempty"`                                                                                       // Date de réalisation du repas
 
+	// Occurrence d'une règle de récurrence, créée à la consultation du planning
+	RecurrenceID   *uint      `json:"recurrence_id,omitempty" gorm:"uniqueIndex:idx_meal_plan_occurrence"`
+	OccurrenceDate *time.Time `json:"occurrence_date,omitempty" gorm:"type:date;uniqueIndex:idx_meal_plan_occurrence"` // Date prévue par la règle
+	Detached       bool       `json:"detached,omitempty" gorm:"not null;default:false"`                                // Modifiée individuellement : ne suit plus la règle
+
+	// Repas préparé avec des restes : ses ingrédients ne sont ni achetés ni pris dans le frigo
+	LeftoverID *uint `json:"leftover_id,omitempty" gorm:"index"`
+
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 
@@ -38,7 +46,13 @@ type MealPlanUpdateRequest struct {
 	MealType    string    `json:"meal_type,omitempty" binding:"omitempty,oneof=breakfast lunch dinner snack"`
 	Servings    int       `json:"servings,omitempty" binding:"omitempty,min=1"`
 	Notes       string    `json:"notes,omitempty" binding:"omitempty,max=500"`
-	IsCompleted bool      `json:"is_completed,omitempty"`
+	IsCompleted *bool     `json:"is_completed,omitempty"` // Absent : le statut de réalisation est inchangé
+	UndoToken   string    `json:"undo_token,omitempty"`   // Jeton reçu à la complétion, pour restaurer le frigo en cas d'annulation
+}
+
+// MealPlanUncompleteRequest représente la requête pour annuler la réalisation d'un repas
+type MealPlanUncompleteRequest struct {
+	UndoToken string `json:"undo_token,omitempty"` // Jeton reçu à la complétion (sans jeton, le frigo n'est pas restauré)
 }
 
 // MealPlanResponse représente la réponse pour un planning de repas
@@ -116,25 +130,60 @@ type UpcomingMealsResponse struct {
 
 // ShoppingListItem représente un ingrédient dans la liste de courses
 type ShoppingListItem struct {
+	IngredientID   uint                    `json:"ingredient_id"`
+	IngredientName string                  `json:"ingredient_name"`
+	Category       string                  `json:"category,omitempty"`     // Catégorie de l'ingrédient
+	TotalQuantity  float64                 `json:"total_quantity"`         // Quantité brute nécessaire pour les repas planifiés
+	NetQuantity    *float64                `json:"net_quantity,omitempty"` // Quantité restant à acheter une fois le stock du frigo déduit
+	Unit           string                  `json:"unit"`
+	Unconvertible  bool                    `json:"unconvertible,omitempty"` // Ingrédient réparti sur plusieurs lignes aux unités non convertibles entre elles
+	Recipes        []ShoppingListRecipeRef `json:"recipes"`                 // Détail des recettes qui utilisent cet ingrédient
+}
+
+// ShoppingListRecipeRef représente l'utilisation d'un ingrédient par une recette planifiée
+type ShoppingListRecipeRef struct {
+	RecipeID   uint    `json:"recipe_id"`
+	RecipeName string  `json:"recipe_name"`
+	Quantity   float64 `json:"quantity"`
+	Unit       string  `json:"unit"` // Unité telle que saisie dans la recette
+	Date       string  `json:"date"`
+	MealType   string  `json:"meal_type"`
+}
+
+// FridgeAllocation représente une quantité du frigo réservée pour un repas planifié
+type FridgeAllocation struct {
+	FridgeItemID   uint    `json:"fridge_item_id"`
 	IngredientID   uint    `json:"ingredient_id"`
 	IngredientName string  `json:"ingredient_name"`
-	TotalQuantity  float64 `json:"total_quantity"`
+	MealPlanID     uint    `json:"meal_plan_id"`
+	RecipeID       uint    `json:"recipe_id"` // Recette qui utilise l'ingrédient (la recette planifiée ou une sous-recette)
+	Date           string  `json:"date"`
+	MealType       string  `json:"meal_type"`
+	Quantity       float64 `json:"quantity"`
 	Unit           string  `json:"unit"`
-	Recipes        []struct {
-		RecipeID   uint    `json:"recipe_id"`
-		RecipeName string  `json:"recipe_name"`
-		Quantity   float64 `json:"quantity"`
-		Date       string  `json:"date"`
-		MealType   string  `json:"meal_type"`
-	} `json:"recipes"` // Détail des recettes qui utilisent cet ingrédient
+}
+
+// ShoppingListOptions regroupe les options de calcul de la liste de courses
+type ShoppingListOptions struct {
+	SubtractFridge bool  // Déduire le stock du frigo des quantités à acheter
+	StoreID        *uint // Magasin dont l'ordre des rayons est appliqué (défaut: magasin par défaut de l'utilisateur)
+	WithCost       bool  // Estimer le coût des articles à acheter avec les prix relevés
 }
 
 // WeeklyShoppingList représente la liste de courses pour une semaine
 type WeeklyShoppingList struct {
-	StartDate    time.Time          `json:"start_date"`
-	EndDate      time.Time          `json:"end_date"`
-	Items        []ShoppingListItem `json:"items"`
-	TotalRecipes int                `json:"total_recipes"`
+	StartDate         time.Time          `json:"start_date"`
+	EndDate           time.Time          `json:"end_date"`
+	Items             []ShoppingListItem `json:"items"`
+	TotalRecipes      int                `json:"total_recipes"`
+	FridgeAllocations []FridgeAllocation `json:"fridge_allocations,omitempty"` // Stock du frigo réservé par repas (si déduit)
+
+	// Regroupement par rayon selon le magasin choisi (les items sont alors triés dans le même ordre)
+	StoreID *uint               `json:"store_id,omitempty"`
+	Aisles  []ShoppingListAisle `json:"aisles,omitempty"`
+
+	// Coût estimé des quantités à acheter (si demandé)
+	Cost *CostEstimate `json:"cost,omitempty"`
 }
 
 // WeeklyShoppingListResponse représente la réponse pour la liste de courses hebdomadaire
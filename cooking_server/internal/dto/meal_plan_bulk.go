@@ -0,0 +1,28 @@
+package dto
+
+// MealPlanCopyRequest représente la copie des repas d'une période vers une autre date
+type MealPlanCopyRequest struct {
+	StartDate  string `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate    string `json:"end_date,omitempty" binding:"omitempty,datetime=2006-01-02"` // Défaut: start_date (un seul jour)
+	TargetDate string `json:"target_date" binding:"required,datetime=2006-01-02"`         // Date où commence la copie
+}
+
+// MealPlanShiftRequest représente le décalage des repas d'une période de N jours
+type MealPlanShiftRequest struct {
+	StartDate string `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date,omitempty" binding:"omitempty,datetime=2006-01-02"` // Défaut: start_date (un seul jour)
+	Days      int    `json:"days" binding:"required,min=-365,max=365"`                   // Négatif pour avancer les repas
+}
+
+// MealPlanSwapRequest représente l'échange de la date et du type de deux repas
+type MealPlanSwapRequest struct {
+	FirstID  uint `json:"first_id" binding:"required"`
+	SecondID uint `json:"second_id" binding:"required,nefield=FirstID"`
+}
+
+// MealPlanClearRequest représente la suppression des repas d'une période
+type MealPlanClearRequest struct {
+	StartDate        string `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate          string `json:"end_date,omitempty" binding:"omitempty,datetime=2006-01-02"` // Défaut: start_date (un seul jour)
+	IncludeCompleted bool   `json:"include_completed,omitempty"`                                // Supprimer aussi les repas déjà réalisés
+}
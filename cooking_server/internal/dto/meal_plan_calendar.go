@@ -0,0 +1,64 @@
+package dto
+
+import "time"
+
+// MealPlanCalendar représente le flux iCalendar du planning d'un utilisateur.
+// Le flux est accessible sans authentification via un jeton personnel, régénérable.
+type MealPlanCalendar struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	UserID        uint      `json:"user_id" gorm:"not null;uniqueIndex"`
+	Token         string    `json:"token" gorm:"type:varchar(64);not null;uniqueIndex"`
+	BreakfastTime string    `json:"breakfast_time" gorm:"type:varchar(5);not null;default:'08:00'"` // Heure des petits-déjeuners (HH:MM)
+	LunchTime     string    `json:"lunch_time" gorm:"type:varchar(5);not null;default:'12:30'"`
+	DinnerTime    string    `json:"dinner_time" gorm:"type:varchar(5);not null;default:'19:30'"`
+	SnackTime     string    `json:"snack_time" gorm:"type:varchar(5);not null;default:'16:00'"`
+	Duration      int       `json:"duration" gorm:"not null;default:60"`                               // Durée des événements en minutes
+	TimeZone      string    `json:"time_zone" gorm:"type:varchar(64);not null;default:'Europe/Paris'"` // Fuseau horaire des heures de repas
+	FeedURL       string    `json:"feed_url,omitempty" gorm:"-"`                                       // URL d'abonnement (calculée)
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// MealTimes associe chaque type de repas à son heure (HH:MM)
+func (c MealPlanCalendar) MealTimes() map[string]string {
+	return map[string]string{
+		"breakfast": c.BreakfastTime,
+		"lunch":     c.LunchTime,
+		"dinner":    c.DinnerTime,
+		"snack":     c.SnackTime,
+	}
+}
+
+// Location retourne le fuseau horaire du calendrier (UTC s'il est inconnu)
+func (c MealPlanCalendar) Location() *time.Location {
+	location, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// MealPlanCalendarUpdateRequest représente la modification des réglages du flux iCalendar
+type MealPlanCalendarUpdateRequest struct {
+	BreakfastTime string `json:"breakfast_time,omitempty" binding:"omitempty,datetime=15:04"`
+	LunchTime     string `json:"lunch_time,omitempty" binding:"omitempty,datetime=15:04"`
+	DinnerTime    string `json:"dinner_time,omitempty" binding:"omitempty,datetime=15:04"`
+	SnackTime     string `json:"snack_time,omitempty" binding:"omitempty,datetime=15:04"`
+	Duration      int    `json:"duration,omitempty" binding:"omitempty,min=5,max=480"`
+	TimeZone      string `json:"time_zone,omitempty" binding:"omitempty,timezone"`
+}
+
+// MealPlanCalendarEvent représente un événement lu dans un fichier iCalendar importé
+type MealPlanCalendarEvent struct {
+	Title    string    // Titre sans le type de repas éventuel
+	Start    time.Time // Début de l'événement
+	AllDay   bool      // Événement sur la journée, sans heure
+	MealType string    // Type de repas indiqué dans le titre (ex. "Dîner : ..."), sinon vide
+}
+
+// MealPlanCalendarImportResult représente le résultat de l'import d'un fichier iCalendar
+type MealPlanCalendarImportResult struct {
+	Created   []*MealPlan `json:"created"`
+	Skipped   int         `json:"skipped"`   // Événements déjà planifiés
+	Unmatched []string    `json:"unmatched"` // Titres sans recette correspondante
+}
@@ -0,0 +1,80 @@
+package dto
+
+// Sources de recettes du générateur de planning
+const (
+	GeneratorSourceFavorites = "favorites" // Recettes favorites de l'utilisateur
+	GeneratorSourceLists     = "lists"     // Recettes des listes de l'utilisateur
+	GeneratorSourcePublic    = "public"    // Recettes publiques les mieux notées
+)
+
+// MealPlanGenerationConstraints regroupe les contraintes communes à la génération du planning
+// et au nouveau tirage d'un créneau
+type MealPlanGenerationConstraints struct {
+	MealTypes      []string       `json:"meal_types,omitempty" binding:"omitempty,dive,oneof=breakfast lunch dinner snack"`                                                       // Défaut: tous
+	Sources        []string       `json:"sources,omitempty" binding:"omitempty,dive,oneof=favorites lists public"`                                                                // Défaut: toutes
+	RecipeListIDs  []uint         `json:"recipe_list_ids,omitempty"`                                                                                                              // Limiter la source lists à ces listes
+	MaxTime        map[string]int `json:"max_time,omitempty" binding:"omitempty,dive,keys,oneof=monday tuesday wednesday thursday friday saturday sunday,endkeys,min=0,max=1440"` // Temps total maximal (minutes) par jour de la semaine
+	Diets          []string       `json:"diets,omitempty" binding:"omitempty,dive,oneof=vegan vegetarian pescatarian pork_free alcohol_free"`
+	AvoidAllergens []string       `json:"avoid_allergens,omitempty" binding:"omitempty,dive,oneof=gluten crustaceans eggs fish peanuts soybeans milk nuts celery mustard sesame sulphites lupin molluscs"`
+	VarietyDays    int            `json:"variety_days,omitempty" binding:"omitempty,min=1,max=60"` // Pas deux fois la même recette à moins de N jours (défaut: 7)
+	Budget         *float64       `json:"budget,omitempty" binding:"omitempty,gt=0"`               // Coût maximal des repas générés, selon les prix relevés
+	StoreID        *uint          `json:"store_id,omitempty"`                                      // Magasin dont les prix sont privilégiés
+	Servings       int            `json:"servings,omitempty" binding:"omitempty,min=1,max=100"`    // Défaut: portions de chaque recette
+	PreferExpiring *bool          `json:"prefer_expiring,omitempty"`                               // Privilégier les recettes utilisant les produits du frigo bientôt périmés (défaut: true)
+	Seed           int64          `json:"seed,omitempty"`                                          // Graine du tirage, pour reproduire une proposition
+}
+
+// MealPlanGenerateRequest représente la période et les contraintes de génération automatique du planning.
+// Seuls les créneaux (jour, type de repas) sans repas planifié sont remplis.
+type MealPlanGenerateRequest struct {
+	StartDate string `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date,omitempty" binding:"omitempty,datetime=2006-01-02"` // Défaut: start_date + 6 jours
+	MealPlanGenerationConstraints
+}
+
+// MealPlanGenerationSlot représente la proposition pour un créneau du planning
+type MealPlanGenerationSlot struct {
+	Date                string         `json:"date"`
+	MealType            string         `json:"meal_type"`
+	RecipeID            uint           `json:"recipe_id,omitempty"`
+	Recipe              *RecipeSummary `json:"recipe,omitempty"`
+	Servings            int            `json:"servings,omitempty"`
+	Cost                *float64       `json:"cost,omitempty"`                 // Coût estimé (si un budget est fixé)
+	CostGaps            []CostGap      `json:"cost_gaps,omitempty"`            // Ingrédients sans prix connu : le coût n'est alors qu'un minimum
+	ExpiringIngredients []string       `json:"expiring_ingredients,omitempty"` // Produits du frigo bientôt périmés utilisés par la recette
+	Unfilled            string         `json:"unfilled,omitempty"`             // Raison pour laquelle aucune recette ne convient
+}
+
+// MealPlanGenerationPreview représente une proposition de planning, à accepter ou à retirer créneau par créneau
+type MealPlanGenerationPreview struct {
+	StartDate      string                   `json:"start_date"`
+	EndDate        string                   `json:"end_date"`
+	Seed           int64                    `json:"seed"`
+	Slots          []MealPlanGenerationSlot `json:"slots"`
+	TotalCost      *float64                 `json:"total_cost,omitempty"`      // Coût estimé des créneaux remplis (si un budget est fixé)
+	IncompleteCost bool                     `json:"incomplete_cost,omitempty"` // Le coût total n'est qu'un minimum : des créneaux ont des ingrédients sans prix
+	Budget         *float64                 `json:"budget,omitempty"`
+}
+
+// MealPlanGenerationChoice représente une recette retenue pour un créneau
+type MealPlanGenerationChoice struct {
+	Date     string `json:"date" binding:"required,datetime=2006-01-02"`
+	MealType string `json:"meal_type" binding:"required,oneof=breakfast lunch dinner snack"`
+	RecipeID uint   `json:"recipe_id" binding:"required"`
+	Servings int    `json:"servings,omitempty" binding:"omitempty,min=1"`
+}
+
+// MealPlanRerollRequest représente un nouveau tirage pour un créneau d'une proposition,
+// avec les mêmes contraintes et les recettes retenues pour les autres créneaux
+type MealPlanRerollRequest struct {
+	MealPlanGenerationConstraints
+	Date             string                     `json:"date" binding:"required,datetime=2006-01-02"`
+	MealType         string                     `json:"meal_type" binding:"required,oneof=breakfast lunch dinner snack"`
+	Chosen           []MealPlanGenerationChoice `json:"chosen,omitempty" binding:"omitempty,dive"` // Autres créneaux de la proposition
+	ExcludeRecipeIDs []uint                     `json:"exclude_recipe_ids,omitempty"`              // Recettes à ne pas proposer (dont la recette actuelle)
+}
+
+// MealPlanGenerationAcceptRequest représente les créneaux acceptés d'une proposition
+type MealPlanGenerationAcceptRequest struct {
+	Slots []MealPlanGenerationChoice `json:"slots" binding:"required,min=1,dive"`
+}
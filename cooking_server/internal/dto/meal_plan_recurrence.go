@@ -0,0 +1,66 @@
+package dto
+
+import "time"
+
+// Fréquences des règles de récurrence des repas planifiés
+const (
+	RecurrenceDaily  = "daily"  // Tous les N jours
+	RecurrenceWeekly = "weekly" // Toutes les N semaines, les jours choisis
+)
+
+// MealPlanRecurrence représente une règle de planification répétée ("pâtes tous les lundis").
+// Les occurrences sont créées comme des MealPlan à la consultation du planning.
+type MealPlanRecurrence struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	RecipeID  uint       `json:"recipe_id" gorm:"not null"`
+	MealType  string     `json:"meal_type" gorm:"type:varchar(20);default:'dinner';check:meal_type IN ('breakfast','lunch','dinner','snack')"`
+	Servings  int        `json:"servings" gorm:"default:1"`
+	Notes     string     `json:"notes,omitempty"`
+	Frequency string     `json:"frequency" gorm:"type:varchar(10);not null;check:frequency IN ('daily','weekly')"` // daily ou weekly
+	Interval  int        `json:"interval" gorm:"column:repeat_interval;not null;default:1"`                        // Tous les N jours ou toutes les N semaines
+	Weekdays  StringList `json:"weekdays" gorm:"type:jsonb;default:'[]'"`                                          // Jours de la semaine (weekly), par défaut celui de la date de début
+	StartDate time.Time  `json:"start_date" gorm:"type:date;not null"`
+	EndDate   *time.Time `json:"end_date,omitempty" gorm:"type:date"` // Vide pour une règle sans fin
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relations
+	Recipe Recipe `json:"recipe" gorm:"foreignKey:RecipeID"`
+}
+
+// MealPlanRecurrenceSkip mémorise une occurrence supprimée, pour qu'elle ne soit pas recréée
+type MealPlanRecurrenceSkip struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	RecurrenceID   uint      `json:"recurrence_id" gorm:"not null;uniqueIndex:idx_meal_plan_recurrence_skip"`
+	OccurrenceDate time.Time `json:"occurrence_date" gorm:"type:date;not null;uniqueIndex:idx_meal_plan_recurrence_skip"`
+}
+
+// MealPlanRecurrenceCreateRequest représente les données pour créer une règle de récurrence
+type MealPlanRecurrenceCreateRequest struct {
+	RecipeID  uint     `json:"recipe_id" binding:"required"`
+	MealType  string   `json:"meal_type" binding:"required,oneof=breakfast lunch dinner snack"`
+	Servings  int      `json:"servings,omitempty" binding:"omitempty,min=1"`
+	Notes     string   `json:"notes,omitempty" binding:"max=500"`
+	Frequency string   `json:"frequency" binding:"required,oneof=daily weekly"`
+	Interval  int      `json:"interval,omitempty" binding:"omitempty,min=1,max=365"` // Défaut: 1
+	Weekdays  []string `json:"weekdays,omitempty" binding:"omitempty,dive,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	StartDate string   `json:"start_date" binding:"required,datetime=2006-01-02"`
+	EndDate   string   `json:"end_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// MealPlanRecurrenceUpdateRequest représente les modifications d'une règle de récurrence.
+// Les occurrences à venir non modifiées individuellement suivent la règle.
+type MealPlanRecurrenceUpdateRequest struct {
+	RecipeID  uint      `json:"recipe_id,omitempty"`
+	MealType  string    `json:"meal_type,omitempty" binding:"omitempty,oneof=breakfast lunch dinner snack"`
+	Servings  int       `json:"servings,omitempty" binding:"omitempty,min=1"`
+	Notes     *string   `json:"notes,omitempty" binding:"omitempty,max=500"`
+	Frequency string    `json:"frequency,omitempty" binding:"omitempty,oneof=daily weekly"`
+	Interval  int       `json:"interval,omitempty" binding:"omitempty,min=1,max=365"`
+	Weekdays  *[]string `json:"weekdays,omitempty" binding:"omitempty,dive,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	StartDate string    `json:"start_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	EndDate   string    `json:"end_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	NoEndDate bool      `json:"no_end_date,omitempty"` // Retirer la date de fin
+}
@@ -0,0 +1,60 @@
+package dto
+
+import "time"
+
+// MealPlanTemplate représente une semaine type réutilisable, applicable à n'importe quelle date de début
+type MealPlanTemplate struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relations
+	Items []MealPlanTemplateItem `json:"items" gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE"`
+}
+
+// MealPlanTemplateItem représente un repas de la semaine type, placé par rapport au premier jour
+type MealPlanTemplateItem struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	TemplateID uint   `json:"template_id" gorm:"not null;index"`
+	RecipeID   uint   `json:"recipe_id" gorm:"not null"`
+	DayOffset  int    `json:"day_offset" gorm:"not null;check:day_offset >= 0"` // 0 = premier jour de la semaine type
+	MealType   string `json:"meal_type" gorm:"type:varchar(20);default:'dinner';check:meal_type IN ('breakfast','lunch','dinner','snack')"`
+	Servings   int    `json:"servings" gorm:"default:1"`
+	Notes      string `json:"notes,omitempty"`
+
+	// Relations
+	Recipe Recipe `json:"recipe" gorm:"foreignKey:RecipeID"`
+}
+
+// MealPlanTemplateItemRequest représente un repas d'une semaine type
+type MealPlanTemplateItemRequest struct {
+	RecipeID  uint   `json:"recipe_id" binding:"required"`
+	DayOffset int    `json:"day_offset" binding:"min=0,max=27"` // Jours après le début (0 = premier jour)
+	MealType  string `json:"meal_type" binding:"required,oneof=breakfast lunch dinner snack"`
+	Servings  int    `json:"servings,omitempty" binding:"omitempty,min=1"`
+	Notes     string `json:"notes,omitempty" binding:"max=500"`
+}
+
+// MealPlanTemplateCreateRequest représente les données pour créer une semaine type,
+// à partir d'une liste de repas ou des repas planifiés d'une semaine existante
+type MealPlanTemplateCreateRequest struct {
+	Name        string                        `json:"name" binding:"required,min=1,max=100"`
+	Description string                        `json:"description,omitempty" binding:"max=500"`
+	Items       []MealPlanTemplateItemRequest `json:"items,omitempty" binding:"omitempty,dive"`
+	FromDate    string                        `json:"from_date,omitempty" binding:"omitempty,datetime=2006-01-02"` // Copier les 7 jours de repas planifiés à partir de cette date
+}
+
+// MealPlanTemplateUpdateRequest représente les modifications d'une semaine type
+type MealPlanTemplateUpdateRequest struct {
+	Name        string                         `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Description *string                        `json:"description,omitempty" binding:"omitempty,max=500"`
+	Items       *[]MealPlanTemplateItemRequest `json:"items,omitempty" binding:"omitempty,dive"` // Remplace tous les repas
+}
+
+// MealPlanTemplateApplyRequest représente l'application d'une semaine type au planning
+type MealPlanTemplateApplyRequest struct {
+	StartDate string `json:"start_date" binding:"required,datetime=2006-01-02"` // Date du premier jour
+}
@@ -0,0 +1,102 @@
+package dto
+
+// Raisons pour lesquelles un ingrédient n'est pas pris en compte dans le calcul nutritionnel
+const (
+	NutritionGapNoData        = "no_nutrition_data"  // L'ingrédient n'a pas de valeurs nutritionnelles
+	NutritionGapNoQuantity    = "no_quantity"        // La quantité n'est pas renseignée
+	NutritionGapUnconvertible = "unconvertible_unit" // La quantité ne peut pas être convertie en grammes
+)
+
+// Nutrients regroupe les valeurs nutritionnelles d'une quantité d'aliments
+type Nutrients struct {
+	EnergyKcal    float64 `json:"energy_kcal"`   // Énergie en kcal
+	Protein       float64 `json:"protein"`       // Protéines en g
+	Fat           float64 `json:"fat"`           // Lipides en g
+	Carbohydrates float64 `json:"carbohydrates"` // Glucides en g
+	Fiber         float64 `json:"fiber"`         // Fibres en g
+	Salt          float64 `json:"salt"`          // Sel en g
+}
+
+// RecipeNutrition représente les valeurs nutritionnelles calculées d'une recette,
+// sous-recettes référencées dans les étapes comprises
+type RecipeNutrition struct {
+	RecipeID   uint                     `json:"recipe_id"`
+	Servings   int                      `json:"servings"`
+	PerRecipe  Nutrients                `json:"per_recipe"`
+	PerServing *Nutrients               `json:"per_serving,omitempty"` // Absent si le nombre de portions est inconnu
+	Coverage   NutritionCoverage        `json:"coverage"`
+	SubRecipes []NutritionSubRecipe     `json:"sub_recipes"`
+	Gaps       []NutritionIngredientGap `json:"gaps"` // Ingrédients non pris en compte
+}
+
+// NutritionCoverage indique la part de la recette couverte par le calcul
+type NutritionCoverage struct {
+	TotalIngredients   int     `json:"total_ingredients"`   // Ingrédients non optionnels, sous-recettes comprises
+	CoveredIngredients int     `json:"covered_ingredients"` // Ingrédients effectivement comptés
+	CoveredGrams       float64 `json:"covered_grams"`       // Masse totale des ingrédients comptés
+	Ratio              float64 `json:"ratio"`               // CoveredIngredients / TotalIngredients (0-1)
+	IsComplete         bool    `json:"is_complete"`
+}
+
+// NutritionSubRecipe représente l'apport d'une sous-recette référencée dans les étapes
+type NutritionSubRecipe struct {
+	RecipeID  uint      `json:"recipe_id"`
+	Title     string    `json:"title"`
+	Nutrients Nutrients `json:"nutrients"`
+}
+
+// NutritionIngredientGap représente un ingrédient exclu du calcul nutritionnel
+type NutritionIngredientGap struct {
+	RecipeID       uint    `json:"recipe_id"` // Recette (ou sous-recette) contenant l'ingrédient
+	IngredientID   uint    `json:"ingredient_id"`
+	IngredientName string  `json:"ingredient_name"`
+	Quantity       float64 `json:"quantity"`
+	Unit           string  `json:"unit"`
+	Reason         string  `json:"reason"`
+}
+
+// Add ajoute les valeurs d'un autre ensemble de nutriments
+func (n *Nutrients) Add(other Nutrients) {
+	n.EnergyKcal += other.EnergyKcal
+	n.Protein += other.Protein
+	n.Fat += other.Fat
+	n.Carbohydrates += other.Carbohydrates
+	n.Fiber += other.Fiber
+	n.Salt += other.Salt
+}
+
+// Scale retourne les valeurs multipliées par un facteur
+func (n Nutrients) Scale(factor float64) Nutrients {
+	return Nutrients{
+		EnergyKcal:    n.EnergyKcal * factor,
+		Protein:       n.Protein * factor,
+		Fat:           n.Fat * factor,
+		Carbohydrates: n.Carbohydrates * factor,
+		Fiber:         n.Fiber * factor,
+		Salt:          n.Salt * factor,
+	}
+}
+
+// HasNutrition indique si au moins une valeur nutritionnelle est renseignée pour l'ingrédient
+func (i Ingredient) HasNutrition() bool {
+	return i.EnergyKcal != nil || i.Protein != nil || i.Fat != nil ||
+		i.Carbohydrates != nil || i.Fiber != nil || i.Salt != nil
+}
+
+// NutrientsPer100g retourne les valeurs pour 100 g de l'ingrédient (valeurs absentes = 0)
+func (i Ingredient) NutrientsPer100g() Nutrients {
+	value := func(v *float64) float64 {
+		if v == nil {
+			return 0
+		}
+		return *v
+	}
+	return Nutrients{
+		EnergyKcal:    value(i.EnergyKcal),
+		Protein:       value(i.Protein),
+		Fat:           value(i.Fat),
+		Carbohydrates: value(i.Carbohydrates),
+		Fiber:         value(i.Fiber),
+		Salt:          value(i.Salt),
+	}
+}
@@ -0,0 +1,73 @@
+package dto
+
+import "time"
+
+// Raisons pour lesquelles le coût d'un ingrédient n'a pas pu être estimé
+const (
+	CostGapNoPrice       = "no_price"      // Aucun prix connu pour l'ingrédient
+	CostGapUnconvertible = "unconvertible" // Unité de la recette non convertible vers celle du prix
+)
+
+// IngredientPrice représente un prix relevé par un utilisateur pour un ingrédient,
+// éventuellement dans un magasin donné. Le prix s'applique à Quantity Unit (ex: 2,50 pour 1 kg).
+type IngredientPrice struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;index"`
+	IngredientID uint      `json:"ingredient_id" gorm:"not null;index"`
+	StoreID      *uint     `json:"store_id,omitempty" gorm:"index"` // Vide pour un prix valable partout
+	Price        float64   `json:"price" gorm:"not null"`
+	Quantity     float64   `json:"quantity" gorm:"not null;default:1"`
+	Unit         string    `json:"unit" gorm:"not null"`
+	PricedAt     time.Time `json:"priced_at" gorm:"type:date;not null;index"` // Date du relevé
+	Notes        string    `json:"notes,omitempty"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// Relations
+	Ingredient *Ingredient `json:"ingredient,omitempty" gorm:"foreignKey:IngredientID"`
+}
+
+// IngredientPriceCreateRequest représente les données pour enregistrer un prix
+type IngredientPriceCreateRequest struct {
+	IngredientID uint    `json:"ingredient_id" binding:"required"`
+	StoreID      *uint   `json:"store_id,omitempty"`
+	Price        float64 `json:"price" binding:"required,gt=0"`
+	Quantity     float64 `json:"quantity,omitempty" binding:"omitempty,gt=0"` // Défaut: 1
+	Unit         string  `json:"unit" binding:"required,max=20"`
+	PricedAt     string  `json:"priced_at,omitempty" binding:"omitempty,datetime=2006-01-02"` // Défaut: aujourd'hui
+	Notes        string  `json:"notes,omitempty" binding:"max=500"`
+}
+
+// CostQuery regroupe les options d'estimation de coût
+type CostQuery struct {
+	StoreID  *uint `form:"store_id"`                                    // Privilégier les prix relevés dans ce magasin
+	Servings int   `form:"servings" binding:"omitempty,min=1,max=1000"` // Nombre de portions (défaut: portions de la recette ou du repas)
+}
+
+// CostLine représente le coût estimé d'un ingrédient
+type CostLine struct {
+	IngredientID   uint     `json:"ingredient_id"`
+	IngredientName string   `json:"ingredient_name"`
+	Quantity       float64  `json:"quantity"`
+	Unit           string   `json:"unit"`
+	Cost           *float64 `json:"cost,omitempty"`      // Vide si le coût n'a pas pu être estimé
+	PriceID        *uint    `json:"price_id,omitempty"`  // Prix utilisé
+	Estimated      bool     `json:"estimated,omitempty"` // Prix d'un ingrédient plus général (tomate pour tomate cerise)
+	Gap            string   `json:"gap,omitempty"`       // no_price ou unconvertible
+}
+
+// CostGap signale un ingrédient dont le coût n'a pas pu être estimé
+type CostGap struct {
+	IngredientID   uint   `json:"ingredient_id"`
+	IngredientName string `json:"ingredient_name"`
+	Reason         string `json:"reason"` // no_price ou unconvertible
+}
+
+// CostEstimate représente le coût estimé d'une recette, d'un repas planifié ou d'une liste de courses
+type CostEstimate struct {
+	Total      float64    `json:"total"`                 // Somme des coûts connus
+	Servings   int        `json:"servings,omitempty"`    // Portions prises en compte
+	PerServing *float64   `json:"per_serving,omitempty"` // Coût par portion
+	Complete   bool       `json:"complete"`              // Vrai si tous les ingrédients ont un coût
+	Lines      []CostLine `json:"lines"`
+	Gaps       []CostGap  `json:"gaps"` // Ingrédients sans prix connu ou non convertibles
+}
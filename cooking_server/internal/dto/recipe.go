@@ -58,7 +58,10 @@ type Recipe struct {
 	IsPublic         bool        `json:"is_public" gorm:"default:true"`                                                                    // Indique si la recette est publique
 	IsOriginal       bool        `json:"is_original" gorm:"default:true"`                                                                  // Indique si la recette est originale
 	OriginalRecipeID *uint       `json:"original_recipe_id,omitempty"`                                                                     // ID de la recette originale si c'est une adaptation
+	ForkedRevision   *int        `json:"forked_revision,omitempty"`                                                                        // Révision de la recette originale au moment de la copie
 	AuthorID         uint        `json:"author_id" gorm:"not null"`                                                                        // ID de l'auteur de la recette
+	Allergens        StringList  `json:"allergens" gorm:"type:jsonb;default:'[]'"`                                                         // Allergènes des ingrédients, sous-recettes comprises (calculé)
+	Diets            StringList  `json:"diets" gorm:"type:jsonb;default:'[]'"`                                                             // Régimes compatibles avec tous les ingrédients (calculé)
 
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
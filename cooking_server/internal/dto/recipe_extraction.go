@@ -33,6 +33,9 @@ type ExtractedIngredient struct {
 	Quantity float64 `json:"quantity"`
 	Unit     string  `json:"unit,omitempty"`
 	Notes    string  `json:"notes,omitempty"`
+
+	IngredientID *uint  `json:"ingredient_id,omitempty"` // Ingrédient existant correspondant (nom ou alias)
+	MatchedName  string `json:"matched_name,omitempty"`  // Nom canonique de l'ingrédient correspondant
 }
 
 // ExtractedInstruction représente une instruction extraite
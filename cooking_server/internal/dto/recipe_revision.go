@@ -0,0 +1,177 @@
+package dto
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// RecipeRevision représente une version enregistrée d'une recette
+type RecipeRevision struct {
+	ID       uint            `json:"id" gorm:"primaryKey"`
+	RecipeID uint            `json:"recipe_id" gorm:"not null;uniqueIndex:idx_recipe_revision_number"`
+	Number   int             `json:"number" gorm:"not null;uniqueIndex:idx_recipe_revision_number"` // Numéro de révision, croissant par recette
+	AuthorID uint            `json:"author_id" gorm:"not null"`                                     // Utilisateur à l'origine de la révision
+	Note     string          `json:"note,omitempty"`                                                // Contexte de la révision (ex. restauration)
+	Snapshot *RecipeSnapshot `json:"snapshot,omitempty" gorm:"type:jsonb"`                          // État complet de la recette
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// RecipeSnapshot représente l'état complet d'une recette (champs, ingrédients, équipements, tags, catégories)
+type RecipeSnapshot struct {
+	Title        string                     `json:"title"`
+	Description  string                     `json:"description"`
+	Instructions RecipeSteps                `json:"instructions"`
+	PrepTime     int                        `json:"prep_time"`
+	CookTime     int                        `json:"cook_time"`
+	Servings     int                        `json:"servings"`
+	Difficulty   string                     `json:"difficulty"`
+	ImageURL     string                     `json:"image_url,omitempty"`
+	IsPublic     bool                       `json:"is_public"`
+	Ingredients  []RecipeSnapshotIngredient `json:"ingredients"`
+	Equipments   []RecipeSnapshotEquipment  `json:"equipments"`
+	Tags         []RecipeSnapshotRef        `json:"tags"`
+	Categories   []RecipeSnapshotRef        `json:"categories"`
+}
+
+// RecipeSnapshotIngredient représente un ingrédient de recette dans une révision
+type RecipeSnapshotIngredient struct {
+	IngredientID   uint    `json:"ingredient_id"`
+	IngredientName string  `json:"ingredient_name"`
+	Quantity       float64 `json:"quantity"`
+	Unit           string  `json:"unit"`
+	Notes          string  `json:"notes,omitempty"`
+	IsOptional     bool    `json:"is_optional"`
+	Group          string  `json:"group,omitempty"`
+	Position       int     `json:"position"`
+}
+
+// RecipeSnapshotEquipment représente un équipement de recette dans une révision
+type RecipeSnapshotEquipment struct {
+	EquipmentID   uint   `json:"equipment_id"`
+	EquipmentName string `json:"equipment_name"`
+	IsOptional    bool   `json:"is_optional"`
+	Notes         string `json:"notes,omitempty"`
+}
+
+// RecipeSnapshotRef représente un tag ou une catégorie dans une révision
+type RecipeSnapshotRef struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+func (s *RecipeSnapshot) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return errors.New("cannot scan non-[]byte into RecipeSnapshot")
+	}
+}
+
+func (s RecipeSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// RecipeRevisionDiff représente les différences structurées entre deux révisions d'une recette
+type RecipeRevisionDiff struct {
+	RecipeID    uint                  `json:"recipe_id"`
+	From        int                   `json:"from"`
+	To          int                   `json:"to"`
+	Fields      []RecipeFieldChange   `json:"fields"`
+	Ingredients RecipeIngredientsDiff `json:"ingredients"`
+	Steps       RecipeStepsDiff       `json:"steps"`
+	Equipments  RecipeEquipmentsDiff  `json:"equipments"`
+	Tags        RecipeRefsDiff        `json:"tags"`
+	Categories  RecipeRefsDiff        `json:"categories"`
+}
+
+// RecipeFieldChange représente la modification d'un champ simple de la recette
+type RecipeFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// RecipeIngredientsDiff regroupe les ingrédients ajoutés, supprimés et modifiés
+type RecipeIngredientsDiff struct {
+	Added   []RecipeSnapshotIngredient `json:"added"`
+	Removed []RecipeSnapshotIngredient `json:"removed"`
+	Changed []RecipeIngredientChange   `json:"changed"`
+}
+
+// RecipeIngredientChange représente un ingrédient présent dans les deux révisions mais modifié
+type RecipeIngredientChange struct {
+	Before RecipeSnapshotIngredient `json:"before"`
+	After  RecipeSnapshotIngredient `json:"after"`
+}
+
+// RecipeStepsDiff regroupe les étapes ajoutées, supprimées et modifiées (par numéro d'étape)
+type RecipeStepsDiff struct {
+	Added   []RecipeStep       `json:"added"`
+	Removed []RecipeStep       `json:"removed"`
+	Changed []RecipeStepChange `json:"changed"`
+}
+
+// RecipeStepChange représente une étape modifiée
+type RecipeStepChange struct {
+	StepNumber int        `json:"step_number"`
+	Before     RecipeStep `json:"before"`
+	After      RecipeStep `json:"after"`
+}
+
+// RecipeEquipmentsDiff regroupe les équipements ajoutés, supprimés et modifiés
+type RecipeEquipmentsDiff struct {
+	Added   []RecipeSnapshotEquipment `json:"added"`
+	Removed []RecipeSnapshotEquipment `json:"removed"`
+	Changed []RecipeEquipmentChange   `json:"changed"`
+}
+
+// RecipeEquipmentChange représente un équipement présent dans les deux révisions mais modifié
+type RecipeEquipmentChange struct {
+	Before RecipeSnapshotEquipment `json:"before"`
+	After  RecipeSnapshotEquipment `json:"after"`
+}
+
+// RecipeRefsDiff regroupe les tags ou catégories ajoutés et supprimés
+type RecipeRefsDiff struct {
+	Added   []RecipeSnapshotRef `json:"added"`
+	Removed []RecipeSnapshotRef `json:"removed"`
+}
+
+// RecipeForkNode représente une recette dans l'arbre de ses copies
+type RecipeForkNode struct {
+	ID             uint              `json:"id"`
+	Title          string            `json:"title"`
+	AuthorID       uint              `json:"author_id"`
+	AuthorUsername string            `json:"author_username"`
+	IsPublic       bool              `json:"is_public"`
+	IsOriginal     bool              `json:"is_original"`
+	ForkedRevision *int              `json:"forked_revision,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	Forks          []*RecipeForkNode `json:"forks"`
+}
+
+// RecipeUpstreamChanges représente les modifications de la recette originale depuis la copie,
+// limitées à celles qui ne sont pas déjà reflétées dans la copie
+type RecipeUpstreamChanges struct {
+	RecipeID         uint               `json:"recipe_id"`
+	OriginalRecipeID uint               `json:"original_recipe_id"`
+	ForkedRevision   int                `json:"forked_revision"`
+	LatestRevision   int                `json:"latest_revision"`
+	Changes          RecipeRevisionDiff `json:"changes"`
+}
+
+// RecipeUpstreamPullRequest représente la sélection de modifications de l'originale à importer dans une copie
+type RecipeUpstreamPullRequest struct {
+	IngredientIDs []uint `json:"ingredient_ids"` // Ingrédients dont les modifications sont importées
+	StepNumbers   []int  `json:"step_numbers"`   // Numéros d'étape (dans la recette originale) à importer
+}
@@ -0,0 +1,19 @@
+package dto
+
+// RecipeScaleRequest représente les paramètres de mise à l'échelle d'une recette.
+// Un seul critère parmi Servings, Factor et YieldGrams doit être renseigné.
+type RecipeScaleRequest struct {
+	Servings   int     `form:"servings" binding:"omitempty,min=1,max=1000"`     // Nombre de portions souhaité
+	Factor     float64 `form:"factor" binding:"omitempty,gt=0,lte=100"`         // Coefficient multiplicateur
+	YieldGrams float64 `form:"yield_grams" binding:"omitempty,gt=0"`            // Masse totale d'ingrédients souhaitée en grammes
+	UnitSystem string  `form:"units" binding:"omitempty,oneof=metric imperial"` // Conversion facultative des unités
+}
+
+// ScaledRecipe représente une recette complète dont les quantités ont été mises à l'échelle
+type ScaledRecipe struct {
+	Recipe           *Recipe `json:"recipe"` // Sous-recettes référencées comprises, mises à l'échelle avec le même coefficient
+	OriginalServings int     `json:"original_servings"`
+	Servings         int     `json:"servings"`
+	Factor           float64 `json:"factor"`
+	UnitSystem       string  `json:"unit_system,omitempty"`
+}
@@ -1,5 +1,14 @@
 package dto
 
+// SortByRelevance trie les résultats par pertinence vis-à-vis du texte recherché
+const SortByRelevance = "relevance"
+
+// Modes de correspondance d'un filtre par liste (ingrédients, équipements, catégories, tags)
+const (
+	MatchAny = "any" // La recette possède au moins un des éléments (défaut)
+	MatchAll = "all" // La recette possède tous les éléments
+)
+
 type SearchQuery struct {
 	Query        string   `json:"query" form:"query"`                 // Recherche textuelle générale
 	Ingredients  []string `json:"ingredients" form:"ingredients"`     // Liste d'ingrédients à filtrer
@@ -13,10 +22,22 @@ type SearchQuery struct {
 	MinRating    float64  `json:"min_rating" form:"min_rating"`         // Note minimale (1-5)
 	AuthorID     uint     `json:"author_id" form:"author_id"`           // ID de l'auteur de la recette
 
+	IngredientsMatch string `json:"ingredients_match" form:"ingredients_match"` // Correspondance des ingrédients : "any" ou "all"
+	EquipmentsMatch  string `json:"equipments_match" form:"equipments_match"`   // Correspondance des équipements : "any" ou "all"
+	CategoriesMatch  string `json:"categories_match" form:"categories_match"`   // Correspondance des catégories : "any" ou "all"
+	TagsMatch        string `json:"tags_match" form:"tags_match"`               // Correspondance des tags : "any" ou "all"
+
+	ExcludeIngredients []string `json:"exclude_ingredients" form:"exclude_ingredients"` // Ingrédients que la recette ne doit pas contenir
+	ExcludeTags        []string `json:"exclude_tags" form:"exclude_tags"`               // Tags que la recette ne doit pas porter
+	ExcludeEquipments  []string `json:"exclude_equipments" form:"exclude_equipments"`   // Équipements que la recette ne doit pas nécessiter
+
+	Diets            []string `json:"diets" form:"diets"`                         // Régimes auxquels la recette doit être compatible (tous requis)
+	ExcludeAllergens []string `json:"exclude_allergens" form:"exclude_allergens"` // Allergènes que la recette ne doit pas contenir
+
 	Page  int `json:"page" form:"page"`   // Numéro de la page pour la pagination
 	Limit int `json:"limit" form:"limit"` // Nombre de résultats par page
 
-	SortBy    string `json:"sort_by" form:"sort_by"`       // Champ de tri (ex: "created_at", "rating")
+	SortBy    string `json:"sort_by" form:"sort_by"`       // Champ de tri (ex: "created_at", "rating", "relevance")
 	SortOrder string `json:"sort_order" form:"sort_order"` // Ordre de tri (ex: "asc", "desc")
 }
 
@@ -28,4 +49,36 @@ type SearchResponse struct {
 
 	HasNext bool `json:"has_next"` // Indique s'il y a une page suivante
 	HasPrev bool `json:"has_prev"` // Indique s'il y a une page précédente
+
+	Facets *SearchFacets `json:"facets,omitempty"` // Compteurs par filtre, si demandés
+}
+
+// SearchFacets regroupe le nombre de résultats qu'obtiendrait chaque valeur de filtre.
+// Chaque facette est calculée sur les résultats filtrés, sans le filtre de la facette elle-même.
+type SearchFacets struct {
+	Tags       []FacetCount      `json:"tags"`
+	Categories []FacetCount      `json:"categories"`
+	Equipments []FacetCount      `json:"equipments"`
+	Difficulty []FacetValueCount `json:"difficulty"`
+	PrepTime   []TimeBucketCount `json:"prep_time"`  // Buckets cumulatifs correspondant à max_prep_time
+	TotalTime  []TimeBucketCount `json:"total_time"` // Buckets cumulatifs correspondant à max_total_time
+}
+
+// FacetCount représente le nombre de recettes liées à un tag, une catégorie ou un équipement
+type FacetCount struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// FacetValueCount représente le nombre de recettes ayant une valeur donnée (ex. difficulté)
+type FacetValueCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// TimeBucketCount représente le nombre de recettes dont la durée ne dépasse pas MaxMinutes
+type TimeBucketCount struct {
+	MaxMinutes int   `json:"max_minutes"`
+	Count      int64 `json:"count"`
 }
@@ -0,0 +1,104 @@
+package dto
+
+import "time"
+
+// Origine d'une ligne de liste de courses enregistrée
+const (
+	ShoppingListSourceMealPlan = "meal_plan" // Générée à partir des repas planifiés
+	ShoppingListSourceManual   = "manual"    // Ajoutée à la main
+)
+
+// ShoppingList représente une liste de courses enregistrée d'un utilisateur.
+// Elle peut être générée à partir des repas planifiés sur une période, puis complétée à la main.
+type ShoppingList struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"user_id" gorm:"not null;index"`
+	Name           string     `json:"name" gorm:"not null"`
+	StartDate      *time.Time `json:"start_date,omitempty" gorm:"type:date"` // Début de la période des repas planifiés
+	EndDate        *time.Time `json:"end_date,omitempty" gorm:"type:date"`   // Fin de la période des repas planifiés
+	SubtractFridge bool       `json:"subtract_fridge" gorm:"not null"`       // Déduire le stock du frigo à la génération
+	GeneratedAt    *time.Time `json:"generated_at,omitempty"`                // Dernière génération à partir des repas planifiés
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Magasin dont l'ordre des rayons est appliqué à la liste
+	StoreID *uint                    `json:"store_id,omitempty" gorm:"index"`
+	Aisles  []ShoppingListEntryAisle `json:"aisles,omitempty" gorm:"-"`
+
+	Items []ShoppingListEntry `json:"items" gorm:"foreignKey:ShoppingListID"`
+}
+
+// ShoppingListEntry représente une ligne d'une liste de courses enregistrée
+type ShoppingListEntry struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	ShoppingListID   uint       `json:"shopping_list_id" gorm:"not null;index"`
+	IngredientID     *uint      `json:"ingredient_id,omitempty"`     // Vide pour un article libre (ex: "sacs congélation")
+	Name             string     `json:"name" gorm:"not null"`        // Nom de l'ingrédient ou texte libre
+	Quantity         float64    `json:"quantity"`                    // Quantité calculée ou saisie
+	QuantityOverride *float64   `json:"quantity_override,omitempty"` // Quantité corrigée à la main (prioritaire)
+	Unit             string     `json:"unit"`                        // Unité de la quantité
+	Notes            string     `json:"notes,omitempty"`
+	Source           string     `json:"source" gorm:"type:varchar(20);not null"` // meal_plan ou manual
+	Checked          bool       `json:"checked" gorm:"default:false"`
+	CheckedAt        *time.Time `json:"checked_at,omitempty"`
+	PurchasedAt      *time.Time `json:"purchased_at,omitempty"` // Passée en caisse : masquée, mais déduite des besoins à la régénération
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// EffectiveQuantity retourne la quantité à acheter : la correction manuelle si elle existe
+func (e ShoppingListEntry) EffectiveQuantity() float64 {
+	if e.QuantityOverride != nil {
+		return *e.QuantityOverride
+	}
+	return e.Quantity
+}
+
+// ShoppingListCreateRequest représente les données pour créer une liste de courses.
+// Avec une période, la liste est générée à partir des repas planifiés.
+type ShoppingListCreateRequest struct {
+	Name           string `json:"name" binding:"required,min=1,max=100"`
+	StartDate      string `json:"start_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	EndDate        string `json:"end_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	SubtractFridge *bool  `json:"subtract_fridge,omitempty"` // Défaut: true
+	StoreID        *uint  `json:"store_id,omitempty"`        // Magasin pour l'ordre des rayons (défaut: magasin par défaut)
+}
+
+// ShoppingListUpdateRequest représente les données pour modifier une liste de courses
+type ShoppingListUpdateRequest struct {
+	Name           string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	SubtractFridge *bool  `json:"subtract_fridge,omitempty"`
+	StoreID        *uint  `json:"store_id,omitempty"` // 0 pour retirer le magasin
+}
+
+// ShoppingListRegenerateRequest représente une nouvelle génération, éventuellement sur une autre période
+type ShoppingListRegenerateRequest struct {
+	StartDate string `json:"start_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	EndDate   string `json:"end_date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// ShoppingListEntryCreateRequest représente un article ajouté à la main (ingrédient ou texte libre)
+type ShoppingListEntryCreateRequest struct {
+	IngredientID *uint   `json:"ingredient_id,omitempty"`
+	Name         string  `json:"name,omitempty" binding:"omitempty,max=100"` // Obligatoire sans ingrédient
+	Quantity     float64 `json:"quantity,omitempty" binding:"min=0"`
+	Unit         string  `json:"unit,omitempty" binding:"max=20"`
+	Notes        string  `json:"notes,omitempty" binding:"max=500"`
+}
+
+// ShoppingListEntryUpdateRequest représente la modification d'une ligne (cochage, quantité, texte)
+type ShoppingListEntryUpdateRequest struct {
+	Checked          *bool    `json:"checked,omitempty"`
+	QuantityOverride *float64 `json:"quantity_override,omitempty" binding:"omitempty,min=0"`
+	ResetQuantity    bool     `json:"reset_quantity,omitempty"` // Supprimer la correction manuelle de quantité
+	Name             string   `json:"name,omitempty" binding:"omitempty,max=100"`
+	Unit             *string  `json:"unit,omitempty" binding:"omitempty,max=20"`
+	Notes            *string  `json:"notes,omitempty" binding:"omitempty,max=500"`
+}
+
+// ShoppingListCheckout résume le passage en caisse : les articles cochés sont rangés dans le frigo
+type ShoppingListCheckout struct {
+	FridgeItems  []FridgeItem `json:"fridge_items"`  // Items du frigo créés
+	RemovedCount int          `json:"removed_count"` // Lignes cochées retirées de la liste (les lignes générées restent mémorisées comme achetées)
+	Unmatched    []string     `json:"unmatched"`     // Articles libres sans ingrédient correspondant, non rangés dans le frigo
+}
@@ -0,0 +1,81 @@
+package dto
+
+import "time"
+
+// Store représente un magasin d'un utilisateur et l'ordre de ses rayons
+type Store struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	Name      string    `json:"name" gorm:"not null"`
+	IsDefault bool      `json:"is_default"` // Magasin utilisé quand aucun n'est précisé
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Aisles []StoreAisle `json:"aisles" gorm:"foreignKey:StoreID"`
+}
+
+// StoreAisle représente un rayon d'un magasin, associé à des catégories d'ingrédients
+type StoreAisle struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	StoreID  uint   `json:"store_id" gorm:"not null;index"`
+	Name     string `json:"name" gorm:"not null"`
+	Position int    `json:"position"` // Ordre de passage dans le magasin
+
+	Categories []IngredientCategory `json:"categories" gorm:"many2many:store_aisle_categories;"`
+}
+
+// StoreIngredientPlacement mémorise le rayon où un utilisateur a trouvé un ingrédient dans un magasin.
+// Ces placements servent à ranger les ingrédients sans catégorie associée à un rayon.
+type StoreIngredientPlacement struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;index"`
+	StoreID      uint      `json:"store_id" gorm:"not null;uniqueIndex:idx_store_ingredient_placement"`
+	IngredientID uint      `json:"ingredient_id" gorm:"not null;uniqueIndex:idx_store_ingredient_placement"`
+	AisleID      uint      `json:"aisle_id" gorm:"not null;index"`
+	Count        int       `json:"count" gorm:"default:1"` // Nombre de fois où l'ingrédient a été placé
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// StoreAisleRequest représente un rayon dans la description d'un magasin (l'ordre du tableau est l'ordre des rayons)
+type StoreAisleRequest struct {
+	ID          uint   `json:"id,omitempty"` // Rayon existant à conserver lors d'une modification
+	Name        string `json:"name" binding:"required,min=1,max=100"`
+	CategoryIDs []uint `json:"category_ids,omitempty"`
+}
+
+// StoreCreateRequest représente les données pour créer un magasin
+type StoreCreateRequest struct {
+	Name      string              `json:"name" binding:"required,min=1,max=100"`
+	IsDefault bool                `json:"is_default,omitempty"`
+	Aisles    []StoreAisleRequest `json:"aisles,omitempty" binding:"dive"`
+}
+
+// StoreUpdateRequest représente les données pour modifier un magasin.
+// Si aisles est fourni, il remplace l'ensemble des rayons.
+type StoreUpdateRequest struct {
+	Name      string               `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	IsDefault *bool                `json:"is_default,omitempty"`
+	Aisles    *[]StoreAisleRequest `json:"aisles,omitempty" binding:"omitempty,dive"`
+}
+
+// StorePlacementRequest indique le rayon où un ingrédient a été trouvé
+type StorePlacementRequest struct {
+	IngredientID uint `json:"ingredient_id" binding:"required"`
+	AisleID      uint `json:"aisle_id" binding:"required"`
+}
+
+// ShoppingListAisle regroupe les articles de la liste de courses hebdomadaire d'un même rayon
+type ShoppingListAisle struct {
+	AisleID  *uint              `json:"aisle_id,omitempty"` // Vide pour les articles sans rayon connu
+	Name     string             `json:"name"`
+	Position int                `json:"position"`
+	Items    []ShoppingListItem `json:"items"`
+}
+
+// ShoppingListEntryAisle regroupe les lignes d'une liste de courses enregistrée d'un même rayon
+type ShoppingListEntryAisle struct {
+	AisleID  *uint               `json:"aisle_id,omitempty"` // Vide pour les articles sans rayon connu
+	Name     string              `json:"name"`
+	Position int                 `json:"position"`
+	Items    []ShoppingListEntry `json:"items"`
+}
@@ -0,0 +1,109 @@
+package dto
+
+import "time"
+
+// Raisons pour lesquelles un ingrédient de recette appelle un remplacement
+const (
+	SubstitutionReasonMissing      = "missing"      // Absent du frigo
+	SubstitutionReasonInsufficient = "insufficient" // Présent en quantité insuffisante
+	SubstitutionReasonAllergen     = "allergen"     // Contient un allergène à éviter
+	SubstitutionReasonDiet         = "diet"         // Incompatible avec un régime demandé
+)
+
+// IngredientSubstitution représente une façon de remplacer un ingrédient par un ou plusieurs autres
+// (ex: 1 œuf → 1 c. à soupe de graines de lin + 3 c. à soupe d'eau). Les quantités des composants
+// correspondent à la quantité de référence de l'ingrédient remplacé et sont proportionnelles.
+type IngredientSubstitution struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	IngredientID uint       `json:"ingredient_id" gorm:"not null;index"`  // Ingrédient remplacé (et ses variantes)
+	Quantity     float64    `json:"quantity" gorm:"not null"`             // Quantité de référence de l'ingrédient remplacé
+	Unit         string     `json:"unit"`                                 // Unité de la quantité de référence
+	Notes        string     `json:"notes,omitempty"`                      // Conseils d'utilisation (ex: "laisser gonfler 10 min")
+	Diets        StringList `json:"diets" gorm:"type:jsonb;default:'[]'"` // Régimes pour lesquels le remplacement convient
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	Components []IngredientSubstitutionComponent `json:"components" gorm:"foreignKey:SubstitutionID"`
+}
+
+// IngredientSubstitutionComponent représente un ingrédient de remplacement et sa quantité
+type IngredientSubstitutionComponent struct {
+	ID             uint    `json:"id" gorm:"primaryKey"`
+	SubstitutionID uint    `json:"substitution_id" gorm:"not null;index"`
+	IngredientID   uint    `json:"ingredient_id" gorm:"not null"`
+	Quantity       float64 `json:"quantity"`
+	Unit           string  `json:"unit"`
+
+	Ingredient Ingredient `json:"ingredient" gorm:"foreignKey:IngredientID"`
+}
+
+// IngredientSubstitutionCreateRequest représente les données pour ajouter un remplacement à un ingrédient
+type IngredientSubstitutionCreateRequest struct {
+	Quantity   float64                                  `json:"quantity" binding:"required,gt=0"`
+	Unit       string                                   `json:"unit,omitempty" binding:"max=20"`
+	Notes      string                                   `json:"notes,omitempty" binding:"max=500"`
+	Diets      []string                                 `json:"diets,omitempty" binding:"omitempty,dive,oneof=vegan vegetarian pescatarian pork_free alcohol_free"`
+	Components []IngredientSubstitutionComponentRequest `json:"components" binding:"required,min=1,dive"`
+}
+
+// IngredientSubstitutionComponentRequest représente un ingrédient de remplacement à enregistrer
+type IngredientSubstitutionComponentRequest struct {
+	IngredientID uint    `json:"ingredient_id" binding:"required"`
+	Quantity     float64 `json:"quantity" binding:"required,gt=0"`
+	Unit         string  `json:"unit,omitempty" binding:"max=20"`
+}
+
+// RecipeSubstitutionQuery représente les critères de recherche de remplacements pour une recette
+type RecipeSubstitutionQuery struct {
+	AvoidAllergens []string `form:"avoid_allergens" binding:"omitempty,dive,oneof=gluten crustaceans eggs fish peanuts soybeans milk nuts celery mustard sesame sulphites lupin molluscs"` // Allergènes à éviter (dans la recette et dans les remplacements)
+	Diets          []string `form:"diets" binding:"omitempty,dive,oneof=vegan vegetarian pescatarian pork_free alcohol_free"`                                                              // Régimes à respecter (dans la recette et dans les remplacements)
+}
+
+// RecipeSubstitutions regroupe les remplacements proposés pour les ingrédients d'une recette
+type RecipeSubstitutions struct {
+	RecipeID    uint                            `json:"recipe_id"`
+	Ingredients []RecipeIngredientSubstitutions `json:"ingredients"`
+}
+
+// RecipeIngredientSubstitutions représente un ingrédient de recette à remplacer et les options proposées
+type RecipeIngredientSubstitutions struct {
+	RecipeIngredientID uint                 `json:"recipe_ingredient_id"`
+	IngredientID       uint                 `json:"ingredient_id"`
+	IngredientName     string               `json:"ingredient_name"`
+	Quantity           float64              `json:"quantity"`
+	Unit               string               `json:"unit"`
+	Reasons            []string             `json:"reasons"`             // missing, insufficient, allergen, diet
+	Allergens          []string             `json:"allergens,omitempty"` // Allergènes à éviter contenus par l'ingrédient
+	Options            []SubstitutionOption `json:"options"`
+}
+
+// SubstitutionOption représente un remplacement appliqué à la quantité demandée par la recette
+type SubstitutionOption struct {
+	SubstitutionID uint                  `json:"substitution_id"`
+	Notes          string                `json:"notes,omitempty"`
+	Diets          StringList            `json:"diets"`
+	Components     []SubstitutionPortion `json:"components"`
+	Available      bool                  `json:"available"`   // Tous les composants sont disponibles dans le frigo
+	Approximate    bool                  `json:"approximate"` // Quantité de la recette non convertible : quantités de référence
+}
+
+// SubstitutionPortion représente la quantité d'un ingrédient de remplacement pour la recette
+type SubstitutionPortion struct {
+	IngredientID   uint    `json:"ingredient_id"`
+	IngredientName string  `json:"ingredient_name"`
+	Quantity       float64 `json:"quantity"`
+	Unit           string  `json:"unit"`
+	InFridge       bool    `json:"in_fridge"`
+}
+
+// RecipeAdaptRequest représente les remplacements à appliquer à une copie adaptée d'une recette
+type RecipeAdaptRequest struct {
+	Title         string                     `json:"title,omitempty" binding:"omitempty,min=3,max=200"`
+	Substitutions []RecipeSubstitutionChoice `json:"substitutions" binding:"required,min=1,dive"`
+}
+
+// RecipeSubstitutionChoice associe un ingrédient de la recette au remplacement choisi
+type RecipeSubstitutionChoice struct {
+	RecipeIngredientID uint `json:"recipe_ingredient_id" binding:"required"`
+	SubstitutionID     uint `json:"substitution_id" binding:"required"`
+}
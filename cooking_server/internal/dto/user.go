@@ -9,6 +9,7 @@ type User struct {
 	Password string `json:"-" gorm:"not null"` // Exclure le mot de passe des réponses JSON
 	Avatar   string `json:"avatar"`
 	IsActive bool   `json:"is_active" gorm:"default:true"`
+	IsAdmin  bool   `json:"is_admin" gorm:"default:false"` // Droits de modération du référentiel partagé (ingrédients)
 
 	// Réinitialisation du mot de passe : token à usage unique + expiration (jamais exposés en JSON)
 	ResetToken          string     `json:"-" gorm:"index"`
@@ -0,0 +1,239 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+)
+
+// Formats de date iCalendar (RFC 5545)
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405"
+	icalUTC      = "20060102T150405Z"
+)
+
+// icalLineLength est la longueur maximale d'une ligne iCalendar en octets, hors fin de ligne
+const icalLineLength = 75
+
+// CalendarOptions regroupe les réglages du flux iCalendar du planning
+type CalendarOptions struct {
+	Name      string            // Nom du calendrier
+	Domain    string            // Domaine des identifiants d'événements
+	Location  *time.Location    // Fuseau horaire des heures de repas
+	MealTimes map[string]string // Heure (HH:MM) de chaque type de repas
+	Duration  time.Duration     // Durée des événements
+	RecipeURL func(uint) string // Lien vers une recette
+}
+
+// MealPlanCalendar exporte les repas planifiés au format iCalendar, un événement par repas
+func MealPlanCalendar(mealPlans []*dto.MealPlan, opts CalendarOptions) []byte {
+	var buf bytes.Buffer
+	writeICalLine(&buf, "BEGIN:VCALENDAR")
+	writeICalLine(&buf, "VERSION:2.0")
+	writeICalLine(&buf, "PRODID:-//cooking_server//Planning des repas//FR")
+	writeICalLine(&buf, "CALSCALE:GREGORIAN")
+	writeICalLine(&buf, "METHOD:PUBLISH")
+	writeICalLine(&buf, "X-WR-CALNAME:"+escapeICalText(opts.Name))
+	writeICalLine(&buf, "X-WR-TIMEZONE:"+opts.Location.String())
+
+	for _, mealPlan := range mealPlans {
+		start := mealStart(mealPlan, opts)
+		summary := mealPlan.Recipe.Title
+		if label, ok := mealTypeLabels[mealPlan.MealType]; ok {
+			summary = capitalize(label) + " : " + summary
+		}
+
+		var description []string
+		description = append(description, fmt.Sprintf("%d portion(s)", mealPlan.Servings))
+		if mealPlan.Notes != "" {
+			description = append(description, mealPlan.Notes)
+		}
+		url := ""
+		if opts.RecipeURL != nil {
+			url = opts.RecipeURL(mealPlan.RecipeID)
+			description = append(description, "Recette : "+url)
+		}
+
+		writeICalLine(&buf, "BEGIN:VEVENT")
+		writeICalLine(&buf, fmt.Sprintf("UID:meal-plan-%d@%s", mealPlan.ID, opts.Domain))
+		writeICalLine(&buf, "DTSTAMP:"+mealPlan.UpdatedAt.UTC().Format(icalUTC))
+		writeICalLine(&buf, "DTSTART:"+start.UTC().Format(icalUTC))
+		writeICalLine(&buf, "DTEND:"+start.Add(opts.Duration).UTC().Format(icalUTC))
+		writeICalLine(&buf, "SUMMARY:"+escapeICalText(summary))
+		writeICalLine(&buf, "DESCRIPTION:"+escapeICalText(strings.Join(description, "\n")))
+		if url != "" {
+			writeICalLine(&buf, "URL:"+url)
+		}
+		writeICalLine(&buf, "END:VEVENT")
+	}
+
+	writeICalLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// ParseCalendar lit les événements d'un fichier iCalendar. Les heures sans fuseau sont lues dans location.
+// Un titre préfixé par un type de repas (ex. "Dîner : Gratin", comme dans le flux exporté) renseigne MealType.
+func ParseCalendar(r io.Reader, location *time.Location) ([]dto.MealPlanCalendarEvent, error) {
+	lines, err := unfoldICalLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("not an iCalendar file")
+	}
+
+	var events []dto.MealPlanCalendarEvent
+	var current *dto.MealPlanCalendarEvent
+	hasStart := false
+	for _, line := range lines {
+		name, params, value, ok := splitICalLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current, hasStart = &dto.MealPlanCalendarEvent{}, false
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if current != nil && hasStart && current.Title != "" {
+				events = append(events, *current)
+			}
+			current = nil
+		case current == nil:
+			continue
+		case name == "SUMMARY":
+			current.Title, current.MealType = splitMealTypeLabel(unescapeICalText(value))
+		case name == "DTSTART":
+			start, allDay, err := parseICalTime(value, params, location)
+			if err != nil {
+				return nil, err
+			}
+			current.Start, current.AllDay, hasStart = start, allDay, true
+		}
+	}
+	return events, nil
+}
+
+// mealStart retourne le début d'un repas : le jour planifié à l'heure de son type de repas
+func mealStart(mealPlan *dto.MealPlan, opts CalendarOptions) time.Time {
+	hour, minute := 12, 0
+	if clock, err := time.Parse("15:04", opts.MealTimes[mealPlan.MealType]); err == nil {
+		hour, minute = clock.Hour(), clock.Minute()
+	}
+	date := mealPlan.PlannedDate
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, opts.Location)
+}
+
+// splitMealTypeLabel sépare le type de repas éventuel du titre d'un événement
+func splitMealTypeLabel(summary string) (string, string) {
+	summary = strings.TrimSpace(summary)
+	prefix, title, found := strings.Cut(summary, ":")
+	if !found {
+		return summary, ""
+	}
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	for mealType, label := range mealTypeLabels {
+		if prefix == label || prefix == mealType {
+			return strings.TrimSpace(title), mealType
+		}
+	}
+	return summary, ""
+}
+
+// parseICalTime lit une date (VALUE=DATE), une heure UTC, une heure avec TZID ou une heure locale
+func parseICalTime(value string, params map[string]string, location *time.Location) (time.Time, bool, error) {
+	if tzid, ok := params["TZID"]; ok {
+		if tz, err := time.LoadLocation(strings.Trim(tzid, `"`)); err == nil {
+			location = tz
+		}
+	}
+	switch {
+	case len(value) == len(icalDate):
+		date, err := time.ParseInLocation(icalDate, value, location)
+		return date, true, err
+	case strings.HasSuffix(value, "Z"):
+		date, err := time.Parse(icalUTC, value)
+		return date, false, err
+	default:
+		date, err := time.ParseInLocation(icalDateTime, value, location)
+		return date, false, err
+	}
+}
+
+// unfoldICalLines lit les lignes d'un fichier iCalendar en recollant les lignes repliées
+func unfoldICalLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitICalLine sépare le nom, les paramètres et la valeur d'une propriété (NOM;PARAM=X:valeur)
+func splitICalLine(line string) (string, map[string]string, string, bool) {
+	head, value, found := strings.Cut(line, ":")
+	if !found {
+		return "", nil, "", false
+	}
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		if key, val, ok := strings.Cut(part, "="); ok {
+			params[strings.ToUpper(key)] = val
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+// escapeICalText échappe une valeur texte iCalendar
+func escapeICalText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// unescapeICalText décode une valeur texte iCalendar
+func unescapeICalText(value string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(value)
+}
+
+// writeICalLine écrit une ligne iCalendar terminée par CRLF, repliée tous les 75 octets
+// sans couper de caractère UTF-8
+func writeICalLine(buf *bytes.Buffer, line string) {
+	limit := icalLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// L'espace de continuation compte dans la longueur des lignes suivantes
+		limit = icalLineLength - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+// capitalize met en majuscule la première lettre d'un texte
+func capitalize(text string) string {
+	r, size := utf8.DecodeRuneInString(text)
+	if r == utf8.RuneError {
+		return text
+	}
+	return strings.ToUpper(string(r)) + text[size:]
+}
@@ -0,0 +1,228 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+)
+
+func TestWriteICalLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"ligne courte", "SUMMARY:Gratin"},
+		{"exactement 75 octets", "SUMMARY:" + strings.Repeat("a", 67)},
+		{"ligne repliée une fois", "SUMMARY:" + strings.Repeat("a", 100)},
+		{"ligne repliée plusieurs fois", "DESCRIPTION:" + strings.Repeat("b", 300)},
+		{"caractères multi-octets", "SUMMARY:" + strings.Repeat("é", 80)},
+		{"caractère à cheval sur la limite", "SUMMARY:" + strings.Repeat("a", 66) + strings.Repeat("€", 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeICalLine(&buf, tt.line)
+			output := buf.String()
+
+			if !strings.HasSuffix(output, "\r\n") {
+				t.Fatalf("output %q does not end with CRLF", output)
+			}
+			physical := strings.Split(strings.TrimSuffix(output, "\r\n"), "\r\n")
+			for i, line := range physical {
+				if len(line) > icalLineLength {
+					t.Errorf("line %d is %d octets long, want at most %d", i, len(line), icalLineLength)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a UTF-8 character: %q", i, line)
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d does not start with a space: %q", i, line)
+				}
+			}
+			if want := (len(tt.line) > icalLineLength); want != (len(physical) > 1) {
+				t.Errorf("folded into %d lines for a %d-octet line", len(physical), len(tt.line))
+			}
+
+			unfolded, err := unfoldICalLines(strings.NewReader(output))
+			if err != nil {
+				t.Fatalf("unfoldICalLines: %v", err)
+			}
+			if len(unfolded) != 1 || unfolded[0] != tt.line {
+				t.Errorf("unfolded = %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
+
+func TestICalTextEscaping(t *testing.T) {
+	tests := []struct {
+		text    string
+		escaped string
+	}{
+		{"Gratin", "Gratin"},
+		{"Pâtes, sauce tomate", `Pâtes\, sauce tomate`},
+		{"Entrée; plat", `Entrée\; plat`},
+		{"2 portion(s)\nRecette : https://example.com", `2 portion(s)\nRecette : https://example.com`},
+		{`C:\chemin`, `C:\\chemin`},
+		{"ligne\r\nsuivante", `ligne\nsuivante`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeICalText(tt.text); got != tt.escaped {
+			t.Errorf("escapeICalText(%q) = %q, want %q", tt.text, got, tt.escaped)
+		}
+		want := strings.ReplaceAll(tt.text, "\r\n", "\n")
+		if got := unescapeICalText(tt.escaped); got != want {
+			t.Errorf("unescapeICalText(%q) = %q, want %q", tt.escaped, got, want)
+		}
+	}
+}
+
+func TestParseCalendar(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		want   []dto.MealPlanCalendarEvent
+		hasErr bool
+	}{
+		{
+			name:  "heure UTC et type de repas",
+			input: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:20240105T190000Z\r\nSUMMARY:Dîner : Gratin dauphinois\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			want: []dto.MealPlanCalendarEvent{
+				{Title: "Gratin dauphinois", Start: time.Date(2024, 1, 5, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+			},
+		},
+		{
+			name:  "journée entière",
+			input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20240106\nSUMMARY:Pique-nique\nEND:VEVENT\nEND:VCALENDAR\n",
+			want: []dto.MealPlanCalendarEvent{
+				{Title: "Pique-nique", Start: time.Date(2024, 1, 6, 0, 0, 0, 0, paris), AllDay: true},
+			},
+		},
+		{
+			name:  "fuseau horaire explicite",
+			input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;TZID=America/New_York:20240107T080000\nSUMMARY:breakfast: Pancakes\nEND:VEVENT\nEND:VCALENDAR\n",
+			want: []dto.MealPlanCalendarEvent{
+				{Title: "Pancakes", Start: time.Date(2024, 1, 7, 13, 0, 0, 0, time.UTC), MealType: "breakfast"},
+			},
+		},
+		{
+			name:  "heure locale, titre replié et échappé",
+			input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20240108T123000\nSUMMARY:Salade de lentilles\\, feta et \n herbes\nEND:VEVENT\nEND:VCALENDAR\n",
+			want: []dto.MealPlanCalendarEvent{
+				{Title: "Salade de lentilles, feta et herbes", Start: time.Date(2024, 1, 8, 12, 30, 0, 0, paris)},
+			},
+		},
+		{
+			name:  "préfixe qui n'est pas un type de repas",
+			input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20240109T200000Z\nSUMMARY:Rappel : acheter du pain\nEND:VEVENT\nEND:VCALENDAR\n",
+			want: []dto.MealPlanCalendarEvent{
+				{Title: "Rappel : acheter du pain", Start: time.Date(2024, 1, 9, 20, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:  "événements sans début ou sans titre ignorés",
+			input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Sans date\nEND:VEVENT\nBEGIN:VEVENT\nDTSTART:20240110T120000Z\nEND:VEVENT\nEND:VCALENDAR\n",
+			want:  nil,
+		},
+		{
+			name:   "pas un fichier iCalendar",
+			input:  "BEGIN:VCARD\nEND:VCARD\n",
+			hasErr: true,
+		},
+		{
+			name:   "date invalide",
+			input:  "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:2024-01-10\nSUMMARY:Gratin\nEND:VEVENT\nEND:VCALENDAR\n",
+			hasErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := ParseCalendar(strings.NewReader(tt.input), paris)
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("ParseCalendar() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCalendar() error = %v", err)
+			}
+			if len(events) != len(tt.want) {
+				t.Fatalf("ParseCalendar() returned %d events, want %d: %+v", len(events), len(tt.want), events)
+			}
+			for i, event := range events {
+				want := tt.want[i]
+				if event.Title != want.Title || event.MealType != want.MealType || event.AllDay != want.AllDay || !event.Start.Equal(want.Start) {
+					t.Errorf("event %d = %+v, want %+v", i, event, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMealPlanCalendarRoundTrip(t *testing.T) {
+	location := time.FixedZone("UTC+2", 2*60*60)
+	mealPlans := []*dto.MealPlan{
+		{
+			ID:          1,
+			RecipeID:    10,
+			PlannedDate: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+			MealType:    "dinner",
+			Servings:    4,
+			Notes:       "Doubler la sauce, sans oignons",
+			Recipe:      dto.Recipe{Title: "Lasagnes aux légumes d'été, ricotta et basilic frais du jardin"},
+		},
+		{
+			ID:          2,
+			RecipeID:    11,
+			PlannedDate: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+			MealType:    "lunch",
+			Servings:    2,
+			Recipe:      dto.Recipe{Title: "Taboulé"},
+		},
+	}
+	opts := CalendarOptions{
+		Name:      "Mes repas",
+		Domain:    "example.com",
+		Location:  location,
+		MealTimes: map[string]string{"dinner": "19:30"},
+		Duration:  time.Hour,
+		RecipeURL: func(id uint) string { return "https://example.com/recipes/" + strings.Repeat("x", int(id)) },
+	}
+
+	output := MealPlanCalendar(mealPlans, opts)
+	for i, line := range strings.Split(strings.TrimSuffix(string(output), "\r\n"), "\r\n") {
+		if len(line) > icalLineLength {
+			t.Errorf("line %d is %d octets long", i, len(line))
+		}
+	}
+
+	events, err := ParseCalendar(bytes.NewReader(output), time.UTC)
+	if err != nil {
+		t.Fatalf("ParseCalendar() error = %v", err)
+	}
+	want := []dto.MealPlanCalendarEvent{
+		{Title: mealPlans[0].Recipe.Title, MealType: "dinner", Start: time.Date(2024, 3, 4, 19, 30, 0, 0, location)},
+		{Title: "Taboulé", MealType: "lunch", Start: time.Date(2024, 3, 5, 12, 0, 0, 0, location)},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("round trip returned %d events, want %d", len(events), len(want))
+	}
+	for i, event := range events {
+		if event.Title != want[i].Title || event.MealType != want[i].MealType || event.AllDay || !event.Start.Equal(want[i].Start) {
+			t.Errorf("event %d = %+v, want %+v", i, event, want[i])
+		}
+	}
+}
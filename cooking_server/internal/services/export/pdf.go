@@ -0,0 +1,168 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+)
+
+// Mise en page A4 (en points) de l'export PDF
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+	pdfBoxSize    = 8.0 // Côté de la case à cocher devant chaque article
+)
+
+// pdfLine représente une ligne de texte à placer dans le document
+type pdfLine struct {
+	text   string
+	bold   bool
+	size   float64
+	indent float64
+	box    bool    // Dessiner une case à cocher devant la ligne
+	before float64 // Espace supplémentaire avant la ligne
+	y      float64 // Position verticale sur la page, calculée à la pagination
+}
+
+// shoppingListPDF exporte la liste en PDF imprimable, avec une case à cocher par article
+func shoppingListPDF(list *dto.WeeklyShoppingList, opts ShoppingListOptions) []byte {
+	lines := []pdfLine{{text: title(list), bold: true, size: 16}}
+	for _, group := range groupByCategory(list) {
+		lines = append(lines, pdfLine{text: group.name, bold: true, size: 12, before: 10})
+		for _, item := range group.items {
+			text := item.IngredientName + " — " + formatAmount(quantityToBuy(item), item.Unit)
+			lines = append(lines, wrapPDFLine(pdfLine{text: text, size: 11, indent: 16, box: true})...)
+			if opts.IncludeRecipes {
+				for _, ref := range item.Recipes {
+					lines = append(lines, wrapPDFLine(pdfLine{text: formatRecipeRef(ref), size: 9, indent: 32})...)
+				}
+			}
+		}
+	}
+	return renderPDF(paginate(lines))
+}
+
+// wrapPDFLine coupe une ligne trop longue pour la largeur de la page (largeur moyenne de l'Helvetica)
+func wrapPDFLine(line pdfLine) []pdfLine {
+	maxChars := int((pdfPageWidth - 2*pdfMargin - line.indent) / (line.size * 0.5))
+	words := strings.Fields(line.text)
+	var result []pdfLine
+	current := ""
+	for _, word := range words {
+		if current != "" && len([]rune(current))+1+len([]rune(word)) > maxChars {
+			next := line
+			next.text = current
+			result = append(result, next)
+			line.box, line.before = false, 0
+			current = word
+			continue
+		}
+		if current != "" {
+			current += " "
+		}
+		current += word
+	}
+	line.text = current
+	return append(result, line)
+}
+
+// paginate répartit les lignes sur des pages, chaque page portant les positions verticales de ses lignes
+func paginate(lines []pdfLine) [][]pdfLine {
+	var pages [][]pdfLine
+	var page []pdfLine
+	y := pdfPageHeight - pdfMargin
+	for _, line := range lines {
+		height := line.size*1.4 + line.before
+		if y-height < pdfMargin && len(page) > 0 {
+			pages = append(pages, page)
+			page = nil
+			y = pdfPageHeight - pdfMargin
+		}
+		y -= height
+		line.y = y
+		page = append(page, line)
+	}
+	return append(pages, page)
+}
+
+// renderPDF écrit un document PDF 1.4 minimal avec les polices standard Helvetica
+func renderPDF(pages [][]pdfLine) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1: catalogue, 2: arbre des pages, 3-4: polices, puis une page et son contenu par page
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for _, page := range pages {
+		var content bytes.Buffer
+		for _, line := range page {
+			x := pdfMargin + line.indent
+			if line.box {
+				fmt.Fprintf(&content, "0.5 w %.2f %.2f %.2f %.2f re S\n", x-pdfBoxSize-6, line.y, pdfBoxSize, pdfBoxSize)
+			}
+			font := "F1"
+			if line.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, line.size, x, line.y, pdfString(line.text))
+		}
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, len(offsets)+2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// winAnsiExtras associe les caractères hors Latin-1 courants à leur code WinAnsi
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '…': 0x85, 'Œ': 0x8C, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, 'œ': 0x9C, 'Ÿ': 0x9F,
+}
+
+// winAnsiFractions remplace les fractions absentes de WinAnsi (⅓, ⅔) par leur écriture en chiffres
+var winAnsiFractions = strings.NewReplacer("⅓", "1/3", "⅔", "2/3")
+
+// pdfString encode un texte en WinAnsi et échappe les caractères spéciaux des chaînes PDF
+func pdfString(text string) string {
+	var buf bytes.Buffer
+	for _, r := range winAnsiFractions.Replace(text) {
+		var b byte
+		switch code, ok := winAnsiExtras[r]; {
+		case ok:
+			b = code
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			b = byte(r)
+		default:
+			b = '?'
+		}
+		if b == '(' || b == ')' || b == '\\' {
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(b)
+	}
+	return buf.String()
+}
@@ -0,0 +1,193 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+)
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Farine", "Farine"},
+		{"Sauce (tomate)", `Sauce \(tomate\)`},
+		{`a\b`, `a\\b`},
+		{"Crème", "Cr\xe8me"},
+		{"Pommes — 3", "Pommes \x97 3"},
+		{"Œuf", "\x8cuf"},
+		{"5 €", "5 \x80"},
+		{"⅓ tasse", "1/3 tasse"},
+		{"½ c. à soupe", "\xbd c. \xe0 soupe"},
+		{"寿司", "??"},
+	}
+
+	for _, tt := range tests {
+		if got := pdfString(tt.text); got != tt.want {
+			t.Errorf("pdfString(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestWrapPDFLine(t *testing.T) {
+	long := strings.TrimSpace(strings.Repeat("farine ", 30))
+
+	tests := []struct {
+		name  string
+		line  pdfLine
+		lines int
+	}{
+		{"ligne courte", pdfLine{text: "Farine — 200 g", size: 11, indent: 16, box: true}, 1},
+		{"ligne longue", pdfLine{text: long, size: 11, indent: 16, box: true, before: 10}, 3},
+		{"petite police", pdfLine{text: long, size: 9, indent: 32}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := wrapPDFLine(tt.line)
+			if len(wrapped) != tt.lines {
+				t.Fatalf("wrapPDFLine() returned %d lines, want %d: %+v", len(wrapped), tt.lines, wrapped)
+			}
+
+			maxChars := int((pdfPageWidth - 2*pdfMargin - tt.line.indent) / (tt.line.size * 0.5))
+			var texts []string
+			for i, line := range wrapped {
+				if n := len([]rune(line.text)); n > maxChars {
+					t.Errorf("line %d has %d characters, want at most %d", i, n, maxChars)
+				}
+				// Seule la première ligne porte la case à cocher et l'espacement
+				if i > 0 && (line.box || line.before != 0) {
+					t.Errorf("continuation line %d keeps box=%v before=%v", i, line.box, line.before)
+				}
+				texts = append(texts, line.text)
+			}
+			if wrapped[0].box != tt.line.box || wrapped[0].before != tt.line.before {
+				t.Errorf("first line lost box or spacing: %+v", wrapped[0])
+			}
+			if got := strings.Join(texts, " "); got != tt.line.text {
+				t.Errorf("wrapped text = %q, want %q", got, tt.line.text)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines int
+		pages int
+	}{
+		{"aucune ligne", 0, 1},
+		{"une page", 10, 1},
+		{"page pleine", 48, 1},
+		{"débordement", 49, 2},
+		{"plusieurs pages", 150, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := make([]pdfLine, tt.lines)
+			for i := range lines {
+				lines[i] = pdfLine{text: fmt.Sprintf("ligne %d", i), size: 11}
+			}
+
+			pages := paginate(lines)
+			if len(pages) != tt.pages {
+				t.Fatalf("paginate() returned %d pages, want %d", len(pages), tt.pages)
+			}
+			total := 0
+			for p, page := range pages {
+				previous := pdfPageHeight
+				for _, line := range page {
+					if line.y < pdfMargin || line.y >= previous {
+						t.Errorf("page %d: line %q at y=%.2f is out of order or below the margin", p, line.text, line.y)
+					}
+					previous = line.y
+				}
+				total += len(page)
+			}
+			if total != tt.lines {
+				t.Errorf("paginate() kept %d lines, want %d", total, tt.lines)
+			}
+		})
+	}
+}
+
+func TestShoppingListPDF(t *testing.T) {
+	list := testShoppingList()
+	for i := 0; i < 120; i++ {
+		list.Items = append(list.Items, dto.ShoppingListItem{
+			IngredientName: fmt.Sprintf("Article %d", i),
+			Category:       "Vrac",
+			TotalQuantity:  1,
+			Unit:           "g",
+		})
+	}
+
+	tests := []struct {
+		name  string
+		list  *dto.WeeklyShoppingList
+		pages int
+	}{
+		{"une page", testShoppingList(), 1},
+		{"plusieurs pages", list, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := shoppingListPDF(tt.list, ShoppingListOptions{IncludeRecipes: true})
+
+			if !bytes.HasPrefix(document, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(document, []byte("%%EOF\n")) {
+				t.Fatalf("missing PDF header or trailer")
+			}
+			if count := fmt.Sprintf("/Count %d ", tt.pages); !bytes.Contains(document, []byte(count)) {
+				t.Errorf("page tree does not contain %q", count)
+			}
+			if got := bytes.Count(document, []byte("/Type /Page ")); got != tt.pages {
+				t.Errorf("document has %d pages, want %d", got, tt.pages)
+			}
+			checkPDFCrossReferences(t, document)
+		})
+	}
+}
+
+// checkPDFCrossReferences vérifie que la table xref pointe sur chaque objet et que les flux ont la bonne longueur
+func checkPDFCrossReferences(t *testing.T, document []byte) {
+	t.Helper()
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(document)
+	if match == nil {
+		t.Fatalf("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(document[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point to the xref table", xref)
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(document[xref:], -1)
+	if len(entries) == 0 {
+		t.Fatalf("empty xref table")
+	}
+	if size := fmt.Sprintf("/Size %d ", len(entries)+1); !bytes.Contains(document, []byte(size)) {
+		t.Errorf("trailer does not contain %q", size)
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if header := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(document[offset:], []byte(header)) {
+			t.Errorf("xref entry %d points to %q", i+1, document[offset:offset+10])
+		}
+	}
+
+	for _, stream := range regexp.MustCompile(`(?s)/Length (\d+) >>\nstream\n(.*?)endstream`).FindAllSubmatch(document, -1) {
+		length, _ := strconv.Atoi(string(stream[1]))
+		if length != len(stream[2]) {
+			t.Errorf("stream declares /Length %d but contains %d bytes", length, len(stream[2]))
+		}
+	}
+}
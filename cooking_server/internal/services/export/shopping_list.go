@@ -0,0 +1,216 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
+)
+
+// Formats d'export de la liste de courses
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatCSV      = "csv"
+	FormatPDF      = "pdf"
+)
+
+// Catégorie des ingrédients qui n'en ont pas
+const uncategorized = "Autres"
+
+// mealTypeLabels traduit les types de repas pour l'affichage
+var mealTypeLabels = map[string]string{
+	"breakfast": "petit-déjeuner",
+	"lunch":     "déjeuner",
+	"dinner":    "dîner",
+	"snack":     "en-cas",
+}
+
+// ShoppingListOptions regroupe les options d'export de la liste de courses
+type ShoppingListOptions struct {
+	IncludeRecipes bool // Détailler les repas qui utilisent chaque article
+}
+
+// categoryGroup regroupe les articles à acheter d'une même catégorie
+type categoryGroup struct {
+	name  string
+	items []dto.ShoppingListItem
+}
+
+// ShoppingList exporte la liste de courses hebdomadaire dans le format demandé.
+// Retourne le contenu et son type MIME.
+func ShoppingList(list *dto.WeeklyShoppingList, format string, opts ShoppingListOptions) ([]byte, string, error) {
+	switch format {
+	case FormatText:
+		return shoppingListText(list, opts), "text/plain; charset=utf-8", nil
+	case FormatMarkdown:
+		return shoppingListMarkdown(list, opts), "text/markdown; charset=utf-8", nil
+	case FormatCSV:
+		content, err := shoppingListCSV(list, opts)
+		return content, "text/csv; charset=utf-8", err
+	case FormatPDF:
+		return shoppingListPDF(list, opts), "application/pdf", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// FileName retourne le nom de fichier proposé pour un export de la liste de courses
+func FileName(list *dto.WeeklyShoppingList, format string) string {
+	extensions := map[string]string{FormatText: "txt", FormatMarkdown: "md", FormatCSV: "csv", FormatPDF: "pdf"}
+	return "liste-de-courses-" + list.StartDate.Format("2006-01-02") + "." + extensions[format]
+}
+
+// groupByCategory regroupe les articles restant à acheter par catégorie (ordre alphabétique,
+// "Autres" en dernier). Les articles entièrement couverts par le frigo sont ignorés.
+func groupByCategory(list *dto.WeeklyShoppingList) []categoryGroup {
+	index := make(map[string]int)
+	var groups []categoryGroup
+	for _, item := range list.Items {
+		if quantityToBuy(item) <= 0 && item.NetQuantity != nil {
+			continue
+		}
+		name := strings.TrimSpace(item.Category)
+		if name == "" {
+			name = uncategorized
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, categoryGroup{name: name})
+		}
+		groups[i].items = append(groups[i].items, item)
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		if (groups[a].name == uncategorized) != (groups[b].name == uncategorized) {
+			return groups[b].name == uncategorized
+		}
+		return units.NormalizeText(groups[a].name) < units.NormalizeText(groups[b].name)
+	})
+	return groups
+}
+
+// quantityToBuy retourne la quantité restant à acheter (nette si le frigo a été déduit)
+func quantityToBuy(item dto.ShoppingListItem) float64 {
+	if item.NetQuantity != nil {
+		return *item.NetQuantity
+	}
+	return item.TotalQuantity
+}
+
+// formatAmount affiche une quantité avec son unité ("1 ½ c. à soupe", "200 g")
+func formatAmount(quantity float64, unit string) string {
+	formatted := units.FormatQuantity(quantity, units.Parse(unit))
+	if unit == "" {
+		return formatted
+	}
+	return formatted + " " + unit
+}
+
+// formatRecipeRef décrit l'utilisation d'un article par un repas ("Gratin — lun. 01/01 dîner : 200 g")
+func formatRecipeRef(ref dto.ShoppingListRecipeRef) string {
+	when := ref.Date
+	if date, err := time.Parse("2006-01-02", ref.Date); err == nil {
+		when = date.Format("02/01")
+	}
+	if label, ok := mealTypeLabels[ref.MealType]; ok {
+		when += " " + label
+	}
+	return fmt.Sprintf("%s — %s : %s", ref.RecipeName, when, formatAmount(units.Round(ref.Quantity), ref.Unit))
+}
+
+// title retourne le titre de la liste avec sa période
+func title(list *dto.WeeklyShoppingList) string {
+	return fmt.Sprintf("Liste de courses du %s au %s", list.StartDate.Format("02/01/2006"), list.EndDate.Format("02/01/2006"))
+}
+
+// shoppingListText exporte la liste en texte brut, à cocher à la main
+func shoppingListText(list *dto.WeeklyShoppingList, opts ShoppingListOptions) []byte {
+	var buf bytes.Buffer
+	heading := title(list)
+	buf.WriteString(heading + "\n")
+	buf.WriteString(strings.Repeat("=", len([]rune(heading))) + "\n")
+
+	for _, group := range groupByCategory(list) {
+		buf.WriteString("\n" + strings.ToUpper(group.name) + "\n")
+		for _, item := range group.items {
+			fmt.Fprintf(&buf, "[ ] %s — %s\n", item.IngredientName, formatAmount(quantityToBuy(item), item.Unit))
+			if opts.IncludeRecipes {
+				for _, ref := range item.Recipes {
+					buf.WriteString("      · " + formatRecipeRef(ref) + "\n")
+				}
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// shoppingListMarkdown exporte la liste en Markdown avec des cases à cocher
+func shoppingListMarkdown(list *dto.WeeklyShoppingList, opts ShoppingListOptions) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# " + title(list) + "\n")
+
+	for _, group := range groupByCategory(list) {
+		buf.WriteString("\n## " + group.name + "\n\n")
+		for _, item := range group.items {
+			fmt.Fprintf(&buf, "- [ ] **%s** — %s\n", markdownEscape(item.IngredientName), formatAmount(quantityToBuy(item), item.Unit))
+			if opts.IncludeRecipes {
+				for _, ref := range item.Recipes {
+					buf.WriteString("  - " + markdownEscape(formatRecipeRef(ref)) + "\n")
+				}
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// markdownEscape neutralise les caractères de mise en forme Markdown
+func markdownEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, "`", "\\`", `[`, `\[`, `]`, `\]`).Replace(text)
+}
+
+// shoppingListCSV exporte la liste en CSV (une ligne par article)
+func shoppingListCSV(list *dto.WeeklyShoppingList, opts ShoppingListOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"Catégorie", "Ingrédient", "Quantité", "Unité"}
+	if opts.IncludeRecipes {
+		header = append(header, "Repas")
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, group := range groupByCategory(list) {
+		for _, item := range group.items {
+			record := []string{
+				group.name,
+				item.IngredientName,
+				strconv.FormatFloat(units.Round(quantityToBuy(item)), 'f', -1, 64),
+				item.Unit,
+			}
+			if opts.IncludeRecipes {
+				refs := make([]string, 0, len(item.Recipes))
+				for _, ref := range item.Recipes {
+					refs = append(refs, formatRecipeRef(ref))
+				}
+				record = append(record, strings.Join(refs, "; "))
+			}
+			if err := writer.Write(record); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+)
+
+// testShoppingList construit une liste couvrant les catégories, le frigo et les repas
+func testShoppingList() *dto.WeeklyShoppingList {
+	zero, half := 0.0, 0.5
+	return &dto.WeeklyShoppingList{
+		StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC),
+		Items: []dto.ShoppingListItem{
+			{
+				IngredientName: "Pommes",
+				Category:       "Fruits",
+				TotalQuantity:  3,
+				Unit:           "pièce",
+				Recipes: []dto.ShoppingListRecipeRef{
+					{RecipeName: "Tarte", Quantity: 3, Unit: "pièce", Date: "2024-01-02", MealType: "dinner"},
+				},
+			},
+			{IngredientName: "Sel", TotalQuantity: 1, Unit: "pincée"},
+			{
+				IngredientName: "Farine",
+				Category:       "Épicerie",
+				TotalQuantity:  250.004,
+				Unit:           "g",
+				Recipes: []dto.ShoppingListRecipeRef{
+					{RecipeName: "Tarte", Quantity: 200, Unit: "g", Date: "2024-01-02", MealType: "dinner"},
+					{RecipeName: "Crêpes, sucre", Quantity: 50.004, Unit: "g", Date: "2024-01-03", MealType: "breakfast"},
+				},
+			},
+			{IngredientName: "Lait", Category: "Crèmerie", TotalQuantity: 1, NetQuantity: &zero, Unit: "l"},
+			{IngredientName: "Beurre", Category: "Crèmerie", TotalQuantity: 1.5, NetQuantity: &half, Unit: "c. à soupe"},
+		},
+	}
+}
+
+func TestShoppingListCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ShoppingListOptions
+		want [][]string
+	}{
+		{
+			name: "articles à acheter par catégorie",
+			want: [][]string{
+				{"Catégorie", "Ingrédient", "Quantité", "Unité"},
+				{"Crèmerie", "Beurre", "0.5", "c. à soupe"},
+				{"Épicerie", "Farine", "250", "g"},
+				{"Fruits", "Pommes", "3", "pièce"},
+				{"Autres", "Sel", "1", "pincée"},
+			},
+		},
+		{
+			name: "avec le détail des repas",
+			opts: ShoppingListOptions{IncludeRecipes: true},
+			want: [][]string{
+				{"Catégorie", "Ingrédient", "Quantité", "Unité", "Repas"},
+				{"Crèmerie", "Beurre", "0.5", "c. à soupe", ""},
+				{"Épicerie", "Farine", "250", "g", "Tarte — 02/01 dîner : 200 g; Crêpes, sucre — 03/01 petit-déjeuner : 50 g"},
+				{"Fruits", "Pommes", "3", "pièce", "Tarte — 02/01 dîner : 3 pièce"},
+				{"Autres", "Sel", "1", "pincée", ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, contentType, err := ShoppingList(testShoppingList(), FormatCSV, tt.opts)
+			if err != nil {
+				t.Fatalf("ShoppingList() error = %v", err)
+			}
+			if contentType != "text/csv; charset=utf-8" {
+				t.Errorf("content type = %q", contentType)
+			}
+
+			records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+			if err != nil {
+				t.Fatalf("invalid CSV output: %v\n%s", err, content)
+			}
+			if !reflect.DeepEqual(records, tt.want) {
+				t.Errorf("records = %q, want %q", records, tt.want)
+			}
+		})
+	}
+}
+
+func TestShoppingListFormats(t *testing.T) {
+	tests := []struct {
+		format      string
+		contentType string
+		contains    []string
+		hasErr      bool
+	}{
+		{FormatText, "text/plain; charset=utf-8", []string{"Liste de courses du 01/01/2024 au 07/01/2024\n", "\nCRÈMERIE\n[ ] Beurre — ½ c. à soupe\n"}, false},
+		{FormatMarkdown, "text/markdown; charset=utf-8", []string{"# Liste de courses", "## Autres\n\n- [ ] **Sel** — 1 pincée\n"}, false},
+		{FormatPDF, "application/pdf", []string{"%PDF-1.4"}, false},
+		{"docx", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			content, contentType, err := ShoppingList(testShoppingList(), tt.format, ShoppingListOptions{})
+			if tt.hasErr {
+				if err == nil {
+					t.Fatalf("ShoppingList(%q) error = nil, want an error", tt.format)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ShoppingList(%q) error = %v", tt.format, err)
+			}
+			if contentType != tt.contentType {
+				t.Errorf("content type = %q, want %q", contentType, tt.contentType)
+			}
+			for _, fragment := range tt.contains {
+				if !strings.Contains(string(content), fragment) {
+					t.Errorf("output does not contain %q:\n%s", fragment, content)
+				}
+			}
+			if strings.Contains(string(content), "Lait") {
+				t.Errorf("item covered by the fridge should not be exported:\n%s", content)
+			}
+		})
+	}
+}
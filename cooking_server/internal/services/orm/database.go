@@ -5,6 +5,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/romainrodriguez/cooking_server/internal/dto"
 	"github.com/romainrodriguez/cooking_server/internal/services/orm/config"
 	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
 	"github.com/romainrodriguez/cooking_server/internal/services/orm/interfaces"
@@ -31,7 +32,21 @@ type ORMService struct {
 	CommentRepository          interfaces.CommentRepository
 	RecipeIngredientRepository interfaces.RecipeIngredientRepository
 	RecipeEquipmentRepository  interfaces.RecipeEquipmentRepository
+	RecipeRevisionRepository   interfaces.RecipeRevisionRepository
+	RecipeLineageRepository    interfaces.RecipeLineageRepository
+	RecipeNutritionRepository  interfaces.RecipeNutritionRepository
 	MealPlanRepository         interfaces.MealPlanRepository
+	FridgeRepository           interfaces.FridgeRepository
+
+	IngredientCategoryRepository     interfaces.IngredientCategoryRepository
+	IngredientSubstitutionRepository interfaces.IngredientSubstitutionRepository
+	ShoppingListRepository           interfaces.ShoppingListRepository
+	StoreRepository                  interfaces.StoreRepository
+	IngredientPriceRepository        interfaces.IngredientPriceRepository
+	MealPlanRecurrenceRepository     interfaces.MealPlanRecurrenceRepository
+	MealPlanTemplateRepository       interfaces.MealPlanTemplateRepository
+	MealPlanCalendarRepository       interfaces.MealPlanCalendarRepository
+	LeftoverRepository               interfaces.LeftoverRepository
 
 	// Nouveaux repositories pour favoris et listes
 	UserFavoriteRecipeRepository interfaces.UserFavoriteRecipeRepository
@@ -101,7 +116,20 @@ func (s *ORMService) initRepositories() {
 	s.CommentRepository = repositories.NewCommentRepository(s.db)
 	s.RecipeIngredientRepository = repositories.NewRecipeIngredientRepository(s.db)
 	s.RecipeEquipmentRepository = repositories.NewRecipeEquipmentRepository(s.db)
+	s.RecipeRevisionRepository = repositories.NewRecipeRevisionRepository(s.db)
+	s.RecipeLineageRepository = repositories.NewRecipeLineageRepository(s.db)
+	s.RecipeNutritionRepository = repositories.NewRecipeNutritionRepository(s.db)
 	s.MealPlanRepository = repositories.NewMealPlanRepository(s.db)
+	s.FridgeRepository = repositories.NewFridgeRepository(s.db)
+	s.IngredientCategoryRepository = repositories.NewIngredientCategoryRepository(s.db)
+	s.IngredientSubstitutionRepository = repositories.NewIngredientSubstitutionRepository(s.db)
+	s.ShoppingListRepository = repositories.NewShoppingListRepository(s.db)
+	s.StoreRepository = repositories.NewStoreRepository(s.db)
+	s.IngredientPriceRepository = repositories.NewIngredientPriceRepository(s.db)
+	s.MealPlanRecurrenceRepository = repositories.NewMealPlanRecurrenceRepository(s.db)
+	s.MealPlanTemplateRepository = repositories.NewMealPlanTemplateRepository(s.db)
+	s.MealPlanCalendarRepository = repositories.NewMealPlanCalendarRepository(s.db)
+	s.LeftoverRepository = repositories.NewLeftoverRepository(s.db)
 
 	// Nouveaux repositories
 	s.UserFavoriteRecipeRepository = repositories.NewUserFavoriteRecipeRepository(s.db)
@@ -111,7 +139,21 @@ func (s *ORMService) initRepositories() {
 
 // Migrate exécute les migrations de la base de données
 func (s *ORMService) Migrate() error {
-	return s.migrationService.RunMigrations()
+	// Les étiquettes alimentaires ne sont calculées qu'à l'enregistrement d'une recette :
+	// lors de l'ajout des colonnes, les calculer pour les recettes existantes
+	backfillLabels := !s.db.Migrator().HasColumn(&dto.Recipe{}, "Diets")
+
+	if err := s.migrationService.RunMigrations(); err != nil {
+		return err
+	}
+
+	if backfillLabels {
+		if err := s.RecipeRepository.RefreshAllDietaryLabels(context.Background()); err != nil {
+			return err
+		}
+		log.Println("Recipe dietary labels backfilled")
+	}
+	return nil
 }
 
 // SeedData insère des données de démonstration
@@ -154,6 +196,16 @@ func (s *ORMService) Transaction(fn func(*gorm.DB) error) error {
 	})
 }
 
+// WithTransaction exécute une fonction avec des repositories liés à une même transaction :
+// toutes les écritures sont validées ensemble ou annulées à la première erreur, retournée telle quelle
+func (s *ORMService) WithTransaction(ctx context.Context, fn func(tx *ORMService) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txService := &ORMService{db: tx, config: s.config, migrationService: s.migrationService}
+		txService.initRepositories()
+		return fn(txService)
+	})
+}
+
 // HealthCheck vérifie l'état de la connexion à la base de données
 func (s *ORMService) HealthCheck(ctx context.Context) error {
 	sqlDB, err := s.db.DB()
@@ -27,10 +27,14 @@ type RecipeRepository interface {
 	Delete(ctx context.Context, id uint) error
 	List(ctx context.Context, limit, offset int) ([]*dto.Recipe, int64, error)
 	Search(ctx context.Context, searchReq *dto.SearchQuery) ([]*dto.Recipe, int64, error)
+	SearchFacets(ctx context.Context, searchReq *dto.SearchQuery) (*dto.SearchFacets, error)
 	Copy(ctx context.Context, originalRecipeID, newAuthorID uint) (*dto.Recipe, error)
 	GetPublicRecipes(ctx context.Context, limit, offset int) ([]*dto.Recipe, int64, error)
 	GetPublicRecipesByRating(ctx context.Context, limit, offset int) ([]*dto.Recipe, int64, error)
 	UpdateRecipeRating(ctx context.Context, recipeID uint) error
+	RefreshDietaryLabels(ctx context.Context, recipeID uint) error
+	RefreshAllDietaryLabels(ctx context.Context) error
+	GetScaled(ctx context.Context, id uint, req *dto.RecipeScaleRequest) (*dto.ScaledRecipe, error)
 }
 
 // IngredientRepository définit les opérations CRUD pour les ingrédients
@@ -42,6 +46,31 @@ type IngredientRepository interface {
 	Delete(ctx context.Context, id uint) error
 	List(ctx context.Context, limit, offset int) ([]*dto.Ingredient, int64, error)
 	SearchByName(ctx context.Context, name string, limit int) ([]*dto.Ingredient, error)
+	GetAliases(ctx context.Context, ingredientID uint) ([]dto.IngredientAlias, error)
+	AddAlias(ctx context.Context, alias *dto.IngredientAlias) error
+	DeleteAlias(ctx context.Context, ingredientID, aliasID uint) error
+	ApplyDisplayNames(ctx context.Context, locale string, ingredients []*dto.Ingredient) error
+	Merge(ctx context.Context, req *dto.IngredientMergeRequest) (*dto.IngredientMergeResult, error)
+	FindDuplicateCandidates(ctx context.Context, threshold float64, limit int) ([]dto.IngredientDuplicateCandidate, error)
+	GetHierarchy(ctx context.Context, id uint) (*dto.IngredientHierarchy, error)
+}
+
+// IngredientCategoryRepository définit les opérations CRUD pour les catégories d'ingrédients
+type IngredientCategoryRepository interface {
+	Create(ctx context.Context, category *dto.IngredientCategory) error
+	GetByID(ctx context.Context, id uint) (*dto.IngredientCategory, error)
+	Update(ctx context.Context, category *dto.IngredientCategory) error
+	Delete(ctx context.Context, id uint) error
+	List(ctx context.Context) ([]dto.IngredientCategory, error)
+}
+
+// IngredientSubstitutionRepository définit les opérations sur les remplacements d'ingrédients
+type IngredientSubstitutionRepository interface {
+	Create(ctx context.Context, substitution *dto.IngredientSubstitution) error
+	GetByIngredient(ctx context.Context, ingredientID uint) ([]dto.IngredientSubstitution, error)
+	Delete(ctx context.Context, ingredientID, substitutionID uint) error
+	SuggestForRecipe(ctx context.Context, recipeID, userID uint, query *dto.RecipeSubstitutionQuery) (*dto.RecipeSubstitutions, error)
+	AdaptRecipe(ctx context.Context, recipeID, userID uint, req *dto.RecipeAdaptRequest) (*dto.Recipe, error)
 }
 
 // EquipmentRepository définit les opérations CRUD pour les équipements
@@ -105,6 +134,28 @@ type RecipeEquipmentRepository interface {
 	DeleteByRecipe(ctx context.Context, recipeID uint) error
 }
 
+// RecipeRevisionRepository définit les opérations sur l'historique des révisions de recettes
+type RecipeRevisionRepository interface {
+	EnsureInitial(ctx context.Context, recipeID, authorID uint) error
+	Record(ctx context.Context, recipeID, authorID uint, note string) (*dto.RecipeRevision, error)
+	GetByRecipe(ctx context.Context, recipeID uint) ([]*dto.RecipeRevision, error)
+	GetByNumber(ctx context.Context, recipeID uint, number int) (*dto.RecipeRevision, error)
+	Diff(ctx context.Context, recipeID uint, from, to int) (*dto.RecipeRevisionDiff, error)
+	Restore(ctx context.Context, recipeID uint, number int, authorID uint) (*dto.RecipeRevision, error)
+}
+
+// RecipeLineageRepository définit les opérations sur la filiation des recettes copiées
+type RecipeLineageRepository interface {
+	GetForkTree(ctx context.Context, recipeID, viewerID uint) (*dto.RecipeForkNode, error)
+	GetUpstreamChanges(ctx context.Context, copyID uint) (*dto.RecipeUpstreamChanges, error)
+	PullUpstreamChanges(ctx context.Context, copyID, authorID uint, req *dto.RecipeUpstreamPullRequest) (*dto.RecipeUpstreamChanges, error)
+}
+
+// RecipeNutritionRepository définit le calcul des valeurs nutritionnelles des recettes
+type RecipeNutritionRepository interface {
+	GetRecipeNutrition(ctx context.Context, recipeID uint) (*dto.RecipeNutrition, error)
+}
+
 // MealPlanRepository définit les opérations CRUD pour le planning de repas
 type MealPlanRepository interface {
 	Create(ctx context.Context, mealPlan *dto.MealPlan) error
@@ -114,9 +165,99 @@ type MealPlanRepository interface {
 	GetByUserAndDate(ctx context.Context, userID uint, date time.Time) ([]*dto.MealPlan, error)
 	Update(ctx context.Context, mealPlan *dto.MealPlan) error
 	Delete(ctx context.Context, id uint) error
-	MarkAsCompleted(ctx context.Context, id uint) error
+	MarkAsCompleted(ctx context.Context, id, userID uint) (*dto.FridgeConsumption, error)
+	UndoCompletion(ctx context.Context, id, userID uint, undoToken string) (bool, error)
 	GetUpcomingMeals(ctx context.Context, userID uint, days int) ([]*dto.MealPlan, error)
-	GetWeeklyShoppingList(ctx context.Context, userID uint, startDate, endDate time.Time) (*dto.WeeklyShoppingList, error)
+	GetWeeklyShoppingList(ctx context.Context, userID uint, startDate, endDate time.Time, opts dto.ShoppingListOptions) (*dto.WeeklyShoppingList, error)
+
+	// Opérations groupées sur le calendrier
+	CopyRange(ctx context.Context, userID uint, startDate, endDate, targetDate time.Time) (int, error)
+	ShiftRange(ctx context.Context, userID uint, startDate, endDate time.Time, days int) (int, error)
+	Swap(ctx context.Context, userID, firstID, secondID uint) ([]*dto.MealPlan, error)
+	ClearRange(ctx context.Context, userID uint, startDate, endDate time.Time, includeCompleted bool) (int, error)
+
+	// Génération automatique sous contraintes
+	Generate(ctx context.Context, userID uint, startDate, endDate time.Time, req *dto.MealPlanGenerateRequest) (*dto.MealPlanGenerationPreview, error)
+	RerollSlot(ctx context.Context, userID uint, req *dto.MealPlanRerollRequest) (*dto.MealPlanGenerationSlot, error)
+	CreateGenerated(ctx context.Context, userID uint, choices []dto.MealPlanGenerationChoice) (int, error)
+}
+
+// MealPlanRecurrenceRepository définit les opérations sur les règles de repas récurrents
+type MealPlanRecurrenceRepository interface {
+	Create(ctx context.Context, recurrence *dto.MealPlanRecurrence) error
+	GetByID(ctx context.Context, id uint) (*dto.MealPlanRecurrence, error)
+	GetByUser(ctx context.Context, userID uint) ([]*dto.MealPlanRecurrence, error)
+	Update(ctx context.Context, recurrence *dto.MealPlanRecurrence) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// MealPlanTemplateRepository définit les opérations sur les semaines types
+type MealPlanTemplateRepository interface {
+	Create(ctx context.Context, template *dto.MealPlanTemplate) error
+	GetByID(ctx context.Context, id uint) (*dto.MealPlanTemplate, error)
+	GetByUser(ctx context.Context, userID uint) ([]*dto.MealPlanTemplate, error)
+	Update(ctx context.Context, template *dto.MealPlanTemplate, items *[]dto.MealPlanTemplateItem) error
+	Delete(ctx context.Context, id uint) error
+	Apply(ctx context.Context, id, userID uint, startDate time.Time) ([]*dto.MealPlan, error)
+}
+
+// MealPlanCalendarRepository définit les opérations sur les flux iCalendar du planning
+type MealPlanCalendarRepository interface {
+	GetOrCreateByUser(ctx context.Context, userID uint) (*dto.MealPlanCalendar, error)
+	GetByToken(ctx context.Context, token string) (*dto.MealPlanCalendar, error)
+	Update(ctx context.Context, calendar *dto.MealPlanCalendar) error
+	RegenerateToken(ctx context.Context, calendar *dto.MealPlanCalendar) error
+	Import(ctx context.Context, calendar *dto.MealPlanCalendar, events []dto.MealPlanCalendarEvent, defaultMealType string) (*dto.MealPlanCalendarImportResult, error)
+}
+
+// ShoppingListRepository définit les opérations sur les listes de courses enregistrées
+type ShoppingListRepository interface {
+	Create(ctx context.Context, list *dto.ShoppingList) error
+	GetByID(ctx context.Context, id uint) (*dto.ShoppingList, error)
+	GetByUser(ctx context.Context, userID uint) ([]*dto.ShoppingList, error)
+	Update(ctx context.Context, list *dto.ShoppingList) error
+	Delete(ctx context.Context, id uint) error
+	Regenerate(ctx context.Context, id uint) (*dto.ShoppingList, error)
+	AddEntry(ctx context.Context, entry *dto.ShoppingListEntry) error
+	GetEntry(ctx context.Context, listID, entryID uint) (*dto.ShoppingListEntry, error)
+	UpdateEntry(ctx context.Context, entry *dto.ShoppingListEntry) error
+	DeleteEntry(ctx context.Context, listID, entryID uint) error
+	Checkout(ctx context.Context, id uint) (*dto.ShoppingListCheckout, error)
+}
+
+// StoreRepository définit les opérations sur les magasins et l'ordre de leurs rayons
+type StoreRepository interface {
+	Create(ctx context.Context, store *dto.Store) error
+	GetByID(ctx context.Context, id uint) (*dto.Store, error)
+	GetByUser(ctx context.Context, userID uint) ([]*dto.Store, error)
+	Update(ctx context.Context, store *dto.Store, aisles *[]dto.StoreAisle) error
+	Delete(ctx context.Context, id uint) error
+	RecordPlacement(ctx context.Context, storeID, userID uint, req *dto.StorePlacementRequest) (*dto.StoreIngredientPlacement, error)
+}
+
+// IngredientPriceRepository définit les opérations sur les prix relevés et les estimations de coût
+type IngredientPriceRepository interface {
+	Create(ctx context.Context, price *dto.IngredientPrice) error
+	GetByID(ctx context.Context, id uint) (*dto.IngredientPrice, error)
+	GetByUser(ctx context.Context, userID uint, ingredientID, storeID *uint) ([]*dto.IngredientPrice, error)
+	Delete(ctx context.Context, id uint) error
+	RecipeCost(ctx context.Context, recipeID, userID uint, query *dto.CostQuery) (*dto.CostEstimate, error)
+	MealPlanCost(ctx context.Context, mealPlanID, userID uint, query *dto.CostQuery) (*dto.CostEstimate, error)
+}
+
+// FridgeRepository définit les opérations de lecture sur le frigo des utilisateurs
+type FridgeRepository interface {
+	GetByUser(ctx context.Context, userID uint) ([]*dto.FridgeItem, error)
+	GetRecipeSuggestions(ctx context.Context, userID uint, req *dto.RecipeSearchByIngredientsRequest) ([]dto.RecipeSuggestion, int, error)
+}
+
+// LeftoverRepository définit les opérations sur les restes de repas conservés au frigo
+type LeftoverRepository interface {
+	Create(ctx context.Context, leftover *dto.Leftover) error
+	GetByID(ctx context.Context, id uint) (*dto.Leftover, error)
+	GetByUser(ctx context.Context, userID uint, includeFinished bool) ([]*dto.Leftover, error)
+	Delete(ctx context.Context, id uint) error
+	Schedule(ctx context.Context, id uint, mealPlan *dto.MealPlan) error
 }
 
 // UserFavoriteRecipeRepository définit les opérations pour les recettes favorites
@@ -27,14 +27,33 @@ func (m *MigrationService) RunMigrations() error {
 		&dto.User{},
 		&dto.Category{},
 		&dto.Tag{},
+		&dto.IngredientCategory{},
 		&dto.Ingredient{},
+		&dto.IngredientAlias{},
+		&dto.IngredientSubstitution{},
+		&dto.IngredientSubstitutionComponent{},
 		&dto.Equipment{},
 		&dto.Recipe{},
 		&dto.RecipeIngredient{},
 		&dto.RecipeEquipment{},
 		&dto.RecipeTag{},
+		&dto.RecipeRevision{},
 		&dto.Comment{},
 		&dto.MealPlan{},
+		&dto.MealPlanRecurrence{},
+		&dto.MealPlanRecurrenceSkip{},
+		&dto.MealPlanTemplate{},
+		&dto.MealPlanTemplateItem{},
+		&dto.MealPlanCalendar{},
+		&dto.Leftover{},
+		&dto.FridgeConsumption{},
+		&dto.FridgeConsumptionItem{},
+		&dto.Store{},
+		&dto.StoreAisle{},
+		&dto.StoreIngredientPlacement{},
+		&dto.IngredientPrice{},
+		&dto.ShoppingList{},
+		&dto.ShoppingListEntry{},
 
 		// Nouvelles tables pour favoris et listes
 		&dto.UserFavoriteRecipe{},
@@ -52,10 +71,154 @@ func (m *MigrationService) RunMigrations() error {
 		log.Printf("Successfully migrated %T", model)
 	}
 
+	if err := m.setupFullTextSearch(); err != nil {
+		return fmt.Errorf("failed to set up full-text search: %w", err)
+	}
+
+	if err := m.backfillIngredientCategories(); err != nil {
+		return fmt.Errorf("failed to backfill ingredient categories: %w", err)
+	}
+
 	log.Println("All migrations completed successfully")
 	return nil
 }
 
+// setupFullTextSearch crée les extensions, configurations et index utilisés par la recherche de recettes
+func (m *MigrationService) setupFullTextSearch() error {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS unaccent",
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'french_unaccent') THEN
+				CREATE TEXT SEARCH CONFIGURATION french_unaccent (COPY = french);
+				ALTER TEXT SEARCH CONFIGURATION french_unaccent
+					ALTER MAPPING FOR hword, hword_part, word WITH unaccent, french_stem;
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'english_unaccent') THEN
+				CREATE TEXT SEARCH CONFIGURATION english_unaccent (COPY = english);
+				ALTER TEXT SEARCH CONFIGURATION english_unaccent
+					ALTER MAPPING FOR hword, hword_part, word WITH unaccent, english_stem;
+			END IF;
+		END
+		$$`,
+		// unaccent n'est pas IMMUTABLE, ce qui empêche son utilisation dans un index
+		`CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text AS $$
+			SELECT public.unaccent('public.unaccent', $1)
+		$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT`,
+		// Document plein texte pondéré (titre > description, ingrédients et tags > étapes) et texte brut
+		// pour la recherche approximative, stockés sur la recette pour pouvoir être indexés
+		"ALTER TABLE recipes ADD COLUMN IF NOT EXISTS search_document tsvector",
+		"ALTER TABLE recipes ADD COLUMN IF NOT EXISTS search_text text",
+		`CREATE OR REPLACE FUNCTION recipe_search_refresh() RETURNS trigger AS $$
+		DECLARE
+			related_names text;
+			step_text text;
+		BEGIN
+			SELECT coalesce((
+				SELECT string_agg(ingredients.name, ' ')
+				FROM recipe_ingredients
+				JOIN ingredients ON ingredients.id = recipe_ingredients.ingredient_id
+				WHERE recipe_ingredients.recipe_id = NEW.id
+			), '') || ' ' || coalesce((
+				SELECT string_agg(tags.name, ' ')
+				FROM recipe_tags
+				JOIN tags ON tags.id = recipe_tags.tag_id
+				WHERE recipe_tags.recipe_id = NEW.id
+			), '') INTO related_names;
+
+			SELECT coalesce(string_agg(coalesce(step->>'title', '') || ' ' || coalesce(step->>'description', '') || ' ' || coalesce(step->>'tips', ''), ' '), '')
+			INTO step_text
+			FROM json_array_elements(coalesce(NEW.instructions::json, '[]'::json)) AS step;
+
+			NEW.search_document :=
+				setweight(to_tsvector('french_unaccent', coalesce(NEW.title, '')), 'A') ||
+				setweight(to_tsvector('english_unaccent', coalesce(NEW.title, '')), 'A') ||
+				setweight(to_tsvector('french_unaccent', coalesce(NEW.description, '') || ' ' || related_names), 'B') ||
+				setweight(to_tsvector('english_unaccent', coalesce(NEW.description, '') || ' ' || related_names), 'B') ||
+				setweight(to_tsvector('french_unaccent', step_text), 'C') ||
+				setweight(to_tsvector('english_unaccent', step_text), 'C');
+			NEW.search_text := immutable_unaccent(lower(coalesce(NEW.title, '') || ' ' || related_names));
+			RETURN NEW;
+		END
+		$$ LANGUAGE plpgsql`,
+		// Remettre search_document à NULL déclenche son recalcul par recipe_search_refresh
+		`CREATE OR REPLACE FUNCTION recipe_search_touch() RETURNS trigger AS $$
+		BEGIN
+			IF TG_TABLE_NAME = 'ingredients' THEN
+				UPDATE recipes SET search_document = NULL
+				WHERE id IN (SELECT recipe_id FROM recipe_ingredients WHERE ingredient_id = NEW.id);
+			ELSIF TG_TABLE_NAME = 'tags' THEN
+				UPDATE recipes SET search_document = NULL
+				WHERE id IN (SELECT recipe_id FROM recipe_tags WHERE tag_id = NEW.id);
+			ELSE
+				IF TG_OP <> 'INSERT' THEN
+					UPDATE recipes SET search_document = NULL WHERE id = OLD.recipe_id;
+				END IF;
+				IF TG_OP <> 'DELETE' THEN
+					UPDATE recipes SET search_document = NULL WHERE id = NEW.recipe_id;
+				END IF;
+			END IF;
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS trg_recipes_search ON recipes",
+		`CREATE TRIGGER trg_recipes_search BEFORE INSERT OR UPDATE OF title, description, instructions, search_document
+			ON recipes FOR EACH ROW EXECUTE FUNCTION recipe_search_refresh()`,
+		"DROP TRIGGER IF EXISTS trg_recipe_ingredients_search ON recipe_ingredients",
+		`CREATE TRIGGER trg_recipe_ingredients_search AFTER INSERT OR UPDATE OR DELETE
+			ON recipe_ingredients FOR EACH ROW EXECUTE FUNCTION recipe_search_touch()`,
+		"DROP TRIGGER IF EXISTS trg_recipe_tags_search ON recipe_tags",
+		`CREATE TRIGGER trg_recipe_tags_search AFTER INSERT OR UPDATE OR DELETE
+			ON recipe_tags FOR EACH ROW EXECUTE FUNCTION recipe_search_touch()`,
+		"DROP TRIGGER IF EXISTS trg_ingredients_search ON ingredients",
+		`CREATE TRIGGER trg_ingredients_search AFTER UPDATE OF name ON ingredients
+			FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name) EXECUTE FUNCTION recipe_search_touch()`,
+		"DROP TRIGGER IF EXISTS trg_tags_search ON tags",
+		`CREATE TRIGGER trg_tags_search AFTER UPDATE OF name ON tags
+			FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name) EXECUTE FUNCTION recipe_search_touch()`,
+		// Calculer le document des recettes créées avant l'ajout des colonnes
+		"UPDATE recipes SET search_document = NULL WHERE search_document IS NULL",
+		"CREATE INDEX IF NOT EXISTS idx_recipes_search_document ON recipes USING gin (search_document)",
+		"CREATE INDEX IF NOT EXISTS idx_recipes_search_text_trgm ON recipes USING gin (search_text gin_trgm_ops)",
+		// Remplacé par idx_recipes_search_text_trgm, que la recherche utilise réellement
+		"DROP INDEX IF EXISTS idx_recipes_title_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_ingredients_name_trgm ON ingredients USING gin (immutable_unaccent(lower(name)) gin_trgm_ops)",
+	}
+
+	for _, statement := range statements {
+		if err := m.db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	log.Println("Full-text search configured")
+	return nil
+}
+
+// backfillIngredientCategories crée les catégories d'ingrédients à partir des noms saisis librement
+// avant l'introduction de la table, et y rattache les ingrédients correspondants
+func (m *MigrationService) backfillIngredientCategories() error {
+	statements := []string{
+		`INSERT INTO ingredient_categories (name, created_at, updated_at)
+		SELECT DISTINCT ON (lower(trim(category))) trim(category), now(), now()
+		FROM ingredients
+		WHERE category_id IS NULL AND trim(coalesce(category, '')) <> ''
+		ORDER BY lower(trim(category)), trim(category)
+		ON CONFLICT (name) DO NOTHING`,
+		`UPDATE ingredients SET category_id = ingredient_categories.id, category = ingredient_categories.name
+		FROM ingredient_categories
+		WHERE ingredients.category_id IS NULL AND lower(ingredient_categories.name) = lower(trim(ingredients.category))`,
+	}
+
+	for _, statement := range statements {
+		if err := m.db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // DropAllTables supprime toutes les tables (utile pour les tests)
 func (m *MigrationService) DropAllTables() error {
 	log.Println("Dropping all tables...")
@@ -66,14 +229,33 @@ func (m *MigrationService) DropAllTables() error {
 		&dto.RecipeListItem{},
 		&dto.RecipeList{},
 		&dto.UserFavoriteRecipe{},
+		&dto.ShoppingListEntry{},
+		&dto.ShoppingList{},
+		&dto.IngredientPrice{},
+		&dto.StoreIngredientPlacement{},
+		&dto.StoreAisle{},
+		&dto.Store{},
+		&dto.FridgeConsumptionItem{},
+		&dto.FridgeConsumption{},
+		&dto.Leftover{},
+		&dto.MealPlanCalendar{},
+		&dto.MealPlanTemplateItem{},
+		&dto.MealPlanTemplate{},
+		&dto.MealPlanRecurrenceSkip{},
+		&dto.MealPlanRecurrence{},
 		&dto.MealPlan{},
 		&dto.Comment{},
+		&dto.RecipeRevision{},
 		&dto.RecipeEquipment{},
 		&dto.RecipeIngredient{},
 		&dto.RecipeTag{},
 		&dto.Recipe{},
 		&dto.Equipment{},
+		&dto.IngredientSubstitutionComponent{},
+		&dto.IngredientSubstitution{},
+		&dto.IngredientAlias{},
 		&dto.Ingredient{},
+		&dto.IngredientCategory{},
 		&dto.Tag{},
 		&dto.Category{},
 		&dto.User{},
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"sort"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
+)
+
+// fridgeAllocator réserve le stock du frigo au fil des repas planifiés (dans l'ordre chronologique des appels)
+type fridgeAllocator struct {
+	stock       fridgeStock
+	remaining   map[uint]float64 // Quantité restante par item du frigo, dans l'unité de l'item
+	allocations []dto.FridgeAllocation
+}
+
+// newFridgeAllocator prépare la réservation : les items qui périment le plus tôt sont utilisés en premier.
+// Un item peut couvrir le besoin d'un ingrédient plus général que le sien (voir newFridgeStock).
+func newFridgeAllocator(items []*dto.FridgeItem, taxonomy ingredientTaxonomy) *fridgeAllocator {
+	allocator := &fridgeAllocator{
+		stock:       newFridgeStock(items, taxonomy),
+		remaining:   make(map[uint]float64),
+		allocations: []dto.FridgeAllocation{},
+	}
+
+	for _, item := range items {
+		if item.Quantity != nil {
+			allocator.remaining[item.ID] = *item.Quantity
+		}
+	}
+
+	for _, ingredientItems := range allocator.stock {
+		sort.SliceStable(ingredientItems, func(i, j int) bool {
+			a, b := ingredientItems[i].ExpiryDate, ingredientItems[j].ExpiryDate
+			if a == nil || b == nil {
+				return a != nil
+			}
+			return a.Before(*b)
+		})
+	}
+
+	return allocator
+}
+
+// reserve réserve pour un repas la quantité d'ingrédient disponible dans le frigo. recipeID est la recette
+// qui utilise l'ingrédient (la recette planifiée ou l'une de ses sous-recettes).
+// Les items périmés avant le jour du repas sont ignorés. Retourne la quantité restant à acheter.
+func (a *fridgeAllocator) reserve(mealPlan *dto.MealPlan, recipeID uint, ingredient dto.Ingredient, quantity float64, rawUnit string) float64 {
+	needed := units.Parse(rawUnit)
+	hints := ingredientHints(ingredient)
+	mealDay := time.Date(mealPlan.PlannedDate.Year(), mealPlan.PlannedDate.Month(), mealPlan.PlannedDate.Day(), 0, 0, 0, 0, mealPlan.PlannedDate.Location())
+	missing := quantity
+
+	for _, item := range a.stock[ingredient.ID] {
+		if missing <= 0 {
+			break
+		}
+		if item.ExpiryDate != nil && item.ExpiryDate.Before(mealDay) {
+			continue
+		}
+
+		// Stock non quantifié : on considère qu'il couvre le besoin
+		if item.Quantity == nil {
+			a.record(item, mealPlan, recipeID, ingredient, missing, needed)
+			missing = 0
+			break
+		}
+
+		remaining := a.remaining[item.ID]
+		if remaining <= 0 {
+			continue
+		}
+
+		itemUnit := units.Parse(fridgeItemUnit(item))
+		available, ok := units.Convert(remaining, itemUnit, needed, hints)
+		if !ok {
+			continue
+		}
+
+		taken := available
+		if taken > missing {
+			taken = missing
+		}
+		used, _ := units.Convert(taken, needed, itemUnit, hints)
+		a.remaining[item.ID] = remaining - used
+		missing -= taken
+		a.record(item, mealPlan, recipeID, ingredient, taken, needed)
+	}
+
+	if missing < 1e-6 {
+		return 0
+	}
+	return missing
+}
+
+// record ajoute une réservation au détail retourné à l'utilisateur
+func (a *fridgeAllocator) record(item *dto.FridgeItem, mealPlan *dto.MealPlan, recipeID uint, ingredient dto.Ingredient, quantity float64, unit units.Unit) {
+	a.allocations = append(a.allocations, dto.FridgeAllocation{
+		FridgeItemID:   item.ID,
+		IngredientID:   ingredient.ID,
+		IngredientName: ingredient.Name,
+		MealPlanID:     mealPlan.ID,
+		RecipeID:       recipeID,
+		Date:           mealPlan.PlannedDate.Format("2006-01-02"),
+		MealType:       mealPlan.MealType,
+		Quantity:       units.Round(quantity),
+		Unit:           unit.Code,
+	})
+}
+
+// fridgeItemUnit retourne l'unité d'un item du frigo (vide = pièce)
+func fridgeItemUnit(item *dto.FridgeItem) string {
+	if item.Unit == nil {
+		return ""
+	}
+	return *item.Unit
+}
+
+// fridgeUsage représente un item quantifié du frigo entamé par les réservations
+type fridgeUsage struct {
+	item      *dto.FridgeItem
+	used      float64 // Quantité réservée, dans l'unité de l'item
+	remaining float64 // Quantité restante, dans l'unité de l'item
+}
+
+// usages retourne les items quantifiés entamés, dans l'ordre des réservations
+func (a *fridgeAllocator) usages() []fridgeUsage {
+	items := make(map[uint]*dto.FridgeItem)
+	for _, ingredientItems := range a.stock {
+		for _, item := range ingredientItems {
+			items[item.ID] = item
+		}
+	}
+
+	seen := make(map[uint]bool)
+	usages := make([]fridgeUsage, 0)
+	for _, allocation := range a.allocations {
+		item := items[allocation.FridgeItemID]
+		if seen[item.ID] || item.Quantity == nil {
+			continue
+		}
+		seen[item.ID] = true
+
+		remaining := a.remaining[item.ID]
+		if remaining < 1e-6 {
+			remaining = 0
+		}
+		usages = append(usages, fridgeUsage{
+			item:      item,
+			used:      *item.Quantity - remaining,
+			remaining: remaining,
+		})
+	}
+	return usages
+}
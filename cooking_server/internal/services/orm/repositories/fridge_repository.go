@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
+	"gorm.io/gorm"
+)
+
+const defaultSuggestionsLimit = 20 // Nombre de suggestions retournées par défaut
+
+type fridgeRepository struct {
+	db *gorm.DB
+}
+
+// NewFridgeRepository crée une nouvelle instance du repository frigo
+func NewFridgeRepository(db *gorm.DB) *fridgeRepository {
+	return &fridgeRepository{db: db}
+}
+
+// GetByUser récupère les items du frigo d'un utilisateur avec leur ingrédient
+func (r *fridgeRepository) GetByUser(ctx context.Context, userID uint) ([]*dto.FridgeItem, error) {
+	var items []*dto.FridgeItem
+	if err := r.db.WithContext(ctx).
+		Preload("Ingredient").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&items).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get fridge items by user", err)
+	}
+	return items, nil
+}
+
+// fridgeStock regroupe les items du frigo par ingrédient
+type fridgeStock map[uint][]*dto.FridgeItem
+
+// newFridgeStock indexe les items du frigo par ID d'ingrédient. Un item est aussi indexé sous les
+// ingrédients plus généraux que le sien : des tomates cerises satisfont une recette demandant des tomates.
+func newFridgeStock(items []*dto.FridgeItem, taxonomy ingredientTaxonomy) fridgeStock {
+	stock := make(fridgeStock)
+	for _, item := range items {
+		stock[item.IngredientID] = append(stock[item.IngredientID], item)
+		for _, ancestorID := range taxonomy.ancestors(item.IngredientID) {
+			stock[ancestorID] = append(stock[ancestorID], item)
+		}
+	}
+	return stock
+}
+
+// covers indique si le frigo contient assez d'un ingrédient pour la quantité demandée.
+// Un item sans quantité renseignée est considéré comme suffisant (stock non suivi).
+func (s fridgeStock) covers(ingredient dto.Ingredient, quantity float64, rawUnit string) bool {
+	items := s[ingredient.ID]
+	if len(items) == 0 {
+		return false
+	}
+	if quantity <= 0 {
+		return true
+	}
+
+	needed := units.Parse(rawUnit)
+	hints := ingredientHints(ingredient)
+	available := 0.0
+	for _, item := range items {
+		if item.Quantity == nil {
+			return true
+		}
+		if converted, ok := units.Convert(*item.Quantity, units.Parse(fridgeItemUnit(item)), needed, hints); ok {
+			available += converted
+		}
+	}
+
+	// Tolérance pour les arrondis de conversion
+	return available+1e-6 >= quantity
+}
+
+// suggestionCandidate représente une recette candidate avec ses compteurs d'ingrédients
+type suggestionCandidate struct {
+	RecipeID uint
+	Matching int
+	Total    int
+}
+
+// GetRecipeSuggestions propose des recettes (publiques ou de l'utilisateur) classées selon les ingrédients du frigo
+func (r *fridgeRepository) GetRecipeSuggestions(ctx context.Context, userID uint, req *dto.RecipeSearchByIngredientsRequest) ([]dto.RecipeSuggestion, int, error) {
+	items, err := r.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(items) == 0 {
+		return []dto.RecipeSuggestion{}, 0, nil
+	}
+
+	taxonomy, err := loadIngredientTaxonomy(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stock := newFridgeStock(items, taxonomy)
+	fridgeIngredientIDs := make([]uint, 0, len(stock))
+	for ingredientID := range stock {
+		fridgeIngredientIDs = append(fridgeIngredientIDs, ingredientID)
+	}
+
+	limit := defaultSuggestionsLimit
+	if req.Limit != nil && *req.Limit > 0 {
+		limit = *req.Limit
+	}
+
+	// Compter, pour chaque recette accessible, les ingrédients obligatoires présents dans le frigo
+	query := r.db.WithContext(ctx).
+		Table("recipes").
+		Select("recipes.id AS recipe_id, "+
+			"COUNT(recipe_ingredients.id) FILTER (WHERE recipe_ingredients.ingredient_id IN ?) AS matching, "+
+			"COUNT(recipe_ingredients.id) AS total", fridgeIngredientIDs).
+		Joins("JOIN recipe_ingredients ON recipe_ingredients.recipe_id = recipes.id AND recipe_ingredients.is_optional = ?", false).
+		Where("recipes.is_public = ? OR recipes.author_id = ?", true, userID).
+		Group("recipes.id").
+		Having("COUNT(recipe_ingredients.id) FILTER (WHERE recipe_ingredients.ingredient_id IN ?) > 0", fridgeIngredientIDs)
+
+	if len(req.ExcludeCategories) > 0 {
+		query = query.Where(`NOT EXISTS (
+			SELECT 1 FROM recipe_category_associations
+			JOIN categories ON categories.id = recipe_category_associations.category_id
+			WHERE recipe_category_associations.recipe_id = recipes.id AND LOWER(categories.name) IN ?)`,
+			toLowerSlice(req.ExcludeCategories))
+	}
+
+	switch {
+	case req.MatchType == "all":
+		query = query.Having("COUNT(recipe_ingredients.id) FILTER (WHERE recipe_ingredients.ingredient_id NOT IN ?) = 0", fridgeIngredientIDs)
+	case req.MaxMissingIngredients != nil:
+		query = query.Having("COUNT(recipe_ingredients.id) FILTER (WHERE recipe_ingredients.ingredient_id NOT IN ?) <= ?", fridgeIngredientIDs, *req.MaxMissingIngredients)
+	}
+
+	var candidates []suggestionCandidate
+	if err := query.
+		Order("matching DESC, total ASC").
+		Limit(limit).
+		Scan(&candidates).Error; err != nil {
+		return nil, 0, ormerrors.NewDatabaseError("find recipe suggestions", err)
+	}
+
+	if len(candidates) == 0 {
+		return []dto.RecipeSuggestion{}, len(items), nil
+	}
+
+	recipeIDs := make([]uint, len(candidates))
+	for i, candidate := range candidates {
+		recipeIDs[i] = candidate.RecipeID
+	}
+
+	var recipes []dto.Recipe
+	if err := r.db.WithContext(ctx).
+		Preload("Ingredients").
+		Preload("Ingredients.Ingredient").
+		Preload("Categories").
+		Where("id IN ?", recipeIDs).
+		Find(&recipes).Error; err != nil {
+		return nil, 0, ormerrors.NewDatabaseError("load suggested recipes", err)
+	}
+
+	suggestions := make([]dto.RecipeSuggestion, 0, len(recipes))
+	for i := range recipes {
+		suggestions = append(suggestions, buildRecipeSuggestion(&recipes[i], stock))
+	}
+
+	// Classement : nombre d'ingrédients utilisés, puis recettes réalisables, puis taux de correspondance
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.MatchingIngredients != b.MatchingIngredients {
+			return a.MatchingIngredients > b.MatchingIngredients
+		}
+		if a.CanCook != b.CanCook {
+			return a.CanCook
+		}
+		return a.MatchPercentage > b.MatchPercentage
+	})
+
+	return suggestions, len(items), nil
+}
+
+// buildRecipeSuggestion compare les ingrédients obligatoires d'une recette au contenu du frigo
+func buildRecipeSuggestion(recipe *dto.Recipe, stock fridgeStock) dto.RecipeSuggestion {
+	suggestion := dto.RecipeSuggestion{
+		Recipe:                  recipeSummary(recipe),
+		MissingIngredients:      []dto.Ingredient{},
+		InsufficientIngredients: []dto.Ingredient{},
+	}
+
+	for _, recipeIngredient := range recipe.Ingredients {
+		if recipeIngredient.IsOptional {
+			continue
+		}
+		suggestion.TotalIngredients++
+
+		if len(stock[recipeIngredient.IngredientID]) == 0 {
+			suggestion.MissingIngredients = append(suggestion.MissingIngredients, recipeIngredient.Ingredient)
+			continue
+		}
+
+		suggestion.MatchingIngredients++
+		if !stock.covers(recipeIngredient.Ingredient, recipeIngredient.Quantity, recipeIngredient.Unit) {
+			suggestion.InsufficientIngredients = append(suggestion.InsufficientIngredients, recipeIngredient.Ingredient)
+		}
+	}
+
+	if suggestion.TotalIngredients > 0 {
+		suggestion.MatchPercentage = units.Round(float64(suggestion.MatchingIngredients) / float64(suggestion.TotalIngredients) * 100)
+	}
+	suggestion.CanCook = len(suggestion.MissingIngredients) == 0 && len(suggestion.InsufficientIngredients) == 0
+
+	return suggestion
+}
+
+// recipeSummary construit le résumé d'une recette pour les suggestions
+func recipeSummary(recipe *dto.Recipe) dto.RecipeSummary {
+	summary := dto.RecipeSummary{
+		ID:            int(recipe.ID),
+		Title:         recipe.Title,
+		CookingTime:   &recipe.TotalTime,
+		Servings:      &recipe.Servings,
+		AverageRating: &recipe.AverageRating,
+	}
+	if recipe.Description != "" {
+		summary.Description = &recipe.Description
+	}
+	if recipe.ImageURL != "" {
+		summary.ImageURL = &recipe.ImageURL
+	}
+	for _, category := range recipe.Categories {
+		summary.Categories = append(summary.Categories, category.Name)
+	}
+	return summary
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+)
+
+type ingredientCategoryRepository struct {
+	db *gorm.DB
+}
+
+// NewIngredientCategoryRepository crée une nouvelle instance du repository des catégories d'ingrédients
+func NewIngredientCategoryRepository(db *gorm.DB) *ingredientCategoryRepository {
+	return &ingredientCategoryRepository{db: db}
+}
+
+// Create crée une nouvelle catégorie d'ingrédients
+func (r *ingredientCategoryRepository) Create(ctx context.Context, category *dto.IngredientCategory) error {
+	if err := r.db.WithContext(ctx).Create(category).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ormerrors.NewDuplicateError("ingredient category", "name", category.Name)
+		}
+		return ormerrors.NewDatabaseError("create ingredient category", err)
+	}
+	return nil
+}
+
+// GetByID récupère une catégorie d'ingrédients par son ID
+func (r *ingredientCategoryRepository) GetByID(ctx context.Context, id uint) (*dto.IngredientCategory, error) {
+	var category dto.IngredientCategory
+	if err := r.db.WithContext(ctx).First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("ingredient category", id)
+		}
+		return nil, ormerrors.NewDatabaseError("get ingredient category by id", err)
+	}
+	return &category, nil
+}
+
+// Update met à jour une catégorie d'ingrédients et le nom de catégorie de ses ingrédients
+func (r *ingredientCategoryRepository) Update(ctx context.Context, category *dto.IngredientCategory) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(category).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ormerrors.NewDuplicateError("ingredient category", "name", category.Name)
+			}
+			return ormerrors.NewDatabaseError("update ingredient category", err)
+		}
+
+		if err := tx.Model(&dto.Ingredient{}).
+			Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error; err != nil {
+			return ormerrors.NewDatabaseError("rename ingredient category", err)
+		}
+		return nil
+	})
+}
+
+// Delete supprime une catégorie d'ingrédients. Ses ingrédients sont conservés, sans catégorie.
+func (r *ingredientCategoryRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&dto.Ingredient{}).
+			Where("category_id = ?", id).
+			Updates(map[string]interface{}{"category_id": nil, "category": ""}).Error; err != nil {
+			return ormerrors.NewDatabaseError("detach ingredient category", err)
+		}
+
+		result := tx.Delete(&dto.IngredientCategory{}, id)
+		if result.Error != nil {
+			return ormerrors.NewDatabaseError("delete ingredient category", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ormerrors.NewNotFoundError("ingredient category", id)
+		}
+		return nil
+	})
+}
+
+// List récupère toutes les catégories d'ingrédients dans l'ordre d'affichage
+func (r *ingredientCategoryRepository) List(ctx context.Context) ([]dto.IngredientCategory, error) {
+	categories := []dto.IngredientCategory{}
+	if err := r.db.WithContext(ctx).
+		Order("position ASC, name ASC").
+		Find(&categories).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("list ingredient categories", err)
+	}
+	return categories, nil
+}
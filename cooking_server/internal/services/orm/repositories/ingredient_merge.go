@@ -0,0 +1,323 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Langue par défaut des alias créés à partir des noms fusionnés
+const defaultMergeLocale = "fr"
+
+// snapshotIngredients retourne la liste des ingrédients d'une révision, ou un tableau vide si elle est absente
+const snapshotIngredients = `(CASE WHEN jsonb_typeof(snapshot->'ingredients') = 'array' THEN snapshot->'ingredients' ELSE '[]'::jsonb END)`
+
+// Merge fusionne des ingrédients en double dans un ingrédient cible, dans une seule transaction :
+// toutes les références (recettes, frigo, consommations, listes de courses, rayons, prix, remplacements, révisions, alias) sont reportées sur la cible,
+// les noms des doublons deviennent des alias de la cible, puis les doublons sont supprimés.
+func (r *ingredientRepository) Merge(ctx context.Context, req *dto.IngredientMergeRequest) (*dto.IngredientMergeResult, error) {
+	duplicateIDs := make([]uint, 0, len(req.DuplicateIDs))
+	for _, id := range uniqueUints(req.DuplicateIDs) {
+		if id != req.TargetID {
+			duplicateIDs = append(duplicateIDs, id)
+		}
+	}
+	if len(duplicateIDs) == 0 {
+		return nil, ormerrors.NewValidationError("duplicate_ids must contain at least one ingredient other than the target")
+	}
+
+	locale := req.Locale
+	if locale == "" {
+		locale = defaultMergeLocale
+	}
+
+	result := &dto.IngredientMergeResult{MergedIDs: duplicateIDs}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target dto.Ingredient
+		if err := tx.First(&target, req.TargetID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ormerrors.NewNotFoundError("ingredient", req.TargetID)
+			}
+			return ormerrors.NewDatabaseError("get merge target", err)
+		}
+
+		var duplicates []dto.Ingredient
+		if err := tx.Where("id IN ?", duplicateIDs).Find(&duplicates).Error; err != nil {
+			return ormerrors.NewDatabaseError("get merged ingredients", err)
+		}
+		if len(duplicates) != len(duplicateIDs) {
+			found := make(map[uint]bool, len(duplicates))
+			for _, duplicate := range duplicates {
+				found[duplicate.ID] = true
+			}
+			for _, id := range duplicateIDs {
+				if !found[id] {
+					return ormerrors.NewNotFoundError("ingredient", id)
+				}
+			}
+		}
+
+		// Recettes concernées, dont les étiquettes alimentaires devront être recalculées
+		var recipeIDs []uint
+		if err := tx.Model(&dto.RecipeIngredient{}).
+			Where("ingredient_id IN ?", duplicateIDs).
+			Distinct().Pluck("recipe_id", &recipeIDs).Error; err != nil {
+			return ormerrors.NewDatabaseError("find merged ingredient recipes", err)
+		}
+
+		moved := tx.Model(&dto.RecipeIngredient{}).Where("ingredient_id IN ?", duplicateIDs).Update("ingredient_id", target.ID)
+		if moved.Error != nil {
+			return ormerrors.NewDatabaseError("merge recipe ingredients", moved.Error)
+		}
+		result.RecipeIngredients = moved.RowsAffected
+
+		moved = tx.Model(&dto.FridgeItem{}).Where("ingredient_id IN ?", duplicateIDs).Update("ingredient_id", target.ID)
+		if moved.Error != nil {
+			return ormerrors.NewDatabaseError("merge fridge items", moved.Error)
+		}
+		result.FridgeItems = moved.RowsAffected
+
+		moved = tx.Model(&dto.FridgeConsumptionItem{}).Where("ingredient_id IN ?", duplicateIDs).Update("ingredient_id", target.ID)
+		if moved.Error != nil {
+			return ormerrors.NewDatabaseError("merge fridge consumptions", moved.Error)
+		}
+		result.FridgeConsumptions = moved.RowsAffected
+
+		moved = tx.Model(&dto.ShoppingListEntry{}).Where("ingredient_id IN ?", duplicateIDs).Update("ingredient_id", target.ID)
+		if moved.Error != nil {
+			return ormerrors.NewDatabaseError("merge shopping list entries", moved.Error)
+		}
+		result.ShoppingListEntries = moved.RowsAffected
+
+		// Un seul emplacement par magasin : celui de la cible est conservé, sinon le plus souvent confirmé des doublons
+		if err := tx.Exec(`DELETE FROM store_ingredient_placements duplicate
+			WHERE duplicate.ingredient_id IN @duplicates AND (
+				EXISTS (SELECT 1 FROM store_ingredient_placements kept
+					WHERE kept.store_id = duplicate.store_id AND kept.ingredient_id = @target)
+				OR duplicate.id <> (SELECT kept.id FROM store_ingredient_placements kept
+					WHERE kept.store_id = duplicate.store_id AND kept.ingredient_id IN @duplicates
+					ORDER BY kept.count DESC, kept.updated_at DESC, kept.id LIMIT 1))`,
+			map[string]interface{}{"duplicates": duplicateIDs, "target": target.ID}).Error; err != nil {
+			return ormerrors.NewDatabaseError("drop conflicting store placements", err)
+		}
+		moved = tx.Model(&dto.StoreIngredientPlacement{}).Where("ingredient_id IN ?", duplicateIDs).Update("ingredient_id", target.ID)
+		if moved.Error != nil {
+			return ormerrors.NewDatabaseError("merge store placements", moved.Error)
+		}
+		result.StorePlacements = moved.RowsAffected
+
+		// Les relevés de prix de la cible priment : ceux des doublons ne sont repris que pour les magasins
+		// (ou l'absence de magasin) où l'utilisateur n'a pas relevé de prix pour la cible
+		if err := tx.Exec(`DELETE FROM ingredient_prices duplicate
+			WHERE duplicate.ingredient_id IN @duplicates AND EXISTS (
+				SELECT 1 FROM ingredient_prices kept
+				WHERE kept.ingredient_id = @target AND kept.user_id = duplicate.user_id
+					AND kept.store_id IS NOT DISTINCT FROM duplicate.store_id)`,
+			map[string]interface{}{"duplicates": duplicateIDs, "target": target.ID}).Error; err != nil {
+			return ormerrors.NewDatabaseError("drop conflicting ingredient prices", err)
+		}
+		moved = tx.Model(&dto.IngredientPrice{}).Where("ingredient_id IN ?", duplicateIDs).Update("ingredient_id", target.ID)
+		if moved.Error != nil {
+			return ormerrors.NewDatabaseError("merge ingredient prices", moved.Error)
+		}
+		result.Prices = moved.RowsAffected
+
+		// Les remplacements des doublons, et ceux qui les proposent, passent à la cible
+		moved = tx.Model(&dto.IngredientSubstitution{}).Where("ingredient_id IN ?", duplicateIDs).Update("ingredient_id", target.ID)
+		if moved.Error != nil {
+			return ormerrors.NewDatabaseError("merge ingredient substitutions", moved.Error)
+		}
+		result.Substitutions = moved.RowsAffected
+
+		moved = tx.Model(&dto.IngredientSubstitutionComponent{}).Where("ingredient_id IN ?", duplicateIDs).Update("ingredient_id", target.ID)
+		if moved.Error != nil {
+			return ormerrors.NewDatabaseError("merge substitution components", moved.Error)
+		}
+		result.Substitutions += moved.RowsAffected
+
+		// Un remplacement de la cible par elle-même n'a plus de sens
+		var selfSubstitutions []uint
+		if err := tx.Model(&dto.IngredientSubstitution{}).
+			Where("ingredient_id = ?", target.ID).
+			Where("NOT EXISTS (SELECT 1 FROM ingredient_substitution_components c "+
+				"WHERE c.substitution_id = ingredient_substitutions.id AND c.ingredient_id <> ?)", target.ID).
+			Pluck("id", &selfSubstitutions).Error; err != nil {
+			return ormerrors.NewDatabaseError("find merged self substitutions", err)
+		}
+		if len(selfSubstitutions) > 0 {
+			if err := tx.Where("substitution_id IN ?", selfSubstitutions).Delete(&dto.IngredientSubstitutionComponent{}).Error; err != nil {
+				return ormerrors.NewDatabaseError("delete merged self substitutions", err)
+			}
+			if err := tx.Delete(&dto.IngredientSubstitution{}, selfSubstitutions).Error; err != nil {
+				return ormerrors.NewDatabaseError("delete merged self substitutions", err)
+			}
+		}
+
+		// Réécrire les révisions pour qu'une restauration ne pointe pas vers un ingrédient supprimé
+		moved = tx.Exec(`UPDATE recipe_revisions SET snapshot = jsonb_set(snapshot, '{ingredients}', (
+				SELECT coalesce(jsonb_agg(CASE
+					WHEN (item->>'ingredient_id')::bigint IN @duplicates
+					THEN item || jsonb_build_object('ingredient_id', @target::bigint, 'ingredient_name', @name::text)
+					ELSE item END ORDER BY ord), '[]'::jsonb)
+				FROM jsonb_array_elements(`+snapshotIngredients+`) WITH ORDINALITY AS items(item, ord)))
+			WHERE EXISTS (
+				SELECT 1 FROM jsonb_array_elements(`+snapshotIngredients+`) AS item
+				WHERE (item->>'ingredient_id')::bigint IN @duplicates)`,
+			map[string]interface{}{"duplicates": duplicateIDs, "target": target.ID, "name": target.Name})
+		if moved.Error != nil {
+			return ormerrors.NewDatabaseError("merge recipe revisions", moved.Error)
+		}
+		result.RecipeRevisions = moved.RowsAffected
+
+		// Les alias des doublons passent à la cible, sans remplacer son nom préféré
+		moved = tx.Model(&dto.IngredientAlias{}).Where("ingredient_id IN ?", duplicateIDs).Updates(map[string]interface{}{
+			"ingredient_id": target.ID,
+			"is_preferred":  false,
+		})
+		if moved.Error != nil {
+			return ormerrors.NewDatabaseError("merge ingredient aliases", moved.Error)
+		}
+		result.AliasesMoved = moved.RowsAffected
+
+		targetName := units.NormalizeName(target.Name)
+		for _, duplicate := range duplicates {
+			result.MergedNames = append(result.MergedNames, duplicate.Name)
+
+			normalized := units.NormalizeName(duplicate.Name)
+			if normalized == targetName {
+				continue
+			}
+			alias := &dto.IngredientAlias{
+				IngredientID:   target.ID,
+				Locale:         locale,
+				Name:           duplicate.Name,
+				NormalizedName: normalized,
+			}
+			created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(alias)
+			if created.Error != nil {
+				return ormerrors.NewDatabaseError("create merged alias", created.Error)
+			}
+			result.AliasesAdded += int(created.RowsAffected)
+		}
+
+		// Les variantes des doublons deviennent des variantes de la cible
+		if err := tx.Model(&dto.Ingredient{}).
+			Where("parent_id IN ? AND id <> ?", duplicateIDs, target.ID).
+			Update("parent_id", target.ID).Error; err != nil {
+			return ormerrors.NewDatabaseError("merge ingredient variants", err)
+		}
+		if target.ParentID != nil {
+			// La cible était une variante d'un doublon : la rattacher au premier ancêtre conservé
+			parents := make(map[uint]*uint, len(duplicates))
+			for _, duplicate := range duplicates {
+				parents[duplicate.ID] = duplicate.ParentID
+			}
+			parentID := target.ParentID
+			seen := make(map[uint]bool, len(duplicates))
+			for parentID != nil && !seen[*parentID] {
+				next, merged := parents[*parentID]
+				if !merged {
+					break
+				}
+				seen[*parentID] = true
+				parentID = next
+			}
+			if parentID != nil && (*parentID == target.ID || seen[*parentID]) {
+				parentID = nil
+			}
+			if parentID != target.ParentID {
+				if err := tx.Model(&target).Update("parent_id", parentID).Error; err != nil {
+					return ormerrors.NewDatabaseError("reparent merge target", err)
+				}
+			}
+		}
+
+		if err := tx.Delete(&dto.Ingredient{}, duplicateIDs).Error; err != nil {
+			return ormerrors.NewDatabaseError("delete merged ingredients", err)
+		}
+
+		if err := refreshRecipeLabels(tx, recipeIDs...); err != nil {
+			return err
+		}
+		result.AffectedRecipes = len(recipeIDs)
+		result.Target = target
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindDuplicateCandidates propose des paires d'ingrédients aux noms proches (similarité trigramme
+// sans accents ni casse), en suggérant de conserver le plus utilisé dans les recettes
+func (r *ingredientRepository) FindDuplicateCandidates(ctx context.Context, threshold float64, limit int) ([]dto.IngredientDuplicateCandidate, error) {
+	var pairs []struct {
+		FirstID    uint
+		FirstName  string
+		SecondID   uint
+		SecondName string
+		Similarity float64
+	}
+	// L'opérateur % (plutôt que similarity() dans le WHERE) permet d'utiliser l'index trigramme
+	// idx_ingredients_name_trgm pour chercher les voisins de chaque ingrédient
+	err := withTrigramThreshold(r.db.WithContext(ctx), "pg_trgm.similarity_threshold", threshold, func(tx *gorm.DB) error {
+		return tx.Raw(`
+			SELECT a.id AS first_id, a.name AS first_name, b.id AS second_id, b.name AS second_name,
+				similarity(immutable_unaccent(lower(a.name)), immutable_unaccent(lower(b.name))) AS similarity
+			FROM ingredients a
+			JOIN ingredients b ON a.id < b.id
+				AND immutable_unaccent(lower(b.name)) % immutable_unaccent(lower(a.name))
+			ORDER BY similarity DESC, a.id, b.id
+			LIMIT ?`, limit).Scan(&pairs).Error
+	})
+	if err != nil {
+		return nil, ormerrors.NewDatabaseError("find duplicate ingredients", err)
+	}
+
+	// Nombre de recettes utilisant chaque ingrédient
+	ids := make([]uint, 0, len(pairs)*2)
+	for _, pair := range pairs {
+		ids = append(ids, pair.FirstID, pair.SecondID)
+	}
+	usage := make(map[uint]int64, len(ids))
+	if len(ids) > 0 {
+		var counts []struct {
+			IngredientID uint
+			Count        int64
+		}
+		if err := r.db.WithContext(ctx).Model(&dto.RecipeIngredient{}).
+			Select("ingredient_id, COUNT(DISTINCT recipe_id) AS count").
+			Where("ingredient_id IN ?", uniqueUints(ids)).
+			Group("ingredient_id").
+			Scan(&counts).Error; err != nil {
+			return nil, ormerrors.NewDatabaseError("count ingredient usage", err)
+		}
+		for _, count := range counts {
+			usage[count.IngredientID] = count.Count
+		}
+	}
+
+	candidates := make([]dto.IngredientDuplicateCandidate, 0, len(pairs))
+	for _, pair := range pairs {
+		candidate := dto.IngredientDuplicateCandidate{
+			First:      dto.IngredientUsage{ID: pair.FirstID, Name: pair.FirstName, RecipeCount: usage[pair.FirstID]},
+			Second:     dto.IngredientUsage{ID: pair.SecondID, Name: pair.SecondName, RecipeCount: usage[pair.SecondID]},
+			Similarity: units.Round(pair.Similarity),
+			Reason:     "similar_name",
+		}
+		if units.NormalizeName(pair.FirstName) == units.NormalizeName(pair.SecondName) {
+			candidate.Reason = "same_normalized_name"
+		}
+		candidate.SuggestedTarget = candidate.First.ID
+		if candidate.Second.RecipeCount > candidate.First.RecipeCount {
+			candidate.SuggestedTarget = candidate.Second.ID
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
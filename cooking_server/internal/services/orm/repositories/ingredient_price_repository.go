@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+)
+
+type ingredientPriceRepository struct {
+	db *gorm.DB
+}
+
+// NewIngredientPriceRepository crée une nouvelle instance du repository des prix d'ingrédients
+func NewIngredientPriceRepository(db *gorm.DB) *ingredientPriceRepository {
+	return &ingredientPriceRepository{db: db}
+}
+
+// Create enregistre un prix relevé par un utilisateur
+func (r *ingredientPriceRepository) Create(ctx context.Context, price *dto.IngredientPrice) error {
+	db := r.db.WithContext(ctx)
+
+	var count int64
+	if err := db.Model(&dto.Ingredient{}).Where("id = ?", price.IngredientID).Count(&count).Error; err != nil {
+		return ormerrors.NewDatabaseError("check ingredient", err)
+	}
+	if count == 0 {
+		return ormerrors.NewNotFoundError("ingredient", price.IngredientID)
+	}
+	if price.StoreID != nil {
+		if err := db.Model(&dto.Store{}).Where("id = ? AND user_id = ?", *price.StoreID, price.UserID).Count(&count).Error; err != nil {
+			return ormerrors.NewDatabaseError("check store", err)
+		}
+		if count == 0 {
+			return ormerrors.NewNotFoundError("store", *price.StoreID)
+		}
+	}
+
+	if err := db.Omit("Ingredient").Create(price).Error; err != nil {
+		return ormerrors.NewDatabaseError("create ingredient price", err)
+	}
+	return nil
+}
+
+// GetByID récupère un prix par son ID
+func (r *ingredientPriceRepository) GetByID(ctx context.Context, id uint) (*dto.IngredientPrice, error) {
+	var price dto.IngredientPrice
+	if err := r.db.WithContext(ctx).Preload("Ingredient").First(&price, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("ingredient price", id)
+		}
+		return nil, ormerrors.NewDatabaseError("get ingredient price by id", err)
+	}
+	return &price, nil
+}
+
+// GetByUser liste les prix relevés par un utilisateur, du plus récent au plus ancien,
+// éventuellement pour un ingrédient ou un magasin
+func (r *ingredientPriceRepository) GetByUser(ctx context.Context, userID uint, ingredientID, storeID *uint) ([]*dto.IngredientPrice, error) {
+	query := r.db.WithContext(ctx).Preload("Ingredient").Where("user_id = ?", userID)
+	if ingredientID != nil {
+		query = query.Where("ingredient_id = ?", *ingredientID)
+	}
+	if storeID != nil {
+		query = query.Where("store_id = ?", *storeID)
+	}
+
+	var prices []*dto.IngredientPrice
+	if err := query.Order("priced_at DESC, id DESC").Find(&prices).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get ingredient prices by user", err)
+	}
+	return prices, nil
+}
+
+// Delete supprime un prix
+func (r *ingredientPriceRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&dto.IngredientPrice{}, id)
+	if result.Error != nil {
+		return ormerrors.NewDatabaseError("delete ingredient price", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ormerrors.NewNotFoundError("ingredient price", id)
+	}
+	return nil
+}
+
+// RecipeCost estime le coût d'une recette (sous-recettes comprises) avec les prix de l'utilisateur.
+// Sans nombre de portions demandé, les portions de la recette sont utilisées.
+func (r *ingredientPriceRepository) RecipeCost(ctx context.Context, recipeID, userID uint, query *dto.CostQuery) (*dto.CostEstimate, error) {
+	var recipe dto.Recipe
+	if err := r.db.WithContext(ctx).
+		Preload("Ingredients").
+		Preload("Ingredients.Ingredient").
+		Where("is_public = ? OR author_id = ?", true, userID).
+		First(&recipe, recipeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("recipe", recipeID)
+		}
+		return nil, ormerrors.NewDatabaseError("get recipe for cost", err)
+	}
+
+	servings := query.Servings
+	if servings <= 0 {
+		servings = recipe.Servings
+	}
+	return r.estimate(ctx, &recipe, servings, userID, query.StoreID)
+}
+
+// MealPlanCost estime le coût d'un repas planifié pour ses portions (nul pour un repas avec des restes)
+func (r *ingredientPriceRepository) MealPlanCost(ctx context.Context, mealPlanID, userID uint, query *dto.CostQuery) (*dto.CostEstimate, error) {
+	var mealPlan dto.MealPlan
+	if err := r.db.WithContext(ctx).
+		Preload("Recipe").
+		Preload("Recipe.Ingredients").
+		Preload("Recipe.Ingredients.Ingredient").
+		Where("user_id = ?", userID).
+		First(&mealPlan, mealPlanID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("meal plan", mealPlanID)
+		}
+		return nil, ormerrors.NewDatabaseError("get meal plan for cost", err)
+	}
+
+	servings := query.Servings
+	if servings <= 0 {
+		servings = mealPlan.Servings
+	}
+	// Un repas préparé avec des restes ne coûte rien de plus, comme dans la liste de courses
+	if mealPlan.LeftoverID != nil {
+		return newCostEstimate().result(servings), nil
+	}
+	return r.estimate(ctx, &mealPlan.Recipe, servings, userID, query.StoreID)
+}
+
+// estimate calcule le coût d'une recette ramenée au nombre de portions donné
+func (r *ingredientPriceRepository) estimate(ctx context.Context, recipe *dto.Recipe, servings int, userID uint, storeID *uint) (*dto.CostEstimate, error) {
+	ratio := 1.0
+	if recipe.Servings > 0 && servings > 0 {
+		ratio = float64(servings) / float64(recipe.Servings)
+	}
+
+	// Mêmes ingrédients que la liste de courses : recette et recettes référencées dans les étapes
+	collector := &RecipeIngredientCollector{
+		Ingredients: make([]RecipeIngredientWithSource, 0),
+		RecipeData:  make(map[uint]*dto.Recipe),
+	}
+	if err := NewMealPlanRepository(r.db).collectNestedIngredients(ctx, recipe, ratio, 0, make(map[uint]bool), collector); err != nil {
+		return nil, err
+	}
+
+	ingredientIDs := make([]uint, 0, len(collector.Ingredients))
+	for _, ingredient := range collector.Ingredients {
+		ingredientIDs = append(ingredientIDs, ingredient.IngredientID)
+	}
+	book, err := loadPriceBook(ctx, r.db, userID, storeID, ingredientIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := newCostEstimate()
+	for _, ingredient := range collector.Ingredients {
+		estimate.add(book.cost(ingredient.Ingredient, ingredient.Quantity*ingredient.QuantityRatio, ingredient.Unit))
+	}
+	return estimate.result(servings), nil
+}
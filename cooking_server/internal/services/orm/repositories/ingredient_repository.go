@@ -7,7 +7,9 @@ import (
 
 	"github.com/romainrodriguez/cooking_server/internal/dto"
 	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ingredientRepository struct {
@@ -21,19 +23,28 @@ func NewIngredientRepository(db *gorm.DB) *ingredientRepository {
 
 // Create crée un nouvel ingrédient
 func (r *ingredientRepository) Create(ctx context.Context, ingredient *dto.Ingredient) error {
-	if err := r.db.WithContext(ctx).Create(ingredient).Error; err != nil {
-		if errors.Is(err, gorm.ErrDuplicatedKey) {
-			return ormerrors.NewDuplicateError("ingredient", "name", ingredient.Name)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := resolveClassification(tx, ingredient); err != nil {
+			return err
 		}
-		return ormerrors.NewDatabaseError("create ingredient", err)
-	}
-	return nil
+
+		if err := tx.Omit(clause.Associations).Create(ingredient).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ormerrors.NewDuplicateError("ingredient", "name", ingredient.Name)
+			}
+			return ormerrors.NewDatabaseError("create ingredient", err)
+		}
+		return nil
+	})
 }
 
 // GetByID récupère un ingrédient par son ID
 func (r *ingredientRepository) GetByID(ctx context.Context, id uint) (*dto.Ingredient, error) {
 	var ingredient dto.Ingredient
-	if err := r.db.WithContext(ctx).First(&ingredient, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Preload("IngredientCategory").
+		Preload("Parent").
+		First(&ingredient, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ormerrors.NewNotFoundError("ingredient", id)
 		}
@@ -42,31 +53,77 @@ func (r *ingredientRepository) GetByID(ctx context.Context, id uint) (*dto.Ingre
 	return &ingredient, nil
 }
 
-// GetByName récupère un ingrédient par son nom
+// GetByName récupère un ingrédient par son nom, puis par ses alias
+// (sans tenir compte de la casse, des accents ni du pluriel)
 func (r *ingredientRepository) GetByName(ctx context.Context, name string) (*dto.Ingredient, error) {
 	var ingredient dto.Ingredient
-	if err := r.db.WithContext(ctx).Where("LOWER(name) = LOWER(?)", name).First(&ingredient).Error; err != nil {
+	err := r.db.WithContext(ctx).Where("LOWER(name) = LOWER(?)", name).First(&ingredient).Error
+	if err == nil {
+		return &ingredient, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ormerrors.NewDatabaseError("get ingredient by name", err)
+	}
+
+	normalized := units.NormalizeName(name)
+	err = r.db.WithContext(ctx).
+		Where("immutable_unaccent(lower(name)) IN ?", []string{units.NormalizeText(name), normalized}).
+		Or("id IN (?)", r.db.Model(&dto.IngredientAlias{}).Select("ingredient_id").Where("normalized_name = ?", normalized)).
+		Order("id ASC").
+		First(&ingredient).Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ormerrors.NewNotFoundError("ingredient", name)
 		}
-		return nil, ormerrors.NewDatabaseError("get ingredient by name", err)
+		return nil, ormerrors.NewDatabaseError("get ingredient by alias", err)
 	}
 	return &ingredient, nil
 }
 
 // Update met à jour un ingrédient
 func (r *ingredientRepository) Update(ctx context.Context, ingredient *dto.Ingredient) error {
-	if err := r.db.WithContext(ctx).Save(ingredient).Error; err != nil {
-		if errors.Is(err, gorm.ErrDuplicatedKey) {
-			return ormerrors.NewDuplicateError("ingredient", "name", ingredient.Name)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := resolveClassification(tx, ingredient); err != nil {
+			return err
 		}
-		return ormerrors.NewDatabaseError("update ingredient", err)
+
+		if err := tx.Omit(clause.Associations).Save(ingredient).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ormerrors.NewDuplicateError("ingredient", "name", ingredient.Name)
+			}
+			return ormerrors.NewDatabaseError("update ingredient", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Recalculer les étiquettes alimentaires des recettes qui utilisent l'ingrédient
+	var recipeIDs []uint
+	if err := r.db.WithContext(ctx).Model(&dto.RecipeIngredient{}).
+		Where("ingredient_id = ?", ingredient.ID).
+		Distinct().Pluck("recipe_id", &recipeIDs).Error; err != nil {
+		return ormerrors.NewDatabaseError("find ingredient recipes", err)
+	}
+	if len(recipeIDs) > 0 {
+		return refreshRecipeLabels(r.db.WithContext(ctx), recipeIDs...)
 	}
 	return nil
 }
 
-// Delete supprime un ingrédient
+// Delete supprime un ingrédient et ses alias. Ses variantes sont rattachées à son propre parent.
 func (r *ingredientRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Where("ingredient_id = ?", id).Delete(&dto.IngredientAlias{}).Error; err != nil {
+		return ormerrors.NewDatabaseError("delete ingredient aliases", err)
+	}
+
+	if err := r.db.WithContext(ctx).Model(&dto.Ingredient{}).
+		Where("parent_id = ?", id).
+		Update("parent_id", r.db.Model(&dto.Ingredient{}).Select("parent_id").Where("id = ?", id)).Error; err != nil {
+		return ormerrors.NewDatabaseError("detach ingredient variants", err)
+	}
+
 	result := r.db.WithContext(ctx).Delete(&dto.Ingredient{}, id)
 	if result.Error != nil {
 		return ormerrors.NewDatabaseError("delete ingredient", result.Error)
@@ -99,13 +156,16 @@ func (r *ingredientRepository) List(ctx context.Context, limit, offset int) ([]*
 	return ingredients, total, nil
 }
 
-// SearchByName recherche des ingrédients par nom (pour l'autocomplétion)
+// SearchByName recherche des ingrédients par nom ou alias (pour l'autocomplétion)
 func (r *ingredientRepository) SearchByName(ctx context.Context, name string, limit int) ([]*dto.Ingredient, error) {
 	var ingredients []*dto.Ingredient
 	searchTerm := "%" + strings.ToLower(name) + "%"
+	aliasTerm := "%" + units.NormalizeText(name) + "%"
 
 	if err := r.db.WithContext(ctx).
 		Where("LOWER(name) LIKE ?", searchTerm).
+		Or("id IN (?)", r.db.Model(&dto.IngredientAlias{}).Select("ingredient_id").
+			Where("normalized_name LIKE ? OR immutable_unaccent(lower(name)) LIKE ?", "%"+units.NormalizeName(name)+"%", aliasTerm)).
 		Limit(limit).
 		Order("name ASC").
 		Find(&ingredients).Error; err != nil {
@@ -114,3 +174,93 @@ func (r *ingredientRepository) SearchByName(ctx context.Context, name string, li
 
 	return ingredients, nil
 }
+
+// GetAliases récupère les alias d'un ingrédient, par langue
+func (r *ingredientRepository) GetAliases(ctx context.Context, ingredientID uint) ([]dto.IngredientAlias, error) {
+	aliases := []dto.IngredientAlias{}
+	if err := r.db.WithContext(ctx).
+		Where("ingredient_id = ?", ingredientID).
+		Order("locale ASC, is_preferred DESC, name ASC").
+		Find(&aliases).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get ingredient aliases", err)
+	}
+	return aliases, nil
+}
+
+// AddAlias ajoute un alias à un ingrédient. Un alias préféré remplace le précédent pour la même langue.
+func (r *ingredientRepository) AddAlias(ctx context.Context, alias *dto.IngredientAlias) error {
+	alias.Locale = strings.ToLower(strings.TrimSpace(alias.Locale))
+	alias.Name = strings.TrimSpace(alias.Name)
+	alias.NormalizedName = units.NormalizeName(alias.Name)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&dto.Ingredient{}).Where("id = ?", alias.IngredientID).Count(&count).Error; err != nil {
+			return ormerrors.NewDatabaseError("check ingredient", err)
+		}
+		if count == 0 {
+			return ormerrors.NewNotFoundError("ingredient", alias.IngredientID)
+		}
+
+		if alias.IsPreferred {
+			if err := tx.Model(&dto.IngredientAlias{}).
+				Where("ingredient_id = ? AND locale = ?", alias.IngredientID, alias.Locale).
+				Update("is_preferred", false).Error; err != nil {
+				return ormerrors.NewDatabaseError("reset preferred alias", err)
+			}
+		}
+
+		if err := tx.Create(alias).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ormerrors.NewDuplicateError("ingredient alias", "name", alias.Name)
+			}
+			return ormerrors.NewDatabaseError("create ingredient alias", err)
+		}
+		return nil
+	})
+}
+
+// DeleteAlias supprime un alias d'un ingrédient
+func (r *ingredientRepository) DeleteAlias(ctx context.Context, ingredientID, aliasID uint) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND ingredient_id = ?", aliasID, ingredientID).
+		Delete(&dto.IngredientAlias{})
+	if result.Error != nil {
+		return ormerrors.NewDatabaseError("delete ingredient alias", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ormerrors.NewNotFoundError("ingredient alias", aliasID)
+	}
+	return nil
+}
+
+// ApplyDisplayNames renseigne le nom affiché des ingrédients pour une langue :
+// l'alias préféré de cette langue s'il existe, sinon le nom canonique
+func (r *ingredientRepository) ApplyDisplayNames(ctx context.Context, locale string, ingredients []*dto.Ingredient) error {
+	ids := make([]uint, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		ingredient.DisplayName = ingredient.Name
+		ids = append(ids, ingredient.ID)
+	}
+	if locale == "" || len(ids) == 0 {
+		return nil
+	}
+
+	var aliases []dto.IngredientAlias
+	if err := r.db.WithContext(ctx).
+		Where("ingredient_id IN ? AND locale = ? AND is_preferred = ?", ids, strings.ToLower(locale), true).
+		Find(&aliases).Error; err != nil {
+		return ormerrors.NewDatabaseError("get ingredient display names", err)
+	}
+
+	names := make(map[uint]string, len(aliases))
+	for _, alias := range aliases {
+		names[alias.IngredientID] = alias.Name
+	}
+	for _, ingredient := range ingredients {
+		if name, ok := names[ingredient.ID]; ok {
+			ingredient.DisplayName = name
+		}
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
+	"gorm.io/gorm"
+)
+
+type ingredientSubstitutionRepository struct {
+	db *gorm.DB
+}
+
+// NewIngredientSubstitutionRepository crée une nouvelle instance du repository des remplacements d'ingrédients
+func NewIngredientSubstitutionRepository(db *gorm.DB) *ingredientSubstitutionRepository {
+	return &ingredientSubstitutionRepository{db: db}
+}
+
+// Create enregistre un remplacement et ses composants
+func (r *ingredientSubstitutionRepository) Create(ctx context.Context, substitution *dto.IngredientSubstitution) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := []uint{substitution.IngredientID}
+		for _, component := range substitution.Components {
+			if component.IngredientID == substitution.IngredientID {
+				return ormerrors.NewValidationError("an ingredient cannot be substituted by itself")
+			}
+			ids = append(ids, component.IngredientID)
+		}
+		ids = uniqueUints(ids)
+
+		var count int64
+		if err := tx.Model(&dto.Ingredient{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+			return ormerrors.NewDatabaseError("check substitution ingredients", err)
+		}
+		if int(count) != len(ids) {
+			return ormerrors.NewNotFoundError("ingredient", ids)
+		}
+
+		if err := tx.Create(substitution).Error; err != nil {
+			return ormerrors.NewDatabaseError("create ingredient substitution", err)
+		}
+		return nil
+	})
+}
+
+// GetByIngredient récupère les remplacements d'un ingrédient et de ses ingrédients plus généraux
+func (r *ingredientSubstitutionRepository) GetByIngredient(ctx context.Context, ingredientID uint) ([]dto.IngredientSubstitution, error) {
+	taxonomy, err := loadIngredientTaxonomy(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	substitutions := []dto.IngredientSubstitution{}
+	if err := r.db.WithContext(ctx).
+		Preload("Components.Ingredient").
+		Where("ingredient_id IN ?", append([]uint{ingredientID}, taxonomy.ancestors(ingredientID)...)).
+		Order("id ASC").
+		Find(&substitutions).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get ingredient substitutions", err)
+	}
+	return substitutions, nil
+}
+
+// Delete supprime un remplacement d'un ingrédient
+func (r *ingredientSubstitutionRepository) Delete(ctx context.Context, ingredientID, substitutionID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var substitution dto.IngredientSubstitution
+		if err := tx.Where("id = ? AND ingredient_id = ?", substitutionID, ingredientID).First(&substitution).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ormerrors.NewNotFoundError("ingredient substitution", substitutionID)
+			}
+			return ormerrors.NewDatabaseError("get ingredient substitution", err)
+		}
+
+		if err := tx.Where("substitution_id = ?", substitution.ID).Delete(&dto.IngredientSubstitutionComponent{}).Error; err != nil {
+			return ormerrors.NewDatabaseError("delete substitution components", err)
+		}
+		if err := tx.Delete(&substitution).Error; err != nil {
+			return ormerrors.NewDatabaseError("delete ingredient substitution", err)
+		}
+		return nil
+	})
+}
+
+// SuggestForRecipe propose des remplacements pour les ingrédients d'une recette absents ou insuffisants
+// dans le frigo de l'utilisateur, contenant un allergène à éviter ou incompatibles avec un régime demandé.
+// Les remplacements contenant eux-mêmes un allergène à éviter ou ne convenant pas aux régimes sont écartés.
+func (r *ingredientSubstitutionRepository) SuggestForRecipe(ctx context.Context, recipeID, userID uint, query *dto.RecipeSubstitutionQuery) (*dto.RecipeSubstitutions, error) {
+	recipe, err := r.loadAccessibleRecipe(ctx, recipeID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fridgeItems, err := NewFridgeRepository(r.db).GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	taxonomy, err := loadIngredientTaxonomy(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	stock := newFridgeStock(fridgeItems, taxonomy)
+
+	substitutions, err := r.substitutionsFor(ctx, recipe, taxonomy)
+	if err != nil {
+		return nil, err
+	}
+
+	avoided := make(map[string]bool, len(query.AvoidAllergens))
+	for _, allergen := range query.AvoidAllergens {
+		avoided[allergen] = true
+	}
+
+	result := &dto.RecipeSubstitutions{
+		RecipeID:    recipe.ID,
+		Ingredients: []dto.RecipeIngredientSubstitutions{},
+	}
+	for _, recipeIngredient := range recipe.Ingredients {
+		entry := dto.RecipeIngredientSubstitutions{
+			RecipeIngredientID: recipeIngredient.ID,
+			IngredientID:       recipeIngredient.IngredientID,
+			IngredientName:     recipeIngredient.Ingredient.Name,
+			Quantity:           recipeIngredient.Quantity,
+			Unit:               recipeIngredient.Unit,
+			Reasons:            []string{},
+			Options:            []dto.SubstitutionOption{},
+		}
+
+		// Les ingrédients optionnels peuvent être omis : seuls les allergènes et régimes les concernent
+		if !recipeIngredient.IsOptional {
+			switch {
+			case len(stock[recipeIngredient.IngredientID]) == 0:
+				entry.Reasons = append(entry.Reasons, dto.SubstitutionReasonMissing)
+			case !stock.covers(recipeIngredient.Ingredient, recipeIngredient.Quantity, recipeIngredient.Unit):
+				entry.Reasons = append(entry.Reasons, dto.SubstitutionReasonInsufficient)
+			}
+		}
+		for _, allergen := range recipeIngredient.Ingredient.Allergens {
+			if avoided[allergen] {
+				entry.Allergens = append(entry.Allergens, allergen)
+			}
+		}
+		if len(entry.Allergens) > 0 {
+			entry.Reasons = append(entry.Reasons, dto.SubstitutionReasonAllergen)
+		}
+		if !containsAll(recipeIngredient.Ingredient.Diets, query.Diets) {
+			entry.Reasons = append(entry.Reasons, dto.SubstitutionReasonDiet)
+		}
+		if len(entry.Reasons) == 0 {
+			continue
+		}
+
+		for _, substitution := range substitutions[recipeIngredient.IngredientID] {
+			if !containsAll(substitution.Diets, query.Diets) || substitutionHasAllergen(substitution, avoided) {
+				continue
+			}
+			entry.Options = append(entry.Options, buildSubstitutionOption(recipeIngredient, substitution, stock))
+		}
+
+		// Les remplacements réalisables avec le frigo d'abord, puis les plus simples
+		sort.SliceStable(entry.Options, func(i, j int) bool {
+			a, b := entry.Options[i], entry.Options[j]
+			if a.Available != b.Available {
+				return a.Available
+			}
+			return len(a.Components) < len(b.Components)
+		})
+		result.Ingredients = append(result.Ingredients, entry)
+	}
+
+	return result, nil
+}
+
+// AdaptRecipe crée une copie privée de la recette pour l'utilisateur, dans laquelle les ingrédients
+// choisis sont remplacés par les composants de leur remplacement à la quantité demandée par la recette
+func (r *ingredientSubstitutionRepository) AdaptRecipe(ctx context.Context, recipeID, userID uint, req *dto.RecipeAdaptRequest) (*dto.Recipe, error) {
+	original, err := r.loadAccessibleRecipe(ctx, recipeID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	taxonomy, err := loadIngredientTaxonomy(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	substitutions, err := r.substitutionsFor(ctx, original, taxonomy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Valider les choix avant de créer la copie
+	chosen := make(map[uint]dto.IngredientSubstitution, len(req.Substitutions))
+	for _, choice := range req.Substitutions {
+		var recipeIngredient *dto.RecipeIngredient
+		for i := range original.Ingredients {
+			if original.Ingredients[i].ID == choice.RecipeIngredientID {
+				recipeIngredient = &original.Ingredients[i]
+				break
+			}
+		}
+		if recipeIngredient == nil {
+			return nil, ormerrors.NewValidationError(fmt.Sprintf("recipe ingredient %d does not belong to this recipe", choice.RecipeIngredientID))
+		}
+
+		found := false
+		for _, substitution := range substitutions[recipeIngredient.IngredientID] {
+			if substitution.ID == choice.SubstitutionID {
+				chosen[recipeIngredient.ID] = substitution
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ormerrors.NewValidationError(fmt.Sprintf("substitution %d does not apply to ingredient %s", choice.SubstitutionID, recipeIngredient.Ingredient.Name))
+		}
+	}
+
+	// La copie et les remplacements sont validés ensemble : pas de copie orpheline en cas d'échec
+	var adapted *dto.Recipe
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		copied, err := NewRecipeRepository(tx).Copy(ctx, recipeID, userID)
+		if err != nil {
+			return err
+		}
+		adapted = copied
+		// La copie reprend les ingrédients dans le même ordre que l'originale
+		if len(adapted.Ingredients) != len(original.Ingredients) {
+			return ormerrors.NewDatabaseError("adapt recipe", errors.New("copied ingredients do not match the original recipe"))
+		}
+
+		if req.Title != "" {
+			if err := tx.Model(&dto.Recipe{}).Where("id = ?", adapted.ID).Update("title", req.Title).Error; err != nil {
+				return ormerrors.NewDatabaseError("rename adapted recipe", err)
+			}
+		}
+
+		for i, recipeIngredient := range original.Ingredients {
+			substitution, ok := chosen[recipeIngredient.ID]
+			if !ok {
+				continue
+			}
+
+			if err := tx.Delete(&dto.RecipeIngredient{}, adapted.Ingredients[i].ID).Error; err != nil {
+				return ormerrors.NewDatabaseError("remove substituted ingredient", err)
+			}
+
+			option := buildSubstitutionOption(recipeIngredient, substitution, nil)
+			note := "Remplace " + recipeIngredient.Ingredient.Name
+			if recipeIngredient.Quantity > 0 {
+				amount := units.FormatQuantity(recipeIngredient.Quantity, units.Parse(recipeIngredient.Unit))
+				if recipeIngredient.Unit != "" {
+					amount += " " + recipeIngredient.Unit
+				}
+				note = "Remplace " + amount + " " + recipeIngredient.Ingredient.Name
+			}
+			for _, portion := range option.Components {
+				replacement := &dto.RecipeIngredient{
+					RecipeID:     adapted.ID,
+					IngredientID: portion.IngredientID,
+					Quantity:     portion.Quantity,
+					Unit:         portion.Unit,
+					Notes:        note,
+					IsOptional:   recipeIngredient.IsOptional,
+					Group:        recipeIngredient.Group,
+					Position:     recipeIngredient.Position,
+				}
+				if err := tx.Create(replacement).Error; err != nil {
+					return ormerrors.NewDatabaseError("add substitute ingredient", err)
+				}
+			}
+		}
+
+		return refreshRecipeLabels(tx, adapted.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRecipeRepository(r.db).GetByID(ctx, adapted.ID)
+}
+
+// loadAccessibleRecipe charge une recette publique ou appartenant à l'utilisateur, avec ses ingrédients
+func (r *ingredientSubstitutionRepository) loadAccessibleRecipe(ctx context.Context, recipeID, userID uint) (*dto.Recipe, error) {
+	var recipe dto.Recipe
+	if err := r.db.WithContext(ctx).
+		Preload("Ingredients", func(db *gorm.DB) *gorm.DB {
+			return db.Order("recipe_ingredients.position ASC").Order("recipe_ingredients.id ASC")
+		}).
+		Preload("Ingredients.Ingredient").
+		Where("is_public = ? OR author_id = ?", true, userID).
+		First(&recipe, recipeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("recipe", recipeID)
+		}
+		return nil, ormerrors.NewDatabaseError("get recipe for substitutions", err)
+	}
+	return &recipe, nil
+}
+
+// substitutionsFor charge les remplacements applicables à chaque ingrédient d'une recette :
+// ceux de l'ingrédient puis ceux de ses ingrédients plus généraux
+func (r *ingredientSubstitutionRepository) substitutionsFor(ctx context.Context, recipe *dto.Recipe, taxonomy ingredientTaxonomy) (map[uint][]dto.IngredientSubstitution, error) {
+	lineages := make(map[uint][]uint, len(recipe.Ingredients))
+	ids := make([]uint, 0, len(recipe.Ingredients))
+	for _, recipeIngredient := range recipe.Ingredients {
+		lineage := append([]uint{recipeIngredient.IngredientID}, taxonomy.ancestors(recipeIngredient.IngredientID)...)
+		lineages[recipeIngredient.IngredientID] = lineage
+		ids = append(ids, lineage...)
+	}
+
+	result := make(map[uint][]dto.IngredientSubstitution, len(lineages))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var substitutions []dto.IngredientSubstitution
+	if err := r.db.WithContext(ctx).
+		Preload("Components.Ingredient").
+		Where("ingredient_id IN ?", uniqueUints(ids)).
+		Order("id ASC").
+		Find(&substitutions).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get recipe substitutions", err)
+	}
+
+	byIngredient := make(map[uint][]dto.IngredientSubstitution)
+	for _, substitution := range substitutions {
+		byIngredient[substitution.IngredientID] = append(byIngredient[substitution.IngredientID], substitution)
+	}
+	for ingredientID, lineage := range lineages {
+		for _, id := range lineage {
+			result[ingredientID] = append(result[ingredientID], byIngredient[id]...)
+		}
+	}
+	return result, nil
+}
+
+// buildSubstitutionOption applique un remplacement à la quantité demandée par la recette.
+// Sans stock (nil), la disponibilité des composants n'est pas évaluée.
+func buildSubstitutionOption(recipeIngredient dto.RecipeIngredient, substitution dto.IngredientSubstitution, stock fridgeStock) dto.SubstitutionOption {
+	option := dto.SubstitutionOption{
+		SubstitutionID: substitution.ID,
+		Notes:          substitution.Notes,
+		Diets:          substitution.Diets,
+		Components:     make([]dto.SubstitutionPortion, 0, len(substitution.Components)),
+		Available:      stock != nil,
+	}
+
+	// Rapport entre la quantité de la recette et la quantité de référence du remplacement
+	ratio := 1.0
+	needed, ok := units.Convert(recipeIngredient.Quantity, units.Parse(recipeIngredient.Unit), units.Parse(substitution.Unit), ingredientHints(recipeIngredient.Ingredient))
+	if ok && needed > 0 && substitution.Quantity > 0 {
+		ratio = needed / substitution.Quantity
+	} else {
+		option.Approximate = true
+	}
+
+	for _, component := range substitution.Components {
+		unit := units.Parse(component.Unit)
+		quantity := units.RoundForKitchen(component.Quantity*ratio, unit, component.Ingredient.Name)
+		portion := dto.SubstitutionPortion{
+			IngredientID:   component.IngredientID,
+			IngredientName: component.Ingredient.Name,
+			Quantity:       quantity,
+			Unit:           unit.Code,
+		}
+		if stock != nil {
+			portion.InFridge = stock.covers(component.Ingredient, quantity, component.Unit)
+			option.Available = option.Available && portion.InFridge
+		}
+		option.Components = append(option.Components, portion)
+	}
+	return option
+}
+
+// substitutionHasAllergen indique si l'un des composants d'un remplacement contient un allergène à éviter
+func substitutionHasAllergen(substitution dto.IngredientSubstitution, avoided map[string]bool) bool {
+	for _, component := range substitution.Components {
+		for _, allergen := range component.Ingredient.Allergens {
+			if avoided[allergen] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsAll indique si la liste contient toutes les valeurs demandées
+func containsAll(list []string, values []string) bool {
+	for _, value := range values {
+		found := false
+		for _, item := range list {
+			if item == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
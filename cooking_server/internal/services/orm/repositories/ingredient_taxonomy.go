@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+)
+
+// ingredientAncestorsSQL sélectionne les ingrédients donnés et tous leurs ancêtres (plus généraux).
+// UNION (et non UNION ALL) arrête la récursion même en présence d'un cycle.
+const ingredientAncestorsSQL = `WITH RECURSIVE lineage(id, parent_id) AS (
+		SELECT id, parent_id FROM ingredients WHERE id IN ?
+		UNION
+		SELECT ingredients.id, ingredients.parent_id FROM ingredients JOIN lineage ON ingredients.id = lineage.parent_id
+	) SELECT id FROM lineage`
+
+// ingredientDescendantsSQL sélectionne les ingrédients donnés et toutes leurs variantes (plus spécifiques)
+const ingredientDescendantsSQL = `WITH RECURSIVE lineage(id) AS (
+		SELECT id FROM ingredients WHERE id IN ?
+		UNION
+		SELECT ingredients.id FROM ingredients JOIN lineage ON ingredients.parent_id = lineage.id
+	) SELECT id FROM lineage`
+
+// ingredientTaxonomy associe chaque ingrédient à son ingrédient parent (plus général)
+type ingredientTaxonomy map[uint]uint
+
+// loadIngredientTaxonomy charge les liens parent/enfant de tous les ingrédients
+func loadIngredientTaxonomy(db *gorm.DB) (ingredientTaxonomy, error) {
+	var links []struct {
+		ID       uint
+		ParentID uint
+	}
+	if err := db.Model(&dto.Ingredient{}).
+		Select("id, parent_id").
+		Where("parent_id IS NOT NULL").
+		Scan(&links).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("load ingredient taxonomy", err)
+	}
+
+	taxonomy := make(ingredientTaxonomy, len(links))
+	for _, link := range links {
+		taxonomy[link.ID] = link.ParentID
+	}
+	return taxonomy, nil
+}
+
+// ancestors retourne les ingrédients plus généraux qu'un ingrédient, du parent direct à la racine
+func (t ingredientTaxonomy) ancestors(id uint) []uint {
+	var result []uint
+	seen := map[uint]bool{id: true}
+	for parent, ok := t[id]; ok && !seen[parent]; parent, ok = t[parent] {
+		seen[parent] = true
+		result = append(result, parent)
+	}
+	return result
+}
+
+// applyIngredientFilter restreint les recettes utilisant les ingrédients donnés. Un ingrédient
+// satisfait aussi les recettes demandant un ingrédient plus général (tomate cerise → tomate).
+// En mode "all", chacun des ingrédients doit être satisfait, sinon au moins un.
+func applyIngredientFilter(query *gorm.DB, values []string, match string) *gorm.DB {
+	ids := uniqueUints(toUintSlice(values))
+	if len(ids) == 0 {
+		return query
+	}
+
+	condition := "EXISTS (SELECT 1 FROM recipe_ingredients WHERE recipe_ingredients.recipe_id = recipes.id" +
+		" AND recipe_ingredients.ingredient_id IN (" + ingredientAncestorsSQL + "))"
+	if match == dto.MatchAll {
+		for _, id := range ids {
+			query = query.Where(condition, []uint{id})
+		}
+		return query
+	}
+	return query.Where(condition, ids)
+}
+
+// applyIngredientExclusion écarte les recettes utilisant l'un des ingrédients donnés ou l'une de ses variantes
+func applyIngredientExclusion(query *gorm.DB, values []string) *gorm.DB {
+	ids := uniqueUints(toUintSlice(values))
+	if len(ids) == 0 {
+		return query
+	}
+
+	return query.Where("NOT EXISTS (SELECT 1 FROM recipe_ingredients WHERE recipe_ingredients.recipe_id = recipes.id"+
+		" AND recipe_ingredients.ingredient_id IN ("+ingredientDescendantsSQL+"))", ids)
+}
+
+// resolveClassification valide la catégorie et le parent d'un ingrédient avant son enregistrement.
+// Le nom de catégorie est synchronisé avec la table des catégories (créée si elle n'existe pas),
+// et un parent ne peut pas être l'ingrédient lui-même ni l'une de ses variantes.
+func resolveClassification(tx *gorm.DB, ingredient *dto.Ingredient) error {
+	ingredient.IngredientCategory = nil
+	if ingredient.CategoryID != nil && *ingredient.CategoryID == 0 {
+		ingredient.CategoryID = nil
+		ingredient.Category = ""
+	}
+
+	switch {
+	case ingredient.CategoryID != nil:
+		var category dto.IngredientCategory
+		if err := tx.First(&category, *ingredient.CategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ormerrors.NewValidationError("ingredient category not found")
+			}
+			return ormerrors.NewDatabaseError("get ingredient category", err)
+		}
+		ingredient.Category = category.Name
+		ingredient.IngredientCategory = &category
+	case strings.TrimSpace(ingredient.Category) != "":
+		category := dto.IngredientCategory{Name: strings.TrimSpace(ingredient.Category)}
+		if err := tx.Where("LOWER(name) = LOWER(?)", category.Name).FirstOrCreate(&category).Error; err != nil {
+			return ormerrors.NewDatabaseError("resolve ingredient category", err)
+		}
+		ingredient.Category = category.Name
+		ingredient.CategoryID = &category.ID
+		ingredient.IngredientCategory = &category
+	}
+
+	ingredient.Parent = nil
+	if ingredient.ParentID != nil && *ingredient.ParentID == 0 {
+		ingredient.ParentID = nil
+	}
+	if ingredient.ParentID == nil {
+		return nil
+	}
+	if *ingredient.ParentID == ingredient.ID {
+		return ormerrors.NewValidationError("an ingredient cannot be its own parent")
+	}
+
+	var parent dto.Ingredient
+	if err := tx.First(&parent, *ingredient.ParentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ormerrors.NewValidationError("parent ingredient not found")
+		}
+		return ormerrors.NewDatabaseError("get parent ingredient", err)
+	}
+
+	if ingredient.ID != 0 {
+		taxonomy, err := loadIngredientTaxonomy(tx)
+		if err != nil {
+			return err
+		}
+		for _, ancestor := range taxonomy.ancestors(parent.ID) {
+			if ancestor == ingredient.ID {
+				return ormerrors.NewValidationError("parent ingredient cannot be one of its variants")
+			}
+		}
+	}
+	ingredient.Parent = &parent
+	return nil
+}
+
+// GetHierarchy récupère un ingrédient avec ses ingrédients plus généraux et ses variantes plus spécifiques
+func (r *ingredientRepository) GetHierarchy(ctx context.Context, id uint) (*dto.IngredientHierarchy, error) {
+	ingredient, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	taxonomy, err := loadIngredientTaxonomy(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	hierarchy := &dto.IngredientHierarchy{
+		Ingredient:  *ingredient,
+		Ancestors:   []dto.Ingredient{},
+		Descendants: []dto.Ingredient{},
+	}
+
+	if ancestorIDs := taxonomy.ancestors(id); len(ancestorIDs) > 0 {
+		var ancestors []dto.Ingredient
+		if err := r.db.WithContext(ctx).Where("id IN ?", ancestorIDs).Find(&ancestors).Error; err != nil {
+			return nil, ormerrors.NewDatabaseError("get ingredient ancestors", err)
+		}
+		byID := make(map[uint]dto.Ingredient, len(ancestors))
+		for _, ancestor := range ancestors {
+			byID[ancestor.ID] = ancestor
+		}
+		for _, ancestorID := range ancestorIDs {
+			if ancestor, ok := byID[ancestorID]; ok {
+				hierarchy.Ancestors = append(hierarchy.Ancestors, ancestor)
+			}
+		}
+	}
+
+	if err := r.db.WithContext(ctx).
+		Where("id <> ? AND id IN ("+ingredientDescendantsSQL+")", id, []uint{id}).
+		Order("name ASC").
+		Find(&hierarchy.Descendants).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get ingredient descendants", err)
+	}
+
+	return hierarchy, nil
+}
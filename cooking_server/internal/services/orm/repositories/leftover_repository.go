@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type leftoverRepository struct {
+	db *gorm.DB
+}
+
+// NewLeftoverRepository crée une nouvelle instance du repository des restes
+func NewLeftoverRepository(db *gorm.DB) *leftoverRepository {
+	return &leftoverRepository{db: db}
+}
+
+// Create enregistre des restes au frigo. Les restes d'un repas ne sont enregistrés qu'une fois.
+func (r *leftoverRepository) Create(ctx context.Context, leftover *dto.Leftover) error {
+	if leftover.MealPlanID != nil {
+		var existing int64
+		if err := r.db.WithContext(ctx).Model(&dto.Leftover{}).
+			Where("meal_plan_id = ?", *leftover.MealPlanID).
+			Count(&existing).Error; err != nil {
+			return ormerrors.NewDatabaseError("check meal plan leftovers", err)
+		}
+		if existing > 0 {
+			return ormerrors.NewDuplicateError("leftover", "meal_plan_id", *leftover.MealPlanID)
+		}
+	}
+
+	if err := r.db.WithContext(ctx).Omit(clause.Associations).Create(leftover).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ormerrors.NewDuplicateError("leftover", "meal_plan_id", *leftover.MealPlanID)
+		}
+		return ormerrors.NewDatabaseError("create leftover", err)
+	}
+	return nil
+}
+
+// GetByID récupère des restes avec leur recette et leurs portions consommées, prévues et disponibles
+func (r *leftoverRepository) GetByID(ctx context.Context, id uint) (*dto.Leftover, error) {
+	var leftover dto.Leftover
+	if err := r.db.WithContext(ctx).Preload("Recipe").First(&leftover, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("leftover", id)
+		}
+		return nil, ormerrors.NewDatabaseError("get leftover by id", err)
+	}
+	if err := loadLeftoverPortions(r.db.WithContext(ctx), []*dto.Leftover{&leftover}); err != nil {
+		return nil, err
+	}
+	return &leftover, nil
+}
+
+// GetByUser liste les restes d'un utilisateur, par date de péremption. Sans includeFinished,
+// seuls les restes non périmés ayant encore des portions disponibles sont retournés.
+func (r *leftoverRepository) GetByUser(ctx context.Context, userID uint, includeFinished bool) ([]*dto.Leftover, error) {
+	query := r.db.WithContext(ctx).Preload("Recipe").Where("user_id = ?", userID)
+	if !includeFinished {
+		query = query.Where("expiry_date >= ?", dateOnly(time.Now()))
+	}
+
+	var leftovers []*dto.Leftover
+	if err := query.Order("expiry_date ASC, id ASC").Find(&leftovers).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get leftovers by user", err)
+	}
+	if err := loadLeftoverPortions(r.db.WithContext(ctx), leftovers); err != nil {
+		return nil, err
+	}
+	if includeFinished {
+		return leftovers, nil
+	}
+
+	available := make([]*dto.Leftover, 0, len(leftovers))
+	for _, leftover := range leftovers {
+		if leftover.AvailablePortions > 0 {
+			available = append(available, leftover)
+		}
+	}
+	return available, nil
+}
+
+// Delete retire des restes du frigo. Les repas à venir prévus avec ces restes restent planifiés
+// mais redeviennent des repas à préparer, dont les ingrédients comptent dans la liste de courses.
+func (r *leftoverRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&dto.MealPlan{}).
+			Where("leftover_id = ? AND is_completed = ?", id, false).
+			Updates(map[string]interface{}{"leftover_id": nil, "updated_at": time.Now()}).Error; err != nil {
+			return ormerrors.NewDatabaseError("unlink leftover meal plans", err)
+		}
+		result := tx.Delete(&dto.Leftover{}, id)
+		if result.Error != nil {
+			return ormerrors.NewDatabaseError("delete leftover", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ormerrors.NewNotFoundError("leftover", id)
+		}
+		return nil
+	})
+}
+
+// Schedule planifie un repas avec des restes. Le repas doit avoir lieu avant leur péremption
+// et ne pas dépasser les portions disponibles ; sans portions demandées, toutes sont utilisées.
+func (r *leftoverRepository) Schedule(ctx context.Context, id uint, mealPlan *dto.MealPlan) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Verrouiller les restes pour que deux planifications simultanées ne réservent pas les mêmes portions
+		var leftover dto.Leftover
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&leftover, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ormerrors.NewNotFoundError("leftover", id)
+			}
+			return ormerrors.NewDatabaseError("get leftover to schedule", err)
+		}
+		if err := loadLeftoverPortions(tx, []*dto.Leftover{&leftover}); err != nil {
+			return err
+		}
+
+		if dateOnly(mealPlan.PlannedDate).After(dateOnly(leftover.ExpiryDate)) {
+			return ormerrors.NewValidationError("leftovers expire before the planned date")
+		}
+		if mealPlan.Servings <= 0 {
+			mealPlan.Servings = leftover.AvailablePortions
+		}
+		if mealPlan.Servings <= 0 || mealPlan.Servings > leftover.AvailablePortions {
+			return ormerrors.NewValidationError("not enough leftover portions available")
+		}
+
+		mealPlan.RecipeID = leftover.RecipeID
+		mealPlan.LeftoverID = &leftover.ID
+		if err := tx.Omit(clause.Associations).Create(mealPlan).Error; err != nil {
+			return ormerrors.NewDatabaseError("schedule leftover meal plan", err)
+		}
+		return nil
+	})
+}
+
+// deleteMealPlanLeftovers retire les restes d'un repas dont la réalisation est annulée. Les repas à venir
+// prévus avec ces restes redeviennent des repas à préparer ; l'annulation est refusée si des restes ont
+// déjà été mangés.
+func deleteMealPlanLeftovers(tx *gorm.DB, mealPlanID uint) error {
+	var leftoverIDs []uint
+	if err := tx.Model(&dto.Leftover{}).Where("meal_plan_id = ?", mealPlanID).Pluck("id", &leftoverIDs).Error; err != nil {
+		return ormerrors.NewDatabaseError("get meal plan leftovers", err)
+	}
+	if len(leftoverIDs) == 0 {
+		return nil
+	}
+
+	var eaten int64
+	if err := tx.Model(&dto.MealPlan{}).
+		Where("leftover_id IN ? AND is_completed = ?", leftoverIDs, true).
+		Count(&eaten).Error; err != nil {
+		return ormerrors.NewDatabaseError("count eaten leftovers", err)
+	}
+	if eaten > 0 {
+		return ormerrors.NewValidationError("leftovers of this meal have already been eaten")
+	}
+
+	if err := tx.Model(&dto.MealPlan{}).
+		Where("leftover_id IN ?", leftoverIDs).
+		Updates(map[string]interface{}{"leftover_id": nil, "updated_at": time.Now()}).Error; err != nil {
+		return ormerrors.NewDatabaseError("unlink leftover meal plans", err)
+	}
+	if err := tx.Delete(&dto.Leftover{}, leftoverIDs).Error; err != nil {
+		return ormerrors.NewDatabaseError("delete meal plan leftovers", err)
+	}
+	return nil
+}
+
+// loadLeftoverPortions calcule les portions réalisées, prévues et disponibles de chaque reste
+func loadLeftoverPortions(db *gorm.DB, leftovers []*dto.Leftover) error {
+	if len(leftovers) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(leftovers))
+	for _, leftover := range leftovers {
+		ids = append(ids, leftover.ID)
+	}
+
+	var usages []struct {
+		LeftoverID  uint
+		IsCompleted bool
+		Servings    int
+	}
+	if err := db.Model(&dto.MealPlan{}).
+		Select("leftover_id, is_completed, SUM(servings) AS servings").
+		Where("leftover_id IN ?", ids).
+		Group("leftover_id, is_completed").
+		Scan(&usages).Error; err != nil {
+		return ormerrors.NewDatabaseError("get leftover portions", err)
+	}
+
+	byID := make(map[uint]*dto.Leftover, len(leftovers))
+	for _, leftover := range leftovers {
+		byID[leftover.ID] = leftover
+	}
+	for _, usage := range usages {
+		leftover := byID[usage.LeftoverID]
+		if usage.IsCompleted {
+			leftover.EatenPortions += usage.Servings
+		} else {
+			leftover.ScheduledPortions += usage.Servings
+		}
+	}
+	for _, leftover := range leftovers {
+		leftover.AvailablePortions = max(leftover.Portions-leftover.EatenPortions-leftover.ScheduledPortions, 0)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CopyRange copie les repas planifiés entre startDate et endDate (jours inclus) à partir de targetDate.
+// Les copies sont des repas indépendants non réalisés, y compris celles d'occurrences de repas
+// récurrents, qui ne suivent pas la règle d'origine. Retourne le nombre de repas créés.
+func (r *mealPlanRepository) CopyRange(ctx context.Context, userID uint, startDate, endDate, targetDate time.Time) (int, error) {
+	offset := daysBetween(dateOnly(startDate), dateOnly(targetDate))
+	created := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Les occurrences sont créées dans la même transaction que l'opération qui les manipule
+		if err := materializeRecurrences(ctx, tx, userID, startDate, endDate); err != nil {
+			return err
+		}
+
+		mealPlans, err := mealPlansInRange(tx, userID, startDate, endDate)
+		if err != nil {
+			return err
+		}
+
+		copies := make([]dto.MealPlan, 0, len(mealPlans))
+		for _, mealPlan := range mealPlans {
+			copies = append(copies, dto.MealPlan{
+				UserID:      mealPlan.UserID,
+				RecipeID:    mealPlan.RecipeID,
+				PlannedDate: mealPlan.PlannedDate.AddDate(0, 0, offset),
+				MealType:    mealPlan.MealType,
+				Servings:    mealPlan.Servings,
+				Notes:       mealPlan.Notes,
+			})
+		}
+		if len(copies) == 0 {
+			return nil
+		}
+		if err := tx.Omit(clause.Associations).Create(&copies).Error; err != nil {
+			return ormerrors.NewDatabaseError("copy meal plans", err)
+		}
+		created = len(copies)
+		return nil
+	})
+	return created, err
+}
+
+// ShiftRange décale de days jours les repas non réalisés planifiés entre startDate et endDate.
+// Les occurrences de repas récurrents déplacées ne suivent plus leur règle, et un repas avec des restes
+// ne peut pas être repoussé après leur péremption. Retourne le nombre de repas déplacés.
+func (r *mealPlanRepository) ShiftRange(ctx context.Context, userID uint, startDate, endDate time.Time, days int) (int, error) {
+	shifted := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := materializeRecurrences(ctx, tx, userID, startDate, endDate); err != nil {
+			return err
+		}
+
+		mealPlans, err := mealPlansInRange(tx.Where("is_completed = ?", false), userID, startDate, endDate)
+		if err != nil {
+			return err
+		}
+		if err := checkShiftedLeftovers(tx, mealPlans, days); err != nil {
+			return err
+		}
+
+		for _, mealPlan := range mealPlans {
+			if err := tx.Model(&dto.MealPlan{}).
+				Where("id = ?", mealPlan.ID).
+				Updates(map[string]interface{}{
+					"planned_date": mealPlan.PlannedDate.AddDate(0, 0, days),
+					"detached":     mealPlan.RecurrenceID != nil,
+					"updated_at":   time.Now(),
+				}).Error; err != nil {
+				return ormerrors.NewDatabaseError("shift meal plan", err)
+			}
+		}
+		shifted = len(mealPlans)
+		return nil
+	})
+	return shifted, err
+}
+
+// Swap échange la date et le type de repas de deux repas planifiés d'un utilisateur.
+// Les occurrences de repas récurrents échangées ne suivent plus leur règle, et un repas avec des restes
+// ne peut pas prendre une date postérieure à leur péremption.
+func (r *mealPlanRepository) Swap(ctx context.Context, userID, firstID, secondID uint) ([]*dto.MealPlan, error) {
+	var swapped []*dto.MealPlan
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var mealPlans []*dto.MealPlan
+		for _, id := range []uint{firstID, secondID} {
+			var mealPlan dto.MealPlan
+			if err := tx.Where("user_id = ?", userID).First(&mealPlan, id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ormerrors.NewNotFoundError("meal plan", id)
+				}
+				return ormerrors.NewDatabaseError("get meal plan to swap", err)
+			}
+			mealPlans = append(mealPlans, &mealPlan)
+		}
+
+		first, second := mealPlans[0], mealPlans[1]
+		first.PlannedDate, second.PlannedDate = second.PlannedDate, first.PlannedDate
+		first.MealType, second.MealType = second.MealType, first.MealType
+		if err := checkShiftedLeftovers(tx, mealPlans, 0); err != nil {
+			return err
+		}
+		for _, mealPlan := range mealPlans {
+			if err := tx.Model(&dto.MealPlan{}).
+				Where("id = ?", mealPlan.ID).
+				Updates(map[string]interface{}{
+					"planned_date": mealPlan.PlannedDate,
+					"meal_type":    mealPlan.MealType,
+					"detached":     mealPlan.Detached || mealPlan.RecurrenceID != nil,
+					"updated_at":   time.Now(),
+				}).Error; err != nil {
+				return ormerrors.NewDatabaseError("swap meal plans", err)
+			}
+		}
+		swapped = mealPlans
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return swapped, nil
+}
+
+// ClearRange supprime les repas planifiés entre startDate et endDate, réalisés compris si demandé.
+// Les occurrences de repas récurrents supprimées ne sont pas recréées. Retourne le nombre de repas supprimés.
+func (r *mealPlanRepository) ClearRange(ctx context.Context, userID uint, startDate, endDate time.Time, includeCompleted bool) (int, error) {
+	cleared := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := materializeRecurrences(ctx, tx, userID, startDate, endDate); err != nil {
+			return err
+		}
+
+		query := tx
+		if !includeCompleted {
+			query = query.Where("is_completed = ?", false)
+		}
+		mealPlans, err := mealPlansInRange(query, userID, startDate, endDate)
+		if err != nil {
+			return err
+		}
+		if len(mealPlans) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(mealPlans))
+		var skips []dto.MealPlanRecurrenceSkip
+		for _, mealPlan := range mealPlans {
+			ids = append(ids, mealPlan.ID)
+			if mealPlan.RecurrenceID != nil && mealPlan.OccurrenceDate != nil {
+				skips = append(skips, dto.MealPlanRecurrenceSkip{RecurrenceID: *mealPlan.RecurrenceID, OccurrenceDate: *mealPlan.OccurrenceDate})
+			}
+		}
+		if len(skips) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&skips).Error; err != nil {
+				return ormerrors.NewDatabaseError("skip meal plan occurrences", err)
+			}
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&dto.MealPlan{}).Error; err != nil {
+			return ormerrors.NewDatabaseError("clear meal plans", err)
+		}
+		cleared = len(ids)
+		return nil
+	})
+	return cleared, err
+}
+
+// checkShiftedLeftovers vérifie que les repas avec des restes, décalés de days jours par rapport
+// à leur date planifiée, restent planifiés avant la péremption de ces restes
+func checkShiftedLeftovers(tx *gorm.DB, mealPlans []*dto.MealPlan, days int) error {
+	var leftoverIDs []uint
+	for _, mealPlan := range mealPlans {
+		if mealPlan.LeftoverID != nil {
+			leftoverIDs = append(leftoverIDs, *mealPlan.LeftoverID)
+		}
+	}
+	if len(leftoverIDs) == 0 {
+		return nil
+	}
+
+	var leftovers []dto.Leftover
+	if err := tx.Where("id IN ?", uniqueUints(leftoverIDs)).Find(&leftovers).Error; err != nil {
+		return ormerrors.NewDatabaseError("get shifted leftovers", err)
+	}
+	expiries := make(map[uint]time.Time, len(leftovers))
+	for _, leftover := range leftovers {
+		expiries[leftover.ID] = dateOnly(leftover.ExpiryDate)
+	}
+	for _, mealPlan := range mealPlans {
+		if mealPlan.LeftoverID == nil {
+			continue
+		}
+		expiry, ok := expiries[*mealPlan.LeftoverID]
+		if ok && dateOnly(mealPlan.PlannedDate.AddDate(0, 0, days)).After(expiry) {
+			return ormerrors.NewValidationError(fmt.Sprintf("meal plan %d would be planned after its leftovers expire", mealPlan.ID))
+		}
+	}
+	return nil
+}
+
+// mealPlansInRange charge les repas d'un utilisateur planifiés entre deux jours (inclus)
+func mealPlansInRange(tx *gorm.DB, userID uint, startDate, endDate time.Time) ([]*dto.MealPlan, error) {
+	var mealPlans []*dto.MealPlan
+	if err := tx.
+		Where("user_id = ? AND planned_date >= ? AND planned_date < ?", userID, dateOnly(startDate), dateOnly(endDate).AddDate(0, 0, 1)).
+		Order("planned_date ASC, id ASC").
+		Find(&mealPlans).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get meal plans in range", err)
+	}
+	return mealPlans, nil
+}
@@ -0,0 +1,254 @@
+package repositories
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type mealPlanCalendarRepository struct {
+	db *gorm.DB
+}
+
+// NewMealPlanCalendarRepository crée une nouvelle instance du repository des flux iCalendar
+func NewMealPlanCalendarRepository(db *gorm.DB) *mealPlanCalendarRepository {
+	return &mealPlanCalendarRepository{db: db}
+}
+
+// GetOrCreateByUser récupère les réglages du flux iCalendar d'un utilisateur, créés au premier accès
+func (r *mealPlanCalendarRepository) GetOrCreateByUser(ctx context.Context, userID uint) (*dto.MealPlanCalendar, error) {
+	var calendar dto.MealPlanCalendar
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&calendar).Error
+	if err == nil {
+		return &calendar, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ormerrors.NewDatabaseError("get meal plan calendar", err)
+	}
+
+	token, err := newCalendarToken()
+	if err != nil {
+		return nil, err
+	}
+	calendar = dto.MealPlanCalendar{UserID: userID, Token: token}
+	// Un accès concurrent a pu créer le flux entre-temps
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&calendar).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("create meal plan calendar", err)
+	}
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&calendar).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get meal plan calendar", err)
+	}
+	return &calendar, nil
+}
+
+// GetByToken récupère le flux iCalendar correspondant à un jeton
+func (r *mealPlanCalendarRepository) GetByToken(ctx context.Context, token string) (*dto.MealPlanCalendar, error) {
+	var calendar dto.MealPlanCalendar
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&calendar).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("meal plan calendar", token)
+		}
+		return nil, ormerrors.NewDatabaseError("get meal plan calendar by token", err)
+	}
+	return &calendar, nil
+}
+
+// Update modifie les heures de repas, la durée et le fuseau horaire du flux
+func (r *mealPlanCalendarRepository) Update(ctx context.Context, calendar *dto.MealPlanCalendar) error {
+	if err := r.db.WithContext(ctx).Model(&dto.MealPlanCalendar{}).
+		Where("id = ?", calendar.ID).
+		Updates(map[string]interface{}{
+			"breakfast_time": calendar.BreakfastTime,
+			"lunch_time":     calendar.LunchTime,
+			"dinner_time":    calendar.DinnerTime,
+			"snack_time":     calendar.SnackTime,
+			"duration":       calendar.Duration,
+			"time_zone":      calendar.TimeZone,
+			"updated_at":     time.Now(),
+		}).Error; err != nil {
+		return ormerrors.NewDatabaseError("update meal plan calendar", err)
+	}
+	return nil
+}
+
+// RegenerateToken remplace le jeton du flux : l'ancienne URL d'abonnement cesse de fonctionner
+func (r *mealPlanCalendarRepository) RegenerateToken(ctx context.Context, calendar *dto.MealPlanCalendar) error {
+	token, err := newCalendarToken()
+	if err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Model(&dto.MealPlanCalendar{}).
+		Where("id = ?", calendar.ID).
+		Updates(map[string]interface{}{"token": token, "updated_at": time.Now()}).Error; err != nil {
+		return ormerrors.NewDatabaseError("regenerate meal plan calendar token", err)
+	}
+	calendar.Token = token
+	return nil
+}
+
+// Import planifie les événements d'un fichier iCalendar dont le titre correspond à une recette accessible
+// (les recettes de l'utilisateur d'abord, puis les recettes publiques les mieux notées). Le type de repas
+// vient du titre, sinon de l'heure de repas la plus proche, sinon de defaultMealType pour les événements
+// sur la journée. Les repas déjà planifiés (même jour, type et recette) sont ignorés.
+func (r *mealPlanCalendarRepository) Import(ctx context.Context, calendar *dto.MealPlanCalendar, events []dto.MealPlanCalendarEvent, defaultMealType string) (*dto.MealPlanCalendarImportResult, error) {
+	result := &dto.MealPlanCalendarImportResult{Created: []*dto.MealPlan{}, Unmatched: []string{}}
+	if len(events) == 0 {
+		return result, nil
+	}
+
+	location := calendar.Location()
+	recipes := make(map[string]*dto.Recipe)
+	unmatched := make(map[string]bool)
+	var mealPlans []*dto.MealPlan
+	startDate, endDate := time.Time{}, time.Time{}
+	for _, event := range events {
+		key := strings.ToLower(strings.TrimSpace(event.Title))
+		recipe, known := recipes[key]
+		if !known {
+			var err error
+			if recipe, err = r.matchRecipe(ctx, calendar.UserID, key); err != nil {
+				return nil, err
+			}
+			recipes[key] = recipe
+		}
+		if recipe == nil {
+			if !unmatched[key] {
+				unmatched[key] = true
+				result.Unmatched = append(result.Unmatched, event.Title)
+			}
+			continue
+		}
+
+		start := event.Start.In(location)
+		date := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		mealType := event.MealType
+		if mealType == "" {
+			mealType = defaultMealType
+			if !event.AllDay {
+				mealType = nearestMealType(calendar, start)
+			}
+		}
+		mealPlans = append(mealPlans, &dto.MealPlan{
+			UserID:      calendar.UserID,
+			RecipeID:    recipe.ID,
+			PlannedDate: date,
+			MealType:    mealType,
+			Servings:    max(recipe.Servings, 1),
+		})
+		if startDate.IsZero() || date.Before(startDate) {
+			startDate = date
+		}
+		if date.After(endDate) {
+			endDate = date
+		}
+	}
+	if len(mealPlans) == 0 {
+		return result, nil
+	}
+
+	if err := materializeRecurrences(ctx, r.db, calendar.UserID, startDate, endDate); err != nil {
+		return nil, err
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := mealPlansInRange(tx, calendar.UserID, startDate, endDate)
+		if err != nil {
+			return err
+		}
+		planned := make(map[string]bool, len(existing))
+		for _, mealPlan := range existing {
+			planned[importKey(mealPlan)] = true
+		}
+
+		var created []*dto.MealPlan
+		for _, mealPlan := range mealPlans {
+			if planned[importKey(mealPlan)] {
+				result.Skipped++
+				continue
+			}
+			planned[importKey(mealPlan)] = true
+			created = append(created, mealPlan)
+		}
+		if len(created) == 0 {
+			return nil
+		}
+		if err := tx.Omit(clause.Associations).Create(&created).Error; err != nil {
+			return ormerrors.NewDatabaseError("import meal plans", err)
+		}
+
+		ids := make([]uint, 0, len(created))
+		for _, mealPlan := range created {
+			ids = append(ids, mealPlan.ID)
+		}
+		if err := tx.Preload("Recipe").
+			Where("id IN ?", ids).
+			Order("planned_date ASC, meal_type").
+			Find(&result.Created).Error; err != nil {
+			return ormerrors.NewDatabaseError("get imported meal plans", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// matchRecipe cherche la recette accessible dont le titre correspond, sans tenir compte de la casse
+func (r *mealPlanCalendarRepository) matchRecipe(ctx context.Context, userID uint, title string) (*dto.Recipe, error) {
+	var recipe dto.Recipe
+	err := r.db.WithContext(ctx).
+		Select("id, servings").
+		Where("LOWER(TRIM(title)) = ? AND (is_public = ? OR author_id = ?)", title, true, userID).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "author_id = ? DESC, average_rating DESC, id ASC", Vars: []interface{}{userID}, WithoutParentheses: true}}).
+		First(&recipe).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, ormerrors.NewDatabaseError("match recipe title", err)
+	}
+	return &recipe, nil
+}
+
+// nearestMealType retourne le type de repas dont l'heure est la plus proche de l'heure donnée
+func nearestMealType(calendar *dto.MealPlanCalendar, start time.Time) string {
+	minutes := start.Hour()*60 + start.Minute()
+	best, bestGap := "dinner", -1
+	for _, mealType := range generatorMealTypes {
+		clock, err := time.Parse("15:04", calendar.MealTimes()[mealType])
+		if err != nil {
+			continue
+		}
+		gap := clock.Hour()*60 + clock.Minute() - minutes
+		if gap < 0 {
+			gap = -gap
+		}
+		if bestGap < 0 || gap < bestGap {
+			best, bestGap = mealType, gap
+		}
+	}
+	return best
+}
+
+// importKey identifie un repas planifié par son jour, son type et sa recette
+func importKey(mealPlan *dto.MealPlan) string {
+	return fmt.Sprintf("%s/%d", slotKey(mealPlan.PlannedDate, mealPlan.MealType), mealPlan.RecipeID)
+}
+
+// newCalendarToken génère le jeton secret de l'URL d'un flux iCalendar
+func newCalendarToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate calendar token: %w", err)
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
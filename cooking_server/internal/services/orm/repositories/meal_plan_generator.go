@@ -0,0 +1,647 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxGenerationDays         = 31  // Durée maximale d'une génération
+	maxPublicGeneratorRecipes = 200 // Recettes publiques candidates, les mieux notées
+	defaultGeneratorVariety   = 7   // Pas deux fois la même recette à moins de 7 jours
+	expiringFridgeHorizonDays = 14  // Au-delà, un produit du frigo n'est plus considéré bientôt périmé
+)
+
+// generatorMealTypes liste les types de repas dans l'ordre de la journée
+var generatorMealTypes = []string{"breakfast", "lunch", "dinner", "snack"}
+
+// generatorCandidate représente une recette pouvant être proposée
+type generatorCandidate struct {
+	recipe        *dto.Recipe
+	favorite      bool
+	ingredientIDs map[uint]bool
+}
+
+// expiringFridgeItem représente un produit du frigo bientôt périmé et les ingrédients qu'il satisfait
+type expiringFridgeItem struct {
+	item          *dto.FridgeItem
+	ingredientIDs []uint // Ingrédient du produit et ingrédients plus généraux
+}
+
+// mealPlanGenerator choisit des recettes pour les créneaux libres du planning selon les contraintes
+type mealPlanGenerator struct {
+	ctx        context.Context
+	db         *gorm.DB
+	prices     *ingredientPriceRepository
+	userID     uint
+	req        *dto.MealPlanGenerationConstraints
+	rng        *rand.Rand
+	today      time.Time
+	candidates []*generatorCandidate
+	planned    map[uint][]time.Time // Jours où chaque recette est déjà planifiée ou proposée
+	occupied   map[string]bool      // Créneaux déjà planifiés
+	expiring   []*expiringFridgeItem
+	used       map[uint]bool              // Produits du frigo déjà utilisés par la proposition
+	costs      map[uint]*dto.CostEstimate // Coût estimé de chaque recette pour ses portions
+	spent      float64                    // Coût connu des créneaux remplis ou retenus
+	incomplete bool                       // Un créneau rempli ou retenu a des ingrédients sans prix
+}
+
+// Generate propose une recette pour chaque créneau libre entre startDate et endDate (jours inclus).
+// Le budget éventuel est réparti sur les créneaux restant à remplir.
+func (r *mealPlanRepository) Generate(ctx context.Context, userID uint, startDate, endDate time.Time, req *dto.MealPlanGenerateRequest) (*dto.MealPlanGenerationPreview, error) {
+	startDate, endDate = dateOnly(startDate), dateOnly(endDate)
+	if daysBetween(startDate, endDate) >= maxGenerationDays {
+		return nil, ormerrors.NewValidationError("generation range cannot exceed 31 days")
+	}
+
+	generator, err := r.newMealPlanGenerator(ctx, userID, startDate, endDate, &req.MealPlanGenerationConstraints)
+	if err != nil {
+		return nil, err
+	}
+
+	var slots []dto.MealPlanGenerationSlot
+	for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
+		for _, mealType := range generator.mealTypes() {
+			if !generator.occupied[slotKey(date, mealType)] {
+				slots = append(slots, dto.MealPlanGenerationSlot{Date: date.Format("2006-01-02"), MealType: mealType})
+			}
+		}
+	}
+
+	for i := range slots {
+		date, _ := time.Parse("2006-01-02", slots[i].Date)
+		var allowance *float64
+		if req.Budget != nil {
+			remaining := (*req.Budget - generator.spent) / float64(len(slots)-i)
+			allowance = &remaining
+		}
+		if err := generator.fill(&slots[i], date, nil, allowance); err != nil {
+			return nil, err
+		}
+	}
+
+	preview := &dto.MealPlanGenerationPreview{
+		StartDate: startDate.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		Seed:      req.Seed,
+		Slots:     slots,
+		Budget:    req.Budget,
+	}
+	if preview.Slots == nil {
+		preview.Slots = []dto.MealPlanGenerationSlot{}
+	}
+	if req.Budget != nil {
+		total := generator.spent
+		preview.TotalCost = &total
+		preview.IncompleteCost = generator.incomplete
+	}
+	return preview, nil
+}
+
+// RerollSlot propose une autre recette pour un créneau d'une proposition, en tenant compte
+// des recettes retenues pour les autres créneaux et du budget qu'elles laissent
+func (r *mealPlanRepository) RerollSlot(ctx context.Context, userID uint, req *dto.MealPlanRerollRequest) (*dto.MealPlanGenerationSlot, error) {
+	// Formats déjà validés par la requête
+	date, _ := time.Parse("2006-01-02", req.Date)
+
+	generator, err := r.newMealPlanGenerator(ctx, userID, date, date, &req.MealPlanGenerationConstraints)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, choice := range req.Chosen {
+		if choice.Date == req.Date && choice.MealType == req.MealType {
+			continue
+		}
+		choiceDate, _ := time.Parse("2006-01-02", choice.Date)
+		if err := generator.retain(choice.RecipeID, choiceDate); err != nil {
+			return nil, err
+		}
+	}
+
+	var allowance *float64
+	if req.Budget != nil {
+		remaining := *req.Budget - generator.spent
+		allowance = &remaining
+	}
+	exclude := make(map[uint]bool, len(req.ExcludeRecipeIDs))
+	for _, id := range req.ExcludeRecipeIDs {
+		exclude[id] = true
+	}
+
+	slot := &dto.MealPlanGenerationSlot{Date: req.Date, MealType: req.MealType}
+	if err := generator.fill(slot, date, exclude, allowance); err != nil {
+		return nil, err
+	}
+	return slot, nil
+}
+
+// CreateGenerated planifie les créneaux acceptés d'une proposition. Les créneaux occupés
+// depuis la génération sont ignorés. Retourne le nombre de repas créés.
+func (r *mealPlanRepository) CreateGenerated(ctx context.Context, userID uint, choices []dto.MealPlanGenerationChoice) (int, error) {
+	recipeIDs := make([]uint, 0, len(choices))
+	for _, choice := range choices {
+		recipeIDs = append(recipeIDs, choice.RecipeID)
+	}
+	recipeIDs = uniqueUints(recipeIDs)
+
+	var recipes []dto.Recipe
+	if err := r.db.WithContext(ctx).
+		Select("id, servings").
+		Where("id IN ? AND (is_public = ? OR author_id = ?)", recipeIDs, true, userID).
+		Find(&recipes).Error; err != nil {
+		return 0, ormerrors.NewDatabaseError("check generated recipes", err)
+	}
+	if len(recipes) != len(recipeIDs) {
+		return 0, ormerrors.NewValidationError("one or more recipes do not exist")
+	}
+	servings := make(map[uint]int, len(recipes))
+	for _, recipe := range recipes {
+		servings[recipe.ID] = recipe.Servings
+	}
+
+	// Formats déjà validés par la requête
+	startDate, endDate := time.Time{}, time.Time{}
+	dates := make([]time.Time, len(choices))
+	for i, choice := range choices {
+		dates[i], _ = time.Parse("2006-01-02", choice.Date)
+		if startDate.IsZero() || dates[i].Before(startDate) {
+			startDate = dates[i]
+		}
+		if dates[i].After(endDate) {
+			endDate = dates[i]
+		}
+	}
+	created := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := materializeRecurrences(ctx, tx, userID, startDate, endDate); err != nil {
+			return err
+		}
+
+		existing, err := mealPlansInRange(tx, userID, startDate, endDate)
+		if err != nil {
+			return err
+		}
+		occupied := make(map[string]bool, len(existing))
+		for _, mealPlan := range existing {
+			occupied[slotKey(mealPlan.PlannedDate, mealPlan.MealType)] = true
+		}
+
+		var mealPlans []dto.MealPlan
+		for i, choice := range choices {
+			key := slotKey(dates[i], choice.MealType)
+			if occupied[key] {
+				continue
+			}
+			occupied[key] = true
+
+			mealPlan := dto.MealPlan{
+				UserID:      userID,
+				RecipeID:    choice.RecipeID,
+				PlannedDate: dates[i],
+				MealType:    choice.MealType,
+				Servings:    choice.Servings,
+			}
+			if mealPlan.Servings <= 0 {
+				mealPlan.Servings = max(servings[choice.RecipeID], 1)
+			}
+			mealPlans = append(mealPlans, mealPlan)
+		}
+		if len(mealPlans) == 0 {
+			return nil
+		}
+		if err := tx.Omit(clause.Associations).Create(&mealPlans).Error; err != nil {
+			return ormerrors.NewDatabaseError("create generated meal plans", err)
+		}
+		created = len(mealPlans)
+		return nil
+	})
+	return created, err
+}
+
+// newMealPlanGenerator charge les recettes candidates, les repas déjà planifiés autour de la
+// période (pour la variété) et les produits du frigo bientôt périmés
+func (r *mealPlanRepository) newMealPlanGenerator(ctx context.Context, userID uint, startDate, endDate time.Time, req *dto.MealPlanGenerationConstraints) (*mealPlanGenerator, error) {
+	if req.Seed == 0 {
+		req.Seed = time.Now().UnixNano()
+	}
+	if req.VarietyDays <= 0 {
+		req.VarietyDays = defaultGeneratorVariety
+	}
+
+	g := &mealPlanGenerator{
+		ctx:      ctx,
+		db:       r.db.WithContext(ctx),
+		prices:   NewIngredientPriceRepository(r.db),
+		userID:   userID,
+		req:      req,
+		rng:      rand.New(rand.NewSource(req.Seed)),
+		today:    dateOnly(time.Now()),
+		planned:  make(map[uint][]time.Time),
+		occupied: make(map[string]bool),
+		used:     make(map[uint]bool),
+		costs:    make(map[uint]*dto.CostEstimate),
+	}
+
+	windowStart := startDate.AddDate(0, 0, -req.VarietyDays)
+	windowEnd := endDate.AddDate(0, 0, req.VarietyDays)
+	if err := materializeRecurrences(ctx, r.db, userID, windowStart, windowEnd); err != nil {
+		return nil, err
+	}
+	existing, err := mealPlansInRange(g.db, userID, windowStart, windowEnd)
+	if err != nil {
+		return nil, err
+	}
+	for _, mealPlan := range existing {
+		date := dateOnly(mealPlan.PlannedDate)
+		g.planned[mealPlan.RecipeID] = append(g.planned[mealPlan.RecipeID], date)
+		g.occupied[slotKey(date, mealPlan.MealType)] = true
+	}
+
+	if err := g.loadCandidates(); err != nil {
+		return nil, err
+	}
+	if req.PreferExpiring == nil || *req.PreferExpiring {
+		if err := g.loadExpiring(endDate); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// loadCandidates charge les recettes des sources demandées compatibles avec les régimes et allergènes
+func (g *mealPlanGenerator) loadCandidates() error {
+	sources := g.req.Sources
+	if len(sources) == 0 {
+		sources = []string{dto.GeneratorSourceFavorites, dto.GeneratorSourceLists, dto.GeneratorSourcePublic}
+	}
+
+	var favoriteIDs, personalIDs []uint
+	for _, source := range sources {
+		switch source {
+		case dto.GeneratorSourceFavorites:
+			if err := g.db.Model(&dto.UserFavoriteRecipe{}).
+				Where("user_id = ?", g.userID).
+				Pluck("recipe_id", &favoriteIDs).Error; err != nil {
+				return ormerrors.NewDatabaseError("get favorite recipes", err)
+			}
+			personalIDs = append(personalIDs, favoriteIDs...)
+		case dto.GeneratorSourceLists:
+			var listRecipeIDs []uint
+			query := g.db.Model(&dto.RecipeListItem{}).
+				Joins("JOIN recipe_lists ON recipe_lists.id = recipe_list_items.recipe_list_id").
+				Where("recipe_lists.user_id = ?", g.userID)
+			if len(g.req.RecipeListIDs) > 0 {
+				query = query.Where("recipe_lists.id IN ?", g.req.RecipeListIDs)
+			}
+			if err := query.Pluck("recipe_list_items.recipe_id", &listRecipeIDs).Error; err != nil {
+				return ormerrors.NewDatabaseError("get recipe list recipes", err)
+			}
+			personalIDs = append(personalIDs, listRecipeIDs...)
+		}
+	}
+	personalIDs = uniqueUints(personalIDs)
+
+	var recipes []*dto.Recipe
+	if len(personalIDs) > 0 {
+		if err := g.recipeQuery().
+			Where("recipes.id IN ?", personalIDs).
+			Find(&recipes).Error; err != nil {
+			return ormerrors.NewDatabaseError("get generator recipes", err)
+		}
+	}
+	for _, source := range sources {
+		if source != dto.GeneratorSourcePublic {
+			continue
+		}
+		var public []*dto.Recipe
+		query := g.recipeQuery().Where("recipes.is_public = ?", true)
+		if len(personalIDs) > 0 {
+			query = query.Where("recipes.id NOT IN ?", personalIDs)
+		}
+		if err := query.
+			Order("recipes.average_rating DESC, recipes.id ASC").
+			Limit(maxPublicGeneratorRecipes).
+			Find(&public).Error; err != nil {
+			return ormerrors.NewDatabaseError("get public generator recipes", err)
+		}
+		recipes = append(recipes, public...)
+	}
+
+	favorites := make(map[uint]bool, len(favoriteIDs))
+	for _, id := range favoriteIDs {
+		favorites[id] = true
+	}
+	// Ordre stable pour qu'une même graine donne la même proposition
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].ID < recipes[j].ID })
+	for _, recipe := range recipes {
+		g.candidates = append(g.candidates, newGeneratorCandidate(recipe, favorites[recipe.ID]))
+	}
+	return nil
+}
+
+// newGeneratorCandidate prépare une recette pour la génération
+func newGeneratorCandidate(recipe *dto.Recipe, favorite bool) *generatorCandidate {
+	candidate := &generatorCandidate{
+		recipe:        recipe,
+		favorite:      favorite,
+		ingredientIDs: make(map[uint]bool, len(recipe.Ingredients)),
+	}
+	for _, ingredient := range recipe.Ingredients {
+		candidate.ingredientIDs[ingredient.IngredientID] = true
+	}
+	return candidate
+}
+
+// recipeQuery prépare le chargement des recettes accessibles respectant les régimes et allergènes demandés
+func (g *mealPlanGenerator) recipeQuery() *gorm.DB {
+	query := g.db.
+		Preload("Ingredients").
+		Preload("Categories").
+		Where("recipes.is_public = ? OR recipes.author_id = ?", true, g.userID)
+	if len(g.req.Diets) > 0 {
+		query = query.Where("recipes.diets @> ?::jsonb", dto.StringList(g.req.Diets))
+	}
+	if len(g.req.AvoidAllergens) > 0 {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM jsonb_array_elements_text(recipes.allergens) AS allergen WHERE allergen IN ?)",
+			g.req.AvoidAllergens)
+	}
+	return query
+}
+
+// loadExpiring charge les produits du frigo périmant d'ici la fin de la période
+func (g *mealPlanGenerator) loadExpiring(endDate time.Time) error {
+	var items []*dto.FridgeItem
+	if err := g.db.
+		Preload("Ingredient").
+		Where("user_id = ? AND expiry_date IS NOT NULL AND expiry_date >= ? AND expiry_date < ?",
+			g.userID, g.today, endDate.AddDate(0, 0, 1)).
+		Order("expiry_date ASC, id ASC").
+		Find(&items).Error; err != nil {
+		return ormerrors.NewDatabaseError("get expiring fridge items", err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	taxonomy, err := loadIngredientTaxonomy(g.db)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		// Des tomates cerises satisfont une recette demandant des tomates
+		ingredientIDs := append([]uint{item.IngredientID}, taxonomy.ancestors(item.IngredientID)...)
+		g.expiring = append(g.expiring, &expiringFridgeItem{item: item, ingredientIDs: ingredientIDs})
+	}
+	return nil
+}
+
+// mealTypes retourne les types de repas à remplir, dans l'ordre de la journée
+func (g *mealPlanGenerator) mealTypes() []string {
+	if len(g.req.MealTypes) == 0 {
+		return generatorMealTypes
+	}
+	requested := make(map[string]bool, len(g.req.MealTypes))
+	for _, mealType := range g.req.MealTypes {
+		requested[mealType] = true
+	}
+	var mealTypes []string
+	for _, mealType := range generatorMealTypes {
+		if requested[mealType] {
+			mealTypes = append(mealTypes, mealType)
+		}
+	}
+	return mealTypes
+}
+
+// fill choisit la meilleure recette pour un créneau : les candidates respectant la durée du jour,
+// la variété et le budget sont classées par un tirage, la note, les favoris et les produits bientôt périmés
+func (g *mealPlanGenerator) fill(slot *dto.MealPlanGenerationSlot, date time.Time, exclude map[uint]bool, allowance *float64) error {
+	type scored struct {
+		candidate *generatorCandidate
+		score     float64
+		expiring  []*expiringFridgeItem
+	}
+
+	maxTime := g.req.MaxTime[strings.ToLower(date.Weekday().String())]
+	var eligible []scored
+	tooLong, tooRecent := 0, 0
+	for _, candidate := range g.candidates {
+		recipe := candidate.recipe
+		if exclude[recipe.ID] {
+			continue
+		}
+		if maxTime > 0 && recipeDuration(recipe) > maxTime {
+			tooLong++
+			continue
+		}
+		if g.plannedWithin(recipe.ID, date) {
+			tooRecent++
+			continue
+		}
+
+		entry := scored{candidate: candidate, score: g.rng.Float64()*2 + recipe.AverageRating/5}
+		if candidate.favorite {
+			entry.score++
+		}
+		for _, expiring := range g.expiring {
+			if g.used[expiring.item.ID] || expiring.item.ExpiryDate.Before(date) || !candidate.uses(expiring) {
+				continue
+			}
+			entry.expiring = append(entry.expiring, expiring)
+			entry.score += expiringBonus(daysBetween(g.today, dateOnly(*expiring.item.ExpiryDate)))
+		}
+		eligible = append(eligible, entry)
+	}
+
+	if len(eligible) == 0 {
+		switch {
+		case len(g.candidates) == 0:
+			slot.Unfilled = "no recipe matches the sources, diets and allergens"
+		case tooLong == 0 && tooRecent == 0:
+			slot.Unfilled = "no other recipe available"
+		case tooLong > 0 && tooRecent == 0:
+			slot.Unfilled = "no recipe fits the time limit of this day"
+		default:
+			slot.Unfilled = "no recipe left without repeating within the variety window"
+		}
+		return nil
+	}
+
+	sort.SliceStable(eligible, func(i, j int) bool { return eligible[i].score > eligible[j].score })
+	chosen := -1
+	var estimate *dto.CostEstimate
+	if allowance == nil {
+		chosen = 0
+	} else {
+		// Un coût incomplet n'est qu'un minimum : une recette dont tous les prix sont connus est
+		// préférée, sinon la mieux classée dont le coût connu tient dans le budget, signalée
+		var fallback *dto.CostEstimate
+		fallbackIndex := -1
+		for i, entry := range eligible {
+			cost, err := g.cost(entry.candidate.recipe)
+			if err != nil {
+				return err
+			}
+			if cost.Total > *allowance+1e-6 {
+				continue
+			}
+			if cost.Complete {
+				chosen, estimate = i, cost
+				break
+			}
+			if fallbackIndex < 0 {
+				fallbackIndex, fallback = i, cost
+			}
+		}
+		if chosen < 0 && fallbackIndex >= 0 {
+			chosen, estimate = fallbackIndex, fallback
+		}
+	}
+	if chosen < 0 {
+		slot.Unfilled = "no recipe fits the remaining budget"
+		return nil
+	}
+
+	entry := eligible[chosen]
+	recipe := entry.candidate.recipe
+	summary := recipeSummary(recipe)
+	slot.RecipeID = recipe.ID
+	slot.Recipe = &summary
+	slot.Servings = g.servings(recipe)
+	if estimate != nil {
+		total := estimate.Total
+		slot.Cost = &total
+		g.spent += total
+		if !estimate.Complete {
+			slot.CostGaps = estimate.Gaps
+			g.incomplete = true
+		}
+	}
+	for _, expiring := range entry.expiring {
+		g.used[expiring.item.ID] = true
+		slot.ExpiringIngredients = append(slot.ExpiringIngredients, expiring.item.Ingredient.Name)
+	}
+	g.planned[recipe.ID] = append(g.planned[recipe.ID], date)
+	return nil
+}
+
+// retain prend en compte une recette déjà retenue dans la proposition : variété, produits du frigo et budget.
+// Une recette retenue qui ne fait plus partie des candidates (régimes ou sources modifiés) compte aussi.
+func (g *mealPlanGenerator) retain(recipeID uint, date time.Time) error {
+	g.planned[recipeID] = append(g.planned[recipeID], dateOnly(date))
+	candidate, err := g.retained(recipeID)
+	if err != nil || candidate == nil {
+		return err
+	}
+	for _, expiring := range g.expiring {
+		if !expiring.item.ExpiryDate.Before(date) && candidate.uses(expiring) {
+			g.used[expiring.item.ID] = true
+		}
+	}
+	if g.req.Budget != nil {
+		cost, err := g.cost(candidate.recipe)
+		if err != nil {
+			return err
+		}
+		g.spent += cost.Total
+		g.incomplete = g.incomplete || !cost.Complete
+	}
+	return nil
+}
+
+// retained retourne la candidate d'une recette retenue, ou charge la recette si elle n'est plus candidate.
+// Retourne nil si la recette n'existe plus ou n'est pas visible par l'utilisateur.
+func (g *mealPlanGenerator) retained(recipeID uint) (*generatorCandidate, error) {
+	for _, candidate := range g.candidates {
+		if candidate.recipe.ID == recipeID {
+			return candidate, nil
+		}
+	}
+
+	var recipe dto.Recipe
+	if err := g.db.
+		Preload("Ingredients").
+		Where("recipes.is_public = ? OR recipes.author_id = ?", true, g.userID).
+		First(&recipe, recipeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, ormerrors.NewDatabaseError("get retained recipe", err)
+	}
+	return newGeneratorCandidate(&recipe, false), nil
+}
+
+// plannedWithin indique si la recette est planifiée ou proposée à moins de VarietyDays jours de la date
+func (g *mealPlanGenerator) plannedWithin(recipeID uint, date time.Time) bool {
+	for _, planned := range g.planned[recipeID] {
+		days := daysBetween(planned, date)
+		if days < 0 {
+			days = -days
+		}
+		if days < g.req.VarietyDays {
+			return true
+		}
+	}
+	return false
+}
+
+// cost estime, une seule fois par recette, le coût d'une recette pour les portions proposées
+func (g *mealPlanGenerator) cost(recipe *dto.Recipe) (*dto.CostEstimate, error) {
+	if estimate, ok := g.costs[recipe.ID]; ok {
+		return estimate, nil
+	}
+	estimate, err := g.prices.estimate(g.ctx, recipe, g.servings(recipe), g.userID, g.req.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	g.costs[recipe.ID] = estimate
+	return estimate, nil
+}
+
+// servings retourne les portions proposées pour une recette
+func (g *mealPlanGenerator) servings(recipe *dto.Recipe) int {
+	if g.req.Servings > 0 {
+		return g.req.Servings
+	}
+	return max(recipe.Servings, 1)
+}
+
+// uses indique si la recette utilise un ingrédient satisfait par le produit du frigo
+func (c *generatorCandidate) uses(expiring *expiringFridgeItem) bool {
+	for _, ingredientID := range expiring.ingredientIDs {
+		if c.ingredientIDs[ingredientID] {
+			return true
+		}
+	}
+	return false
+}
+
+// recipeDuration retourne le temps total d'une recette, ou préparation et cuisson s'il n'est pas renseigné
+func recipeDuration(recipe *dto.Recipe) int {
+	if recipe.TotalTime > 0 {
+		return recipe.TotalTime
+	}
+	return recipe.PrepTime + recipe.CookTime
+}
+
+// expiringBonus favorise d'autant plus un produit du frigo qu'il périme bientôt
+func expiringBonus(days int) float64 {
+	days = min(max(days, 0), expiringFridgeHorizonDays)
+	return 1 + 2*float64(expiringFridgeHorizonDays-days)/expiringFridgeHorizonDays
+}
+
+// slotKey identifie un créneau du planning
+func slotKey(date time.Time, mealType string) string {
+	return date.Format("2006-01-02") + "/" + mealType
+}
@@ -0,0 +1,294 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recurrenceWeekdays associe les jours des règles hebdomadaires aux jours Go
+var recurrenceWeekdays = map[string]time.Weekday{
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+	"sunday":    time.Sunday,
+}
+
+type mealPlanRecurrenceRepository struct {
+	db *gorm.DB
+}
+
+// NewMealPlanRecurrenceRepository crée une nouvelle instance du repository des repas récurrents
+func NewMealPlanRecurrenceRepository(db *gorm.DB) *mealPlanRecurrenceRepository {
+	return &mealPlanRecurrenceRepository{db: db}
+}
+
+// Create crée une règle de récurrence
+func (r *mealPlanRecurrenceRepository) Create(ctx context.Context, recurrence *dto.MealPlanRecurrence) error {
+	if err := validateRecurrence(recurrence); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Omit("Recipe").Create(recurrence).Error; err != nil {
+		return ormerrors.NewDatabaseError("create meal plan recurrence", err)
+	}
+	return nil
+}
+
+// GetByID récupère une règle de récurrence avec sa recette
+func (r *mealPlanRecurrenceRepository) GetByID(ctx context.Context, id uint) (*dto.MealPlanRecurrence, error) {
+	var recurrence dto.MealPlanRecurrence
+	if err := r.db.WithContext(ctx).Preload("Recipe").First(&recurrence, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("meal plan recurrence", id)
+		}
+		return nil, ormerrors.NewDatabaseError("get meal plan recurrence by id", err)
+	}
+	return &recurrence, nil
+}
+
+// GetByUser liste les règles de récurrence d'un utilisateur
+func (r *mealPlanRecurrenceRepository) GetByUser(ctx context.Context, userID uint) ([]*dto.MealPlanRecurrence, error) {
+	var recurrences []*dto.MealPlanRecurrence
+	if err := r.db.WithContext(ctx).
+		Preload("Recipe").
+		Where("user_id = ?", userID).
+		Order("start_date ASC, id ASC").
+		Find(&recurrences).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get meal plan recurrences by user", err)
+	}
+	return recurrences, nil
+}
+
+// Update modifie une règle de récurrence. Les occurrences à venir qui n'ont été ni modifiées
+// ni réalisées sont supprimées : elles seront recréées selon la nouvelle règle.
+func (r *mealPlanRecurrenceRepository) Update(ctx context.Context, recurrence *dto.MealPlanRecurrence) error {
+	if err := validateRecurrence(recurrence); err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Recipe", "CreatedAt").Save(recurrence).Error; err != nil {
+			return ormerrors.NewDatabaseError("update meal plan recurrence", err)
+		}
+		if err := deletePendingOccurrences(tx, recurrence.ID); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// Delete supprime une règle de récurrence et ses occurrences à venir. Les occurrences passées,
+// réalisées ou modifiées individuellement sont conservées comme repas indépendants.
+func (r *mealPlanRecurrenceRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deletePendingOccurrences(tx, id); err != nil {
+			return err
+		}
+		if err := tx.Model(&dto.MealPlan{}).
+			Where("recurrence_id = ?", id).
+			Updates(map[string]interface{}{"recurrence_id": nil, "occurrence_date": nil}).Error; err != nil {
+			return ormerrors.NewDatabaseError("detach meal plan occurrences", err)
+		}
+		if err := tx.Where("recurrence_id = ?", id).Delete(&dto.MealPlanRecurrenceSkip{}).Error; err != nil {
+			return ormerrors.NewDatabaseError("delete meal plan recurrence skips", err)
+		}
+
+		result := tx.Delete(&dto.MealPlanRecurrence{}, id)
+		if result.Error != nil {
+			return ormerrors.NewDatabaseError("delete meal plan recurrence", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ormerrors.NewNotFoundError("meal plan recurrence", id)
+		}
+		return nil
+	})
+}
+
+// validateRecurrence normalise une règle et vérifie sa cohérence
+func validateRecurrence(recurrence *dto.MealPlanRecurrence) error {
+	if recurrence.Interval <= 0 {
+		recurrence.Interval = 1
+	}
+	if recurrence.Servings <= 0 {
+		recurrence.Servings = 1
+	}
+	if recurrence.Frequency != dto.RecurrenceWeekly {
+		recurrence.Weekdays = dto.StringList{}
+	}
+	for _, day := range recurrence.Weekdays {
+		if _, ok := recurrenceWeekdays[day]; !ok {
+			return ormerrors.NewValidationError("unknown weekday: " + day)
+		}
+	}
+	if recurrence.EndDate != nil && recurrence.EndDate.Before(recurrence.StartDate) {
+		return ormerrors.NewValidationError("end_date must be after or equal to start_date")
+	}
+	return nil
+}
+
+// deletePendingOccurrences supprime les occurrences à venir d'une règle qui la suivent encore
+func deletePendingOccurrences(tx *gorm.DB, recurrenceID uint) error {
+	if err := tx.
+		Where("recurrence_id = ? AND occurrence_date >= ? AND detached = ? AND is_completed = ?",
+			recurrenceID, dateOnly(time.Now()), false, false).
+		Delete(&dto.MealPlan{}).Error; err != nil {
+		return ormerrors.NewDatabaseError("delete pending meal plan occurrences", err)
+	}
+	return nil
+}
+
+// materializeRecurrences crée les occurrences des règles de l'utilisateur tombant entre from et to
+// (inclus) qui n'existent pas encore. Les occurrences supprimées ne sont pas recréées.
+func materializeRecurrences(ctx context.Context, db *gorm.DB, userID uint, from, to time.Time) error {
+	db = db.WithContext(ctx)
+	from, to = dateOnly(from), dateOnly(to)
+
+	var recurrences []dto.MealPlanRecurrence
+	if err := db.
+		Where("user_id = ? AND start_date <= ? AND (end_date IS NULL OR end_date >= ?)", userID, to, from).
+		Find(&recurrences).Error; err != nil {
+		return ormerrors.NewDatabaseError("load meal plan recurrences", err)
+	}
+	if len(recurrences) == 0 {
+		return nil
+	}
+
+	recurrenceIDs := make([]uint, 0, len(recurrences))
+	for _, recurrence := range recurrences {
+		recurrenceIDs = append(recurrenceIDs, recurrence.ID)
+	}
+
+	// Occurrences déjà créées ou supprimées sur la période
+	known := make(map[occurrenceKey]bool)
+
+	var existing []dto.MealPlan
+	if err := db.Select("recurrence_id", "occurrence_date").
+		Where("recurrence_id IN ? AND occurrence_date >= ? AND occurrence_date <= ?", recurrenceIDs, from, to).
+		Find(&existing).Error; err != nil {
+		return ormerrors.NewDatabaseError("load meal plan occurrences", err)
+	}
+	for _, mealPlan := range existing {
+		if mealPlan.RecurrenceID != nil && mealPlan.OccurrenceDate != nil {
+			known[newOccurrenceKey(*mealPlan.RecurrenceID, *mealPlan.OccurrenceDate)] = true
+		}
+	}
+
+	var skips []dto.MealPlanRecurrenceSkip
+	if err := db.Where("recurrence_id IN ? AND occurrence_date >= ? AND occurrence_date <= ?", recurrenceIDs, from, to).
+		Find(&skips).Error; err != nil {
+		return ormerrors.NewDatabaseError("load meal plan recurrence skips", err)
+	}
+	for _, skip := range skips {
+		known[newOccurrenceKey(skip.RecurrenceID, skip.OccurrenceDate)] = true
+	}
+
+	missing := missingOccurrences(recurrences, known, from, to)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// Une consultation concurrente a pu créer les mêmes occurrences entre-temps
+	if err := db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&missing).Error; err != nil {
+		return ormerrors.NewDatabaseError("create meal plan occurrences", err)
+	}
+	return nil
+}
+
+// occurrenceKey identifie une occurrence d'une règle par son jour prévu
+type occurrenceKey struct {
+	recurrenceID uint
+	date         string
+}
+
+// newOccurrenceKey construit la clé d'une occurrence
+func newOccurrenceKey(recurrenceID uint, date time.Time) occurrenceKey {
+	return occurrenceKey{recurrenceID, date.Format("2006-01-02")}
+}
+
+// missingOccurrences construit les repas des occurrences des règles entre from et to (inclus)
+// qui ne sont ni déjà créées ni supprimées (known)
+func missingOccurrences(recurrences []dto.MealPlanRecurrence, known map[occurrenceKey]bool, from, to time.Time) []dto.MealPlan {
+	var missing []dto.MealPlan
+	for _, recurrence := range recurrences {
+		for _, date := range recurrenceOccurrences(&recurrence, from, to) {
+			if known[newOccurrenceKey(recurrence.ID, date)] {
+				continue
+			}
+			recurrenceID, occurrenceDate := recurrence.ID, date
+			missing = append(missing, dto.MealPlan{
+				UserID:         recurrence.UserID,
+				RecipeID:       recurrence.RecipeID,
+				PlannedDate:    date,
+				MealType:       recurrence.MealType,
+				Servings:       recurrence.Servings,
+				Notes:          recurrence.Notes,
+				RecurrenceID:   &recurrenceID,
+				OccurrenceDate: &occurrenceDate,
+			})
+		}
+	}
+	return missing
+}
+
+// recurrenceOccurrences retourne les dates d'une règle comprises entre from et to (inclus)
+func recurrenceOccurrences(recurrence *dto.MealPlanRecurrence, from, to time.Time) []time.Time {
+	start := dateOnly(recurrence.StartDate)
+	if recurrence.EndDate != nil && dateOnly(*recurrence.EndDate).Before(to) {
+		to = dateOnly(*recurrence.EndDate)
+	}
+	if from.Before(start) {
+		from = start
+	}
+	interval := recurrence.Interval
+	if interval <= 0 {
+		interval = 1
+	}
+
+	var dates []time.Time
+	switch recurrence.Frequency {
+	case dto.RecurrenceDaily:
+		// Première occurrence à partir de from, alignée sur la date de début
+		offset := daysBetween(start, from)
+		if remainder := offset % interval; remainder != 0 {
+			offset += interval - remainder
+		}
+		for date := start.AddDate(0, 0, offset); !date.After(to); date = date.AddDate(0, 0, interval) {
+			dates = append(dates, date)
+		}
+
+	case dto.RecurrenceWeekly:
+		weekdays := make(map[time.Weekday]bool)
+		for _, day := range recurrence.Weekdays {
+			weekdays[recurrenceWeekdays[day]] = true
+		}
+		if len(weekdays) == 0 {
+			weekdays[start.Weekday()] = true
+		}
+		// Les semaines sont comptées à partir du lundi de la semaine de début
+		monday := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+			if weekdays[date.Weekday()] && (daysBetween(monday, date)/7)%interval == 0 {
+				dates = append(dates, date)
+			}
+		}
+	}
+	return dates
+}
+
+// dateOnly retourne le jour calendaire d'une date, à minuit UTC
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween retourne le nombre de jours entre deux dates à minuit UTC
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+)
+
+// day retourne une date de janvier 2024 (le 1er est un lundi)
+func day(d int) time.Time {
+	return time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC)
+}
+
+// formatDates met des dates sous forme comparable
+func formatDates(dates []time.Time) []string {
+	var formatted []string
+	for _, date := range dates {
+		formatted = append(formatted, date.Format("2006-01-02"))
+	}
+	return formatted
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	endDate := day(3)
+
+	tests := []struct {
+		name       string
+		recurrence dto.MealPlanRecurrence
+		from, to   time.Time
+		want       []string
+	}{
+		{
+			name:       "tous les jours",
+			recurrence: dto.MealPlanRecurrence{Frequency: dto.RecurrenceDaily, Interval: 1, StartDate: day(1)},
+			from:       day(3),
+			to:         day(5),
+			want:       []string{"2024-01-03", "2024-01-04", "2024-01-05"},
+		},
+		{
+			name:       "tous les trois jours, alignés sur le début",
+			recurrence: dto.MealPlanRecurrence{Frequency: dto.RecurrenceDaily, Interval: 3, StartDate: day(1)},
+			from:       day(2),
+			to:         day(10),
+			want:       []string{"2024-01-04", "2024-01-07", "2024-01-10"},
+		},
+		{
+			name:       "période commençant avant la règle",
+			recurrence: dto.MealPlanRecurrence{Frequency: dto.RecurrenceDaily, Interval: 1, StartDate: day(5)},
+			from:       day(1),
+			to:         day(7),
+			want:       []string{"2024-01-05", "2024-01-06", "2024-01-07"},
+		},
+		{
+			name:       "règle terminée pendant la période",
+			recurrence: dto.MealPlanRecurrence{Frequency: dto.RecurrenceDaily, Interval: 1, StartDate: day(1), EndDate: &endDate},
+			from:       day(1),
+			to:         day(10),
+			want:       []string{"2024-01-01", "2024-01-02", "2024-01-03"},
+		},
+		{
+			name:       "période après la fin de la règle",
+			recurrence: dto.MealPlanRecurrence{Frequency: dto.RecurrenceDaily, Interval: 1, StartDate: day(1), EndDate: &endDate},
+			from:       day(5),
+			to:         day(10),
+			want:       nil,
+		},
+		{
+			name:       "intervalle nul traité comme 1",
+			recurrence: dto.MealPlanRecurrence{Frequency: dto.RecurrenceDaily, StartDate: day(1)},
+			from:       day(1),
+			to:         day(2),
+			want:       []string{"2024-01-01", "2024-01-02"},
+		},
+		{
+			name:       "chaque semaine le jour du début",
+			recurrence: dto.MealPlanRecurrence{Frequency: dto.RecurrenceWeekly, Interval: 1, StartDate: day(3)},
+			from:       day(1),
+			to:         day(21),
+			want:       []string{"2024-01-03", "2024-01-10", "2024-01-17"},
+		},
+		{
+			name: "une semaine sur deux, lundi et vendredi",
+			recurrence: dto.MealPlanRecurrence{
+				Frequency: dto.RecurrenceWeekly,
+				Interval:  2,
+				Weekdays:  dto.StringList{"monday", "friday"},
+				StartDate: day(3),
+			},
+			from: day(1),
+			to:   day(28),
+			want: []string{"2024-01-05", "2024-01-15", "2024-01-19"},
+		},
+		{
+			name:       "fréquence inconnue",
+			recurrence: dto.MealPlanRecurrence{Frequency: "monthly", Interval: 1, StartDate: day(1)},
+			from:       day(1),
+			to:         day(31),
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatDates(recurrenceOccurrences(&tt.recurrence, tt.from, tt.to))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recurrenceOccurrences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissingOccurrences(t *testing.T) {
+	recurrences := []dto.MealPlanRecurrence{
+		{ID: 1, UserID: 7, RecipeID: 10, MealType: "dinner", Servings: 4, Notes: "Sans sel", Frequency: dto.RecurrenceDaily, Interval: 1, StartDate: day(1)},
+		{ID: 2, UserID: 7, RecipeID: 11, MealType: "lunch", Servings: 2, Frequency: dto.RecurrenceWeekly, Interval: 1, StartDate: day(1)},
+	}
+
+	tests := []struct {
+		name  string
+		known []occurrenceKey
+		want  []string
+	}{
+		{
+			name: "aucune occurrence connue",
+			want: []string{"1:2024-01-01", "1:2024-01-02", "1:2024-01-03", "2:2024-01-01"},
+		},
+		{
+			name:  "occurrences supprimées ou déjà créées",
+			known: []occurrenceKey{newOccurrenceKey(1, day(2)), newOccurrenceKey(2, day(1))},
+			want:  []string{"1:2024-01-01", "1:2024-01-03"},
+		},
+		{
+			name:  "même jour d'une autre règle",
+			known: []occurrenceKey{newOccurrenceKey(3, day(1))},
+			want:  []string{"1:2024-01-01", "1:2024-01-02", "1:2024-01-03", "2:2024-01-01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			known := make(map[occurrenceKey]bool)
+			for _, key := range tt.known {
+				known[key] = true
+			}
+
+			var got []string
+			for _, mealPlan := range missingOccurrences(recurrences, known, day(1), day(3)) {
+				recurrence := recurrences[*mealPlan.RecurrenceID-1]
+				if mealPlan.UserID != recurrence.UserID || mealPlan.RecipeID != recurrence.RecipeID ||
+					mealPlan.MealType != recurrence.MealType || mealPlan.Servings != recurrence.Servings || mealPlan.Notes != recurrence.Notes {
+					t.Errorf("occurrence %+v does not copy its rule %+v", mealPlan, recurrence)
+				}
+				if mealPlan.OccurrenceDate == nil || !mealPlan.OccurrenceDate.Equal(mealPlan.PlannedDate) {
+					t.Errorf("occurrence date %v differs from planned date %v", mealPlan.OccurrenceDate, mealPlan.PlannedDate)
+				}
+				got = append(got, fmt.Sprintf("%d:%s", *mealPlan.RecurrenceID, mealPlan.PlannedDate.Format("2006-01-02")))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missingOccurrences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
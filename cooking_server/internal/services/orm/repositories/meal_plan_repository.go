@@ -2,13 +2,17 @@ package repositories
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/romainrodriguez/cooking_server/internal/dto"
 	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const MaxNestedRecipeDepth = 3 // Profondeur maximale pour les recettes imbriquées
@@ -82,6 +86,11 @@ func (r *mealPlanRepository) GetByUser(ctx context.Context, userID uint, limit,
 func (r *mealPlanRepository) GetByUserAndDateRange(ctx context.Context, userID uint, startDate, endDate time.Time) ([]*dto.MealPlan, error) {
 	var mealPlans []*dto.MealPlan
 
+	// Créer les occurrences des repas récurrents de la période
+	if err := materializeRecurrences(ctx, r.db, userID, startDate, endDate); err != nil {
+		return nil, err
+	}
+
 	if err := r.db.WithContext(ctx).
 		Preload("Recipe").
 		Preload("Recipe.Author").
@@ -104,6 +113,10 @@ func (r *mealPlanRepository) GetByUserAndDate(ctx context.Context, userID uint,
 	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
+	if err := materializeRecurrences(ctx, r.db, userID, startOfDay, startOfDay); err != nil {
+		return nil, err
+	}
+
 	if err := r.db.WithContext(ctx).
 		Preload("Recipe").
 		Preload("Recipe.Author").
@@ -130,6 +143,8 @@ func (r *mealPlanRepository) Update(ctx context.Context, mealPlan *dto.MealPlan)
 		"servings":     mealPlan.Servings,
 		"notes":        mealPlan.Notes,
 		"is_completed": mealPlan.IsCompleted,
+		"detached":     mealPlan.Detached,
+		"leftover_id":  mealPlan.LeftoverID,
 		"updated_at":   time.Now(),
 	}
 
@@ -147,36 +162,270 @@ func (r *mealPlanRepository) Update(ctx context.Context, mealPlan *dto.MealPlan)
 	return nil
 }
 
-// Delete supprime un planning de repas
+// Delete supprime un planning de repas. La suppression d'une occurrence de repas récurrent
+// est mémorisée pour qu'elle ne soit pas recréée.
 func (r *mealPlanRepository) Delete(ctx context.Context, id uint) error {
-	result := r.db.WithContext(ctx).Delete(&dto.MealPlan{}, id)
-	if result.Error != nil {
-		return ormerrors.NewDatabaseError("delete meal plan", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return ormerrors.NewNotFoundError("meal plan", id)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var mealPlan dto.MealPlan
+		if err := tx.Select("id", "recurrence_id", "occurrence_date").First(&mealPlan, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ormerrors.NewNotFoundError("meal plan", id)
+			}
+			return ormerrors.NewDatabaseError("get meal plan to delete", err)
+		}
+
+		if mealPlan.RecurrenceID != nil && mealPlan.OccurrenceDate != nil {
+			skip := dto.MealPlanRecurrenceSkip{RecurrenceID: *mealPlan.RecurrenceID, OccurrenceDate: *mealPlan.OccurrenceDate}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&skip).Error; err != nil {
+				return ormerrors.NewDatabaseError("skip meal plan occurrence", err)
+			}
+		}
+
+		if err := tx.Delete(&dto.MealPlan{}, id).Error; err != nil {
+			return ormerrors.NewDatabaseError("delete meal plan", err)
+		}
+		return nil
+	})
+}
+
+// MarkAsCompleted marque un planning de repas de l'utilisateur comme terminé et consomme le stock du frigo
+// correspondant. Les quantités sont ajustées aux portions planifiées et incluent les recettes imbriquées ;
+// les items entièrement consommés sont supprimés. Retourne la consommation (avec son jeton
+// d'annulation), ou nil si le repas était déjà terminé.
+func (r *mealPlanRepository) MarkAsCompleted(ctx context.Context, id, userID uint) (*dto.FridgeConsumption, error) {
+	var consumption *dto.FridgeConsumption
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Verrouiller le repas pour que deux complétions simultanées ne consomment pas deux fois le stock
+		var mealPlan dto.MealPlan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).
+			First(&mealPlan, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ormerrors.NewNotFoundError("meal plan", id)
+			}
+			return ormerrors.NewDatabaseError("get meal plan to complete", err)
+		}
+		if mealPlan.IsCompleted {
+			return nil
+		}
+
+		if err := tx.
+			Preload("Recipe").
+			Preload("Recipe.Ingredients").
+			Preload("Recipe.Ingredients.Ingredient").
+			First(&mealPlan, id).Error; err != nil {
+			return ormerrors.NewDatabaseError("get meal plan recipe to complete", err)
+		}
+
+		now := time.Now()
+		if err := tx.Model(&dto.MealPlan{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"is_completed": true,
+				"completed_at": &now,
+			}).Error; err != nil {
+			return ormerrors.NewDatabaseError("mark meal plan as completed", err)
+		}
+
+		// Les restes sont comptés à part : rien n'est retiré du frigo
+		if mealPlan.LeftoverID != nil {
+			consumption = &dto.FridgeConsumption{UserID: mealPlan.UserID, MealPlanID: mealPlan.ID, Items: []dto.FridgeConsumptionItem{}}
+			return nil
+		}
+
+		var err error
+		consumption, err = (&mealPlanRepository{db: tx}).consumeFridgeStock(ctx, &mealPlan)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+
+	return consumption, nil
 }
 
-// MarkAsCompleted marque un planning de repas comme terminé
-func (r *mealPlanRepository) MarkAsCompleted(ctx context.Context, id uint) error {
-	now := time.Now()
-	result := r.db.WithContext(ctx).
-		Model(&dto.MealPlan{}).
-		Where("id = ?", id).
-		Updates(map[string]interface{}{
-			"is_completed": true,
-			"completed_at": &now,
+// consumeFridgeStock retire du frigo les ingrédients utilisés par un repas et enregistre la consommation
+func (r *mealPlanRepository) consumeFridgeStock(ctx context.Context, mealPlan *dto.MealPlan) (*dto.FridgeConsumption, error) {
+	fridgeItems, err := NewFridgeRepository(r.db).GetByUser(ctx, mealPlan.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	servingsRatio := 1.0
+	if mealPlan.Recipe.Servings > 0 {
+		servingsRatio = float64(mealPlan.Servings) / float64(mealPlan.Recipe.Servings)
+	}
+
+	collector := &RecipeIngredientCollector{
+		Ingredients: make([]RecipeIngredientWithSource, 0),
+		RecipeData:  make(map[uint]*dto.Recipe),
+	}
+	if err := r.collectNestedIngredients(ctx, &mealPlan.Recipe, servingsRatio, 0, make(map[uint]bool), collector); err != nil {
+		return nil, err
+	}
+
+	taxonomy, err := loadIngredientTaxonomy(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	// Les ingrédients optionnels ne sont pas déduits : rien n'indique qu'ils ont été utilisés
+	allocator := newFridgeAllocator(fridgeItems, taxonomy)
+	for _, ingredientWithSource := range collector.Ingredients {
+		recipeIngredient := ingredientWithSource.RecipeIngredient
+		if recipeIngredient.IsOptional {
+			continue
+		}
+		allocator.reserve(mealPlan, ingredientWithSource.SourceRecipeID, recipeIngredient.Ingredient, recipeIngredient.Quantity*ingredientWithSource.QuantityRatio, recipeIngredient.Unit)
+	}
+
+	undoToken, err := newUndoToken()
+	if err != nil {
+		return nil, err
+	}
+
+	consumption := &dto.FridgeConsumption{
+		UserID:     mealPlan.UserID,
+		MealPlanID: mealPlan.ID,
+		UndoToken:  undoToken,
+		Items:      []dto.FridgeConsumptionItem{},
+	}
+
+	for _, usage := range allocator.usages() {
+		item := usage.item
+		removed := usage.remaining == 0
+
+		if removed {
+			if err := r.db.WithContext(ctx).Delete(&dto.FridgeItem{}, item.ID).Error; err != nil {
+				return nil, ormerrors.NewDatabaseError("remove consumed fridge item", err)
+			}
+		} else if err := r.db.WithContext(ctx).
+			Model(&dto.FridgeItem{}).
+			Where("id = ?", item.ID).
+			Update("quantity", usage.remaining).Error; err != nil {
+			return nil, ormerrors.NewDatabaseError("update consumed fridge item", err)
+		}
+
+		consumption.Items = append(consumption.Items, dto.FridgeConsumptionItem{
+			FridgeItemID:     item.ID,
+			IngredientID:     item.IngredientID,
+			IngredientName:   item.Ingredient.Name,
+			ConsumedQuantity: usage.used,
+			Unit:             item.Unit,
+			ExpiryDate:       item.ExpiryDate,
+			Notes:            item.Notes,
+			Removed:          removed,
 		})
+	}
 
-	if result.Error != nil {
-		return ormerrors.NewDatabaseError("mark meal plan as completed", result.Error)
+	if err := r.db.WithContext(ctx).Create(consumption).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("record fridge consumption", err)
+	}
+
+	return consumption, nil
+}
+
+// UndoCompletion remet un planning de repas terminé de l'utilisateur comme non terminé. Si le jeton
+// d'annulation de la dernière complétion est fourni, le stock du frigo consommé est restauré (une seule fois).
+// Un repas qui n'est pas terminé est laissé tel quel. Retourne vrai si le stock a été restauré.
+func (r *mealPlanRepository) UndoCompletion(ctx context.Context, id, userID uint, undoToken string) (bool, error) {
+	restored := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Verrouiller le repas pour que deux annulations simultanées ne restaurent pas deux fois le stock
+		var mealPlan dto.MealPlan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).
+			First(&mealPlan, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ormerrors.NewNotFoundError("meal plan", id)
+			}
+			return ormerrors.NewDatabaseError("get meal plan to uncomplete", err)
+		}
+		if !mealPlan.IsCompleted {
+			return nil
+		}
+
+		// Un repas non réalisé n'a pas laissé de restes
+		if err := deleteMealPlanLeftovers(tx, id); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&dto.MealPlan{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"is_completed": false,
+				"completed_at": nil,
+			}).Error; err != nil {
+			return ormerrors.NewDatabaseError("mark meal plan as not completed", err)
+		}
+
+		if undoToken == "" {
+			return nil
+		}
+
+		// Seule la consommation de la dernière complétion peut être annulée : le jeton d'une complétion
+		// précédente, remise comme non terminée sans restauration, ne restaure plus rien
+		var consumption dto.FridgeConsumption
+		if err := tx.
+			Preload("Items").
+			Where("meal_plan_id = ?", id).
+			Order("id DESC").
+			First(&consumption).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return ormerrors.NewDatabaseError("get fridge consumption", err)
+		}
+		if consumption.ID == 0 || consumption.UndoToken != undoToken || consumption.RevertedAt != nil {
+			return ormerrors.NewNotFoundError("fridge consumption", undoToken)
+		}
+
+		for _, consumed := range consumption.Items {
+			if !consumed.Removed {
+				result := tx.Model(&dto.FridgeItem{}).
+					Where("id = ?", consumed.FridgeItemID).
+					Update("quantity", gorm.Expr("COALESCE(quantity, 0) + ?", consumed.ConsumedQuantity))
+				if result.Error != nil {
+					return ormerrors.NewDatabaseError("restore fridge item", result.Error)
+				}
+				if result.RowsAffected > 0 {
+					continue
+				}
+			}
+
+			// Item supprimé (par la consommation ou depuis) : le recréer avec la quantité consommée
+			quantity := consumed.ConsumedQuantity
+			restoredItem := &dto.FridgeItem{
+				UserID:       consumption.UserID,
+				IngredientID: consumed.IngredientID,
+				Quantity:     &quantity,
+				Unit:         consumed.Unit,
+				ExpiryDate:   consumed.ExpiryDate,
+				Notes:        consumed.Notes,
+			}
+			if err := tx.Create(restoredItem).Error; err != nil {
+				return ormerrors.NewDatabaseError("restore removed fridge item", err)
+			}
+		}
+
+		now := time.Now()
+		if err := tx.Model(&consumption).Update("reverted_at", &now).Error; err != nil {
+			return ormerrors.NewDatabaseError("mark fridge consumption as reverted", err)
+		}
+		restored = true
+		return nil
+	})
+	if err != nil {
+		return false, err
 	}
-	if result.RowsAffected == 0 {
-		return ormerrors.NewNotFoundError("meal plan", id)
+	return restored, nil
+}
+
+// newUndoToken génère un jeton aléatoire pour annuler une consommation du frigo
+func newUndoToken() (string, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate undo token: %w", err)
 	}
-	return nil
+	return hex.EncodeToString(tokenBytes), nil
 }
 
 // GetUpcomingMeals récupère les prochains repas planifiés pour un utilisateur
@@ -186,6 +435,11 @@ func (r *mealPlanRepository) GetUpcomingMeals(ctx context.Context, userID uint,
 	startDate := time.Now()
 	endDate := startDate.Add(time.Duration(days) * 24 * time.Hour)
 
+	// Créer les occurrences des repas récurrents à venir
+	if err := materializeRecurrences(ctx, r.db, userID, startDate, endDate); err != nil {
+		return nil, err
+	}
+
 	if err := r.db.WithContext(ctx).
 		Preload("Recipe").
 		Preload("Recipe.Author").
@@ -274,8 +528,12 @@ func (r *mealPlanRepository) collectNestedIngredients(ctx context.Context, recip
 }
 
 // GetWeeklyShoppingList récupère la liste de courses pour une semaine donnée
-// GetWeeklyShoppingList récupère la liste de courses pour une semaine donnée
-func (r *mealPlanRepository) GetWeeklyShoppingList(ctx context.Context, userID uint, startDate, endDate time.Time) (*dto.WeeklyShoppingList, error) {
+// Avec opts.SubtractFridge, le stock du frigo est réservé repas par repas dans l'ordre chronologique.
+func (r *mealPlanRepository) GetWeeklyShoppingList(ctx context.Context, userID uint, startDate, endDate time.Time, opts dto.ShoppingListOptions) (*dto.WeeklyShoppingList, error) {
+	if err := materializeRecurrences(ctx, r.db, userID, startDate, endDate); err != nil {
+		return nil, err
+	}
+
 	// Récupérer tous les meal plans de la semaine avec leurs recettes et ingrédients
 	var mealPlans []*dto.MealPlan
 	if err := r.db.WithContext(ctx).
@@ -283,13 +541,29 @@ func (r *mealPlanRepository) GetWeeklyShoppingList(ctx context.Context, userID u
 		Preload("Recipe.Ingredients").
 		Preload("Recipe.Ingredients.Ingredient").
 		Where("user_id = ? AND planned_date >= ? AND planned_date <= ?", userID, startDate, endDate).
-		Order("planned_date ASC").
+		// Les repas préparés avec des restes n'ont rien à acheter
+		Where("leftover_id IS NULL").
+		Order("planned_date ASC, id ASC").
 		Find(&mealPlans).Error; err != nil {
 		return nil, ormerrors.NewDatabaseError("get meal plans for shopping list", err)
 	}
 
-	// Créer une map pour agréger les ingrédients par ID
-	ingredientMap := make(map[uint]*dto.ShoppingListItem)
+	// Agréger les ingrédients par ID en convertissant les unités compatibles
+	aggregator := newShoppingListAggregator(opts.SubtractFridge)
+
+	// Préparer la réservation du stock du frigo si demandée
+	var allocator *fridgeAllocator
+	if opts.SubtractFridge {
+		fridgeItems, err := NewFridgeRepository(r.db).GetByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		taxonomy, err := loadIngredientTaxonomy(r.db.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		allocator = newFridgeAllocator(fridgeItems, taxonomy)
+	}
 
 	// Initialiser le collecteur pour les recettes imbriquées
 	collector := &RecipeIngredientCollector{
@@ -297,6 +571,10 @@ func (r *mealPlanRepository) GetWeeklyShoppingList(ctx context.Context, userID u
 		RecipeData:  make(map[uint]*dto.Recipe),
 	}
 
+	// Ingrédients rencontrés et portions planifiées, pour l'estimation du coût
+	ingredients := make(map[uint]dto.Ingredient)
+	totalServings := 0
+
 	for _, mealPlan := range mealPlans {
 		// Calculer le ratio de portions (portions planifiées / portions de base de la recette)
 		servingsRatio := float64(mealPlan.Servings) / float64(mealPlan.Recipe.Servings)
@@ -309,60 +587,31 @@ func (r *mealPlanRepository) GetWeeklyShoppingList(ctx context.Context, userID u
 
 		// Traiter tous les ingrédients collectés
 		for _, ingredientWithSource := range collector.Ingredients {
-			ingredient := ingredientWithSource.RecipeIngredient.Ingredient
-			adjustedQuantity := ingredientWithSource.RecipeIngredient.Quantity * ingredientWithSource.QuantityRatio
-
-			if existingItem, exists := ingredientMap[ingredient.ID]; exists {
-				// Additionner les quantités si l'ingrédient existe déjà
-				existingItem.TotalQuantity += adjustedQuantity
-
-				// Ajouter les détails de cette recette source
-				existingItem.Recipes = append(existingItem.Recipes, struct {
-					RecipeID   uint    `json:"recipe_id"`
-					RecipeName string  `json:"recipe_name"`
-					Quantity   float64 `json:"quantity"`
-					Date       string  `json:"date"`
-					MealType   string  `json:"meal_type"`
-				}{
-					RecipeID:   ingredientWithSource.SourceRecipeID,
-					RecipeName: ingredientWithSource.SourceRecipeName,
-					Quantity:   adjustedQuantity,
-					Date:       mealPlan.PlannedDate.Format("2006-01-02"),
-					MealType:   mealPlan.MealType,
-				})
-			} else {
-				// Créer un nouvel item
-				ingredientMap[ingredient.ID] = &dto.ShoppingListItem{
-					IngredientID:   ingredient.ID,
-					IngredientName: ingredient.Name,
-					TotalQuantity:  adjustedQuantity,
-					Unit:           ingredientWithSource.RecipeIngredient.Unit,
-					Recipes: []struct {
-						RecipeID   uint    `json:"recipe_id"`
-						RecipeName string  `json:"recipe_name"`
-						Quantity   float64 `json:"quantity"`
-						Date       string  `json:"date"`
-						MealType   string  `json:"meal_type"`
-					}{{
-						RecipeID:   ingredientWithSource.SourceRecipeID,
-						RecipeName: ingredientWithSource.SourceRecipeName,
-						Quantity:   adjustedQuantity,
-						Date:       mealPlan.PlannedDate.Format("2006-01-02"),
-						MealType:   mealPlan.MealType,
-					}},
-				}
+			recipeIngredient := ingredientWithSource.RecipeIngredient
+			adjustedQuantity := recipeIngredient.Quantity * ingredientWithSource.QuantityRatio
+			ingredients[recipeIngredient.IngredientID] = recipeIngredient.Ingredient
+
+			netQuantity := adjustedQuantity
+			if allocator != nil {
+				netQuantity = allocator.reserve(mealPlan, ingredientWithSource.SourceRecipeID, recipeIngredient.Ingredient, adjustedQuantity, recipeIngredient.Unit)
 			}
+
+			aggregator.add(recipeIngredient.Ingredient, adjustedQuantity, netQuantity, recipeIngredient.Unit, dto.ShoppingListRecipeRef{
+				RecipeID:   ingredientWithSource.SourceRecipeID,
+				RecipeName: ingredientWithSource.SourceRecipeName,
+				Quantity:   adjustedQuantity,
+				Date:       mealPlan.PlannedDate.Format("2006-01-02"),
+				MealType:   mealPlan.MealType,
+			})
 		}
 
+		totalServings += mealPlan.Servings
+
 		// Réinitialiser le collecteur pour le prochain meal plan
 		collector.Ingredients = collector.Ingredients[:0]
 	}
 
-	// Convertir la map en slice
-	items := make([]dto.ShoppingListItem, 0, len(ingredientMap))
-	for _, item := range ingredientMap {
-		items = append(items, *item)
-	}
+	items := aggregator.items()
 
 	// Compter le nombre unique de recettes (y compris les recettes imbriquées)
 	uniqueRecipes := make(map[uint]bool)
@@ -374,10 +623,50 @@ func (r *mealPlanRepository) GetWeeklyShoppingList(ctx context.Context, userID u
 		uniqueRecipes[recipeID] = true
 	}
 
-	return &dto.WeeklyShoppingList{
+	shoppingList := &dto.WeeklyShoppingList{
 		StartDate:    startDate,
 		EndDate:      endDate,
 		Items:        items,
 		TotalRecipes: len(uniqueRecipes),
-	}, nil
+	}
+	if allocator != nil {
+		shoppingList.FridgeAllocations = allocator.allocations
+	}
+
+	// Ranger les articles selon les rayons du magasin choisi (ou du magasin par défaut)
+	ingredientIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		ingredientIDs = append(ingredientIDs, item.IngredientID)
+	}
+	layout, err := loadStoreLayout(ctx, r.db, userID, opts.StoreID, ingredientIDs)
+	if err != nil {
+		return nil, err
+	}
+	if layout != nil {
+		storeID := layout.store.ID
+		shoppingList.StoreID = &storeID
+		shoppingList.Items, shoppingList.Aisles = layout.groupItems(items)
+	}
+
+	// Estimer le coût des quantités restant à acheter avec les prix du magasin choisi
+	if opts.WithCost {
+		book, err := loadPriceBook(ctx, r.db, userID, opts.StoreID, ingredientIDs)
+		if err != nil {
+			return nil, err
+		}
+		estimate := newCostEstimate()
+		for _, item := range items {
+			quantity := item.TotalQuantity
+			if item.NetQuantity != nil {
+				quantity = *item.NetQuantity
+			}
+			if quantity <= 0 {
+				continue
+			}
+			estimate.add(book.cost(ingredients[item.IngredientID], quantity, item.Unit))
+		}
+		shoppingList.Cost = estimate.result(totalServings)
+	}
+
+	return shoppingList, nil
 }
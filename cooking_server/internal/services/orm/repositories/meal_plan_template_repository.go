@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type mealPlanTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewMealPlanTemplateRepository crée une nouvelle instance du repository des semaines types
+func NewMealPlanTemplateRepository(db *gorm.DB) *mealPlanTemplateRepository {
+	return &mealPlanTemplateRepository{db: db}
+}
+
+// Create crée une semaine type avec ses repas
+func (r *mealPlanTemplateRepository) Create(ctx context.Context, template *dto.MealPlanTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkTemplateRecipes(tx, template.Items); err != nil {
+			return err
+		}
+		if err := tx.Omit("Items").Create(template).Error; err != nil {
+			return ormerrors.NewDatabaseError("create meal plan template", err)
+		}
+		if len(template.Items) == 0 {
+			return nil
+		}
+		for i := range template.Items {
+			template.Items[i].TemplateID = template.ID
+		}
+		if err := tx.Omit("Recipe").Create(&template.Items).Error; err != nil {
+			return ormerrors.NewDatabaseError("create meal plan template items", err)
+		}
+		return nil
+	})
+}
+
+// GetByID récupère une semaine type avec ses repas, dans l'ordre des jours
+func (r *mealPlanTemplateRepository) GetByID(ctx context.Context, id uint) (*dto.MealPlanTemplate, error) {
+	var template dto.MealPlanTemplate
+	if err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("day_offset ASC, meal_type, id ASC")
+		}).
+		Preload("Items.Recipe").
+		First(&template, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("meal plan template", id)
+		}
+		return nil, ormerrors.NewDatabaseError("get meal plan template by id", err)
+	}
+	return &template, nil
+}
+
+// GetByUser liste les semaines types d'un utilisateur
+func (r *mealPlanTemplateRepository) GetByUser(ctx context.Context, userID uint) ([]*dto.MealPlanTemplate, error) {
+	var templates []*dto.MealPlanTemplate
+	if err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("day_offset ASC, meal_type, id ASC")
+		}).
+		Preload("Items.Recipe").
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Find(&templates).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get meal plan templates by user", err)
+	}
+	return templates, nil
+}
+
+// Update modifie une semaine type et, si fournis, remplace ses repas
+func (r *mealPlanTemplateRepository) Update(ctx context.Context, template *dto.MealPlanTemplate, items *[]dto.MealPlanTemplateItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&dto.MealPlanTemplate{}).
+			Where("id = ?", template.ID).
+			Updates(map[string]interface{}{
+				"name":        template.Name,
+				"description": template.Description,
+				"updated_at":  time.Now(),
+			}).Error; err != nil {
+			return ormerrors.NewDatabaseError("update meal plan template", err)
+		}
+
+		if items == nil {
+			return nil
+		}
+		if err := checkTemplateRecipes(tx, *items); err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&dto.MealPlanTemplateItem{}).Error; err != nil {
+			return ormerrors.NewDatabaseError("delete meal plan template items", err)
+		}
+		if len(*items) == 0 {
+			return nil
+		}
+		for i := range *items {
+			(*items)[i].ID = 0
+			(*items)[i].TemplateID = template.ID
+		}
+		if err := tx.Omit("Recipe").Create(items).Error; err != nil {
+			return ormerrors.NewDatabaseError("create meal plan template items", err)
+		}
+		return nil
+	})
+}
+
+// Delete supprime une semaine type et ses repas
+func (r *mealPlanTemplateRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", id).Delete(&dto.MealPlanTemplateItem{}).Error; err != nil {
+			return ormerrors.NewDatabaseError("delete meal plan template items", err)
+		}
+		result := tx.Delete(&dto.MealPlanTemplate{}, id)
+		if result.Error != nil {
+			return ormerrors.NewDatabaseError("delete meal plan template", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ormerrors.NewNotFoundError("meal plan template", id)
+		}
+		return nil
+	})
+}
+
+// Apply planifie les repas d'une semaine type à partir de la date donnée et retourne les repas créés
+func (r *mealPlanTemplateRepository) Apply(ctx context.Context, id, userID uint, startDate time.Time) ([]*dto.MealPlan, error) {
+	template, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	mealPlans := make([]*dto.MealPlan, 0, len(template.Items))
+	for _, item := range template.Items {
+		mealPlans = append(mealPlans, &dto.MealPlan{
+			UserID:      userID,
+			RecipeID:    item.RecipeID,
+			PlannedDate: startDate.AddDate(0, 0, item.DayOffset),
+			MealType:    item.MealType,
+			Servings:    item.Servings,
+			Notes:       item.Notes,
+		})
+	}
+	if len(mealPlans) == 0 {
+		return mealPlans, nil
+	}
+
+	if err := r.db.WithContext(ctx).Omit(clause.Associations).Create(&mealPlans).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("apply meal plan template", err)
+	}
+
+	// Recharger les repas créés avec leurs recettes
+	ids := make([]uint, 0, len(mealPlans))
+	for _, mealPlan := range mealPlans {
+		ids = append(ids, mealPlan.ID)
+	}
+	var created []*dto.MealPlan
+	if err := r.db.WithContext(ctx).
+		Preload("Recipe").
+		Preload("Recipe.Author").
+		Where("id IN ?", ids).
+		Order("planned_date ASC, meal_type").
+		Find(&created).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get applied meal plans", err)
+	}
+	return created, nil
+}
+
+// checkTemplateRecipes vérifie que les recettes des repas d'une semaine type existent
+func checkTemplateRecipes(tx *gorm.DB, items []dto.MealPlanTemplateItem) error {
+	recipeIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		recipeIDs = append(recipeIDs, item.RecipeID)
+	}
+	recipeIDs = uniqueUints(recipeIDs)
+	if len(recipeIDs) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&dto.Recipe{}).Where("id IN ?", recipeIDs).Count(&count).Error; err != nil {
+		return ormerrors.NewDatabaseError("check template recipes", err)
+	}
+	if int(count) != len(recipeIDs) {
+		return ormerrors.NewValidationError("one or more recipes do not exist")
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// priceBook retient, pour chaque ingrédient, le prix le plus pertinent relevé par un utilisateur :
+// celui du magasin demandé s'il existe, sinon le plus récent. Un ingrédient sans prix utilise celui
+// de l'ingrédient plus général le plus proche.
+type priceBook struct {
+	prices   map[uint]dto.IngredientPrice
+	taxonomy ingredientTaxonomy
+}
+
+// loadPriceBook charge les prix de l'utilisateur pour les ingrédients donnés et leurs ancêtres
+func loadPriceBook(ctx context.Context, db *gorm.DB, userID uint, storeID *uint, ingredientIDs []uint) (*priceBook, error) {
+	db = db.WithContext(ctx)
+	book := &priceBook{prices: make(map[uint]dto.IngredientPrice)}
+
+	ingredientIDs = uniqueUints(ingredientIDs)
+	if len(ingredientIDs) == 0 {
+		return book, nil
+	}
+
+	taxonomy, err := loadIngredientTaxonomy(db)
+	if err != nil {
+		return nil, err
+	}
+	book.taxonomy = taxonomy
+
+	lineage := append([]uint(nil), ingredientIDs...)
+	for _, id := range ingredientIDs {
+		lineage = append(lineage, taxonomy.ancestors(id)...)
+	}
+
+	query := db.Where("user_id = ? AND ingredient_id IN ?", userID, uniqueUints(lineage))
+	if storeID != nil {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "(store_id = ?) DESC NULLS LAST, priced_at DESC, id DESC",
+			Vars: []interface{}{*storeID},
+		}})
+	} else {
+		query = query.Order("priced_at DESC, id DESC")
+	}
+	var prices []dto.IngredientPrice
+	if err := query.Find(&prices).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("load ingredient prices", err)
+	}
+	for _, price := range prices {
+		if _, exists := book.prices[price.IngredientID]; !exists {
+			book.prices[price.IngredientID] = price
+		}
+	}
+	return book, nil
+}
+
+// cost estime le coût d'une quantité d'ingrédient exprimée dans l'unité saisie
+func (b *priceBook) cost(ingredient dto.Ingredient, quantity float64, rawUnit string) dto.CostLine {
+	unit := units.Parse(rawUnit)
+	line := dto.CostLine{
+		IngredientID:   ingredient.ID,
+		IngredientName: ingredient.Name,
+		Quantity:       units.Round(quantity),
+		Unit:           unit.Code,
+	}
+
+	price, found := b.prices[ingredient.ID]
+	if !found {
+		for _, ancestor := range b.taxonomy.ancestors(ingredient.ID) {
+			if price, found = b.prices[ancestor]; found {
+				line.Estimated = true
+				break
+			}
+		}
+	}
+	if !found {
+		line.Gap = dto.CostGapNoPrice
+		return line
+	}
+
+	converted, ok := units.Convert(quantity, unit, units.Parse(price.Unit), ingredientHints(ingredient))
+	if !ok || price.Quantity <= 0 {
+		line.Gap = dto.CostGapUnconvertible
+		return line
+	}
+	cost := units.Round(price.Price * converted / price.Quantity)
+	priceID := price.ID
+	line.Cost = &cost
+	line.PriceID = &priceID
+	return line
+}
+
+// costLineKey identifie une ligne de coût : un ingrédient dans une unité
+type costLineKey struct {
+	ingredientID uint
+	unit         string
+}
+
+// costEstimate cumule des lignes de coût, regroupées par ingrédient et unité
+type costEstimate struct {
+	estimate *dto.CostEstimate
+	index    map[costLineKey]int
+	gaps     map[uint]bool
+}
+
+// newCostEstimate crée une estimation vide
+func newCostEstimate() *costEstimate {
+	return &costEstimate{
+		estimate: &dto.CostEstimate{Complete: true, Lines: []dto.CostLine{}, Gaps: []dto.CostGap{}},
+		index:    make(map[costLineKey]int),
+		gaps:     make(map[uint]bool),
+	}
+}
+
+// add ajoute une ligne de coût, en la fusionnant avec une ligne du même ingrédient dans la même unité
+func (e *costEstimate) add(line dto.CostLine) {
+	if line.Cost != nil {
+		e.estimate.Total += *line.Cost
+	} else {
+		e.estimate.Complete = false
+		if !e.gaps[line.IngredientID] {
+			e.gaps[line.IngredientID] = true
+			e.estimate.Gaps = append(e.estimate.Gaps, dto.CostGap{
+				IngredientID:   line.IngredientID,
+				IngredientName: line.IngredientName,
+				Reason:         line.Gap,
+			})
+		}
+	}
+
+	key := costLineKey{ingredientID: line.IngredientID, unit: units.Parse(line.Unit).Key()}
+	if i, ok := e.index[key]; ok {
+		existing := &e.estimate.Lines[i]
+		existing.Quantity = units.Round(existing.Quantity + line.Quantity)
+		if existing.Cost != nil && line.Cost != nil {
+			total := units.Round(*existing.Cost + *line.Cost)
+			existing.Cost = &total
+		}
+		return
+	}
+	e.index[key] = len(e.estimate.Lines)
+	e.estimate.Lines = append(e.estimate.Lines, line)
+}
+
+// result retourne l'estimation, avec le coût par portion si le nombre de portions est connu
+func (e *costEstimate) result(servings int) *dto.CostEstimate {
+	e.estimate.Total = units.Round(e.estimate.Total)
+	if servings > 0 {
+		e.estimate.Servings = servings
+		perServing := units.Round(e.estimate.Total / float64(servings))
+		e.estimate.PerServing = &perServing
+	}
+	return e.estimate
+}
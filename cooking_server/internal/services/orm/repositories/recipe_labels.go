@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+)
+
+// recipeLabels représente les étiquettes alimentaires dérivées d'une recette
+type recipeLabels struct {
+	allergens      map[string]bool
+	diets          map[string]bool // nil tant qu'aucun ingrédient n'a été rencontré
+	hasIngredients bool
+}
+
+// RefreshDietaryLabels recalcule les allergènes et régimes d'une recette et des recettes qui la référencent
+func (r *recipeRepository) RefreshDietaryLabels(ctx context.Context, recipeID uint) error {
+	return refreshRecipeLabels(r.db.WithContext(ctx), recipeID)
+}
+
+// RefreshAllDietaryLabels recalcule les allergènes et régimes de toutes les recettes
+func (r *recipeRepository) RefreshAllDietaryLabels(ctx context.Context) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&dto.Recipe{}).Order("id").Pluck("id", &ids).Error; err != nil {
+			return ormerrors.NewDatabaseError("list recipes", err)
+		}
+		return refreshRecipeLabels(tx, ids...)
+	})
+}
+
+// refreshRecipeLabels recalcule les étiquettes des recettes données ainsi que de toutes les recettes
+// qui les utilisent comme sous-recette. Les recettes supprimées entre-temps sont ignorées.
+func refreshRecipeLabels(db *gorm.DB, recipeIDs ...uint) error {
+	affected, err := referencingRecipeIDs(db, recipeIDs)
+	if err != nil {
+		return err
+	}
+
+	memo := map[uint]*recipeLabels{}
+	for _, id := range affected {
+		labels, err := deriveRecipeLabels(db, id, memo, map[uint]bool{})
+		if err != nil {
+			return err
+		}
+		if labels == nil {
+			continue
+		}
+
+		if err := db.Model(&dto.Recipe{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"allergens": labelList(labels.allergens),
+			"diets":     labelList(labels.diets),
+		}).Error; err != nil {
+			return ormerrors.NewDatabaseError("update recipe dietary labels", err)
+		}
+	}
+	return nil
+}
+
+// referencingRecipeIDs retourne les recettes données complétées des recettes qui y font référence, transitivement
+func referencingRecipeIDs(db *gorm.DB, recipeIDs []uint) ([]uint, error) {
+	seen := map[uint]bool{}
+	result := []uint{}
+	frontier := []uint{}
+	for _, id := range recipeIDs {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+			frontier = append(frontier, id)
+		}
+	}
+
+	for len(frontier) > 0 {
+		var parents []uint
+		if err := db.Model(&dto.Recipe{}).
+			Where("EXISTS (SELECT 1 FROM json_array_elements(coalesce(recipes.instructions::json, '[]'::json)) AS step "+
+				"WHERE (step->>'referenced_recipe_id')::bigint IN ?)", frontier).
+			Pluck("id", &parents).Error; err != nil {
+			return nil, ormerrors.NewDatabaseError("find referencing recipes", err)
+		}
+
+		frontier = frontier[:0]
+		for _, id := range parents {
+			if !seen[id] {
+				seen[id] = true
+				result = append(result, id)
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return result, nil
+}
+
+// deriveRecipeLabels calcule les étiquettes d'une recette à partir de ses ingrédients et de ses sous-recettes.
+// Les allergènes sont l'union de ceux des ingrédients ; un régime n'est retenu que si tous les ingrédients
+// le déclarent. Les ingrédients optionnels sont pris en compte. Retourne nil si la recette n'existe pas.
+func deriveRecipeLabels(db *gorm.DB, recipeID uint, memo map[uint]*recipeLabels, path map[uint]bool) (*recipeLabels, error) {
+	if labels, ok := memo[recipeID]; ok {
+		return labels, nil
+	}
+
+	var recipe dto.Recipe
+	if err := db.Select("id", "instructions").
+		Preload("Ingredients.Ingredient").
+		First(&recipe, recipeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, ormerrors.NewDatabaseError("get recipe dietary labels", err)
+	}
+
+	path[recipeID] = true
+	defer delete(path, recipeID)
+
+	labels := &recipeLabels{allergens: map[string]bool{}}
+	for _, ri := range recipe.Ingredients {
+		labels.merge(ri.Ingredient.Allergens, ri.Ingredient.Diets)
+	}
+
+	for _, step := range recipe.Instructions {
+		if step.ReferencedRecipeID == nil || path[*step.ReferencedRecipeID] {
+			continue
+		}
+		sub, err := deriveRecipeLabels(db, *step.ReferencedRecipeID, memo, path)
+		if err != nil {
+			return nil, err
+		}
+		if sub == nil || !sub.hasIngredients {
+			continue
+		}
+		labels.merge(labelList(sub.allergens), labelList(sub.diets))
+	}
+
+	memo[recipeID] = labels
+	return labels, nil
+}
+
+// merge ajoute les étiquettes d'un ingrédient (ou d'une sous-recette)
+func (l *recipeLabels) merge(allergens, diets []string) {
+	for _, allergen := range allergens {
+		l.allergens[allergen] = true
+	}
+
+	compatible := make(map[string]bool, len(diets))
+	for _, diet := range diets {
+		compatible[diet] = true
+	}
+	if !l.hasIngredients {
+		l.diets = compatible
+		l.hasIngredients = true
+		return
+	}
+	for diet := range l.diets {
+		if !compatible[diet] {
+			delete(l.diets, diet)
+		}
+	}
+}
+
+// labelList convertit un ensemble d'étiquettes en liste triée
+func labelList(set map[string]bool) dto.StringList {
+	list := make(dto.StringList, 0, len(set))
+	for label := range set {
+		list = append(list, label)
+	}
+	sort.Strings(list)
+	return list
+}
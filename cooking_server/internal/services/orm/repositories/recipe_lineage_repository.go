@@ -0,0 +1,468 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+)
+
+type recipeLineageRepository struct {
+	db *gorm.DB
+}
+
+// NewRecipeLineageRepository crée une nouvelle instance du repository de filiation des recettes
+func NewRecipeLineageRepository(db *gorm.DB) *recipeLineageRepository {
+	return &recipeLineageRepository{db: db}
+}
+
+// GetForkTree retourne l'arbre des copies auquel appartient une recette, depuis l'originale racine.
+// Les recettes privées n'apparaissent que pour leur auteur (viewerID = 0 pour un visiteur anonyme) :
+// une recette demandée invisible est introuvable, et la racine est le plus ancien ancêtre visible.
+func (r *recipeLineageRepository) GetForkTree(ctx context.Context, recipeID, viewerID uint) (*dto.RecipeForkNode, error) {
+	// Remonter jusqu'à l'originale racine, sans dépasser un ancêtre privé
+	rootID := recipeID
+	currentID := recipeID
+	visited := map[uint]bool{}
+	for !visited[currentID] {
+		visited[currentID] = true
+
+		var recipe dto.Recipe
+		if err := r.db.WithContext(ctx).Select("id", "original_recipe_id", "is_public", "author_id").First(&recipe, currentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if currentID == recipeID {
+					return nil, ormerrors.NewNotFoundError("recipe", recipeID)
+				}
+				break
+			}
+			return nil, ormerrors.NewDatabaseError("get recipe lineage", err)
+		}
+		if !recipe.IsPublic && recipe.AuthorID != viewerID {
+			if currentID == recipeID {
+				return nil, ormerrors.NewNotFoundError("recipe", recipeID)
+			}
+			break
+		}
+		rootID = currentID
+		if recipe.OriginalRecipeID == nil {
+			break
+		}
+		currentID = *recipe.OriginalRecipeID
+	}
+
+	var root dto.Recipe
+	if err := r.db.WithContext(ctx).Preload("Author").First(&root, rootID).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get root recipe", err)
+	}
+
+	rootNode := newRecipeForkNode(&root)
+	nodes := map[uint]*dto.RecipeForkNode{root.ID: rootNode}
+	level := []uint{root.ID}
+
+	// Descendre niveau par niveau dans les copies
+	for len(level) > 0 {
+		var forks []dto.Recipe
+		if err := r.db.WithContext(ctx).
+			Preload("Author").
+			Where("original_recipe_id IN ?", level).
+			Where("is_public = ? OR author_id = ?", true, viewerID).
+			Order("created_at ASC").
+			Find(&forks).Error; err != nil {
+			return nil, ormerrors.NewDatabaseError("get recipe forks", err)
+		}
+
+		level = level[:0]
+		for i := range forks {
+			fork := &forks[i]
+			if nodes[fork.ID] != nil {
+				continue
+			}
+			node := newRecipeForkNode(fork)
+			nodes[fork.ID] = node
+			parent := nodes[*fork.OriginalRecipeID]
+			parent.Forks = append(parent.Forks, node)
+			level = append(level, fork.ID)
+		}
+	}
+
+	return rootNode, nil
+}
+
+// newRecipeForkNode construit un nœud de l'arbre des copies
+func newRecipeForkNode(recipe *dto.Recipe) *dto.RecipeForkNode {
+	return &dto.RecipeForkNode{
+		ID:             recipe.ID,
+		Title:          recipe.Title,
+		AuthorID:       recipe.AuthorID,
+		AuthorUsername: recipe.Author.Username,
+		IsPublic:       recipe.IsPublic,
+		IsOriginal:     recipe.IsOriginal,
+		ForkedRevision: recipe.ForkedRevision,
+		CreatedAt:      recipe.CreatedAt,
+		Forks:          []*dto.RecipeForkNode{},
+	}
+}
+
+// GetUpstreamChanges retourne les modifications de la recette originale depuis la copie
+// qui ne sont pas encore reflétées dans la copie
+func (r *recipeLineageRepository) GetUpstreamChanges(ctx context.Context, copyID uint) (*dto.RecipeUpstreamChanges, error) {
+	changes, _, err := r.upstreamChanges(ctx, copyID)
+	return changes, err
+}
+
+// upstreamChanges calcule les modifications en attente et retourne aussi l'état actuel de la copie
+func (r *recipeLineageRepository) upstreamChanges(ctx context.Context, copyID uint) (*dto.RecipeUpstreamChanges, *dto.RecipeSnapshot, error) {
+	var recipeCopy dto.Recipe
+	if err := r.db.WithContext(ctx).First(&recipeCopy, copyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ormerrors.NewNotFoundError("recipe", copyID)
+		}
+		return nil, nil, ormerrors.NewDatabaseError("get recipe copy", err)
+	}
+	if recipeCopy.OriginalRecipeID == nil {
+		return nil, nil, ormerrors.NewValidationError("recipe is not a copy")
+	}
+	originalID := *recipeCopy.OriginalRecipeID
+
+	var original dto.Recipe
+	if err := r.db.WithContext(ctx).Select("id").First(&original, originalID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ormerrors.NewNotFoundError("recipe", originalID)
+		}
+		return nil, nil, ormerrors.NewDatabaseError("get original recipe", err)
+	}
+
+	// Lecture seule : l'historique de l'originale est initialisé à la copie (voir recipeRepository.Copy)
+	revisions := NewRecipeRevisionRepository(r.db)
+	latest, err := revisions.latestNumber(ctx, originalID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, err := revisions.snapshot(ctx, copyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Originale jamais modifiée depuis l'introduction de l'historique : aucune modification à importer
+	if latest == 0 {
+		return &dto.RecipeUpstreamChanges{
+			RecipeID:         copyID,
+			OriginalRecipeID: originalID,
+			Changes:          *diffRecipeSnapshots(current, current),
+		}, current, nil
+	}
+
+	forked, err := r.forkedRevision(ctx, &recipeCopy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	diff, err := revisions.Diff(ctx, originalID, forked, latest)
+	if err != nil {
+		return nil, nil, err
+	}
+	pendingUpstreamChanges(diff, current)
+
+	return &dto.RecipeUpstreamChanges{
+		RecipeID:         copyID,
+		OriginalRecipeID: originalID,
+		ForkedRevision:   forked,
+		LatestRevision:   latest,
+		Changes:          *diff,
+	}, current, nil
+}
+
+// forkedRevision retourne la révision de l'originale sur laquelle la copie est basée.
+// Pour les copies antérieures au suivi, on retient la dernière révision de l'originale créée avant la copie.
+func (r *recipeLineageRepository) forkedRevision(ctx context.Context, recipeCopy *dto.Recipe) (int, error) {
+	if recipeCopy.ForkedRevision != nil {
+		return *recipeCopy.ForkedRevision, nil
+	}
+
+	var number int
+	if err := r.db.WithContext(ctx).
+		Model(&dto.RecipeRevision{}).
+		Where("recipe_id = ? AND created_at <= ?", *recipeCopy.OriginalRecipeID, recipeCopy.CreatedAt).
+		Select("COALESCE(MAX(number), 1)").
+		Scan(&number).Error; err != nil {
+		return 0, ormerrors.NewDatabaseError("get forked revision", err)
+	}
+	return number, nil
+}
+
+// PullUpstreamChanges importe dans une copie les modifications sélectionnées de la recette originale
+// (ingrédients par ID, étapes par numéro dans l'originale) et enregistre une nouvelle révision de la copie
+func (r *recipeLineageRepository) PullUpstreamChanges(ctx context.Context, copyID, authorID uint, req *dto.RecipeUpstreamPullRequest) (*dto.RecipeUpstreamChanges, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := &recipeLineageRepository{db: tx}
+		revisions := NewRecipeRevisionRepository(tx)
+
+		if err := revisions.EnsureInitial(ctx, copyID, authorID); err != nil {
+			return err
+		}
+
+		changes, current, err := txRepo.upstreamChanges(ctx, copyID)
+		if err != nil {
+			return err
+		}
+
+		pullIngredientChanges(current, &changes.Changes.Ingredients, req.IngredientIDs)
+		pullStepChanges(current, &changes.Changes.Steps, req.StepNumbers)
+
+		if err := applyRecipeSnapshot(tx, copyID, current); err != nil {
+			return err
+		}
+
+		_, err = revisions.Record(ctx, copyID, authorID, fmt.Sprintf("Import des modifications de la révision %d de l'originale", changes.LatestRevision))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetUpstreamChanges(ctx, copyID)
+}
+
+// pendingUpstreamChanges retire du diff les modifications déjà présentes dans la copie
+func pendingUpstreamChanges(diff *dto.RecipeRevisionDiff, current *dto.RecipeSnapshot) {
+	currentFields := map[string]interface{}{
+		"title":       current.Title,
+		"description": current.Description,
+		"prep_time":   current.PrepTime,
+		"cook_time":   current.CookTime,
+		"servings":    current.Servings,
+		"difficulty":  current.Difficulty,
+		"image_url":   current.ImageURL,
+		"is_public":   current.IsPublic,
+	}
+	fields := diff.Fields[:0]
+	for _, change := range diff.Fields {
+		if currentFields[change.Field] != change.After {
+			fields = append(fields, change)
+		}
+	}
+	diff.Fields = fields
+
+	// Ingrédients
+	added := diff.Ingredients.Added[:0]
+	for _, ingredient := range diff.Ingredients.Added {
+		if findSnapshotIngredient(current.Ingredients, ingredient) < 0 {
+			added = append(added, ingredient)
+		}
+	}
+	diff.Ingredients.Added = added
+
+	removed := diff.Ingredients.Removed[:0]
+	for _, ingredient := range diff.Ingredients.Removed {
+		if findSnapshotIngredient(current.Ingredients, ingredient) >= 0 {
+			removed = append(removed, ingredient)
+		}
+	}
+	diff.Ingredients.Removed = removed
+
+	changed := diff.Ingredients.Changed[:0]
+	for _, change := range diff.Ingredients.Changed {
+		index := findSnapshotIngredient(current.Ingredients, change.After)
+		if index < 0 || !sameSnapshotIngredientContent(current.Ingredients[index], change.After) {
+			changed = append(changed, change)
+		}
+	}
+	diff.Ingredients.Changed = changed
+
+	// Étapes (reconnues par leur contenu, la numérotation pouvant différer dans la copie)
+	addedSteps := diff.Steps.Added[:0]
+	for _, step := range diff.Steps.Added {
+		if findRecipeStep(current.Instructions, step) < 0 {
+			addedSteps = append(addedSteps, step)
+		}
+	}
+	diff.Steps.Added = addedSteps
+
+	removedSteps := diff.Steps.Removed[:0]
+	for _, step := range diff.Steps.Removed {
+		if findRecipeStep(current.Instructions, step) >= 0 {
+			removedSteps = append(removedSteps, step)
+		}
+	}
+	diff.Steps.Removed = removedSteps
+
+	changedSteps := diff.Steps.Changed[:0]
+	for _, change := range diff.Steps.Changed {
+		if findRecipeStep(current.Instructions, change.After) < 0 {
+			changedSteps = append(changedSteps, change)
+		}
+	}
+	diff.Steps.Changed = changedSteps
+
+	// Tags et catégories
+	diff.Tags = pendingRefs(diff.Tags, current.Tags)
+	diff.Categories = pendingRefs(diff.Categories, current.Categories)
+}
+
+// pendingRefs retire les tags ou catégories déjà ajoutés ou supprimés dans la copie
+func pendingRefs(diff dto.RecipeRefsDiff, current []dto.RecipeSnapshotRef) dto.RecipeRefsDiff {
+	present := make(map[uint]bool)
+	for _, ref := range current {
+		present[ref.ID] = true
+	}
+
+	pending := dto.RecipeRefsDiff{Added: []dto.RecipeSnapshotRef{}, Removed: []dto.RecipeSnapshotRef{}}
+	for _, ref := range diff.Added {
+		if !present[ref.ID] {
+			pending.Added = append(pending.Added, ref)
+		}
+	}
+	for _, ref := range diff.Removed {
+		if present[ref.ID] {
+			pending.Removed = append(pending.Removed, ref)
+		}
+	}
+	return pending
+}
+
+// findSnapshotIngredient retourne l'index d'un ingrédient (même ingrédient, même groupe) ou -1
+func findSnapshotIngredient(ingredients []dto.RecipeSnapshotIngredient, target dto.RecipeSnapshotIngredient) int {
+	for i, ingredient := range ingredients {
+		if ingredient.IngredientID == target.IngredientID && ingredient.Group == target.Group {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameSnapshotIngredientContent compare les quantités et annotations de deux ingrédients
+func sameSnapshotIngredientContent(a, b dto.RecipeSnapshotIngredient) bool {
+	return a.Quantity == b.Quantity &&
+		a.Unit == b.Unit &&
+		a.Notes == b.Notes &&
+		a.IsOptional == b.IsOptional
+}
+
+// findRecipeStep retourne l'index d'une étape de même contenu (numéro ignoré) ou -1
+func findRecipeStep(steps dto.RecipeSteps, target dto.RecipeStep) int {
+	for i, step := range steps {
+		target.StepNumber = step.StepNumber
+		if sameRecipeStep(step, target) {
+			return i
+		}
+	}
+	return -1
+}
+
+// pullIngredientChanges applique à la copie les modifications d'ingrédients sélectionnées
+func pullIngredientChanges(current *dto.RecipeSnapshot, changes *dto.RecipeIngredientsDiff, ingredientIDs []uint) {
+	selected := make(map[uint]bool)
+	for _, id := range ingredientIDs {
+		selected[id] = true
+	}
+	if len(selected) == 0 {
+		return
+	}
+
+	for _, ingredient := range changes.Removed {
+		if !selected[ingredient.IngredientID] {
+			continue
+		}
+		if index := findSnapshotIngredient(current.Ingredients, ingredient); index >= 0 {
+			current.Ingredients = append(current.Ingredients[:index], current.Ingredients[index+1:]...)
+		}
+	}
+
+	for _, change := range changes.Changed {
+		if !selected[change.After.IngredientID] {
+			continue
+		}
+		if index := findSnapshotIngredient(current.Ingredients, change.After); index >= 0 {
+			ingredient := &current.Ingredients[index]
+			ingredient.Quantity = change.After.Quantity
+			ingredient.Unit = change.After.Unit
+			ingredient.Notes = change.After.Notes
+			ingredient.IsOptional = change.After.IsOptional
+			continue
+		}
+		current.Ingredients = append(current.Ingredients, change.After)
+	}
+
+	for _, ingredient := range changes.Added {
+		if selected[ingredient.IngredientID] {
+			current.Ingredients = append(current.Ingredients, ingredient)
+		}
+	}
+
+	for i := range current.Ingredients {
+		current.Ingredients[i].Position = i
+	}
+}
+
+// pullStepChanges applique à la copie les modifications d'étapes sélectionnées
+func pullStepChanges(current *dto.RecipeSnapshot, changes *dto.RecipeStepsDiff, stepNumbers []int) {
+	selected := make(map[int]bool)
+	for _, number := range stepNumbers {
+		selected[number] = true
+	}
+	if len(selected) == 0 {
+		return
+	}
+
+	steps := append(dto.RecipeSteps{}, current.Instructions...)
+
+	for _, step := range changes.Removed {
+		if !selected[step.StepNumber] {
+			continue
+		}
+		if index := findRecipeStep(steps, step); index >= 0 {
+			steps = append(steps[:index], steps[index+1:]...)
+		}
+	}
+
+	for _, change := range changes.Changed {
+		if !selected[change.StepNumber] {
+			continue
+		}
+		index := findRecipeStep(steps, change.Before)
+		if index < 0 {
+			for i, step := range steps {
+				if step.StepNumber == change.StepNumber {
+					index = i
+					break
+				}
+			}
+		}
+		if index >= 0 {
+			updated := change.After
+			updated.StepNumber = steps[index].StepNumber
+			steps[index] = updated
+			continue
+		}
+		steps = append(steps, change.After)
+	}
+
+	// Les étapes ajoutées sont insérées à leur rang dans l'originale
+	added := make([]dto.RecipeStep, 0, len(changes.Added))
+	for _, step := range changes.Added {
+		if selected[step.StepNumber] {
+			added = append(added, step)
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].StepNumber < added[j].StepNumber })
+	for _, step := range added {
+		index := step.StepNumber - 1
+		if index > len(steps) {
+			index = len(steps)
+		}
+		if index < 0 {
+			index = 0
+		}
+		steps = append(steps[:index], append(dto.RecipeSteps{step}, steps[index:]...)...)
+	}
+
+	for i := range steps {
+		steps[i].StepNumber = i + 1
+	}
+	current.Instructions = steps
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
+	"gorm.io/gorm"
+)
+
+type recipeNutritionRepository struct {
+	db *gorm.DB
+}
+
+// NewRecipeNutritionRepository crée une nouvelle instance du repository de valeurs nutritionnelles
+func NewRecipeNutritionRepository(db *gorm.DB) *recipeNutritionRepository {
+	return &recipeNutritionRepository{db: db}
+}
+
+// GetRecipeNutrition calcule les valeurs nutritionnelles d'une recette, par recette et par portion.
+// Les sous-recettes référencées dans les étapes sont comptées en entier ; les ingrédients optionnels sont ignorés.
+func (r *recipeNutritionRepository) GetRecipeNutrition(ctx context.Context, recipeID uint) (*dto.RecipeNutrition, error) {
+	recipe, err := r.loadRecipe(ctx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if recipe == nil {
+		return nil, ormerrors.NewNotFoundError("recipe", recipeID)
+	}
+
+	result := &dto.RecipeNutrition{
+		RecipeID:   recipe.ID,
+		Servings:   recipe.Servings,
+		SubRecipes: []dto.NutritionSubRecipe{},
+		Gaps:       []dto.NutritionIngredientGap{},
+	}
+
+	total, err := r.recipeTotal(ctx, recipe, result, map[uint]bool{}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result.PerRecipe = roundNutrients(total)
+	if recipe.Servings > 0 {
+		perServing := roundNutrients(total.Scale(1 / float64(recipe.Servings)))
+		result.PerServing = &perServing
+	}
+
+	coverage := &result.Coverage
+	coverage.CoveredGrams = units.Round(coverage.CoveredGrams)
+	if coverage.TotalIngredients > 0 {
+		coverage.Ratio = units.Round(float64(coverage.CoveredIngredients) / float64(coverage.TotalIngredients))
+	}
+	coverage.IsComplete = coverage.CoveredIngredients == coverage.TotalIngredients
+	return result, nil
+}
+
+// loadRecipe charge une recette avec ses ingrédients (nil si elle n'existe pas)
+func (r *recipeNutritionRepository) loadRecipe(ctx context.Context, recipeID uint) (*dto.Recipe, error) {
+	var recipe dto.Recipe
+	err := r.db.WithContext(ctx).
+		Preload("Ingredients.Ingredient").
+		First(&recipe, recipeID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, ormerrors.NewDatabaseError("get recipe nutrition", err)
+	}
+	return &recipe, nil
+}
+
+// recipeTotal additionne les valeurs nutritionnelles des ingrédients d'une recette et de ses sous-recettes.
+// path contient les recettes en cours de calcul pour ignorer les références circulaires.
+func (r *recipeNutritionRepository) recipeTotal(ctx context.Context, recipe *dto.Recipe, result *dto.RecipeNutrition, path map[uint]bool, isRoot bool) (dto.Nutrients, error) {
+	path[recipe.ID] = true
+	defer delete(path, recipe.ID)
+
+	var total dto.Nutrients
+	for _, ri := range recipe.Ingredients {
+		if ri.IsOptional {
+			continue
+		}
+		result.Coverage.TotalIngredients++
+
+		gap := dto.NutritionIngredientGap{
+			RecipeID:       recipe.ID,
+			IngredientID:   ri.IngredientID,
+			IngredientName: ri.Ingredient.Name,
+			Quantity:       ri.Quantity,
+			Unit:           ri.Unit,
+		}
+
+		if !ri.Ingredient.HasNutrition() {
+			gap.Reason = dto.NutritionGapNoData
+			result.Gaps = append(result.Gaps, gap)
+			continue
+		}
+		if ri.Quantity <= 0 {
+			gap.Reason = dto.NutritionGapNoQuantity
+			result.Gaps = append(result.Gaps, gap)
+			continue
+		}
+		grams, ok := units.Convert(ri.Quantity, units.Parse(ri.Unit), units.Gram, ingredientHints(ri.Ingredient))
+		if !ok {
+			gap.Reason = dto.NutritionGapUnconvertible
+			result.Gaps = append(result.Gaps, gap)
+			continue
+		}
+
+		total.Add(ri.Ingredient.NutrientsPer100g().Scale(grams / 100))
+		result.Coverage.CoveredIngredients++
+		result.Coverage.CoveredGrams += grams
+	}
+
+	// Sous-recettes référencées dans les étapes (une seule fois chacune)
+	seen := map[uint]bool{}
+	for _, step := range recipe.Instructions {
+		if step.ReferencedRecipeID == nil {
+			continue
+		}
+		subID := *step.ReferencedRecipeID
+		if seen[subID] || path[subID] {
+			continue
+		}
+		seen[subID] = true
+
+		sub, err := r.loadRecipe(ctx, subID)
+		if err != nil {
+			return dto.Nutrients{}, err
+		}
+		if sub == nil {
+			continue
+		}
+
+		subTotal, err := r.recipeTotal(ctx, sub, result, path, false)
+		if err != nil {
+			return dto.Nutrients{}, err
+		}
+		total.Add(subTotal)
+
+		if isRoot {
+			result.SubRecipes = append(result.SubRecipes, dto.NutritionSubRecipe{
+				RecipeID:  sub.ID,
+				Title:     sub.Title,
+				Nutrients: roundNutrients(subTotal),
+			})
+		}
+	}
+
+	return total, nil
+}
+
+// roundNutrients arrondit chaque valeur à deux décimales
+func roundNutrients(n dto.Nutrients) dto.Nutrients {
+	return dto.Nutrients{
+		EnergyKcal:    units.Round(n.EnergyKcal),
+		Protein:       units.Round(n.Protein),
+		Fat:           units.Round(n.Fat),
+		Carbohydrates: units.Round(n.Carbohydrates),
+		Fiber:         units.Round(n.Fiber),
+		Salt:          units.Round(n.Salt),
+	}
+}
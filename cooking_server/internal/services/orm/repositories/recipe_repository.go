@@ -205,6 +205,7 @@ func (r *recipeRepository) Delete(ctx context.Context, id uint) error {
 		if err := tx.Model(&firstCopy).Updates(map[string]interface{}{
 			"is_original":        true,
 			"original_recipe_id": nil,
+			"forked_revision":    nil,
 		}).Error; err != nil {
 			log.Printf("Error updating first copy to original: %v", err)
 			tx.Rollback()
@@ -214,9 +215,13 @@ func (r *recipeRepository) Delete(ctx context.Context, id uint) error {
 		// Mettre à jour toutes les autres copies pour pointer vers la nouvelle originale
 		if len(copiedRecipes) > 1 {
 			log.Printf("Updating %d other copies to point to new original (ID: %d)", len(copiedRecipes)-1, firstCopy.ID)
+			// La révision de départ n'a plus de sens par rapport à la nouvelle originale
 			if err := tx.Model(&dto.Recipe{}).
 				Where("original_recipe_id = ? AND id != ?", id, firstCopy.ID).
-				Update("original_recipe_id", firstCopy.ID).Error; err != nil {
+				Updates(map[string]interface{}{
+					"original_recipe_id": firstCopy.ID,
+					"forked_revision":    nil,
+				}).Error; err != nil {
 				log.Printf("Error updating other copies: %v", err)
 				tx.Rollback()
 				return ormerrors.NewDatabaseError("update other copies", err)
@@ -283,6 +288,13 @@ func (r *recipeRepository) Delete(ctx context.Context, id uint) error {
 		return ormerrors.NewDatabaseError("delete meal plans", err)
 	}
 
+	// 9. Supprimer l'historique des révisions
+	if err := tx.Where("recipe_id = ?", id).Delete(&dto.RecipeRevision{}).Error; err != nil {
+		log.Printf("Error deleting recipe revisions: %v", err)
+		tx.Rollback()
+		return ormerrors.NewDatabaseError("delete recipe revisions", err)
+	}
+
 	log.Printf("All associations deleted, now deleting recipe with ID: %d", id)
 
 	// Finalement, supprimer la recette
@@ -306,6 +318,11 @@ func (r *recipeRepository) Delete(ctx context.Context, id uint) error {
 		return ormerrors.NewDatabaseError("commit transaction", err)
 	}
 
+	// Les recettes qui utilisaient celle-ci comme sous-recette perdent ses étiquettes
+	if err := refreshRecipeLabels(r.db.WithContext(ctx), id); err != nil {
+		log.Printf("Error refreshing dietary labels after deletion: %v", err)
+	}
+
 	log.Printf("Recipe deletion completed successfully for ID: %d", id)
 	return nil
 }
@@ -335,233 +352,106 @@ func (r *recipeRepository) List(ctx context.Context, limit, offset int) ([]*dto.
 	return recipes, total, nil
 }
 
-// Search effectue une recherche avancée de recettes
+// Search effectue une recherche avancée de recettes.
+// Le texte est recherché en plein texte (titre, description, ingrédients, tags, étapes), sans tenir
+// compte des accents et avec tolérance aux fautes de frappe.
 func (r *recipeRepository) Search(ctx context.Context, searchReq *dto.SearchQuery) ([]*dto.Recipe, int64, error) {
 	log.Printf("Search starting with query: %+v", searchReq)
 
-	query := r.db.WithContext(ctx).Model(&dto.Recipe{})
+	var total int64
+	var recipes []*dto.Recipe
+	err := r.searchSession(ctx, func(tx *gorm.DB) error {
+		// Compter le total avec exactement les mêmes filtres que la requête principale
+		countQuery := r.applySearchFilters(tx.Model(&dto.Recipe{}), searchReq)
+		if err := countQuery.Count(&total).Error; err != nil {
+			log.Printf("Error counting results: %v", err)
+			return ormerrors.NewDatabaseError("count search results", err)
+		}
+		log.Printf("Found %d total results", total)
+
+		// Récupérer les résultats
+		finalQuery := r.applySearchFilters(tx.Model(&dto.Recipe{}), searchReq)
+		finalQuery = applySearchOrder(finalQuery, searchReq)
+
+		if err := finalQuery.
+			Preload("Author").
+			Preload("Categories").
+			Preload("Tags").
+			Limit(searchReq.Limit).
+			Offset((searchReq.Page - 1) * searchReq.Limit).
+			Find(&recipes).Error; err != nil {
+			log.Printf("Error fetching full recipes: %v", err)
+			return ormerrors.NewDatabaseError("search recipes", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
 
-	// Recherche textuelle
-	if searchReq.Query != "" {
-		searchTerm := "%" + strings.ToLower(searchReq.Query) + "%"
-		log.Printf("Adding text search for term: %s", searchTerm)
-		query = query.Where("LOWER(title) LIKE ? OR LOWER(description) LIKE ?", searchTerm, searchTerm)
+	// Charger les recettes référencées dans les étapes
+	if err := r.loadReferencedRecipesInMultipleRecipes(ctx, recipes); err != nil {
+		return nil, 0, err
 	}
 
+	log.Printf("Successfully found %d recipes", len(recipes))
+	return recipes, total, nil
+}
+
+// applySearchFilters applique les critères de recherche à une requête sur les recettes.
+// Les filtres sur les relations utilisent EXISTS pour ne pas dupliquer les recettes.
+func (r *recipeRepository) applySearchFilters(query *gorm.DB, searchReq *dto.SearchQuery) *gorm.DB {
+	// Recherche textuelle
+	query = applySearchText(query, searchReq.Query)
+
 	// Filtrage par temps
 	if searchReq.MaxPrepTime > 0 {
-		query = query.Where("prep_time <= ?", searchReq.MaxPrepTime)
+		query = query.Where("recipes.prep_time <= ?", searchReq.MaxPrepTime)
 	}
 	if searchReq.MaxCookTime > 0 {
-		query = query.Where("cook_time <= ?", searchReq.MaxCookTime)
+		query = query.Where("recipes.cook_time <= ?", searchReq.MaxCookTime)
 	}
 	if searchReq.MaxTotalTime > 0 {
-		query = query.Where("total_time <= ?", searchReq.MaxTotalTime)
+		query = query.Where("recipes.total_time <= ?", searchReq.MaxTotalTime)
 	}
 
 	// Filtrage par difficulté
 	if searchReq.Difficulty != "" {
-		query = query.Where("difficulty = ?", searchReq.Difficulty)
+		query = query.Where("recipes.difficulty = ?", searchReq.Difficulty)
 	}
 
 	// Filtrage par note minimale
 	if searchReq.MinRating > 0 {
-		query = query.Where("average_rating >= ?", searchReq.MinRating)
+		query = query.Where("recipes.average_rating >= ?", searchReq.MinRating)
 	}
 
 	// Filtrage par auteur
 	if searchReq.AuthorID > 0 {
-		fmt.Printf("DEBUG: Repository - Applying author filter with ID: %d\n", searchReq.AuthorID)
-		query = query.Where("author_id = ?", searchReq.AuthorID)
-	} else {
-		fmt.Printf("DEBUG: Repository - No author filter applied (AuthorID: %d)\n", searchReq.AuthorID)
-	}
-
-	// Filtrage par ingrédients
-	if len(searchReq.Ingredients) > 0 {
-		query = query.Joins("JOIN recipe_ingredients ON recipes.id = recipe_ingredients.recipe_id").
-			Where("recipe_ingredients.ingredient_id IN ?", toUintSlice(searchReq.Ingredients))
-	}
-
-	// Filtrage par équipements
-	if len(searchReq.Equipments) > 0 {
-		query = query.Joins("JOIN recipe_equipments ON recipes.id = recipe_equipments.recipe_id").
-			Where("recipe_equipments.equipment_id IN ?", toUintSlice(searchReq.Equipments))
-	}
-
-	// Filtrage par catégories
-	if len(searchReq.Categories) > 0 {
-		query = query.Joins("JOIN recipe_category_associations ON recipes.id = recipe_category_associations.recipe_id").
-			Where("recipe_category_associations.category_id IN ?", toUintSlice(searchReq.Categories))
-	}
-
-	// Filtrage par tags
-	if len(searchReq.Tags) > 0 {
-		query = query.Joins("JOIN recipe_tags ON recipes.id = recipe_tags.recipe_id").
-			Where("recipe_tags.tag_id IN ?", toUintSlice(searchReq.Tags))
-	}
-
-	// Seules les recettes publiques sont cherchables (sauf si c'est l'auteur)
-	query = query.Where("is_public = ?", true)
-
-	log.Printf("About to count results...")
-	// Compter le total (distinct pour éviter les doublons)
-	var total int64
-	// Utiliser Select pour éviter le problème avec les colonnes JSON
-	countQuery := r.db.WithContext(ctx).Model(&dto.Recipe{}).Select("DISTINCT recipes.id")
-
-	// Appliquer les mêmes filtres que la requête principale
-	if searchReq.Query != "" {
-		searchTerm := "%" + strings.ToLower(searchReq.Query) + "%"
-		countQuery = countQuery.Where("LOWER(title) LIKE ? OR LOWER(description) LIKE ?", searchTerm, searchTerm)
-	}
-
-	if searchReq.MaxPrepTime > 0 {
-		countQuery = countQuery.Where("prep_time <= ?", searchReq.MaxPrepTime)
-	}
-	if searchReq.MaxCookTime > 0 {
-		countQuery = countQuery.Where("cook_time <= ?", searchReq.MaxCookTime)
-	}
-	if searchReq.MaxTotalTime > 0 {
-		countQuery = countQuery.Where("total_time <= ?", searchReq.MaxTotalTime)
-	}
-
-	if searchReq.Difficulty != "" {
-		countQuery = countQuery.Where("difficulty = ?", searchReq.Difficulty)
-	}
-
-	if searchReq.MinRating > 0 {
-		countQuery = countQuery.Where("average_rating >= ?", searchReq.MinRating)
-	}
-
-	if searchReq.AuthorID > 0 {
-		countQuery = countQuery.Where("author_id = ?", searchReq.AuthorID)
+		query = query.Where("recipes.author_id = ?", searchReq.AuthorID)
 	}
 
-	if len(searchReq.Ingredients) > 0 {
-		countQuery = countQuery.Joins("JOIN recipe_ingredients ON recipes.id = recipe_ingredients.recipe_id").
-			Where("recipe_ingredients.ingredient_id IN ?", toUintSlice(searchReq.Ingredients))
-	}
+	// Filtrage par ingrédients (un ingrédient satisfait ses ingrédients plus généraux), équipements, catégories et tags
+	query = applyIngredientFilter(query, searchReq.Ingredients, searchReq.IngredientsMatch)
+	query = applyRelationFilter(query, "recipe_equipments", "equipment_id", searchReq.Equipments, searchReq.EquipmentsMatch)
+	query = applyRelationFilter(query, "recipe_category_associations", "category_id", searchReq.Categories, searchReq.CategoriesMatch)
+	query = applyRelationFilter(query, "recipe_tags", "tag_id", searchReq.Tags, searchReq.TagsMatch)
 
-	if len(searchReq.Equipments) > 0 {
-		countQuery = countQuery.Joins("JOIN recipe_equipments ON recipes.id = recipe_equipments.recipe_id").
-			Where("recipe_equipments.equipment_id IN ?", toUintSlice(searchReq.Equipments))
-	}
+	// Exclusions (un ingrédient exclu écarte aussi ses variantes)
+	query = applyIngredientExclusion(query, searchReq.ExcludeIngredients)
+	query = applyRelationExclusion(query, "recipe_equipments", "equipment_id", searchReq.ExcludeEquipments)
+	query = applyRelationExclusion(query, "recipe_tags", "tag_id", searchReq.ExcludeTags)
 
-	if len(searchReq.Categories) > 0 {
-		countQuery = countQuery.Joins("JOIN recipe_category_associations ON recipes.id = recipe_category_associations.recipe_id").
-			Where("recipe_category_associations.category_id IN ?", toUintSlice(searchReq.Categories))
+	// Filtrage par régimes (tous requis) et allergènes à éviter
+	if len(searchReq.Diets) > 0 {
+		query = query.Where("recipes.diets @> ?::jsonb", dto.StringList(searchReq.Diets))
 	}
-
-	if len(searchReq.Tags) > 0 {
-		countQuery = countQuery.Joins("JOIN recipe_tags ON recipes.id = recipe_tags.recipe_id").
-			Where("recipe_tags.tag_id IN ?", toUintSlice(searchReq.Tags))
+	if len(searchReq.ExcludeAllergens) > 0 {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM jsonb_array_elements_text(recipes.allergens) AS allergen WHERE allergen IN ?)",
+			searchReq.ExcludeAllergens)
 	}
 
-	countQuery = countQuery.Where("is_public = ?", true)
-
-	if err := countQuery.Count(&total).Error; err != nil {
-		log.Printf("Error counting results: %v", err)
-		return nil, 0, ormerrors.NewDatabaseError("count search results", err)
-	}
-	log.Printf("Found %d total results", total)
-
-	// Tri
-	orderClause := "created_at DESC" // Tri par défaut
-	if searchReq.SortBy != "" {
-		orderClause = searchReq.SortBy
-		if searchReq.SortOrder == "asc" {
-			orderClause += " ASC"
-		} else {
-			orderClause += " DESC"
-		}
-	}
-
-	// Récupérer les résultats
-	var recipes []*dto.Recipe
-	log.Printf("About to fetch results with order: %s, limit: %d, offset: %d", orderClause, searchReq.Limit, (searchReq.Page-1)*searchReq.Limit)
-
-	// Créer une requête finale sans DISTINCT pour éviter les problèmes PostgreSQL
-	finalQuery := r.db.WithContext(ctx).Model(&dto.Recipe{})
-
-	// Appliquer tous les filtres
-	if searchReq.Query != "" {
-		searchTerm := "%" + strings.ToLower(searchReq.Query) + "%"
-		finalQuery = finalQuery.Where("LOWER(title) LIKE ? OR LOWER(description) LIKE ?", searchTerm, searchTerm)
-	}
-
-	if searchReq.MaxPrepTime > 0 {
-		finalQuery = finalQuery.Where("prep_time <= ?", searchReq.MaxPrepTime)
-	}
-	if searchReq.MaxCookTime > 0 {
-		finalQuery = finalQuery.Where("cook_time <= ?", searchReq.MaxCookTime)
-	}
-	if searchReq.MaxTotalTime > 0 {
-		finalQuery = finalQuery.Where("(prep_time + cook_time) <= ?", searchReq.MaxTotalTime)
-	}
-	if searchReq.Difficulty != "" {
-		finalQuery = finalQuery.Where("difficulty = ?", searchReq.Difficulty)
-	}
-	if searchReq.MinRating > 0 {
-		finalQuery = finalQuery.Where("average_rating >= ?", searchReq.MinRating)
-	}
-	if searchReq.AuthorID > 0 {
-		finalQuery = finalQuery.Where("author_id = ?", searchReq.AuthorID)
-	}
-
-	// Pour les relations many-to-many, utilisons GROUP BY au lieu de DISTINCT
-	hasJoins := false
-
-	if len(searchReq.Ingredients) > 0 {
-		finalQuery = finalQuery.Joins("JOIN recipe_ingredients ON recipes.id = recipe_ingredients.recipe_id").
-			Where("recipe_ingredients.ingredient_id IN ?", toUintSlice(searchReq.Ingredients))
-		hasJoins = true
-	}
-
-	if len(searchReq.Equipments) > 0 {
-		finalQuery = finalQuery.Joins("JOIN recipe_equipments ON recipes.id = recipe_equipments.recipe_id").
-			Where("recipe_equipments.equipment_id IN ?", toUintSlice(searchReq.Equipments))
-		hasJoins = true
-	}
-
-	if len(searchReq.Categories) > 0 {
-		finalQuery = finalQuery.Joins("JOIN recipe_category_associations ON recipes.id = recipe_category_associations.recipe_id").
-			Where("recipe_category_associations.category_id IN ?", toUintSlice(searchReq.Categories))
-		hasJoins = true
-	}
-
-	if len(searchReq.Tags) > 0 {
-		finalQuery = finalQuery.Joins("JOIN recipe_tags ON recipes.id = recipe_tags.recipe_id").
-			Where("recipe_tags.tag_id IN ?", toUintSlice(searchReq.Tags))
-		hasJoins = true
-	}
-
-	finalQuery = finalQuery.Where("is_public = ?", true)
-
-	// Si on a des joins, utiliser GROUP BY pour éviter les doublons
-	if hasJoins {
-		finalQuery = finalQuery.Group("recipes.id")
-	}
-
-	if err := finalQuery.
-		Preload("Author").
-		Preload("Categories").
-		Preload("Tags").
-		Order(orderClause).
-		Limit(searchReq.Limit).
-		Offset((searchReq.Page - 1) * searchReq.Limit).
-		Find(&recipes).Error; err != nil {
-		log.Printf("Error fetching full recipes: %v", err)
-		return nil, 0, ormerrors.NewDatabaseError("search recipes", err)
-	}
-
-	// Charger les recettes référencées dans les étapes
-	if err := r.loadReferencedRecipesInMultipleRecipes(ctx, recipes); err != nil {
-		return nil, 0, err
-	}
-
-	log.Printf("Successfully found %d recipes", len(recipes))
-	return recipes, total, nil
+	// Seules les recettes publiques sont cherchables
+	return query.Where("recipes.is_public = ?", true)
 }
 
 // Copy copie une recette existante pour un nouvel auteur
@@ -572,68 +462,92 @@ func (r *recipeRepository) Copy(ctx context.Context, originalRecipeID, newAuthor
 		return nil, err
 	}
 
-	// Créer une nouvelle recette basée sur l'originale
-	newRecipe := &dto.Recipe{
-		Title:            originalRecipe.Title + " (Copie)",
-		Description:      originalRecipe.Description,
-		Instructions:     originalRecipe.Instructions,
-		PrepTime:         originalRecipe.PrepTime,
-		CookTime:         originalRecipe.CookTime,
-		TotalTime:        originalRecipe.TotalTime,
-		Servings:         originalRecipe.Servings,
-		Difficulty:       originalRecipe.Difficulty,
-		ImageURL:         originalRecipe.ImageURL,
-		IsPublic:         false, // Les copies sont privées par défaut
-		OriginalRecipeID: &originalRecipeID,
-		AuthorID:         newAuthorID,
-	}
-
-	if err := r.db.WithContext(ctx).Create(newRecipe).Error; err != nil {
-		return nil, ormerrors.NewDatabaseError("copy recipe", err)
-	}
-
-	// Forcer explicitement IsOriginal à false après la création
-	if err := r.db.WithContext(ctx).Model(newRecipe).Update("is_original", false).Error; err != nil {
-		return nil, ormerrors.NewDatabaseError("update is_original", err)
-	}
-
-	// Copier les ingrédients
-	for _, ingredient := range originalRecipe.Ingredients {
-		newIngredient := &dto.RecipeIngredient{
-			RecipeID:     newRecipe.ID,
-			IngredientID: ingredient.IngredientID,
-			Quantity:     ingredient.Quantity,
-			Unit:         ingredient.Unit,
-			Notes:        ingredient.Notes,
-			IsOptional:   ingredient.IsOptional,
-			Group:        ingredient.Group,
-			Position:     ingredient.Position,
+	// La copie, son contenu et sa première révision sont créés ensemble
+	var newRecipe *dto.Recipe
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Mémoriser la révision de l'originale sur laquelle la copie est basée
+		revisions := NewRecipeRevisionRepository(tx)
+		if err := revisions.EnsureInitial(ctx, originalRecipeID, originalRecipe.AuthorID); err != nil {
+			return err
 		}
-		if err := r.db.WithContext(ctx).Create(newIngredient).Error; err != nil {
-			return nil, ormerrors.NewDatabaseError("copy recipe ingredients", err)
+		forkedRevision, err := revisions.latestNumber(ctx, originalRecipeID)
+		if err != nil {
+			return err
 		}
-	}
 
-	// Copier les équipements
-	for _, equipment := range originalRecipe.Equipments {
-		newEquipment := &dto.RecipeEquipment{
-			RecipeID:    newRecipe.ID,
-			EquipmentID: equipment.EquipmentID,
-			IsOptional:  equipment.IsOptional,
-			Notes:       equipment.Notes,
+		// Créer une nouvelle recette basée sur l'originale
+		newRecipe = &dto.Recipe{
+			Title:            originalRecipe.Title + " (Copie)",
+			Description:      originalRecipe.Description,
+			Instructions:     originalRecipe.Instructions,
+			PrepTime:         originalRecipe.PrepTime,
+			CookTime:         originalRecipe.CookTime,
+			TotalTime:        originalRecipe.TotalTime,
+			Servings:         originalRecipe.Servings,
+			Difficulty:       originalRecipe.Difficulty,
+			ImageURL:         originalRecipe.ImageURL,
+			IsPublic:         false, // Les copies sont privées par défaut
+			OriginalRecipeID: &originalRecipeID,
+			ForkedRevision:   &forkedRevision,
+			AuthorID:         newAuthorID,
+			Allergens:        originalRecipe.Allergens,
+			Diets:            originalRecipe.Diets,
 		}
-		if err := r.db.WithContext(ctx).Create(newEquipment).Error; err != nil {
-			return nil, ormerrors.NewDatabaseError("copy recipe equipment", err)
+
+		if err := tx.Create(newRecipe).Error; err != nil {
+			return ormerrors.NewDatabaseError("copy recipe", err)
 		}
-	}
 
-	// Associer les mêmes catégories et tags
-	if err := r.db.WithContext(ctx).Model(newRecipe).Association("Categories").Append(originalRecipe.Categories); err != nil {
-		return nil, ormerrors.NewDatabaseError("copy recipe categories", err)
-	}
+		// Forcer explicitement IsOriginal à false après la création
+		if err := tx.Model(newRecipe).Update("is_original", false).Error; err != nil {
+			return ormerrors.NewDatabaseError("update is_original", err)
+		}
+
+		// Copier les ingrédients
+		for _, ingredient := range originalRecipe.Ingredients {
+			newIngredient := &dto.RecipeIngredient{
+				RecipeID:     newRecipe.ID,
+				IngredientID: ingredient.IngredientID,
+				Quantity:     ingredient.Quantity,
+				Unit:         ingredient.Unit,
+				Notes:        ingredient.Notes,
+				IsOptional:   ingredient.IsOptional,
+				Group:        ingredient.Group,
+				Position:     ingredient.Position,
+			}
+			if err := tx.Create(newIngredient).Error; err != nil {
+				return ormerrors.NewDatabaseError("copy recipe ingredients", err)
+			}
+		}
 
-	if err := r.db.WithContext(ctx).Model(newRecipe).Association("Tags").Append(originalRecipe.Tags); err != nil {
-		return nil, ormerrors.NewDatabaseError("copy recipe tags", err)
+		// Copier les équipements
+		for _, equipment := range originalRecipe.Equipments {
+			newEquipment := &dto.RecipeEquipment{
+				RecipeID:    newRecipe.ID,
+				EquipmentID: equipment.EquipmentID,
+				IsOptional:  equipment.IsOptional,
+				Notes:       equipment.Notes,
+			}
+			if err := tx.Create(newEquipment).Error; err != nil {
+				return ormerrors.NewDatabaseError("copy recipe equipment", err)
+			}
+		}
+
+		// Associer les mêmes catégories et tags
+		if err := tx.Model(newRecipe).Association("Categories").Append(originalRecipe.Categories); err != nil {
+			return ormerrors.NewDatabaseError("copy recipe categories", err)
+		}
+
+		if err := tx.Model(newRecipe).Association("Tags").Append(originalRecipe.Tags); err != nil {
+			return ormerrors.NewDatabaseError("copy recipe tags", err)
+		}
+
+		// Historique de la copie : son état initial
+		_, err = revisions.Record(ctx, newRecipe.ID, newAuthorID, fmt.Sprintf("Copie de la révision %d de la recette %d", forkedRevision, originalRecipeID))
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return r.GetByID(ctx, newRecipe.ID)
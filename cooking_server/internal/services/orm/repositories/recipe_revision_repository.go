@@ -0,0 +1,459 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type recipeRevisionRepository struct {
+	db *gorm.DB
+}
+
+// NewRecipeRevisionRepository crée une nouvelle instance du repository des révisions de recettes
+func NewRecipeRevisionRepository(db *gorm.DB) *recipeRevisionRepository {
+	return &recipeRevisionRepository{db: db}
+}
+
+// EnsureInitial enregistre l'état actuel d'une recette comme première révision si elle n'en a aucune.
+// Appelé avant une modification pour ne pas perdre l'état des recettes antérieures à l'historique.
+func (r *recipeRevisionRepository) EnsureInitial(ctx context.Context, recipeID, authorID uint) error {
+	if err := r.lockRecipe(ctx, recipeID); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&dto.RecipeRevision{}).
+		Where("recipe_id = ?", recipeID).
+		Count(&count).Error; err != nil {
+		return ormerrors.NewDatabaseError("count recipe revisions", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err := r.Record(ctx, recipeID, authorID, "")
+	return err
+}
+
+// Record enregistre l'état actuel complet d'une recette comme nouvelle révision. Appelé dans la transaction
+// de la modification, pour que la révision et la modification soient enregistrées ensemble.
+func (r *recipeRevisionRepository) Record(ctx context.Context, recipeID, authorID uint, note string) (*dto.RecipeRevision, error) {
+	if err := r.lockRecipe(ctx, recipeID); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := r.snapshot(ctx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	lastNumber, err := r.latestNumber(ctx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+
+	revision := &dto.RecipeRevision{
+		RecipeID: recipeID,
+		Number:   lastNumber + 1,
+		AuthorID: authorID,
+		Note:     note,
+		Snapshot: snapshot,
+	}
+	if err := r.db.WithContext(ctx).Create(revision).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("create recipe revision", err)
+	}
+	return revision, nil
+}
+
+// lockRecipe verrouille la recette jusqu'à la fin de la transaction : deux modifications simultanées
+// numérotent leurs révisions l'une après l'autre (l'index unique (recipe_id, number) garantit le reste)
+func (r *recipeRevisionRepository) lockRecipe(ctx context.Context, recipeID uint) error {
+	var recipe dto.Recipe
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&recipe, recipeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ormerrors.NewNotFoundError("recipe", recipeID)
+		}
+		return ormerrors.NewDatabaseError("lock recipe for revision", err)
+	}
+	return nil
+}
+
+// latestNumber retourne le numéro de la dernière révision d'une recette (0 si aucune)
+func (r *recipeRevisionRepository) latestNumber(ctx context.Context, recipeID uint) (int, error) {
+	var number int
+	if err := r.db.WithContext(ctx).
+		Model(&dto.RecipeRevision{}).
+		Where("recipe_id = ?", recipeID).
+		Select("COALESCE(MAX(number), 0)").
+		Scan(&number).Error; err != nil {
+		return 0, ormerrors.NewDatabaseError("get last recipe revision number", err)
+	}
+	return number, nil
+}
+
+// snapshot construit l'état complet d'une recette à partir de la base
+func (r *recipeRevisionRepository) snapshot(ctx context.Context, recipeID uint) (*dto.RecipeSnapshot, error) {
+	var recipe dto.Recipe
+	if err := r.db.WithContext(ctx).
+		Preload("Ingredients", func(db *gorm.DB) *gorm.DB {
+			return db.Order("recipe_ingredients.position ASC").Order("recipe_ingredients.id ASC")
+		}).
+		Preload("Ingredients.Ingredient").
+		Preload("Equipments.Equipment").
+		Preload("Tags").
+		Preload("Categories").
+		First(&recipe, recipeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("recipe", recipeID)
+		}
+		return nil, ormerrors.NewDatabaseError("load recipe for revision", err)
+	}
+
+	snapshot := &dto.RecipeSnapshot{
+		Title:        recipe.Title,
+		Description:  recipe.Description,
+		Instructions: recipe.Instructions,
+		PrepTime:     recipe.PrepTime,
+		CookTime:     recipe.CookTime,
+		Servings:     recipe.Servings,
+		Difficulty:   recipe.Difficulty,
+		ImageURL:     recipe.ImageURL,
+		IsPublic:     recipe.IsPublic,
+		Ingredients:  make([]dto.RecipeSnapshotIngredient, 0, len(recipe.Ingredients)),
+		Equipments:   make([]dto.RecipeSnapshotEquipment, 0, len(recipe.Equipments)),
+		Tags:         make([]dto.RecipeSnapshotRef, 0, len(recipe.Tags)),
+		Categories:   make([]dto.RecipeSnapshotRef, 0, len(recipe.Categories)),
+	}
+
+	for _, ingredient := range recipe.Ingredients {
+		snapshot.Ingredients = append(snapshot.Ingredients, dto.RecipeSnapshotIngredient{
+			IngredientID:   ingredient.IngredientID,
+			IngredientName: ingredient.Ingredient.Name,
+			Quantity:       ingredient.Quantity,
+			Unit:           ingredient.Unit,
+			Notes:          ingredient.Notes,
+			IsOptional:     ingredient.IsOptional,
+			Group:          ingredient.Group,
+			Position:       ingredient.Position,
+		})
+	}
+	for _, equipment := range recipe.Equipments {
+		snapshot.Equipments = append(snapshot.Equipments, dto.RecipeSnapshotEquipment{
+			EquipmentID:   equipment.EquipmentID,
+			EquipmentName: equipment.Equipment.Name,
+			IsOptional:    equipment.IsOptional,
+			Notes:         equipment.Notes,
+		})
+	}
+	for _, tag := range recipe.Tags {
+		snapshot.Tags = append(snapshot.Tags, dto.RecipeSnapshotRef{ID: tag.ID, Name: tag.Name})
+	}
+	for _, category := range recipe.Categories {
+		snapshot.Categories = append(snapshot.Categories, dto.RecipeSnapshotRef{ID: category.ID, Name: category.Name})
+	}
+
+	return snapshot, nil
+}
+
+// GetByRecipe liste les révisions d'une recette (sans leur contenu), de la plus récente à la plus ancienne
+func (r *recipeRevisionRepository) GetByRecipe(ctx context.Context, recipeID uint) ([]*dto.RecipeRevision, error) {
+	var revisions []*dto.RecipeRevision
+	if err := r.db.WithContext(ctx).
+		Omit("snapshot").
+		Where("recipe_id = ?", recipeID).
+		Order("number DESC").
+		Find(&revisions).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get recipe revisions", err)
+	}
+	return revisions, nil
+}
+
+// GetByNumber récupère une révision complète d'une recette par son numéro
+func (r *recipeRevisionRepository) GetByNumber(ctx context.Context, recipeID uint, number int) (*dto.RecipeRevision, error) {
+	var revision dto.RecipeRevision
+	if err := r.db.WithContext(ctx).
+		Where("recipe_id = ? AND number = ?", recipeID, number).
+		First(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("recipe revision", number)
+		}
+		return nil, ormerrors.NewDatabaseError("get recipe revision", err)
+	}
+	return &revision, nil
+}
+
+// Diff calcule les différences structurées entre deux révisions d'une recette
+func (r *recipeRevisionRepository) Diff(ctx context.Context, recipeID uint, from, to int) (*dto.RecipeRevisionDiff, error) {
+	fromRevision, err := r.GetByNumber(ctx, recipeID, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := r.GetByNumber(ctx, recipeID, to)
+	if err != nil {
+		return nil, err
+	}
+	if fromRevision.Snapshot == nil || toRevision.Snapshot == nil {
+		return nil, ormerrors.NewValidationError("recipe revision has no snapshot")
+	}
+
+	diff := diffRecipeSnapshots(fromRevision.Snapshot, toRevision.Snapshot)
+	diff.RecipeID = recipeID
+	diff.From = from
+	diff.To = to
+	return diff, nil
+}
+
+// Restore réapplique le contenu d'une révision à la recette et l'enregistre comme nouvelle révision
+func (r *recipeRevisionRepository) Restore(ctx context.Context, recipeID uint, number int, authorID uint) (*dto.RecipeRevision, error) {
+	var restored *dto.RecipeRevision
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := &recipeRevisionRepository{db: tx}
+
+		revision, err := txRepo.GetByNumber(ctx, recipeID, number)
+		if err != nil {
+			return err
+		}
+		if revision.Snapshot == nil {
+			return ormerrors.NewValidationError("recipe revision has no snapshot")
+		}
+		if err := applyRecipeSnapshot(tx, recipeID, revision.Snapshot); err != nil {
+			return err
+		}
+
+		restored, err = txRepo.Record(ctx, recipeID, authorID, fmt.Sprintf("Restauration de la révision %d", number))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// applyRecipeSnapshot remplace le contenu d'une recette par celui d'un snapshot
+func applyRecipeSnapshot(tx *gorm.DB, recipeID uint, snapshot *dto.RecipeSnapshot) error {
+	recipe := &dto.Recipe{ID: recipeID}
+
+	if err := tx.Model(recipe).Updates(map[string]interface{}{
+		"title":        snapshot.Title,
+		"description":  snapshot.Description,
+		"instructions": snapshot.Instructions,
+		"prep_time":    snapshot.PrepTime,
+		"cook_time":    snapshot.CookTime,
+		"total_time":   snapshot.PrepTime + snapshot.CookTime,
+		"servings":     snapshot.Servings,
+		"difficulty":   snapshot.Difficulty,
+		"image_url":    snapshot.ImageURL,
+		"is_public":    snapshot.IsPublic,
+	}).Error; err != nil {
+		return ormerrors.NewDatabaseError("restore recipe fields", err)
+	}
+
+	if err := tx.Where("recipe_id = ?", recipeID).Delete(&dto.RecipeIngredient{}).Error; err != nil {
+		return ormerrors.NewDatabaseError("clear recipe ingredients", err)
+	}
+	for _, ingredient := range snapshot.Ingredients {
+		if err := tx.Create(&dto.RecipeIngredient{
+			RecipeID:     recipeID,
+			IngredientID: ingredient.IngredientID,
+			Quantity:     ingredient.Quantity,
+			Unit:         ingredient.Unit,
+			Notes:        ingredient.Notes,
+			IsOptional:   ingredient.IsOptional,
+			Group:        ingredient.Group,
+			Position:     ingredient.Position,
+		}).Error; err != nil {
+			return ormerrors.NewDatabaseError("restore recipe ingredients", err)
+		}
+	}
+
+	if err := tx.Where("recipe_id = ?", recipeID).Delete(&dto.RecipeEquipment{}).Error; err != nil {
+		return ormerrors.NewDatabaseError("clear recipe equipments", err)
+	}
+	for _, equipment := range snapshot.Equipments {
+		if err := tx.Create(&dto.RecipeEquipment{
+			RecipeID:    recipeID,
+			EquipmentID: equipment.EquipmentID,
+			IsOptional:  equipment.IsOptional,
+			Notes:       equipment.Notes,
+		}).Error; err != nil {
+			return ormerrors.NewDatabaseError("restore recipe equipments", err)
+		}
+	}
+
+	tags := make([]dto.Tag, 0, len(snapshot.Tags))
+	for _, tag := range snapshot.Tags {
+		tags = append(tags, dto.Tag{ID: tag.ID})
+	}
+	if err := tx.Model(recipe).Association("Tags").Replace(tags); err != nil {
+		return ormerrors.NewDatabaseError("restore recipe tags", err)
+	}
+
+	categories := make([]dto.Category, 0, len(snapshot.Categories))
+	for _, category := range snapshot.Categories {
+		categories = append(categories, dto.Category{ID: category.ID})
+	}
+	if err := tx.Model(recipe).Association("Categories").Replace(categories); err != nil {
+		return ormerrors.NewDatabaseError("restore recipe categories", err)
+	}
+
+	return refreshRecipeLabels(tx, recipeID)
+}
+
+// diffRecipeSnapshots compare deux états d'une recette
+func diffRecipeSnapshots(before, after *dto.RecipeSnapshot) *dto.RecipeRevisionDiff {
+	diff := &dto.RecipeRevisionDiff{
+		Fields:      []dto.RecipeFieldChange{},
+		Ingredients: dto.RecipeIngredientsDiff{Added: []dto.RecipeSnapshotIngredient{}, Removed: []dto.RecipeSnapshotIngredient{}, Changed: []dto.RecipeIngredientChange{}},
+		Steps:       dto.RecipeStepsDiff{Added: []dto.RecipeStep{}, Removed: []dto.RecipeStep{}, Changed: []dto.RecipeStepChange{}},
+		Equipments:  dto.RecipeEquipmentsDiff{Added: []dto.RecipeSnapshotEquipment{}, Removed: []dto.RecipeSnapshotEquipment{}, Changed: []dto.RecipeEquipmentChange{}},
+	}
+
+	addField := func(field string, a, b interface{}) {
+		if a != b {
+			diff.Fields = append(diff.Fields, dto.RecipeFieldChange{Field: field, Before: a, After: b})
+		}
+	}
+	addField("title", before.Title, after.Title)
+	addField("description", before.Description, after.Description)
+	addField("prep_time", before.PrepTime, after.PrepTime)
+	addField("cook_time", before.CookTime, after.CookTime)
+	addField("servings", before.Servings, after.Servings)
+	addField("difficulty", before.Difficulty, after.Difficulty)
+	addField("image_url", before.ImageURL, after.ImageURL)
+	addField("is_public", before.IsPublic, after.IsPublic)
+
+	// Ingrédients : appariés par ingrédient et groupe
+	ingredientKey := func(i dto.RecipeSnapshotIngredient) string {
+		return fmt.Sprintf("%d|%s", i.IngredientID, i.Group)
+	}
+	remainingIngredients := make(map[string][]dto.RecipeSnapshotIngredient)
+	for _, ingredient := range before.Ingredients {
+		key := ingredientKey(ingredient)
+		remainingIngredients[key] = append(remainingIngredients[key], ingredient)
+	}
+	for _, ingredient := range after.Ingredients {
+		key := ingredientKey(ingredient)
+		candidates := remainingIngredients[key]
+		if len(candidates) == 0 {
+			diff.Ingredients.Added = append(diff.Ingredients.Added, ingredient)
+			continue
+		}
+		previous := candidates[0]
+		remainingIngredients[key] = candidates[1:]
+		// La position seule n'est pas une modification (décalage après un ajout ou une suppression)
+		moved := previous
+		moved.Position = ingredient.Position
+		if moved != ingredient {
+			diff.Ingredients.Changed = append(diff.Ingredients.Changed, dto.RecipeIngredientChange{Before: previous, After: ingredient})
+		}
+	}
+	for _, ingredient := range before.Ingredients {
+		key := ingredientKey(ingredient)
+		if len(remainingIngredients[key]) > 0 {
+			diff.Ingredients.Removed = append(diff.Ingredients.Removed, remainingIngredients[key]...)
+			delete(remainingIngredients, key)
+		}
+	}
+
+	// Étapes : appariées par numéro d'étape
+	beforeSteps := make(map[int]dto.RecipeStep)
+	for _, step := range before.Instructions {
+		beforeSteps[step.StepNumber] = step
+	}
+	afterSteps := make(map[int]bool)
+	for _, step := range after.Instructions {
+		afterSteps[step.StepNumber] = true
+		previous, exists := beforeSteps[step.StepNumber]
+		if !exists {
+			diff.Steps.Added = append(diff.Steps.Added, step)
+			continue
+		}
+		if !sameRecipeStep(previous, step) {
+			diff.Steps.Changed = append(diff.Steps.Changed, dto.RecipeStepChange{StepNumber: step.StepNumber, Before: previous, After: step})
+		}
+	}
+	for _, step := range before.Instructions {
+		if !afterSteps[step.StepNumber] {
+			diff.Steps.Removed = append(diff.Steps.Removed, step)
+		}
+	}
+
+	// Équipements : appariés par équipement
+	beforeEquipments := make(map[uint]dto.RecipeSnapshotEquipment)
+	for _, equipment := range before.Equipments {
+		beforeEquipments[equipment.EquipmentID] = equipment
+	}
+	afterEquipments := make(map[uint]bool)
+	for _, equipment := range after.Equipments {
+		afterEquipments[equipment.EquipmentID] = true
+		previous, exists := beforeEquipments[equipment.EquipmentID]
+		if !exists {
+			diff.Equipments.Added = append(diff.Equipments.Added, equipment)
+			continue
+		}
+		if previous != equipment {
+			diff.Equipments.Changed = append(diff.Equipments.Changed, dto.RecipeEquipmentChange{Before: previous, After: equipment})
+		}
+	}
+	for _, equipment := range before.Equipments {
+		if !afterEquipments[equipment.EquipmentID] {
+			diff.Equipments.Removed = append(diff.Equipments.Removed, equipment)
+		}
+	}
+
+	diff.Tags = diffRecipeRefs(before.Tags, after.Tags)
+	diff.Categories = diffRecipeRefs(before.Categories, after.Categories)
+
+	return diff
+}
+
+// sameRecipeStep compare le contenu persisté de deux étapes
+func sameRecipeStep(a, b dto.RecipeStep) bool {
+	sameReference := (a.ReferencedRecipeID == nil && b.ReferencedRecipeID == nil) ||
+		(a.ReferencedRecipeID != nil && b.ReferencedRecipeID != nil && *a.ReferencedRecipeID == *b.ReferencedRecipeID)
+	return sameReference &&
+		a.Title == b.Title &&
+		a.Description == b.Description &&
+		a.Duration == b.Duration &&
+		a.Temperature == b.Temperature &&
+		a.Tips == b.Tips
+}
+
+// diffRecipeRefs calcule les tags ou catégories ajoutés et supprimés
+func diffRecipeRefs(before, after []dto.RecipeSnapshotRef) dto.RecipeRefsDiff {
+	diff := dto.RecipeRefsDiff{Added: []dto.RecipeSnapshotRef{}, Removed: []dto.RecipeSnapshotRef{}}
+
+	beforeIDs := make(map[uint]bool)
+	for _, ref := range before {
+		beforeIDs[ref.ID] = true
+	}
+	afterIDs := make(map[uint]bool)
+	for _, ref := range after {
+		afterIDs[ref.ID] = true
+		if !beforeIDs[ref.ID] {
+			diff.Added = append(diff.Added, ref)
+		}
+	}
+	for _, ref := range before {
+		if !afterIDs[ref.ID] {
+			diff.Removed = append(diff.Removed, ref)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Name < diff.Added[j].Name })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Name < diff.Removed[j].Name })
+	return diff
+}
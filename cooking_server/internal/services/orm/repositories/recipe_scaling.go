@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
+)
+
+// GetScaled retourne une recette complète dont les quantités sont mises à l'échelle et arrondies
+// à des valeurs mesurables. Les sous-recettes référencées reçoivent le même coefficient.
+func (r *recipeRepository) GetScaled(ctx context.Context, id uint, req *dto.RecipeScaleRequest) (*dto.ScaledRecipe, error) {
+	recipe, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	factor, err := scaleFactor(recipe, req)
+	if err != nil {
+		return nil, err
+	}
+
+	originalServings := recipe.Servings
+	if err := r.scaleRecipe(ctx, recipe, factor, req.UnitSystem, map[uint]bool{}); err != nil {
+		return nil, err
+	}
+
+	return &dto.ScaledRecipe{
+		Recipe:           recipe,
+		OriginalServings: originalServings,
+		Servings:         recipe.Servings,
+		Factor:           units.Round(factor),
+		UnitSystem:       req.UnitSystem,
+	}, nil
+}
+
+// scaleFactor calcule le coefficient à appliquer selon le critère demandé
+func scaleFactor(recipe *dto.Recipe, req *dto.RecipeScaleRequest) (float64, error) {
+	criteria := 0
+	for _, set := range []bool{req.Servings > 0, req.Factor > 0, req.YieldGrams > 0} {
+		if set {
+			criteria++
+		}
+	}
+	if criteria != 1 {
+		return 0, ormerrors.NewValidationError("exactly one of servings, factor or yield_grams is required")
+	}
+
+	switch {
+	case req.Factor > 0:
+		return req.Factor, nil
+	case req.Servings > 0:
+		if recipe.Servings <= 0 {
+			return 0, ormerrors.NewValidationError("recipe has no servings to scale from")
+		}
+		return float64(req.Servings) / float64(recipe.Servings), nil
+	default:
+		var grams float64
+		for _, ri := range recipe.Ingredients {
+			if converted, ok := units.Convert(ri.Quantity, units.Parse(ri.Unit), units.Gram, ingredientHints(ri.Ingredient)); ok {
+				grams += converted
+			}
+		}
+		if grams <= 0 {
+			return 0, ormerrors.NewValidationError("recipe ingredients cannot be weighed to scale by yield")
+		}
+		return req.YieldGrams / grams, nil
+	}
+}
+
+// scaleRecipe applique le coefficient aux portions et aux ingrédients d'une recette puis de ses sous-recettes.
+// path contient les recettes en cours de traitement pour ignorer les références circulaires.
+func (r *recipeRepository) scaleRecipe(ctx context.Context, recipe *dto.Recipe, factor float64, system string, path map[uint]bool) error {
+	path[recipe.ID] = true
+	defer delete(path, recipe.ID)
+
+	if recipe.Servings > 0 {
+		recipe.Servings = int(math.Max(1, math.Round(float64(recipe.Servings)*factor)))
+	}
+
+	for i := range recipe.Ingredients {
+		ri := &recipe.Ingredients[i]
+		quantity, unit := ri.Quantity*factor, units.Parse(ri.Unit)
+		if system != "" {
+			quantity, unit = units.ToSystem(quantity, unit, system)
+			if unit.Dimension != units.Unknown {
+				ri.Unit = unit.Code
+			}
+		}
+
+		ri.Quantity = units.RoundForKitchen(quantity, unit, ri.Ingredient.Name)
+		ri.DisplayQuantity = units.FormatQuantity(ri.Quantity, unit)
+	}
+
+	// Les sous-recettes sont chargées complètement pour pouvoir en afficher les quantités
+	for i := range recipe.Instructions {
+		step := &recipe.Instructions[i]
+		if step.ReferencedRecipeID == nil || path[*step.ReferencedRecipeID] {
+			continue
+		}
+
+		sub, err := r.GetByID(ctx, *step.ReferencedRecipeID)
+		if errors.Is(err, ormerrors.ErrRecordNotFound) {
+			// Sous-recette supprimée depuis : l'étape reste affichée sans ses quantités
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.scaleRecipe(ctx, sub, factor, system, path); err != nil {
+			return err
+		}
+		step.ReferencedRecipeData = sub
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Seuil de similarité trigramme (0-1) au-delà duquel un mot mal orthographié est accepté
+const searchSimilarityThreshold = 0.4
+
+// recipeSearchQuery est la requête plein texte combinant les configurations française et anglaise.
+// Elle porte sur recipes.search_document (titre > description, ingrédients et tags > étapes) et
+// recipes.search_text (titre et noms sans accents), tenus à jour par des triggers et indexés
+// par la migration de recherche plein texte.
+const recipeSearchQuery = "(websearch_to_tsquery('french_unaccent', @q) || websearch_to_tsquery('english_unaccent', @q))"
+
+// applySearchText filtre les recettes correspondant au texte recherché (plein texte ou approximatif).
+// L'opérateur <% utilise le seuil pg_trgm.word_similarity_threshold fixé par withTrigramThreshold.
+func applySearchText(query *gorm.DB, text string) *gorm.DB {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return query
+	}
+
+	return query.
+		Where("(recipes.search_document @@ "+recipeSearchQuery+
+			" OR immutable_unaccent(lower(@q)) <% recipes.search_text)",
+			map[string]interface{}{"q": text})
+}
+
+// searchRelevanceOrder classe les résultats par pertinence : rang plein texte puis similarité trigramme
+func searchRelevanceOrder(text string) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.NamedExpr{
+		SQL: "ts_rank_cd(recipes.search_document, " + recipeSearchQuery + ") + " +
+			"word_similarity(immutable_unaccent(lower(@q)), recipes.search_text) DESC, recipes.average_rating DESC",
+		Vars: []interface{}{map[string]interface{}{"q": strings.TrimSpace(text)}},
+	}}
+}
+
+// withTrigramThreshold exécute fn dans une transaction où le seuil pg_trgm donné (par exemple
+// pg_trgm.similarity_threshold pour l'opérateur %) vaut threshold. Contrairement aux fonctions
+// similarity(), les opérateurs de pg_trgm peuvent utiliser les index GIN trigrammes.
+func withTrigramThreshold(db *gorm.DB, setting string, threshold float64, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config(?, ?, true)", setting, fmt.Sprintf("%g", threshold)).Error; err != nil {
+			return ormerrors.NewDatabaseError("set "+setting, err)
+		}
+		return fn(tx)
+	})
+}
+
+// searchSession exécute fn avec le seuil de similarité de la recherche de recettes
+func (r *recipeRepository) searchSession(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return withTrigramThreshold(r.db.WithContext(ctx), "pg_trgm.word_similarity_threshold", searchSimilarityThreshold, fn)
+}
+
+// searchSortColumns associe les valeurs acceptées de SortBy aux colonnes triables
+var searchSortColumns = map[string]string{
+	"created_at":     "recipes.created_at",
+	"updated_at":     "recipes.updated_at",
+	"title":          "recipes.title",
+	"rating":         "recipes.average_rating",
+	"average_rating": "recipes.average_rating",
+	"prep_time":      "recipes.prep_time",
+	"cook_time":      "recipes.cook_time",
+	"total_time":     "recipes.total_time",
+}
+
+// applySearchOrder applique le tri demandé (pertinence, colonne connue, ou date de création par défaut)
+func applySearchOrder(query *gorm.DB, searchReq *dto.SearchQuery) *gorm.DB {
+	if searchReq.SortBy == dto.SortByRelevance && strings.TrimSpace(searchReq.Query) != "" {
+		return query.Order(searchRelevanceOrder(searchReq.Query))
+	}
+
+	column, ok := searchSortColumns[searchReq.SortBy]
+	if !ok {
+		return query.Order("recipes.created_at DESC")
+	}
+	if searchReq.SortOrder == "asc" {
+		return query.Order(column + " ASC")
+	}
+	return query.Order(column + " DESC")
+}
+
+// applyRelationFilter restreint les recettes liées aux éléments donnés via la table de jointure.
+// En mode "all", la recette doit être liée à chacun des éléments, sinon à au moins un.
+func applyRelationFilter(query *gorm.DB, table, column string, values []string, match string) *gorm.DB {
+	ids := uniqueUints(toUintSlice(values))
+	if len(ids) == 0 {
+		return query
+	}
+
+	if match == dto.MatchAll {
+		return query.Where("(SELECT COUNT(DISTINCT "+table+"."+column+") FROM "+table+
+			" WHERE "+table+".recipe_id = recipes.id AND "+table+"."+column+" IN ?) = ?", ids, len(ids))
+	}
+	return query.Where("EXISTS (SELECT 1 FROM "+table+
+		" WHERE "+table+".recipe_id = recipes.id AND "+table+"."+column+" IN ?)", ids)
+}
+
+// applyRelationExclusion écarte les recettes liées à l'un des éléments donnés via la table de jointure
+func applyRelationExclusion(query *gorm.DB, table, column string, values []string) *gorm.DB {
+	ids := uniqueUints(toUintSlice(values))
+	if len(ids) == 0 {
+		return query
+	}
+
+	return query.Where("NOT EXISTS (SELECT 1 FROM "+table+
+		" WHERE "+table+".recipe_id = recipes.id AND "+table+"."+column+" IN ?)", ids)
+}
+
+// uniqueUints supprime les doublons en conservant l'ordre
+func uniqueUints(values []uint) []uint {
+	seen := make(map[uint]bool, len(values))
+	result := make([]uint, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// searchTimeBuckets liste les durées maximales (en minutes) proposées comme facettes de temps
+var searchTimeBuckets = []int{15, 30, 45, 60, 120}
+
+// SearchFacets calcule les compteurs de chaque facette pour la recherche donnée.
+// Chaque facette ignore son propre filtre afin d'indiquer ce que donnerait le choix d'une autre valeur.
+func (r *recipeRepository) SearchFacets(ctx context.Context, searchReq *dto.SearchQuery) (*dto.SearchFacets, error) {
+	facets := &dto.SearchFacets{}
+	err := r.searchSession(ctx, func(tx *gorm.DB) error {
+		withoutTags := *searchReq
+		withoutTags.Tags = nil
+		tags, err := r.relationFacet(tx, &withoutTags, "recipe_tags", "tag_id", "tags")
+		if err != nil {
+			return err
+		}
+		facets.Tags = tags
+
+		withoutCategories := *searchReq
+		withoutCategories.Categories = nil
+		categories, err := r.relationFacet(tx, &withoutCategories, "recipe_category_associations", "category_id", "categories")
+		if err != nil {
+			return err
+		}
+		facets.Categories = categories
+
+		withoutEquipments := *searchReq
+		withoutEquipments.Equipments = nil
+		equipments, err := r.relationFacet(tx, &withoutEquipments, "recipe_equipments", "equipment_id", "equipment")
+		if err != nil {
+			return err
+		}
+		facets.Equipments = equipments
+
+		withoutDifficulty := *searchReq
+		withoutDifficulty.Difficulty = ""
+		facets.Difficulty = []dto.FacetValueCount{}
+		if err := r.applySearchFilters(tx.Model(&dto.Recipe{}), &withoutDifficulty).
+			Select("recipes.difficulty AS value, COUNT(*) AS count").
+			Where("recipes.difficulty <> ''").
+			Group("recipes.difficulty").
+			Order("count DESC, value").
+			Scan(&facets.Difficulty).Error; err != nil {
+			return ormerrors.NewDatabaseError("count difficulty facet", err)
+		}
+
+		withoutPrepTime := *searchReq
+		withoutPrepTime.MaxPrepTime = 0
+		if facets.PrepTime, err = r.timeFacet(tx, &withoutPrepTime, "recipes.prep_time"); err != nil {
+			return err
+		}
+
+		withoutTotalTime := *searchReq
+		withoutTotalTime.MaxTotalTime = 0
+		facets.TotalTime, err = r.timeFacet(tx, &withoutTotalTime, "recipes.total_time")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return facets, nil
+}
+
+// relationFacet compte les recettes correspondantes par élément lié (tag, catégorie, équipement)
+func (r *recipeRepository) relationFacet(db *gorm.DB, searchReq *dto.SearchQuery, joinTable, column, table string) ([]dto.FacetCount, error) {
+	counts := []dto.FacetCount{}
+	err := r.applySearchFilters(db.Model(&dto.Recipe{}), searchReq).
+		Joins("JOIN " + joinTable + " ON " + joinTable + ".recipe_id = recipes.id").
+		Joins("JOIN " + table + " ON " + table + ".id = " + joinTable + "." + column).
+		Select(table + ".id AS id, " + table + ".name AS name, COUNT(DISTINCT recipes.id) AS count").
+		Group(table + ".id, " + table + ".name").
+		Order("count DESC, name").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, ormerrors.NewDatabaseError("count "+table+" facet", err)
+	}
+	return counts, nil
+}
+
+// timeFacet compte les recettes correspondantes pour chaque durée maximale de searchTimeBuckets
+func (r *recipeRepository) timeFacet(db *gorm.DB, searchReq *dto.SearchQuery, column string) ([]dto.TimeBucketCount, error) {
+	// Répartir les recettes dans le plus petit bucket qui les contient, puis cumuler
+	bucketExpr := "CASE"
+	for _, bucket := range searchTimeBuckets {
+		bucketExpr += fmt.Sprintf(" WHEN %s <= %d THEN %d", column, bucket, bucket)
+	}
+	bucketExpr += " ELSE 0 END"
+
+	var rows []struct {
+		Bucket int
+		Count  int64
+	}
+	err := r.applySearchFilters(db.Model(&dto.Recipe{}), searchReq).
+		Select(bucketExpr + " AS bucket, COUNT(*) AS count").
+		Group("bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, ormerrors.NewDatabaseError("count time facet", err)
+	}
+
+	perBucket := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		perBucket[row.Bucket] = row.Count
+	}
+
+	counts := make([]dto.TimeBucketCount, 0, len(searchTimeBuckets))
+	var cumulative int64
+	for _, bucket := range searchTimeBuckets {
+		cumulative += perBucket[bucket]
+		counts = append(counts, dto.TimeBucketCount{MaxMinutes: bucket, Count: cumulative})
+	}
+	return counts, nil
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
+)
+
+// shoppingLine représente une ligne de la liste de courses en cours d'agrégation
+type shoppingLine struct {
+	item   *dto.ShoppingListItem
+	unit   units.Unit  // Unité de référence de la ligne (celle de la première occurrence)
+	hints  units.Hints // Indications de conversion de l'ingrédient
+	amount float64     // Quantité brute cumulée exprimée dans l'unité de référence
+	net    float64     // Quantité restant à acheter après déduction du frigo, dans l'unité de référence
+	mixed  bool        // Vrai si des unités différentes ont été additionnées
+}
+
+// shoppingListAggregator additionne les quantités d'ingrédients en tenant compte des unités.
+// Les quantités qui ne peuvent pas être converties vers une ligne existante forment une ligne séparée.
+type shoppingListAggregator struct {
+	lines   map[uint][]*shoppingLine
+	order   []*shoppingLine // Ordre d'apparition des lignes, pour un résultat stable
+	withNet bool            // Renseigner la quantité nette (stock du frigo déduit)
+}
+
+// newShoppingListAggregator crée un agrégateur vide
+func newShoppingListAggregator(withNet bool) *shoppingListAggregator {
+	return &shoppingListAggregator{
+		lines:   make(map[uint][]*shoppingLine),
+		withNet: withNet,
+	}
+}
+
+// ingredientHints retourne les indications de conversion d'un ingrédient
+func ingredientHints(ingredient dto.Ingredient) units.Hints {
+	return units.HintsFor(ingredient.Name, ingredient.Density, ingredient.PieceWeight)
+}
+
+// add ajoute une quantité d'ingrédient utilisée par une recette planifiée.
+// net est la part de cette quantité qui reste à acheter (égale à quantity sans déduction du frigo).
+func (a *shoppingListAggregator) add(ingredient dto.Ingredient, quantity, net float64, rawUnit string, ref dto.ShoppingListRecipeRef) {
+	unit := units.Parse(rawUnit)
+	ref.Unit = unit.Code
+
+	for _, line := range a.lines[ingredient.ID] {
+		converted, ok := units.Convert(quantity, unit, line.unit, line.hints)
+		if !ok {
+			continue
+		}
+		convertedNet, _ := units.Convert(net, unit, line.unit, line.hints)
+		line.amount += converted
+		line.net += convertedNet
+		if unit.Key() != line.unit.Key() {
+			line.mixed = true
+		}
+		line.item.Recipes = append(line.item.Recipes, ref)
+		return
+	}
+
+	line := &shoppingLine{
+		item: &dto.ShoppingListItem{
+			IngredientID:   ingredient.ID,
+			IngredientName: ingredient.Name,
+			Category:       ingredient.Category,
+			Recipes:        []dto.ShoppingListRecipeRef{ref},
+		},
+		unit:   unit,
+		hints:  ingredientHints(ingredient),
+		amount: quantity,
+		net:    net,
+	}
+	a.lines[ingredient.ID] = append(a.lines[ingredient.ID], line)
+	a.order = append(a.order, line)
+}
+
+// items retourne les lignes agrégées avec leur quantité totale dans une unité lisible
+func (a *shoppingListAggregator) items() []dto.ShoppingListItem {
+	items := make([]dto.ShoppingListItem, 0, len(a.order))
+	for _, line := range a.order {
+		quantity, unit := line.amount, line.unit
+
+		// Plusieurs unités fusionnées : exprimer le total dans l'unité lisible de la dimension de la ligne
+		// (le lait reste en volume, les oeufs en pièces)
+		if line.mixed {
+			quantity, unit = units.Humanize(quantity, unit)
+		}
+
+		item := *line.item
+		// Toutes les lignes d'un ingrédient réparti sur des unités incompatibles sont signalées
+		item.Unconvertible = len(a.lines[item.IngredientID]) > 1
+		item.TotalQuantity = units.Round(quantity)
+		item.Unit = unit.Code
+		if a.withNet {
+			net, _ := units.Convert(line.net, line.unit, unit, line.hints)
+			net = units.Round(net)
+			item.NetQuantity = &net
+		}
+		items = append(items, item)
+	}
+	return items
+}
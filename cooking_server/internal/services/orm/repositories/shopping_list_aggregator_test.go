@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"math"
+	"testing"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+)
+
+func TestShoppingListAggregator(t *testing.T) {
+	eggWeight := 55.0
+	milkDensity := 1.03
+	flour := dto.Ingredient{ID: 1, Name: "Farine", Category: "Épicerie"}
+	milk := dto.Ingredient{ID: 2, Name: "Lait", Density: &milkDensity}
+	eggs := dto.Ingredient{ID: 3, Name: "Oeufs", PieceWeight: &eggWeight}
+	garlic := dto.Ingredient{ID: 4, Name: "Ail"}
+
+	type usage struct {
+		ingredient dto.Ingredient
+		quantity   float64
+		net        float64
+		unit       string
+	}
+	type line struct {
+		name          string
+		total         float64
+		net           float64
+		unit          string
+		unconvertible bool
+		recipes       int
+	}
+
+	tests := []struct {
+		name    string
+		withNet bool
+		usages  []usage
+		want    []line
+	}{
+		{
+			name: "même unité",
+			usages: []usage{
+				{flour, 200, 200, "g"},
+				{flour, 300, 300, "grammes"},
+			},
+			want: []line{{"Farine", 500, 0, "g", false, 2}},
+		},
+		{
+			name: "unités de même dimension",
+			usages: []usage{
+				{flour, 1, 1, "kg"},
+				{flour, 500, 500, "g"},
+			},
+			want: []line{{"Farine", 1.5, 0, "kg", false, 2}},
+		},
+		{
+			name: "petites quantités en unité lisible",
+			usages: []usage{
+				{milk, 2, 2, "c. à soupe"},
+				{milk, 20, 20, "cl"},
+			},
+			want: []line{{"Lait", 230, 0, "ml", false, 2}},
+		},
+		{
+			name: "masse convertie en volume avec la densité",
+			usages: []usage{
+				{milk, 500, 500, "ml"},
+				{milk, 1030, 1030, "g"},
+			},
+			want: []line{{"Lait", 1.5, 0, "l", false, 2}},
+		},
+		{
+			name: "masse convertie en pièces avec le poids moyen",
+			usages: []usage{
+				{eggs, 2, 2, ""},
+				{eggs, 110, 110, "g"},
+			},
+			want: []line{{"Oeufs", 4, 0, "pièce", false, 2}},
+		},
+		{
+			name: "unités incompatibles sur des lignes séparées",
+			usages: []usage{
+				{garlic, 2, 2, "gousses"},
+				{garlic, 10, 10, "g"},
+				{garlic, 1, 1, "gousse"},
+			},
+			want: []line{
+				{"Ail", 3, 0, "gousse", true, 2},
+				{"Ail", 10, 0, "g", true, 1},
+			},
+		},
+		{
+			name: "ordre d'apparition des ingrédients",
+			usages: []usage{
+				{milk, 250, 250, "ml"},
+				{flour, 100, 100, "g"},
+				{milk, 250, 250, "ml"},
+			},
+			want: []line{
+				{"Lait", 500, 0, "ml", false, 2},
+				{"Farine", 100, 0, "g", false, 1},
+			},
+		},
+		{
+			name:    "quantité nette après déduction du frigo",
+			withNet: true,
+			usages: []usage{
+				{flour, 200, 50, "g"},
+				{flour, 0.3, 0.3, "kg"},
+			},
+			want: []line{{"Farine", 500, 350, "g", false, 2}},
+		},
+		{
+			name:    "entièrement couvert par le frigo",
+			withNet: true,
+			usages: []usage{
+				{eggs, 3, 0, "pièces"},
+			},
+			want: []line{{"Oeufs", 3, 0, "pièce", false, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggregator := newShoppingListAggregator(tt.withNet)
+			for _, u := range tt.usages {
+				aggregator.add(u.ingredient, u.quantity, u.net, u.unit, dto.ShoppingListRecipeRef{RecipeName: "Recette", Quantity: u.quantity})
+			}
+
+			items := aggregator.items()
+			if len(items) != len(tt.want) {
+				t.Fatalf("items() returned %d lines, want %d: %+v", len(items), len(tt.want), items)
+			}
+			for i, item := range items {
+				want := tt.want[i]
+				if item.IngredientName != want.name || item.Unit != want.unit || item.Unconvertible != want.unconvertible ||
+					math.Abs(item.TotalQuantity-want.total) > 1e-9 || len(item.Recipes) != want.recipes {
+					t.Errorf("line %d = %s %v %s (unconvertible=%v, %d recipes), want %s %v %s (unconvertible=%v, %d recipes)",
+						i, item.IngredientName, item.TotalQuantity, item.Unit, item.Unconvertible, len(item.Recipes),
+						want.name, want.total, want.unit, want.unconvertible, want.recipes)
+				}
+				switch {
+				case !tt.withNet && item.NetQuantity != nil:
+					t.Errorf("line %d has a net quantity without fridge deduction", i)
+				case tt.withNet && (item.NetQuantity == nil || math.Abs(*item.NetQuantity-want.net) > 1e-9):
+					t.Errorf("line %d net quantity = %v, want %v", i, item.NetQuantity, want.net)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
+	"gorm.io/gorm"
+)
+
+// Quantité en dessous de laquelle un besoin est considéré comme couvert
+const shoppingListEpsilon = 0.005
+
+type shoppingListRepository struct {
+	db *gorm.DB
+}
+
+// NewShoppingListRepository crée une nouvelle instance du repository des listes de courses
+func NewShoppingListRepository(db *gorm.DB) *shoppingListRepository {
+	return &shoppingListRepository{db: db}
+}
+
+// Create crée une liste de courses, générée à partir des repas planifiés si une période est définie
+func (r *shoppingListRepository) Create(ctx context.Context, list *dto.ShoppingList) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Create(list).Error; err != nil {
+			return ormerrors.NewDatabaseError("create shopping list", err)
+		}
+		if list.StartDate == nil || list.EndDate == nil {
+			return nil
+		}
+		return r.merge(ctx, tx, list)
+	})
+}
+
+// GetByID récupère une liste de courses avec ses lignes (non cochées d'abord), hors lignes déjà achetées
+func (r *shoppingListRepository) GetByID(ctx context.Context, id uint) (*dto.ShoppingList, error) {
+	var list dto.ShoppingList
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Where("purchased_at IS NULL").Order("checked ASC, name ASC, id ASC")
+		}).
+		First(&list, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("shopping list", id)
+		}
+		return nil, ormerrors.NewDatabaseError("get shopping list by id", err)
+	}
+
+	// Regrouper les lignes selon les rayons du magasin de la liste (ou du magasin par défaut)
+	ingredientIDs := make([]uint, 0, len(list.Items))
+	for _, entry := range list.Items {
+		if entry.IngredientID != nil {
+			ingredientIDs = append(ingredientIDs, *entry.IngredientID)
+		}
+	}
+	layout, err := loadStoreLayout(ctx, r.db, list.UserID, list.StoreID, ingredientIDs)
+	if err != nil && !errors.Is(err, ormerrors.ErrRecordNotFound) {
+		return nil, err
+	}
+	if layout != nil {
+		list.Aisles = layout.groupEntries(list.Items)
+	}
+	return &list, nil
+}
+
+// GetByUser récupère les listes de courses d'un utilisateur, sans leurs lignes
+func (r *shoppingListRepository) GetByUser(ctx context.Context, userID uint) ([]*dto.ShoppingList, error) {
+	var lists []*dto.ShoppingList
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("updated_at DESC").
+		Find(&lists).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get shopping lists by user", err)
+	}
+	return lists, nil
+}
+
+// Update met à jour les informations d'une liste de courses (nom, période, déduction du frigo)
+func (r *shoppingListRepository) Update(ctx context.Context, list *dto.ShoppingList) error {
+	if err := r.db.WithContext(ctx).Omit("Items").Save(list).Error; err != nil {
+		return ormerrors.NewDatabaseError("update shopping list", err)
+	}
+	return nil
+}
+
+// Delete supprime une liste de courses et ses lignes
+func (r *shoppingListRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("shopping_list_id = ?", id).Delete(&dto.ShoppingListEntry{}).Error; err != nil {
+			return ormerrors.NewDatabaseError("delete shopping list entries", err)
+		}
+		result := tx.Delete(&dto.ShoppingList{}, id)
+		if result.Error != nil {
+			return ormerrors.NewDatabaseError("delete shopping list", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ormerrors.NewNotFoundError("shopping list", id)
+		}
+		return nil
+	})
+}
+
+// Regenerate recalcule les lignes issues des repas planifiés sur la période de la liste.
+// Les lignes cochées et les ajouts manuels sont conservés tels quels.
+func (r *shoppingListRepository) Regenerate(ctx context.Context, id uint) (*dto.ShoppingList, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var list dto.ShoppingList
+		if err := tx.First(&list, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ormerrors.NewNotFoundError("shopping list", id)
+			}
+			return ormerrors.NewDatabaseError("get shopping list", err)
+		}
+		if list.StartDate == nil || list.EndDate == nil {
+			return ormerrors.NewValidationError("shopping list has no meal plan period")
+		}
+		return r.merge(ctx, tx, &list)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// merge fusionne la liste calculée à partir des repas planifiés dans les lignes existantes.
+// Pour chaque ingrédient, les quantités déjà cochées ou achetées sont déduites du besoin, et la ligne
+// générée non cochée est ajustée au reste (créée, mise à jour ou supprimée).
+func (r *shoppingListRepository) merge(ctx context.Context, tx *gorm.DB, list *dto.ShoppingList) error {
+	computed, err := NewMealPlanRepository(tx).GetWeeklyShoppingList(ctx, list.UserID, *list.StartDate, *list.EndDate,
+		dto.ShoppingListOptions{SubtractFridge: list.SubtractFridge})
+	if err != nil {
+		return err
+	}
+
+	var entries []*dto.ShoppingListEntry
+	if err := tx.Where("shopping_list_id = ? AND source = ?", list.ID, dto.ShoppingListSourceMealPlan).
+		Order("id ASC").
+		Find(&entries).Error; err != nil {
+		return ormerrors.NewDatabaseError("get shopping list entries", err)
+	}
+
+	// Ingrédients concernés, pour les conversions entre unités
+	ingredientIDs := make([]uint, 0, len(computed.Items))
+	for _, item := range computed.Items {
+		ingredientIDs = append(ingredientIDs, item.IngredientID)
+	}
+	var ingredients []dto.Ingredient
+	if len(ingredientIDs) > 0 {
+		if err := tx.Where("id IN ?", uniqueUints(ingredientIDs)).Find(&ingredients).Error; err != nil {
+			return ormerrors.NewDatabaseError("get shopping list ingredients", err)
+		}
+	}
+	hints := make(map[uint]units.Hints, len(ingredients))
+	for _, ingredient := range ingredients {
+		hints[ingredient.ID] = ingredientHints(ingredient)
+	}
+
+	// Quantités cochées encore disponibles par ingrédient, et lignes ouvertes par ingrédient et unité
+	checked := make(map[uint][]*checkedQuantity)
+	open := make(map[uint][]*dto.ShoppingListEntry)
+	for _, entry := range entries {
+		if entry.IngredientID == nil {
+			continue
+		}
+		if entry.PurchasedAt != nil && list.SubtractFridge {
+			// Déjà rangée dans le frigo, dont le stock est déduit du besoin calculé
+			continue
+		}
+		if entry.Checked {
+			checked[*entry.IngredientID] = append(checked[*entry.IngredientID], &checkedQuantity{
+				unit:      units.Parse(entry.Unit),
+				remaining: entry.EffectiveQuantity(),
+			})
+			continue
+		}
+		open[*entry.IngredientID] = append(open[*entry.IngredientID], entry)
+	}
+
+	kept := make(map[uint]bool, len(entries))
+	for _, item := range computed.Items {
+		need := item.TotalQuantity
+		if item.NetQuantity != nil {
+			need = *item.NetQuantity
+		}
+		unit := units.Parse(item.Unit)
+
+		// Déduire ce qui a déjà été coché pour cet ingrédient
+		for _, bought := range checked[item.IngredientID] {
+			if need <= shoppingListEpsilon {
+				break
+			}
+			available, ok := units.Convert(bought.remaining, bought.unit, unit, hints[item.IngredientID])
+			if !ok || available <= 0 {
+				continue
+			}
+			used := min(available, need)
+			need -= used
+			consumed, _ := units.Convert(used, unit, bought.unit, hints[item.IngredientID])
+			bought.remaining -= consumed
+		}
+		need = units.Round(max(need, 0))
+
+		var entry *dto.ShoppingListEntry
+		for _, candidate := range open[item.IngredientID] {
+			if !kept[candidate.ID] && units.Parse(candidate.Unit).Key() == unit.Key() {
+				entry = candidate
+				break
+			}
+		}
+
+		switch {
+		case entry == nil && need > shoppingListEpsilon:
+			ingredientID := item.IngredientID
+			entry = &dto.ShoppingListEntry{
+				ShoppingListID: list.ID,
+				IngredientID:   &ingredientID,
+				Name:           item.IngredientName,
+				Quantity:       need,
+				Unit:           item.Unit,
+				Source:         dto.ShoppingListSourceMealPlan,
+			}
+			if err := tx.Create(entry).Error; err != nil {
+				return ormerrors.NewDatabaseError("create shopping list entry", err)
+			}
+			kept[entry.ID] = true
+		case entry != nil && (need > shoppingListEpsilon || entry.QuantityOverride != nil):
+			// Une quantité corrigée à la main est conservée, seule la quantité calculée change
+			kept[entry.ID] = true
+			if err := tx.Model(entry).Updates(map[string]interface{}{
+				"quantity": need,
+				"name":     item.IngredientName,
+			}).Error; err != nil {
+				return ormerrors.NewDatabaseError("update shopping list entry", err)
+			}
+		}
+	}
+
+	// Lignes générées non cochées qui ne correspondent plus à aucun besoin
+	var stale []uint
+	for _, entry := range entries {
+		if !entry.Checked && !kept[entry.ID] && entry.QuantityOverride == nil {
+			stale = append(stale, entry.ID)
+		}
+	}
+	if len(stale) > 0 {
+		if err := tx.Delete(&dto.ShoppingListEntry{}, stale).Error; err != nil {
+			return ormerrors.NewDatabaseError("delete stale shopping list entries", err)
+		}
+	}
+
+	now := time.Now()
+	list.GeneratedAt = &now
+	if err := tx.Model(list).Update("generated_at", now).Error; err != nil {
+		return ormerrors.NewDatabaseError("update shopping list generation date", err)
+	}
+	return nil
+}
+
+// checkedQuantity suit la part d'une ligne cochée pas encore déduite d'un besoin
+type checkedQuantity struct {
+	unit      units.Unit
+	remaining float64
+}
+
+// AddEntry ajoute une ligne manuelle à une liste de courses
+func (r *shoppingListRepository) AddEntry(ctx context.Context, entry *dto.ShoppingListEntry) error {
+	if entry.IngredientID != nil {
+		var ingredient dto.Ingredient
+		if err := r.db.WithContext(ctx).First(&ingredient, *entry.IngredientID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ormerrors.NewNotFoundError("ingredient", *entry.IngredientID)
+			}
+			return ormerrors.NewDatabaseError("get shopping list ingredient", err)
+		}
+		if entry.Name == "" {
+			entry.Name = ingredient.Name
+		}
+	}
+	if entry.Name == "" {
+		return ormerrors.NewValidationError("name is required for an item without ingredient")
+	}
+
+	entry.Source = dto.ShoppingListSourceManual
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return ormerrors.NewDatabaseError("create shopping list entry", err)
+	}
+	return nil
+}
+
+// GetEntry récupère une ligne d'une liste de courses
+func (r *shoppingListRepository) GetEntry(ctx context.Context, listID, entryID uint) (*dto.ShoppingListEntry, error) {
+	var entry dto.ShoppingListEntry
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND shopping_list_id = ? AND purchased_at IS NULL", entryID, listID).
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("shopping list entry", entryID)
+		}
+		return nil, ormerrors.NewDatabaseError("get shopping list entry", err)
+	}
+	return &entry, nil
+}
+
+// UpdateEntry met à jour une ligne d'une liste de courses
+func (r *shoppingListRepository) UpdateEntry(ctx context.Context, entry *dto.ShoppingListEntry) error {
+	if err := r.db.WithContext(ctx).Save(entry).Error; err != nil {
+		return ormerrors.NewDatabaseError("update shopping list entry", err)
+	}
+	return nil
+}
+
+// DeleteEntry supprime une ligne d'une liste de courses
+func (r *shoppingListRepository) DeleteEntry(ctx context.Context, listID, entryID uint) error {
+	result := r.db.WithContext(ctx).
+		Where("shopping_list_id = ? AND purchased_at IS NULL", listID).
+		Delete(&dto.ShoppingListEntry{}, entryID)
+	if result.Error != nil {
+		return ormerrors.NewDatabaseError("delete shopping list entry", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ormerrors.NewNotFoundError("shopping list entry", entryID)
+	}
+	return nil
+}
+
+// Checkout range les articles cochés dans le frigo de l'utilisateur puis les retire de la liste.
+// Les articles libres sont rapprochés d'un ingrédient par leur nom ou un alias ; sinon ils sont seulement retirés.
+// Les lignes générées sont conservées comme achetées pour ne pas être rajoutées à la régénération.
+func (r *shoppingListRepository) Checkout(ctx context.Context, id uint) (*dto.ShoppingListCheckout, error) {
+	result := &dto.ShoppingListCheckout{
+		FridgeItems: []dto.FridgeItem{},
+		Unmatched:   []string{},
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var list dto.ShoppingList
+		if err := tx.First(&list, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ormerrors.NewNotFoundError("shopping list", id)
+			}
+			return ormerrors.NewDatabaseError("get shopping list", err)
+		}
+
+		var entries []dto.ShoppingListEntry
+		if err := tx.Where("shopping_list_id = ? AND checked = ? AND purchased_at IS NULL", id, true).
+			Order("id ASC").
+			Find(&entries).Error; err != nil {
+			return ormerrors.NewDatabaseError("get checked shopping list entries", err)
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		ingredients := NewIngredientRepository(tx)
+		var purchasedIDs, removedIDs []uint
+		for _, entry := range entries {
+			if entry.Source == dto.ShoppingListSourceMealPlan {
+				purchasedIDs = append(purchasedIDs, entry.ID)
+			} else {
+				removedIDs = append(removedIDs, entry.ID)
+			}
+
+			ingredientID := uint(0)
+			if entry.IngredientID != nil {
+				ingredientID = *entry.IngredientID
+			} else {
+				ingredient, err := ingredients.GetByName(ctx, entry.Name)
+				if err != nil {
+					if errors.Is(err, ormerrors.ErrRecordNotFound) {
+						result.Unmatched = append(result.Unmatched, entry.Name)
+						continue
+					}
+					return err
+				}
+				ingredientID = ingredient.ID
+			}
+
+			item := dto.FridgeItem{
+				UserID:       list.UserID,
+				IngredientID: ingredientID,
+			}
+			if quantity := entry.EffectiveQuantity(); quantity > 0 {
+				item.Quantity = &quantity
+			}
+			if entry.Unit != "" {
+				unit := entry.Unit
+				item.Unit = &unit
+			}
+			if entry.Notes != "" {
+				notes := entry.Notes
+				item.Notes = &notes
+			}
+			if err := tx.Omit("Ingredient").Create(&item).Error; err != nil {
+				return ormerrors.NewDatabaseError("create fridge item from shopping list", err)
+			}
+			result.FridgeItems = append(result.FridgeItems, item)
+		}
+
+		if len(purchasedIDs) > 0 {
+			if err := tx.Model(&dto.ShoppingListEntry{}).Where("id IN ?", purchasedIDs).
+				Update("purchased_at", time.Now()).Error; err != nil {
+				return ormerrors.NewDatabaseError("mark purchased shopping list entries", err)
+			}
+		}
+		if len(removedIDs) > 0 {
+			if err := tx.Delete(&dto.ShoppingListEntry{}, removedIDs).Error; err != nil {
+				return ormerrors.NewDatabaseError("delete checked shopping list entries", err)
+			}
+		}
+		result.RemovedCount = len(entries)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,271 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"github.com/romainrodriguez/cooking_server/internal/services/units"
+	"gorm.io/gorm"
+)
+
+// Nom du groupe des articles dont le rayon est inconnu, placé après les rayons du magasin
+const unknownAisleName = "Autres"
+
+// storeLayout range les ingrédients dans les rayons d'un magasin. Pour chaque ingrédient, dans l'ordre :
+// le rayon où l'utilisateur l'a déjà placé dans ce magasin, le rayon associé à sa catégorie (ou à celle
+// d'un ingrédient plus général), le rayon où il a placé d'autres ingrédients de la même catégorie,
+// puis le rayon du même nom que celui où il l'a placé dans un autre magasin.
+type storeLayout struct {
+	store      *dto.Store
+	aisles     map[uint]*dto.StoreAisle
+	byCategory map[uint]*dto.StoreAisle // Premier rayon associé à chaque catégorie
+	byName     map[string]*dto.StoreAisle
+
+	taxonomy   ingredientTaxonomy
+	categories map[uint]uint // Catégorie de chaque ingrédient concerné (et de ses ancêtres)
+
+	placements         map[uint]uint // Ingrédient → rayon choisi dans ce magasin
+	categoryPlacements map[uint]uint // Catégorie → rayon le plus utilisé pour ses ingrédients dans ce magasin
+	elsewhere          map[uint]uint // Ingrédient → rayon de même nom appris dans un autre magasin
+}
+
+// loadStoreLayout charge la disposition d'un magasin de l'utilisateur pour les ingrédients donnés.
+// Sans magasin précisé, le magasin par défaut est utilisé ; sans magasin par défaut, le résultat est nil.
+func loadStoreLayout(ctx context.Context, db *gorm.DB, userID uint, storeID *uint, ingredientIDs []uint) (*storeLayout, error) {
+	db = db.WithContext(ctx)
+
+	var store dto.Store
+	query := db.Preload("Aisles", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC, id ASC")
+	}).Preload("Aisles.Categories").Where("user_id = ?", userID)
+	if storeID != nil {
+		query = query.Where("id = ?", *storeID)
+	} else {
+		query = query.Where("is_default")
+	}
+	if err := query.First(&store).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewDatabaseError("get store layout", err)
+		}
+		if storeID != nil {
+			return nil, ormerrors.NewNotFoundError("store", *storeID)
+		}
+		return nil, nil
+	}
+
+	layout := &storeLayout{
+		store:              &store,
+		aisles:             make(map[uint]*dto.StoreAisle, len(store.Aisles)),
+		byCategory:         make(map[uint]*dto.StoreAisle),
+		byName:             make(map[string]*dto.StoreAisle, len(store.Aisles)),
+		categories:         make(map[uint]uint),
+		placements:         make(map[uint]uint),
+		categoryPlacements: make(map[uint]uint),
+		elsewhere:          make(map[uint]uint),
+	}
+	for i := range store.Aisles {
+		aisle := &store.Aisles[i]
+		layout.aisles[aisle.ID] = aisle
+		if _, exists := layout.byName[units.NormalizeText(aisle.Name)]; !exists {
+			layout.byName[units.NormalizeText(aisle.Name)] = aisle
+		}
+		for _, category := range aisle.Categories {
+			if _, exists := layout.byCategory[category.ID]; !exists {
+				layout.byCategory[category.ID] = aisle
+			}
+		}
+	}
+
+	ingredientIDs = uniqueUints(ingredientIDs)
+	if len(ingredientIDs) == 0 || len(store.Aisles) == 0 {
+		return layout, nil
+	}
+
+	taxonomy, err := loadIngredientTaxonomy(db)
+	if err != nil {
+		return nil, err
+	}
+	layout.taxonomy = taxonomy
+
+	// Catégories des ingrédients et de leurs ancêtres
+	lineage := append([]uint(nil), ingredientIDs...)
+	for _, id := range ingredientIDs {
+		lineage = append(lineage, taxonomy.ancestors(id)...)
+	}
+	var classified []struct {
+		ID         uint
+		CategoryID uint
+	}
+	if err := db.Model(&dto.Ingredient{}).
+		Select("id, category_id").
+		Where("id IN ? AND category_id IS NOT NULL", uniqueUints(lineage)).
+		Scan(&classified).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get ingredient categories", err)
+	}
+	for _, ingredient := range classified {
+		layout.categories[ingredient.ID] = ingredient.CategoryID
+	}
+
+	// Placements appris dans ce magasin, et rayon préféré par catégorie
+	var placed []struct {
+		IngredientID uint
+		AisleID      uint
+		CategoryID   *uint
+		Count        int
+	}
+	if err := db.Table("store_ingredient_placements").
+		Select("store_ingredient_placements.ingredient_id, store_ingredient_placements.aisle_id, ingredients.category_id, store_ingredient_placements.count").
+		Joins("JOIN ingredients ON ingredients.id = store_ingredient_placements.ingredient_id").
+		Where("store_ingredient_placements.store_id = ?", store.ID).
+		Scan(&placed).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get store placements", err)
+	}
+	votes := make(map[uint]map[uint]int)
+	for _, placement := range placed {
+		layout.placements[placement.IngredientID] = placement.AisleID
+		if placement.CategoryID == nil {
+			continue
+		}
+		if votes[*placement.CategoryID] == nil {
+			votes[*placement.CategoryID] = make(map[uint]int)
+		}
+		votes[*placement.CategoryID][placement.AisleID] += placement.Count
+	}
+	for categoryID, perAisle := range votes {
+		best, bestCount := uint(0), 0
+		for aisleID, count := range perAisle {
+			if count > bestCount || (count == bestCount && aisleID < best) {
+				best, bestCount = aisleID, count
+			}
+		}
+		layout.categoryPlacements[categoryID] = best
+	}
+
+	// Placements appris dans les autres magasins de l'utilisateur, rapprochés par nom de rayon
+	var learned []struct {
+		IngredientID uint
+		AisleName    string
+	}
+	if err := db.Table("store_ingredient_placements").
+		Select("store_ingredient_placements.ingredient_id, store_aisles.name AS aisle_name").
+		Joins("JOIN store_aisles ON store_aisles.id = store_ingredient_placements.aisle_id").
+		Where("store_ingredient_placements.user_id = ? AND store_ingredient_placements.store_id <> ? AND store_ingredient_placements.ingredient_id IN ?",
+			userID, store.ID, ingredientIDs).
+		Order("store_ingredient_placements.count DESC, store_ingredient_placements.updated_at DESC").
+		Scan(&learned).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get learned placements", err)
+	}
+	for _, placement := range learned {
+		if _, exists := layout.elsewhere[placement.IngredientID]; exists {
+			continue
+		}
+		if aisle, ok := layout.byName[units.NormalizeText(placement.AisleName)]; ok {
+			layout.elsewhere[placement.IngredientID] = aisle.ID
+		}
+	}
+
+	return layout, nil
+}
+
+// aisleFor retourne le rayon d'un ingrédient dans le magasin, ou nil s'il est inconnu
+func (l *storeLayout) aisleFor(ingredientID *uint) *dto.StoreAisle {
+	if ingredientID == nil {
+		return nil
+	}
+	id := *ingredientID
+
+	if aisle, ok := l.aisles[l.placements[id]]; ok {
+		return aisle
+	}
+
+	// Catégorie de l'ingrédient, ou à défaut de l'ingrédient plus général le plus proche
+	categoryID, classified := l.categories[id]
+	if !classified {
+		for _, ancestor := range l.taxonomy.ancestors(id) {
+			if categoryID, classified = l.categories[ancestor]; classified {
+				break
+			}
+		}
+	}
+	if classified {
+		if aisle, ok := l.byCategory[categoryID]; ok {
+			return aisle
+		}
+		if aisle, ok := l.aisles[l.categoryPlacements[categoryID]]; ok {
+			return aisle
+		}
+	}
+
+	if aisle, ok := l.aisles[l.elsewhere[id]]; ok {
+		return aisle
+	}
+	return nil
+}
+
+// groupItems trie les articles de la liste hebdomadaire selon l'ordre des rayons et les regroupe par rayon
+func (l *storeLayout) groupItems(items []dto.ShoppingListItem) ([]dto.ShoppingListItem, []dto.ShoppingListAisle) {
+	groups := make(map[uint]*dto.ShoppingListAisle)
+	unknown := &dto.ShoppingListAisle{Name: unknownAisleName, Position: len(l.store.Aisles)}
+	for _, item := range items {
+		ingredientID := item.IngredientID
+		group := unknown
+		if aisle := l.aisleFor(&ingredientID); aisle != nil {
+			if group = groups[aisle.ID]; group == nil {
+				aisleID := aisle.ID
+				group = &dto.ShoppingListAisle{AisleID: &aisleID, Name: aisle.Name, Position: aisle.Position}
+				groups[aisle.ID] = group
+			}
+		}
+		group.Items = append(group.Items, item)
+	}
+
+	result := make([]dto.ShoppingListAisle, 0, len(groups)+1)
+	for _, aisle := range l.store.Aisles {
+		if group, ok := groups[aisle.ID]; ok {
+			result = append(result, *group)
+		}
+	}
+	if len(unknown.Items) > 0 {
+		result = append(result, *unknown)
+	}
+
+	sorted := make([]dto.ShoppingListItem, 0, len(items))
+	for i := range result {
+		sort.SliceStable(result[i].Items, func(a, b int) bool {
+			return units.NormalizeText(result[i].Items[a].IngredientName) < units.NormalizeText(result[i].Items[b].IngredientName)
+		})
+		sorted = append(sorted, result[i].Items...)
+	}
+	return sorted, result
+}
+
+// groupEntries regroupe les lignes d'une liste de courses enregistrée par rayon, dans l'ordre du magasin
+func (l *storeLayout) groupEntries(entries []dto.ShoppingListEntry) []dto.ShoppingListEntryAisle {
+	groups := make(map[uint]*dto.ShoppingListEntryAisle)
+	unknown := &dto.ShoppingListEntryAisle{Name: unknownAisleName, Position: len(l.store.Aisles)}
+	for _, entry := range entries {
+		group := unknown
+		if aisle := l.aisleFor(entry.IngredientID); aisle != nil {
+			if group = groups[aisle.ID]; group == nil {
+				aisleID := aisle.ID
+				group = &dto.ShoppingListEntryAisle{AisleID: &aisleID, Name: aisle.Name, Position: aisle.Position}
+				groups[aisle.ID] = group
+			}
+		}
+		group.Items = append(group.Items, entry)
+	}
+
+	result := make([]dto.ShoppingListEntryAisle, 0, len(groups)+1)
+	for _, aisle := range l.store.Aisles {
+		if group, ok := groups[aisle.ID]; ok {
+			result = append(result, *group)
+		}
+	}
+	if len(unknown.Items) > 0 {
+		result = append(result, *unknown)
+	}
+	return result
+}
@@ -0,0 +1,265 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/romainrodriguez/cooking_server/internal/dto"
+	ormerrors "github.com/romainrodriguez/cooking_server/internal/services/orm/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type storeRepository struct {
+	db *gorm.DB
+}
+
+// NewStoreRepository crée une nouvelle instance du repository des magasins
+func NewStoreRepository(db *gorm.DB) *storeRepository {
+	return &storeRepository{db: db}
+}
+
+// Create crée un magasin avec ses rayons
+func (r *storeRepository) Create(ctx context.Context, store *dto.Store) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		aisles := store.Aisles
+		store.Aisles = nil
+		if err := tx.Create(store).Error; err != nil {
+			return ormerrors.NewDatabaseError("create store", err)
+		}
+		if store.IsDefault {
+			if err := unsetOtherDefaultStores(tx, store); err != nil {
+				return err
+			}
+		}
+		if err := saveStoreAisles(tx, store.ID, aisles); err != nil {
+			return err
+		}
+		store.Aisles = aisles
+		return nil
+	})
+}
+
+// GetByID récupère un magasin avec ses rayons dans l'ordre de passage
+func (r *storeRepository) GetByID(ctx context.Context, id uint) (*dto.Store, error) {
+	var store dto.Store
+	err := r.db.WithContext(ctx).
+		Preload("Aisles", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC, id ASC")
+		}).
+		Preload("Aisles.Categories").
+		First(&store, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ormerrors.NewNotFoundError("store", id)
+		}
+		return nil, ormerrors.NewDatabaseError("get store by id", err)
+	}
+	return &store, nil
+}
+
+// GetByUser récupère les magasins d'un utilisateur, le magasin par défaut en premier
+func (r *storeRepository) GetByUser(ctx context.Context, userID uint) ([]*dto.Store, error) {
+	var stores []*dto.Store
+	if err := r.db.WithContext(ctx).
+		Preload("Aisles", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC, id ASC")
+		}).
+		Preload("Aisles.Categories").
+		Where("user_id = ?", userID).
+		Order("is_default DESC, name ASC").
+		Find(&stores).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get stores by user", err)
+	}
+	return stores, nil
+}
+
+// Update met à jour un magasin. Si aisles n'est pas nil, il remplace les rayons du magasin :
+// les rayons existants cités par leur ID sont conservés (avec les placements appris), les autres sont supprimés.
+func (r *storeRepository) Update(ctx context.Context, store *dto.Store, aisles *[]dto.StoreAisle) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Aisles").Save(store).Error; err != nil {
+			return ormerrors.NewDatabaseError("update store", err)
+		}
+		if store.IsDefault {
+			if err := unsetOtherDefaultStores(tx, store); err != nil {
+				return err
+			}
+		}
+		if aisles == nil {
+			return nil
+		}
+
+		var existing []uint
+		if err := tx.Model(&dto.StoreAisle{}).Where("store_id = ?", store.ID).Pluck("id", &existing).Error; err != nil {
+			return ormerrors.NewDatabaseError("get store aisles", err)
+		}
+		known := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			known[id] = true
+		}
+		keep := make(map[uint]bool, len(*aisles))
+		for _, aisle := range *aisles {
+			if aisle.ID == 0 {
+				continue
+			}
+			if !known[aisle.ID] {
+				return ormerrors.NewValidationError("aisle does not belong to this store")
+			}
+			keep[aisle.ID] = true
+		}
+
+		var removed []uint
+		for _, id := range existing {
+			if !keep[id] {
+				removed = append(removed, id)
+			}
+		}
+		if err := deleteStoreAisles(tx, removed); err != nil {
+			return err
+		}
+		if err := saveStoreAisles(tx, store.ID, *aisles); err != nil {
+			return err
+		}
+		store.Aisles = *aisles
+		return nil
+	})
+}
+
+// Delete supprime un magasin, ses rayons, les placements appris et les prix relevés dans ce magasin. Les listes de courses associées le perdent.
+func (r *storeRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var aisleIDs []uint
+		if err := tx.Model(&dto.StoreAisle{}).Where("store_id = ?", id).Pluck("id", &aisleIDs).Error; err != nil {
+			return ormerrors.NewDatabaseError("get store aisles", err)
+		}
+		if err := deleteStoreAisles(tx, aisleIDs); err != nil {
+			return err
+		}
+		if err := tx.Model(&dto.ShoppingList{}).Where("store_id = ?", id).Update("store_id", nil).Error; err != nil {
+			return ormerrors.NewDatabaseError("detach store from shopping lists", err)
+		}
+		// Un prix relevé dans ce magasin ne doit pas devenir un prix valable partout
+		if err := tx.Where("store_id = ?", id).Delete(&dto.IngredientPrice{}).Error; err != nil {
+			return ormerrors.NewDatabaseError("delete store prices", err)
+		}
+
+		result := tx.Delete(&dto.Store{}, id)
+		if result.Error != nil {
+			return ormerrors.NewDatabaseError("delete store", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ormerrors.NewNotFoundError("store", id)
+		}
+		return nil
+	})
+}
+
+// RecordPlacement mémorise le rayon où l'utilisateur a trouvé un ingrédient dans un magasin.
+// Un placement répété dans le même rayon renforce le compteur, un autre rayon le remplace.
+func (r *storeRepository) RecordPlacement(ctx context.Context, storeID, userID uint, req *dto.StorePlacementRequest) (*dto.StoreIngredientPlacement, error) {
+	db := r.db.WithContext(ctx)
+
+	var aisleCount int64
+	if err := db.Model(&dto.StoreAisle{}).Where("id = ? AND store_id = ?", req.AisleID, storeID).Count(&aisleCount).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("check store aisle", err)
+	}
+	if aisleCount == 0 {
+		return nil, ormerrors.NewNotFoundError("store aisle", req.AisleID)
+	}
+
+	var ingredientCount int64
+	if err := db.Model(&dto.Ingredient{}).Where("id = ?", req.IngredientID).Count(&ingredientCount).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("check ingredient", err)
+	}
+	if ingredientCount == 0 {
+		return nil, ormerrors.NewNotFoundError("ingredient", req.IngredientID)
+	}
+
+	placement := &dto.StoreIngredientPlacement{
+		UserID:       userID,
+		StoreID:      storeID,
+		IngredientID: req.IngredientID,
+		AisleID:      req.AisleID,
+		Count:        1,
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "store_id"}, {Name: "ingredient_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count": gorm.Expr("CASE WHEN store_ingredient_placements.aisle_id = excluded.aisle_id " +
+				"THEN store_ingredient_placements.count + 1 ELSE 1 END"),
+			"aisle_id":   gorm.Expr("excluded.aisle_id"),
+			"user_id":    gorm.Expr("excluded.user_id"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(placement).Error
+	if err != nil {
+		return nil, ormerrors.NewDatabaseError("record store placement", err)
+	}
+
+	if err := db.Where("store_id = ? AND ingredient_id = ?", storeID, req.IngredientID).First(placement).Error; err != nil {
+		return nil, ormerrors.NewDatabaseError("get store placement", err)
+	}
+	return placement, nil
+}
+
+// unsetOtherDefaultStores retire l'indicateur par défaut des autres magasins de l'utilisateur
+func unsetOtherDefaultStores(tx *gorm.DB, store *dto.Store) error {
+	if err := tx.Model(&dto.Store{}).
+		Where("user_id = ? AND id <> ? AND is_default", store.UserID, store.ID).
+		Update("is_default", false).Error; err != nil {
+		return ormerrors.NewDatabaseError("unset default store", err)
+	}
+	return nil
+}
+
+// saveStoreAisles crée ou met à jour les rayons d'un magasin dans l'ordre donné, avec leurs catégories
+func saveStoreAisles(tx *gorm.DB, storeID uint, aisles []dto.StoreAisle) error {
+	var categoryIDs []uint
+	for _, aisle := range aisles {
+		for _, category := range aisle.Categories {
+			categoryIDs = append(categoryIDs, category.ID)
+		}
+	}
+	if categoryIDs = uniqueUints(categoryIDs); len(categoryIDs) > 0 {
+		var count int64
+		if err := tx.Model(&dto.IngredientCategory{}).Where("id IN ?", categoryIDs).Count(&count).Error; err != nil {
+			return ormerrors.NewDatabaseError("check aisle categories", err)
+		}
+		if int(count) != len(categoryIDs) {
+			return ormerrors.NewValidationError("unknown ingredient category in aisles")
+		}
+	}
+
+	for i := range aisles {
+		aisle := &aisles[i]
+		aisle.StoreID = storeID
+		aisle.Position = i
+		categories := aisle.Categories
+		if err := tx.Omit("Categories").Save(aisle).Error; err != nil {
+			return ormerrors.NewDatabaseError("save store aisle", err)
+		}
+		if err := tx.Model(aisle).Association("Categories").Replace(categories); err != nil {
+			return ormerrors.NewDatabaseError("save store aisle categories", err)
+		}
+	}
+	return nil
+}
+
+// deleteStoreAisles supprime des rayons avec leurs catégories et les placements qui y mènent
+func deleteStoreAisles(tx *gorm.DB, aisleIDs []uint) error {
+	if len(aisleIDs) == 0 {
+		return nil
+	}
+	if err := tx.Where("aisle_id IN ?", aisleIDs).Delete(&dto.StoreIngredientPlacement{}).Error; err != nil {
+		return ormerrors.NewDatabaseError("delete store placements", err)
+	}
+	if err := tx.Exec("DELETE FROM store_aisle_categories WHERE store_aisle_id IN ?", aisleIDs).Error; err != nil {
+		return ormerrors.NewDatabaseError("delete store aisle categories", err)
+	}
+	if err := tx.Delete(&dto.StoreAisle{}, aisleIDs).Error; err != nil {
+		return ormerrors.NewDatabaseError("delete store aisles", err)
+	}
+	return nil
+}
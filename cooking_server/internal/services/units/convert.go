@@ -0,0 +1,157 @@
+package units
+
+import (
+	"math"
+	"strings"
+)
+
+// Hints contient les indications propres à un ingrédient permettant de passer d'une dimension à l'autre
+type Hints struct {
+	Density     float64 // Masse volumique en g/ml (0 = inconnue)
+	PieceWeight float64 // Poids moyen d'une pièce en grammes (0 = inconnu)
+}
+
+// defaultHints fournit des valeurs usuelles quand l'ingrédient n'en définit pas
+// (clés comparées après NormalizeName, comme le nom de l'ingrédient)
+var defaultHints = []struct {
+	keyword string
+	hints   Hints
+}{
+	{"sucre glace", Hints{Density: 0.56}},
+	{"farine", Hints{Density: 0.55}},
+	{"sucre", Hints{Density: 0.85}},
+	{"sel", Hints{Density: 1.2}},
+	{"riz", Hints{Density: 0.85}},
+	{"semoule", Hints{Density: 0.7}},
+	{"flocons d'avoine", Hints{Density: 0.4}},
+	{"cacao", Hints{Density: 0.45}},
+	{"eau", Hints{Density: 1}},
+	{"lait", Hints{Density: 1.03}},
+	{"creme", Hints{Density: 1}},
+	{"huile", Hints{Density: 0.92}},
+	{"beurre", Hints{Density: 0.91}},
+	{"miel", Hints{Density: 1.42}},
+	{"vinaigre", Hints{Density: 1.01}},
+	{"sauce soja", Hints{Density: 1.2}},
+	{"oeuf", Hints{Density: 1.03, PieceWeight: 55}},
+	{"oignon", Hints{PieceWeight: 110}},
+	{"echalote", Hints{PieceWeight: 30}},
+	{"tomate cerise", Hints{PieceWeight: 15}},
+	{"tomate", Hints{PieceWeight: 120}},
+	{"pomme de terre", Hints{PieceWeight: 150}},
+	{"pomme", Hints{PieceWeight: 150}},
+	{"citron", Hints{PieceWeight: 100}},
+	{"carotte", Hints{PieceWeight: 80}},
+	{"courgette", Hints{PieceWeight: 200}},
+	{"poivron", Hints{PieceWeight: 150}},
+	{"banane", Hints{PieceWeight: 120}},
+}
+
+// HintsFor combine les valeurs propres à un ingrédient avec les valeurs usuelles connues pour son nom
+func HintsFor(name string, density, pieceWeight *float64) Hints {
+	var hints Hints
+	// Comparaison mot à mot sur la forme singulière ("oeufs" → "oeuf", sans que "eau" ne corresponde à "poireau")
+	key := " " + NormalizeName(name) + " "
+	for _, entry := range defaultHints {
+		if strings.Contains(key, " "+NormalizeName(entry.keyword)+" ") {
+			hints = entry.hints
+			break
+		}
+	}
+
+	if density != nil && *density > 0 {
+		hints.Density = *density
+	}
+	if pieceWeight != nil && *pieceWeight > 0 {
+		hints.PieceWeight = *pieceWeight
+	}
+	return hints
+}
+
+// Convert convertit une quantité d'une unité vers une autre.
+// Le second retour vaut false si la conversion est impossible avec les indications fournies.
+func Convert(quantity float64, from, to Unit, hints Hints) (float64, bool) {
+	// Unités non convertibles : seule une unité identique est acceptée
+	if !from.IsConvertible() || !to.IsConvertible() {
+		if from.Dimension == to.Dimension && from.Key() == to.Key() {
+			return quantity, true
+		}
+		return 0, false
+	}
+
+	base, ok := toDimension(quantity*from.Factor, from.Dimension, to.Dimension, hints)
+	if !ok {
+		return 0, false
+	}
+	return base / to.Factor, true
+}
+
+// toDimension convertit une quantité exprimée dans l'unité de base d'une dimension vers l'unité de base d'une autre
+func toDimension(base float64, from, to Dimension, hints Hints) (float64, bool) {
+	if from == to {
+		return base, true
+	}
+
+	// Passer par la masse comme pivot
+	var grams float64
+	switch from {
+	case Mass:
+		grams = base
+	case Volume:
+		if hints.Density <= 0 {
+			return 0, false
+		}
+		grams = base * hints.Density
+	case Count:
+		if hints.PieceWeight <= 0 {
+			return 0, false
+		}
+		grams = base * hints.PieceWeight
+	default:
+		return 0, false
+	}
+
+	switch to {
+	case Mass:
+		return grams, true
+	case Volume:
+		if hints.Density <= 0 {
+			return 0, false
+		}
+		return grams / hints.Density, true
+	case Count:
+		if hints.PieceWeight <= 0 {
+			return 0, false
+		}
+		return grams / hints.PieceWeight, true
+	}
+	return 0, false
+}
+
+// Humanize exprime une quantité dans l'unité la plus lisible de sa dimension (g/kg, ml/l, pièce)
+func Humanize(quantity float64, unit Unit) (float64, Unit) {
+	if !unit.IsConvertible() {
+		return quantity, unit
+	}
+
+	base := quantity * unit.Factor
+	switch unit.Dimension {
+	case Mass:
+		if base >= 1000 {
+			return base / Kilogram.Factor, Kilogram
+		}
+		return base, Gram
+	case Volume:
+		if base >= 1000 {
+			return base / Liter.Factor, Liter
+		}
+		return base, Milliliter
+	default:
+		return base, Piece
+	}
+}
+
+// Round arrondit une quantité à deux décimales pour éviter les artefacts flottants
+func Round(quantity float64) float64 {
+	return math.Round(quantity*100) / 100
+}
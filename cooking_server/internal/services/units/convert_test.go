@@ -0,0 +1,99 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	flour := Hints{Density: 0.55}
+	egg := Hints{Density: 1.03, PieceWeight: 55}
+
+	tests := []struct {
+		name     string
+		quantity float64
+		from, to string
+		hints    Hints
+		want     float64
+		ok       bool
+	}{
+		{"kilogrammes en grammes", 1.5, "kg", "g", Hints{}, 1500, true},
+		{"livre en grammes", 1, "lb", "g", Hints{}, 453.592, true},
+		{"cuillères à soupe en millilitres", 2, "c. à soupe", "ml", Hints{}, 30, true},
+		{"litres en centilitres", 0.25, "l", "cl", Hints{}, 25, true},
+		{"volume en masse avec densité", 250, "ml", "g", flour, 137.5, true},
+		{"masse en volume avec densité", 137.5, "g", "ml", flour, 250, true},
+		{"pièces en masse", 2, "pièce", "g", egg, 110, true},
+		{"douzaine en pièces", 1, "douzaine", "pièce", Hints{}, 12, true},
+		{"volume en masse sans densité", 100, "ml", "g", Hints{}, 0, false},
+		{"pièces en masse sans poids", 2, "pièce", "g", Hints{Density: 1}, 0, false},
+		{"unité culinaire identique", 3, "gousses", "gousse", Hints{}, 3, true},
+		{"unité culinaire vers masse", 3, "gousse", "g", Hints{}, 0, false},
+		{"unités culinaires différentes", 1, "pincée", "gousse", Hints{}, 0, false},
+		{"unité inconnue identique", 2, "Poignée", "poignée", Hints{}, 2, true},
+		{"unité inconnue vers masse", 2, "poignée", "g", Hints{}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Convert(tt.quantity, Parse(tt.from), Parse(tt.to), tt.hints)
+			if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Convert(%v %s → %s) = %v, %v, want %v, %v", tt.quantity, tt.from, tt.to, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestHintsFor(t *testing.T) {
+	custom := 0.6
+	weight := 60.0
+
+	tests := []struct {
+		name        string
+		ingredient  string
+		density     *float64
+		pieceWeight *float64
+		want        Hints
+	}{
+		{"mot composé avec apostrophe", "Flocons d'avoine", nil, nil, Hints{Density: 0.4}},
+		{"pluriel et accents", "Crème fraîche", nil, nil, Hints{Density: 1}},
+		{"pluriel", "Oeufs frais", nil, nil, Hints{Density: 1.03, PieceWeight: 55}},
+		{"expression la plus précise d'abord", "Sucre glace", nil, nil, Hints{Density: 0.56}},
+		{"expression de plusieurs mots", "Tomates cerises", nil, nil, Hints{PieceWeight: 15}},
+		{"mot entier seulement", "Poireau", nil, nil, Hints{}},
+		{"ingrédient inconnu", "Safran", nil, nil, Hints{}},
+		{"densité de l'ingrédient prioritaire", "Farine", &custom, nil, Hints{Density: 0.6}},
+		{"poids de l'ingrédient prioritaire", "Oeuf", nil, &weight, Hints{Density: 1.03, PieceWeight: 60}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HintsFor(tt.ingredient, tt.density, tt.pieceWeight); got != tt.want {
+				t.Errorf("HintsFor(%q) = %+v, want %+v", tt.ingredient, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		unit     string
+		want     float64
+		code     string
+	}{
+		{1500, "g", 1.5, "kg"},
+		{0.5, "kg", 500, "g"},
+		{150, "cl", 1.5, "l"},
+		{3, "c. à soupe", 45, "ml"},
+		{1, "douzaine", 12, "pièce"},
+		{2, "gousse", 2, "gousse"},
+	}
+
+	for _, tt := range tests {
+		got, unit := Humanize(tt.quantity, Parse(tt.unit))
+		if math.Abs(got-tt.want) > 1e-9 || unit.Code != tt.code {
+			t.Errorf("Humanize(%v %s) = %v %s, want %v %s", tt.quantity, tt.unit, got, unit.Code, tt.want, tt.code)
+		}
+	}
+}
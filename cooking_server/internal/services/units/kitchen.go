@@ -0,0 +1,186 @@
+package units
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Systèmes d'unités proposés lors de la mise à l'échelle d'une recette
+const (
+	SystemMetric   = "metric"
+	SystemImperial = "imperial"
+)
+
+// Unités impériales et cuillères utilisées lors des conversions de système
+var (
+	Ounce       = Parse("oz")
+	Pound       = Parse("lb")
+	FluidOunce  = Parse("fl oz")
+	Cup         = Parse("cup")
+	Teaspoon    = Parse("c. à café")
+	Tablespoon  = Parse("c. à soupe")
+	wholePieces = []string{"oeuf", "egg"} // Ingrédients qui ne se comptent qu'à l'unité entière
+)
+
+// fractionSteps liste les fractions usuelles en cuisine avec leur affichage
+var fractionSteps = []struct {
+	value float64
+	label string
+}{
+	{0, ""},
+	{0.25, "¼"},
+	{1.0 / 3, "⅓"},
+	{0.5, "½"},
+	{2.0 / 3, "⅔"},
+	{0.75, "¾"},
+	{1, ""},
+}
+
+// ToSystem exprime une quantité dans le système d'unités demandé.
+// Les cuillères et les unités non convertibles sont conservées telles quelles.
+func ToSystem(quantity float64, unit Unit, system string) (float64, Unit) {
+	if !unit.IsConvertible() || unit.Dimension == Count || isSpoon(unit) {
+		return quantity, unit
+	}
+
+	base := quantity * unit.Factor
+	switch system {
+	case SystemMetric:
+		if isImperial(unit) {
+			if unit.Dimension == Mass {
+				return Humanize(base, Gram)
+			}
+			return Humanize(base, Milliliter)
+		}
+	case SystemImperial:
+		if isImperial(unit) {
+			return quantity, unit
+		}
+		if unit.Dimension == Mass {
+			if base >= Pound.Factor {
+				return base / Pound.Factor, Pound
+			}
+			return base / Ounce.Factor, Ounce
+		}
+		switch {
+		case base < Tablespoon.Factor:
+			return base / Teaspoon.Factor, Teaspoon
+		case base < Cup.Factor/4:
+			return base / Tablespoon.Factor, Tablespoon
+		default:
+			return base / Cup.Factor, Cup
+		}
+	}
+	return quantity, unit
+}
+
+// RoundForKitchen arrondit une quantité mise à l'échelle à une valeur mesurable en cuisine :
+// œufs entiers, demi-pièces, fractions pour les cuillères et tasses, pas ronds pour les grammes et millilitres.
+func RoundForKitchen(quantity float64, unit Unit, ingredientName string) float64 {
+	if quantity <= 0 {
+		return 0
+	}
+
+	switch {
+	case unit.Dimension == Count:
+		if isWholePiece(ingredientName) || quantity >= 2 {
+			return math.Max(1, math.Round(quantity*unit.Factor)) / unit.Factor
+		}
+		return math.Max(0.5, math.Round(quantity*unit.Factor*2)/2) / unit.Factor
+	case usesFractions(unit):
+		return math.Max(0.25, roundToFraction(quantity))
+	case unit.Dimension == Mass || unit.Dimension == Volume:
+		base := quantity * unit.Factor
+		var step float64
+		switch {
+		case base < 10:
+			step = 0.5
+		case base < 100:
+			step = 5
+		case base < 1000:
+			step = 10
+		default:
+			step = 50
+		}
+		return math.Max(step, math.Round(base/step)*step) / unit.Factor
+	case unit.Dimension == Other:
+		return math.Max(1, math.Round(quantity))
+	default:
+		return Round(quantity)
+	}
+}
+
+// FormatQuantity affiche une quantité, avec des fractions pour les cuillères et les tasses ("1 ½")
+func FormatQuantity(quantity float64, unit Unit) string {
+	if usesFractions(unit) {
+		whole := math.Floor(quantity)
+		fraction := nearestFraction(quantity - whole)
+		if fraction.value == 1 {
+			whole++
+		}
+		switch {
+		case fraction.label == "":
+			return strconv.FormatFloat(whole, 'f', -1, 64)
+		case whole == 0:
+			return fraction.label
+		default:
+			return strconv.FormatFloat(whole, 'f', -1, 64) + " " + fraction.label
+		}
+	}
+	return strconv.FormatFloat(Round(quantity), 'f', -1, 64)
+}
+
+// roundToFraction arrondit à la fraction usuelle la plus proche
+func roundToFraction(quantity float64) float64 {
+	whole := math.Floor(quantity)
+	return whole + nearestFraction(quantity-whole).value
+}
+
+// nearestFraction retourne la fraction usuelle la plus proche d'une partie décimale (0-1)
+func nearestFraction(decimal float64) struct {
+	value float64
+	label string
+} {
+	best := fractionSteps[0]
+	for _, step := range fractionSteps[1:] {
+		if math.Abs(decimal-step.value) < math.Abs(decimal-best.value) {
+			best = step
+		}
+	}
+	return best
+}
+
+// usesFractions indique si l'unité se mesure en fractions (cuillères, tasses, verres)
+func usesFractions(unit Unit) bool {
+	switch unit.Code {
+	case Teaspoon.Code, Tablespoon.Code, Cup.Code, "tasse", "verre":
+		return true
+	}
+	return false
+}
+
+// isSpoon indique si l'unité est une cuillère
+func isSpoon(unit Unit) bool {
+	return unit.Code == Teaspoon.Code || unit.Code == Tablespoon.Code
+}
+
+// isImperial indique si l'unité appartient au système impérial
+func isImperial(unit Unit) bool {
+	switch unit.Code {
+	case Ounce.Code, Pound.Code, FluidOunce.Code, Cup.Code:
+		return true
+	}
+	return false
+}
+
+// isWholePiece indique si l'ingrédient ne peut être utilisé qu'en pièces entières
+func isWholePiece(ingredientName string) bool {
+	key := " " + NormalizeName(ingredientName) + " "
+	for _, keyword := range wholePieces {
+		if strings.Contains(key, " "+keyword+" ") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRoundForKitchen(t *testing.T) {
+	tests := []struct {
+		name       string
+		quantity   float64
+		unit       string
+		ingredient string
+		want       float64
+	}{
+		{"quantité nulle", 0, "g", "Farine", 0},
+		{"quantité négative", -2, "g", "Farine", 0},
+		{"oeufs entiers", 1.4, "", "Oeufs", 1},
+		{"au moins un oeuf", 0.3, "", "Oeuf", 1},
+		{"demi-pièce", 0.7, "", "Citron", 0.5},
+		{"pièce arrondie à la demie", 1.3, "", "Citron", 1.5},
+		{"pièces entières à partir de deux", 2.6, "", "Citron", 3},
+		{"cuillère arrondie à la fraction", 1.1, "c. à soupe", "Huile", 1},
+		{"au moins un quart de cuillère", 0.1, "c. à café", "Sel", 0.25},
+		{"tasse au tiers", 1.3, "cup", "Farine", 1 + 1.0/3},
+		{"grammes au demi", 7.3, "g", "Levure", 7.5},
+		{"au moins un demi-gramme", 0.2, "g", "Safran", 0.5},
+		{"grammes à la dizaine", 123, "g", "Farine", 120},
+		{"kilogrammes aux 50 g", 1.234, "kg", "Farine", 1.25},
+		{"millilitres aux 5 ml", 42, "ml", "Lait", 40},
+		{"unité culinaire entière", 2.4, "pincée", "Sel", 2},
+		{"unité inconnue à deux décimales", 1.234, "poignée", "Roquette", 1.23},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RoundForKitchen(tt.quantity, Parse(tt.unit), tt.ingredient)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("RoundForKitchen(%v %s %q) = %v, want %v", tt.quantity, tt.unit, tt.ingredient, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatQuantity(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		unit     string
+		want     string
+	}{
+		{1.5, "c. à soupe", "1 ½"},
+		{0.25, "c. à café", "¼"},
+		{2, "cup", "2"},
+		{0.98, "tasse", "1"},
+		{2.8, "verre", "2 ¾"},
+		{1 + 2.0/3, "cup", "1 ⅔"},
+		{2.3333, "g", "2.33"},
+		{1500, "g", "1500"},
+		{0.5, "", "0.5"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatQuantity(tt.quantity, Parse(tt.unit)); got != tt.want {
+			t.Errorf("FormatQuantity(%v %s) = %q, want %q", tt.quantity, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestToSystem(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		unit     string
+		system   string
+		want     float64
+		code     string
+	}{
+		{500, "g", SystemImperial, 500 / 453.592, "lb"},
+		{100, "g", SystemImperial, 100 / 28.3495, "oz"},
+		{10, "ml", SystemImperial, 2, "c. à café"},
+		{30, "ml", SystemImperial, 2, "c. à soupe"},
+		{480, "ml", SystemImperial, 2, "cup"},
+		{2, "cup", SystemMetric, 480, "ml"},
+		{2, "lb", SystemMetric, 907.184, "g"},
+		{4, "lb", SystemMetric, 1.814368, "kg"},
+		{2, "c. à soupe", SystemImperial, 2, "c. à soupe"},
+		{3, "", SystemImperial, 3, "pièce"},
+		{200, "g", SystemMetric, 200, "g"},
+	}
+
+	for _, tt := range tests {
+		got, unit := ToSystem(tt.quantity, Parse(tt.unit), tt.system)
+		if math.Abs(got-tt.want) > 1e-9 || unit.Code != tt.code {
+			t.Errorf("ToSystem(%v %s, %s) = %v %s, want %v %s", tt.quantity, tt.unit, tt.system, got, unit.Code, tt.want, tt.code)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package units
+
+import "strings"
+
+// Dimension représente la grandeur physique mesurée par une unité
+type Dimension string
+
+const (
+	Mass    Dimension = "mass"    // Unité de base : gramme
+	Volume  Dimension = "volume"  // Unité de base : millilitre
+	Count   Dimension = "count"   // Unité de base : pièce
+	Other   Dimension = "other"   // Unité culinaire non convertible (gousse, pincée, ...)
+	Unknown Dimension = "unknown" // Unité inconnue, conservée telle quelle
+)
+
+// Unit représente une unité de mesure normalisée
+type Unit struct {
+	Code      string    // Libellé canonique (ex. "g", "c. à soupe")
+	Dimension Dimension // Grandeur mesurée
+	Factor    float64   // Facteur vers l'unité de base de la dimension
+}
+
+// Unités de base de chaque dimension convertible
+var (
+	Gram       = Unit{Code: "g", Dimension: Mass, Factor: 1}
+	Kilogram   = Unit{Code: "kg", Dimension: Mass, Factor: 1000}
+	Milliliter = Unit{Code: "ml", Dimension: Volume, Factor: 1}
+	Liter      = Unit{Code: "l", Dimension: Volume, Factor: 1000}
+	Piece      = Unit{Code: "pièce", Dimension: Count, Factor: 1}
+)
+
+// catalog associe chaque unité canonique à ses variantes d'écriture (sans accents, en minuscules)
+var catalog = []struct {
+	unit    Unit
+	aliases []string
+}{
+	{Unit{Code: "mg", Dimension: Mass, Factor: 0.001}, []string{"mg", "milligramme", "milligram"}},
+	{Gram, []string{"g", "gr", "grs", "gramme", "gram"}},
+	{Kilogram, []string{"kg", "kilo", "kilogramme", "kilogram"}},
+	{Unit{Code: "oz", Dimension: Mass, Factor: 28.3495}, []string{"oz", "once", "ounce"}},
+	{Unit{Code: "lb", Dimension: Mass, Factor: 453.592}, []string{"lb", "lbs", "livre", "pound"}},
+
+	{Milliliter, []string{"ml", "millilitre", "milliliter"}},
+	{Unit{Code: "cl", Dimension: Volume, Factor: 10}, []string{"cl", "centilitre", "centiliter"}},
+	{Unit{Code: "dl", Dimension: Volume, Factor: 100}, []string{"dl", "decilitre", "deciliter"}},
+	{Liter, []string{"l", "litre", "liter", "lt"}},
+	{Unit{Code: "c. à café", Dimension: Volume, Factor: 5}, []string{"c a cafe", "c a c", "cac", "cc", "c cafe", "cuillere a cafe", "cuilliere a cafe", "cuillere cafe", "tsp", "teaspoon"}},
+	{Unit{Code: "c. à soupe", Dimension: Volume, Factor: 15}, []string{"c a soupe", "c a s", "cas", "cs", "c soupe", "cuillere a soupe", "cuilliere a soupe", "cuillere soupe", "tbsp", "tablespoon"}},
+	{Unit{Code: "tasse", Dimension: Volume, Factor: 250}, []string{"tasse"}},
+	{Unit{Code: "cup", Dimension: Volume, Factor: 240}, []string{"cup"}},
+	{Unit{Code: "verre", Dimension: Volume, Factor: 200}, []string{"verre"}},
+	{Unit{Code: "fl oz", Dimension: Volume, Factor: 29.5735}, []string{"fl oz", "floz", "fluid ounce"}},
+
+	{Piece, []string{"", "piece", "pc", "pcs", "unite", "u", "unit", "x"}},
+	{Unit{Code: "douzaine", Dimension: Count, Factor: 12}, []string{"douzaine", "dozen"}},
+
+	{Unit{Code: "pincée", Dimension: Other, Factor: 1}, []string{"pincee", "pinch"}},
+	{Unit{Code: "gousse", Dimension: Other, Factor: 1}, []string{"gousse", "clove"}},
+	{Unit{Code: "tranche", Dimension: Other, Factor: 1}, []string{"tranche", "slice"}},
+	{Unit{Code: "branche", Dimension: Other, Factor: 1}, []string{"branche", "brin", "sprig"}},
+	{Unit{Code: "feuille", Dimension: Other, Factor: 1}, []string{"feuille", "leaf"}},
+	{Unit{Code: "botte", Dimension: Other, Factor: 1}, []string{"botte", "bouquet", "bunch"}},
+	{Unit{Code: "sachet", Dimension: Other, Factor: 1}, []string{"sachet"}},
+	{Unit{Code: "boîte", Dimension: Other, Factor: 1}, []string{"boite", "can", "conserve"}},
+}
+
+// aliasIndex est l'index des variantes construit au démarrage
+var aliasIndex = buildAliasIndex()
+
+func buildAliasIndex() map[string]Unit {
+	index := make(map[string]Unit)
+	for _, entry := range catalog {
+		index[normalizeKey(entry.unit.Code)] = entry.unit
+		for _, alias := range entry.aliases {
+			index[alias] = entry.unit
+		}
+	}
+	return index
+}
+
+// Parse normalise une unité saisie librement ("Cuillères à soupe", "c.à.s", "grammes"...)
+// Une unité non reconnue est conservée avec la dimension Unknown.
+func Parse(raw string) Unit {
+	key := normalizeKey(raw)
+	if unit, ok := aliasIndex[key]; ok {
+		return unit
+	}
+
+	// Tenter la forme singulière de chaque mot ("cuilleres a soupe" → "cuillere a soupe")
+	if singular := singularize(key); singular != key {
+		if unit, ok := aliasIndex[singular]; ok {
+			return unit
+		}
+	}
+
+	return Unit{Code: strings.TrimSpace(raw), Dimension: Unknown, Factor: 1}
+}
+
+// IsConvertible indique si l'unité appartient à une dimension convertible
+func (u Unit) IsConvertible() bool {
+	return u.Dimension == Mass || u.Dimension == Volume || u.Dimension == Count
+}
+
+// Key retourne la clé de comparaison de l'unité (utile pour les unités inconnues)
+func (u Unit) Key() string {
+	return normalizeKey(u.Code)
+}
+
+// normalizeKey met une unité sous forme comparable : minuscules, sans accents ni ponctuation
+func normalizeKey(raw string) string {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	s = stripAccents(s)
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '.' || r == '-' || r == '_' || r == '\'' || r == '’':
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// singularize retire le "s" final de chaque mot (pluriels français et anglais simples)
+func singularize(key string) string {
+	words := strings.Fields(key)
+	for i, word := range words {
+		if len(word) > 2 && strings.HasSuffix(word, "s") {
+			words[i] = strings.TrimSuffix(word, "s")
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// accentReplacer remplace les caractères accentués courants en français
+var accentReplacer = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i",
+	"ô", "o", "ö", "o",
+	"ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ÿ", "y", "ñ", "n",
+	"œ", "oe", "æ", "ae",
+)
+
+// stripAccents supprime les diacritiques ("crème" → "creme")
+func stripAccents(s string) string {
+	return accentReplacer.Replace(s)
+}
+
+// NormalizeText met un texte libre sous forme comparable (minuscules, sans accents)
+func NormalizeText(raw string) string {
+	return strings.Join(strings.Fields(stripAccents(strings.ToLower(raw))), " ")
+}
+
+// NormalizeName met un nom d'ingrédient sous forme comparable : minuscules, sans accents, au singulier
+func NormalizeName(raw string) string {
+	return singularize(NormalizeText(raw))
+}
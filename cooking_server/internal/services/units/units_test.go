@@ -0,0 +1,54 @@
+package units
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw       string
+		code      string
+		dimension Dimension
+	}{
+		{"g", "g", Mass},
+		{"Grammes", "g", Mass},
+		{"KG", "kg", Mass},
+		{"Cuillères à soupe", "c. à soupe", Volume},
+		{"c.à.s", "c. à soupe", Volume},
+		{"c. à café", "c. à café", Volume},
+		{"tsp", "c. à café", Volume},
+		{"fl. oz", "fl oz", Volume},
+		{"", "pièce", Count},
+		{"pcs", "pièce", Count},
+		{"douzaine", "douzaine", Count},
+		{"Pincées", "pincée", Other},
+		{"gousses", "gousse", Other},
+		{"poignée", "poignée", Unknown},
+		{"  poignée  ", "poignée", Unknown},
+	}
+
+	for _, tt := range tests {
+		unit := Parse(tt.raw)
+		if unit.Code != tt.code || unit.Dimension != tt.dimension {
+			t.Errorf("Parse(%q) = %q (%s), want %q (%s)", tt.raw, unit.Code, unit.Dimension, tt.code, tt.dimension)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"Crème fraîche", "creme fraiche"},
+		{"  Oeufs   frais ", "oeuf frai"},
+		{"Flocons d'avoine", "flocon d'avoine"},
+		{"Œufs", "oeuf"},
+		{"riz", "riz"},
+		{"as", "as"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeName(tt.raw); got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}